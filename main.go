@@ -13,6 +13,7 @@ import (
 	"github.com/iotaledger/wasp/plugins/nodeconn"
 	"github.com/iotaledger/wasp/plugins/peering"
 	"github.com/iotaledger/wasp/plugins/publisher"
+	"github.com/iotaledger/wasp/plugins/registry"
 	"github.com/iotaledger/wasp/plugins/runvm"
 	"github.com/iotaledger/wasp/plugins/testplugins/nodeping"
 	"github.com/iotaledger/wasp/plugins/testplugins/roundtrip"
@@ -27,6 +28,7 @@ var PLUGINS = node.Plugins(
 	webapi.Plugin,
 	cli.Plugin,
 	database.Plugin,
+	registry.Plugin,
 	peering.Plugin,
 	nodeconn.Plugin,
 	dispatcher.Plugin,
@@ -1,7 +1,6 @@
 package apilib
 
 import (
-	"bytes"
 	"math/rand"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
@@ -10,6 +9,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+// GenerateNewDistributedKeySet runs DKG between the nodes. The first node initiates the DKG,
+// nodes exchange key shares directly with each other
 func GenerateNewDistributedKeySet(nodes []string, n, t uint16) (*address.Address, error) {
 	if len(nodes) != int(n) {
 		return nil, errors.New("wrong params")
@@ -17,65 +18,30 @@ func GenerateNewDistributedKeySet(nodes []string, n, t uint16) (*address.Address
 	if err := tcrypto.ValidateDKSParams(t, n, 0); err != nil {
 		return nil, err
 	}
-	// temporary numeric id during DKG
-	params := dkgapi.NewDKSRequest{
+	addr, err := callRunDKG(nodes[0], dkgapi.RunDKGRequest{
+		// temporary numeric id during DKG
 		TmpId: rand.Int(),
-		N:     n,
 		T:     t,
+		Hosts: nodes,
+	})
+	if err != nil {
+		return nil, err
 	}
-	// generate new key shares
-	// results in the matrix
-	priSharesMatrix := make([][]string, params.N)
-	for i, host := range nodes {
-		par := params
-		par.Index = uint16(i)
-		resp, err := callNewKey(host, par)
-		if err != nil {
-			return nil, err
-		}
-		if len(resp.PriShares) != int(params.N) {
-			return nil, errors.New("apilib: len(resp.PriShares) != int(params.N)")
-		}
-		priSharesMatrix[i] = resp.PriShares
-	}
-
-	// aggregate private shares
-	pubShares := make([]string, params.N)
-	priSharesCol := make([]string, params.N)
-	for col, host := range nodes {
-		for row := range nodes {
-			priSharesCol[row] = priSharesMatrix[row][col]
-		}
-		resp, err := callAggregate(host, dkgapi.AggregateDKSRequest{
-			TmpId:     params.TmpId,
-			Index:     uint16(col),
-			PriShares: priSharesCol,
-		})
-		if err != nil {
-			return nil, err
-		}
-		pubShares[col] = resp.PubShare
+	if addr.Version() != address.VersionBLS {
+		return nil, errors.New("DKG returned non-BLS address")
 	}
+	return addr, nil
+}
 
-	// commit keys
-	var addrRet *address.Address
-	for _, host := range nodes {
-		addr, err := callCommit(host, dkgapi.CommitDKSRequest{
-			TmpId:     params.TmpId,
-			PubShares: pubShares,
-		})
-		if err != nil {
-			return nil, err
-		}
-		if addrRet != nil && !bytes.Equal(addrRet.Bytes(), addr.Bytes()) {
-			return nil, errors.New("key commit returned different addresses from different nodes")
-		}
-		if addr.Version() != address.VersionBLS {
-			return nil, errors.New("key commit returned non-BLS address")
-		}
-		addrRet = addr
-	}
-	return addrRet, nil
+// RefreshDistributedKeySet refreshes key shares of the key set with the address on all nodes.
+// Nodes must be listed in the order of indices of their key shares. The address remains the same
+func RefreshDistributedKeySet(nodes []string, addr *address.Address) error {
+	return callRefreshDKS(nodes[0], dkgapi.RefreshDKSRequest{
+		// temporary numeric id during DKG
+		TmpId:   rand.Int(),
+		Address: addr.String(),
+		Hosts:   nodes,
+	})
 }
 
 // retrieves public info about key with specific address
@@ -90,9 +56,12 @@ func GetPublicKeyInfo(nodes []string, address *address.Address) []*dkgapi.GetPub
 	return ret
 }
 
-func ExportDKShare(node string, address *address.Address) (string, error) {
+// ExportDKShare exports the key share of the node encrypted for the identity key of the recipient node.
+// Only the recipient node can import the returned blob
+func ExportDKShare(node string, address *address.Address, recipientPubKey string) (string, error) {
 	return callExportDKShare(node, dkgapi.ExportDKShareRequest{
-		Address: address.String(),
+		Address:         address.String(),
+		RecipientPubKey: recipientPubKey,
 	})
 }
 
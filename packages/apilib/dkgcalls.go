@@ -11,71 +11,50 @@ import (
 	"github.com/pkg/errors"
 )
 
-func callNewKey(netLoc string, params dkgapi.NewDKSRequest) (*dkgapi.NewDKSResponse, error) {
+func callRunDKG(netLoc string, params dkgapi.RunDKGRequest) (*address.Address, error) {
 	data, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
-	url := fmt.Sprintf("http://%s/adm/newdks", netLoc)
+	url := fmt.Sprintf("http://%s/adm/rundkg", netLoc)
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
-	result := &dkgapi.NewDKSResponse{}
+	result := &dkgapi.RunDKGResponse{}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
 		return nil, err
 	}
 	if result.Err == "" {
-		return result, nil
-	}
-	return nil, errors.New(result.Err)
-}
-
-func callAggregate(netLoc string, params dkgapi.AggregateDKSRequest) (*dkgapi.AggregateDKSResponse, error) {
-	data, err := json.Marshal(params)
-	if err != nil {
-		return nil, err
-	}
-	url := fmt.Sprintf("http://%s/adm/aggregatedks", netLoc)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
-	if err != nil {
-		return nil, err
-	}
-	result := &dkgapi.AggregateDKSResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		return nil, err
-	}
-	if result.Err == "" {
-		return result, nil
+		addrRet, err := address.FromBase58(result.Address)
+		if err != nil {
+			return nil, err
+		}
+		return &addrRet, nil
 	}
 	return nil, errors.New(result.Err)
 }
 
-func callCommit(netloc string, params dkgapi.CommitDKSRequest) (*address.Address, error) {
+func callRefreshDKS(netLoc string, params dkgapi.RefreshDKSRequest) error {
 	data, err := json.Marshal(params)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	url := fmt.Sprintf("http://%s/adm/commitdks", netloc)
+	url := fmt.Sprintf("http://%s/adm/refreshdks", netLoc)
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	result := &dkgapi.CommitDKSResponse{}
+	result := &dkgapi.RefreshDKSResponse{}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if result.Err == "" {
-		addrRet, err := address.FromBase58(result.Address)
-		if err != nil {
-			return nil, err
-		}
-		return &addrRet, nil
+	if result.Err != "" {
+		return errors.New(result.Err)
 	}
-	return nil, errors.New(result.Err)
+	return nil
 }
 
 func callGetPubKeyInfo(netLoc string, params dkgapi.GetPubKeyInfoRequest) *dkgapi.GetPubKeyInfoResponse {
@@ -0,0 +1,43 @@
+package apilib
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/wasp/plugins/webapi/admapi"
+	"net/http"
+)
+
+// GetNodeIdentity returns network id and base58 encoded public identity key of the node
+func GetNodeIdentity(host string) (*admapi.NodeIdentityResponse, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/adm/nodeidentity", host))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("adm/nodeidentity returned code %d", resp.StatusCode)
+	}
+	var ret admapi.NodeIdentityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// GetCommitteePubKeys returns public identity keys of nodes in the same order as hosts.
+// The keys are required in the bootup data of the smart contract
+func GetCommitteePubKeys(hosts []string) ([]ed25519.PublicKey, error) {
+	ret := make([]ed25519.PublicKey, len(hosts))
+	for i, host := range hosts {
+		identity, err := GetNodeIdentity(host)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys, err := admapi.PubKeysFromBase58([]string{identity.PubKey})
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = pubKeys[0]
+	}
+	return ret, nil
+}
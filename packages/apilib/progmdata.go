@@ -18,6 +18,7 @@ func PutProgramMetadata(host string, md registry.ProgramMetadata) error {
 		ProgramHash: md.ProgramHash.String(),
 		Location:    md.Location,
 		Description: md.Description,
+		Schema:      md.Schema,
 	})
 	if err != nil {
 		return err
@@ -75,6 +76,7 @@ func GetProgramMetadata(host string, progHash *hashing.HashValue) (*registry.Pro
 		ProgramHash: ph,
 		Location:    dresp.Location,
 		Description: dresp.Description,
+		Schema:      dresp.Schema,
 	}
 
 	return ret, true, dresp.ExistsCode, nil
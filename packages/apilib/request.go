@@ -10,6 +10,7 @@ import (
 	nodeapi "github.com/iotaledger/goshimmer/dapps/waspconn/packages/apilib"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/schema"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/sctransaction/txbuilder"
 )
@@ -71,21 +72,37 @@ func convertArgs(vars map[string]interface{}) kv.Map {
 		case int64:
 			codec.SetInt64(key, vt)
 		case uint16:
-			codec.SetInt64(key, int64(vt))
+			codec.SetUint16(key, vt)
 		case uint32:
-			codec.SetInt64(key, int64(vt))
+			codec.SetUint32(key, vt)
 		case uint64:
-			codec.SetInt64(key, int64(vt))
+			codec.SetUint64(key, vt)
+		case bool:
+			codec.SetBool(key, vt)
 		case string:
 			codec.SetString(key, vt)
 		case []byte:
 			codec.Set(key, vt)
 		case *hashing.HashValue:
-			args.Codec().SetHashValue(key, vt)
+			codec.SetHashValue(key, vt)
+		case hashing.HashValue:
+			codec.SetHashValue(key, &vt)
 		case *address.Address:
-			args.Codec().Set(key, vt.Bytes())
+			codec.SetAddress(key, vt)
+		case address.Address:
+			codec.SetAddress(key, &vt)
 		case *balance.Color:
-			args.Codec().Set(key, vt.Bytes())
+			codec.SetColor(key, vt)
+		case balance.Color:
+			codec.SetColor(key, &vt)
+		case []*balance.Balance:
+			codec.SetBalances(key, vt)
+		case *sctransaction.RequestId:
+			codec.SetRequestId(key, vt)
+		case sctransaction.RequestId:
+			codec.SetRequestId(key, &vt)
+		case kv.Map:
+			codec.SetMap(key, vt)
 		default:
 			return nil
 		}
@@ -100,6 +117,8 @@ type RequestBlockJson struct {
 	Timelock    uint32                    `json:"timelock"`
 	AmountIotas int64                     `json:"amount_iotas"` // minimum 1 iota will be taken anyway
 	Vars        map[string]interface{}    `json:"vars"`
+	// if not nil, the request code and vars are validated against the schema before posting
+	Schema *schema.Schema `json:"-"`
 }
 
 // Deprecated
@@ -154,14 +173,20 @@ func requestBlockFromJson(reqBlkJson *RequestBlockJson) (*sctransaction.RequestB
 	ret := sctransaction.NewRequestBlock(addr, sctransaction.RequestCode(reqBlkJson.RequestCode))
 	ret.WithTimelock(reqBlkJson.Timelock)
 
-	if reqBlkJson.Vars == nil {
-		// no args
-		return ret, nil
+	var args kv.Map
+	if reqBlkJson.Vars != nil {
+		if args = convertArgs(reqBlkJson.Vars); args == nil {
+			return nil, errors.New("wrong arguments")
+		}
+	}
+	if reqBlkJson.Schema != nil {
+		if err := reqBlkJson.Schema.ValidateArgs(ret.RequestCode(), args); err != nil {
+			return nil, err
+		}
 	}
-
-	args := convertArgs(reqBlkJson.Vars)
 	if args == nil {
-		return nil, errors.New("wrong arguments")
+		// no args
+		return ret, nil
 	}
 	ret.SetArgs(args)
 
@@ -0,0 +1,53 @@
+package apilib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/wasp/plugins/webapi/admapi"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+)
+
+// SetRetentionPolicy sets the retention policy of batches of the smart contract on the node.
+// req.NodeDefault resets it to the node's policy
+func SetRetentionPolicy(host string, req *admapi.RetentionPolicyRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/adm/setretention", host), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response status %d", resp.StatusCode)
+	}
+	var result misc.SimpleResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+func GetRetentionPolicy(host string, scAddress string) (*admapi.RetentionPolicyResponse, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/adm/getretention/%s", host, scAddress))
+	if err != nil {
+		return nil, err
+	}
+	var result admapi.RetentionPolicyResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || result.Err != "" {
+		return nil, fmt.Errorf("adm/getretention returned code %d: %s", resp.StatusCode, result.Err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,217 @@
+package apilib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	nodeapi "github.com/iotaledger/goshimmer/dapps/waspconn/packages/apilib"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/plugins/webapi/admapi"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+)
+
+type RotateCommitteeParams struct {
+	Node           string // goshimmer host
+	SCAddress      *address.Address
+	OwnerSigScheme signaturescheme.SignatureScheme
+	// web API hosts of the current committee
+	CommitteeApiHosts []string
+	// web API and peering hosts of the new committee, in the same order
+	NewCommitteeApiHosts     []string
+	NewCommitteePeeringHosts []string
+	T                        uint16
+	// maximum time to wait for the current committee to confirm the rotation
+	Timeout time.Duration
+}
+
+// RotateCommittee hands the smart contract over to the new committee:
+//   - runs DKG between nodes of the new committee, which results in the new address of the smart contract
+//   - puts bootup records of the new address to nodes of the new committee
+//   - sends the rotation request, signed by the owner. The current committee moves the smart contract token
+//     and all funds to the new address in the state transaction, marks its bootup records as rotated
+//     and is dismissed
+//   - completes the handover with CompleteRotation
+//
+// Returns the new address of the smart contract
+func RotateCommittee(par RotateCommitteeParams) (*address.Address, error) {
+	n := uint16(len(par.NewCommitteeApiHosts))
+	if len(par.NewCommitteePeeringHosts) != int(n) || len(par.CommitteeApiHosts) == 0 {
+		return nil, errors.New("wrong params")
+	}
+	bd, exists, err := GetSCData(par.CommitteeApiHosts[0], par.SCAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("smart contract %s not found", par.SCAddress.String())
+	}
+	if bd.RotatedTo != nil {
+		return nil, fmt.Errorf("smart contract %s was already rotated to %s", par.SCAddress.String(), bd.RotatedTo.String())
+	}
+	newAddr, err := GenerateNewDistributedKeySet(par.NewCommitteeApiHosts, n, par.T)
+	if err != nil {
+		return nil, err
+	}
+	newBd := registry.BootupData{
+		Address:        *newAddr,
+		OwnerAddress:   bd.OwnerAddress,
+		Color:          bd.Color,
+		CommitteeNodes: par.NewCommitteePeeringHosts,
+		AccessNodes:    bd.AccessNodes,
+	}
+	if newBd.CommitteePubKeys, err = GetCommitteePubKeys(par.NewCommitteeApiHosts); err != nil {
+		return nil, err
+	}
+	for _, host := range par.NewCommitteeApiHosts {
+		if err = PutSCData(host, newBd); err != nil {
+			return nil, fmt.Errorf("PutSCData to %s: %v", host, err)
+		}
+	}
+
+	tx, err := CreateSimpleRequest(par.Node, par.OwnerSigScheme, CreateSimpleRequestParams{
+		SCAddress:   par.SCAddress,
+		RequestCode: vmconst.RequestCodeRotateCommittee,
+		Vars: map[string]interface{}{
+			vmconst.VarNameSCAddress: newAddr,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = nodeapi.PostTransaction(par.Node, tx.Transaction); err != nil {
+		return nil, err
+	}
+	rotatedTo, err := CompleteRotation(CompleteRotationParams{
+		SCAddress:            par.SCAddress,
+		CommitteeApiHosts:    par.CommitteeApiHosts,
+		NewCommitteeApiHosts: par.NewCommitteeApiHosts,
+		Timeout:              par.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if *rotatedTo != *newAddr {
+		return nil, fmt.Errorf("smart contract %s was rotated to %s", par.SCAddress.String(), rotatedTo.String())
+	}
+	return newAddr, nil
+}
+
+type CompleteRotationParams struct {
+	// the old address of the smart contract
+	SCAddress *address.Address
+	// web API hosts of the old and of the new committee
+	CommitteeApiHosts    []string
+	NewCommitteeApiHosts []string
+	// maximum time to wait for the old committee to hand the smart contract over
+	Timeout time.Duration
+}
+
+// CompleteRotation waits until the old committee hands the smart contract over and completes the handover:
+//   - takes the snapshot of the last state of the smart contract from a node of the old committee
+//   - imports it into nodes of the new committee, which don't have the state yet,
+//     and activates the smart contract there
+//   - confirms the rotation to nodes of the old committee, which remove their bootup records
+//
+// Nodes of the old committee keep the state and the bootup record until the rotation is confirmed,
+// so the call can be repeated if it failed or the client was stopped after the rotation request was sent.
+// Returns the new address of the smart contract
+func CompleteRotation(par CompleteRotationParams) (*address.Address, error) {
+	newAddr, snapshot, err := waitRotatedSnapshot(par.CommitteeApiHosts, par.SCAddress, par.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	// the state is the same, only the address of the smart contract changes
+	snapshot.SCAddress = *newAddr
+	data, err := util.Bytes(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range par.NewCommitteeApiHosts {
+		if _, err = ExportSnapshot(host, newAddr.String()); err == nil {
+			// the state was imported before
+			continue
+		}
+		if err = ImportSnapshot(host, data); err != nil {
+			return nil, fmt.Errorf("ImportSnapshot to %s: %v", host, err)
+		}
+	}
+	for _, host := range par.NewCommitteeApiHosts {
+		if err = ActivateSC(host, newAddr.String()); err != nil {
+			return nil, fmt.Errorf("ActivateSC on %s: %v", host, err)
+		}
+	}
+	for _, host := range par.CommitteeApiHosts {
+		if err = ConfirmRotation(host, par.SCAddress); err != nil {
+			return nil, fmt.Errorf("ConfirmRotation on %s: %v", host, err)
+		}
+	}
+	return newAddr, nil
+}
+
+// waitRotatedSnapshot polls nodes of the old committee until one of them has handed the smart contract over.
+// Returns the new address and the snapshot of the last state at the old address
+func waitRotatedSnapshot(hosts []string, scAddress *address.Address, timeout time.Duration) (*address.Address, *state.Snapshot, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, host := range hosts {
+			bd, exists, err := GetSCData(host, scAddress)
+			if err != nil || !exists || bd.RotatedTo == nil {
+				continue
+			}
+			resp, err := ExportSnapshot(host, scAddress.String())
+			if err != nil {
+				continue
+			}
+			snapshot, err := state.SnapshotFromBytes(resp.Snapshot)
+			if err != nil {
+				return nil, nil, err
+			}
+			addr, ok, err := snapshot.Variables.Codec().GetAddress(vmconst.VarNameSCAddress)
+			if err != nil || !ok || *addr != *bd.RotatedTo {
+				return nil, nil, fmt.Errorf("last state of %s on %s is not rotated to %s",
+					scAddress.String(), host, bd.RotatedTo.String())
+			}
+			return bd.RotatedTo, snapshot, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, nil, fmt.Errorf("rotation of %s was not confirmed in %v", scAddress.String(), timeout)
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// ConfirmRotation tells the node of the old committee that the new committee runs the smart contract,
+// so the node removes the bootup record of the old address
+func ConfirmRotation(host string, scAddress *address.Address) error {
+	data, err := json.Marshal(&admapi.ConfirmRotationRequest{
+		Address: scAddress.String(),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/adm/confirmrotation", host), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response status %d", resp.StatusCode)
+	}
+	var result misc.SimpleResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
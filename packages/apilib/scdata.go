@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/webapi/admapi"
 	"github.com/iotaledger/wasp/plugins/webapi/misc"
 	"net/http"
@@ -15,11 +16,12 @@ import (
 // PutSCData calls node to write BootupData record
 func PutSCData(host string, bd registry.BootupData) error {
 	data, err := json.Marshal(&admapi.BootupDataJsonable{
-		Address:        bd.Address.String(),
-		OwnerAddress:   bd.OwnerAddress.String(),
-		Color:          bd.Color.String(),
-		CommitteeNodes: bd.CommitteeNodes,
-		AccessNodes:    bd.AccessNodes,
+		Address:          bd.Address.String(),
+		OwnerAddress:     bd.OwnerAddress.String(),
+		Color:            bd.Color.String(),
+		CommitteeNodes:   bd.CommitteeNodes,
+		AccessNodes:      bd.AccessNodes,
+		CommitteePubKeys: admapi.PubKeysToBase58(bd.CommitteePubKeys),
 	})
 	if err != nil {
 		return err
@@ -74,6 +76,22 @@ func GetSCData(host string, addr *address.Address) (*registry.BootupData, bool,
 	if ret.Address, err = address.FromBase58(dresp.Address); err != nil {
 		return nil, false, err
 	}
+	if ret.OwnerAddress, err = address.FromBase58(dresp.OwnerAddress); err != nil {
+		return nil, false, err
+	}
+	if ret.Color, err = util.ColorFromString(dresp.Color); err != nil {
+		return nil, false, err
+	}
+	if dresp.RotatedTo != "" {
+		rotatedTo, err := address.FromBase58(dresp.RotatedTo)
+		if err != nil {
+			return nil, false, err
+		}
+		ret.RotatedTo = &rotatedTo
+	}
+	if ret.CommitteePubKeys, err = admapi.PubKeysFromBase58(dresp.CommitteePubKeys); err != nil {
+		return nil, false, err
+	}
 
 	return ret, true, nil
 }
@@ -0,0 +1,27 @@
+package apilib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/wasp/packages/schema"
+	"github.com/iotaledger/wasp/plugins/webapi/stateapi"
+)
+
+// GetSchema returns the schema of the program run by the smart contract
+func GetSchema(host string, scAddress string) (*schema.Schema, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/sc/schema/%s", host, scAddress))
+	if err != nil {
+		return nil, err
+	}
+	var result stateapi.SchemaResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		return nil, fmt.Errorf("sc/schema returned code %d: %s", resp.StatusCode, result.Error)
+	}
+	return result.Schema, nil
+}
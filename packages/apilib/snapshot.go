@@ -0,0 +1,58 @@
+package apilib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/wasp/plugins/webapi/admapi"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+)
+
+// ExportSnapshot returns the snapshot of the solid state of the smart contract on the node.
+// The snapshot is verified against the state hash in the state transaction before import
+func ExportSnapshot(host string, scAddress string) (*admapi.ExportSnapshotResponse, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/adm/exportsnapshot/%s", host, scAddress))
+	if err != nil {
+		return nil, err
+	}
+	var result admapi.ExportSnapshotResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || result.Err != "" {
+		return nil, fmt.Errorf("adm/exportsnapshot returned code %d: %s", resp.StatusCode, result.Err)
+	}
+	return &result, nil
+}
+
+// ImportSnapshot imports the snapshot into the node which has no state of the smart contract yet.
+// Must be called before the smart contract is activated on the node.
+// The node verifies the snapshot against the state hash in the state transaction of the snapshot
+func ImportSnapshot(host string, snapshot []byte) error {
+	data, err := json.Marshal(&admapi.ImportSnapshotRequest{
+		Snapshot: snapshot,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/adm/importsnapshot", host), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response status %d", resp.StatusCode)
+	}
+	var result misc.SimpleResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
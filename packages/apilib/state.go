@@ -6,14 +6,25 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/plugins/webapi/admapi"
 	"github.com/iotaledger/wasp/plugins/webapi/stateapi"
 )
 
 func DumpSCState(host string, scAddress string) (*admapi.DumpSCStateResponse, error) {
-	url := fmt.Sprintf("http://%s/adm/dumpscstate/%s", host, scAddress)
+	return dumpSCState(fmt.Sprintf("http://%s/adm/dumpscstate/%s", host, scAddress))
+}
+
+// DumpSCStateDecoded dumps the state together with variables decoded according to the schema of the smart contract
+func DumpSCStateDecoded(host string, scAddress string) (*admapi.DumpSCStateResponse, error) {
+	return dumpSCState(fmt.Sprintf("http://%s/adm/dumpscstate/%s?decode=true", host, scAddress))
+}
+
+func dumpSCState(url string) (*admapi.DumpSCStateResponse, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
@@ -32,6 +43,25 @@ func DumpSCState(host string, scAddress string) (*admapi.DumpSCStateResponse, er
 	return &result, nil
 }
 
+// ReadCacheStats returns statistics of the read cache of state variables of the smart contract on the node
+func ReadCacheStats(host string, scAddress string) (*admapi.ReadCacheStatsResponse, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/adm/cachestats/%s", host, scAddress))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status %d", resp.StatusCode)
+	}
+	var result admapi.ReadCacheStatsResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Err != "" {
+		return nil, errors.New(result.Err)
+	}
+	return &result, nil
+}
+
 func QuerySCState(host string, query *stateapi.QueryRequest) (map[kv.Key]*stateapi.QueryResult, error) {
 	url := fmt.Sprintf("http://%s/sc/state/query", host)
 	data, err := json.Marshal(query)
@@ -58,6 +88,97 @@ func QuerySCState(host string, query *stateapi.QueryRequest) (map[kv.Key]*statea
 	return results, nil
 }
 
+// QueryStateProof returns the value of the state variable with the proof.
+// The caller verifies it with ProofResponse.Verify against the state hash of the state transaction
+func QueryStateProof(host string, scAddress string, key kv.Key) (*stateapi.ProofResponse, error) {
+	data, err := json.Marshal(&stateapi.ProofRequest{Address: scAddress, Key: []byte(key)})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/sc/state/proof", host), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	var proofResponse stateapi.ProofResponse
+	err = json.NewDecoder(resp.Body).Decode(&proofResponse)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || proofResponse.Error != "" {
+		return nil, fmt.Errorf("sc/state/proof returned code %d: %s", resp.StatusCode, proofResponse.Error)
+	}
+	return &proofResponse, nil
+}
+
+// QuerySCEvents returns events of the smart contract emitted in states fromIndex..toIndex inclusive.
+// Empty name means events with any name
+func QuerySCEvents(host string, scAddress string, name string, fromIndex, toIndex uint32) ([]*stateapi.EventInfo, error) {
+	query := url.Values{}
+	query.Set("from", strconv.FormatUint(uint64(fromIndex), 10))
+	query.Set("to", strconv.FormatUint(uint64(toIndex), 10))
+	if name != "" {
+		query.Set("name", name)
+	}
+	resp, err := http.Get(fmt.Sprintf("http://%s/sc/events/%s?%s", host, scAddress, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	var eventsResponse stateapi.EventsResponse
+	err = json.NewDecoder(resp.Body).Decode(&eventsResponse)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || eventsResponse.Error != "" {
+		return nil, fmt.Errorf("sc/events returned code %d: %s", resp.StatusCode, eventsResponse.Error)
+	}
+	return eventsResponse.Events, nil
+}
+
+// QueryRequestReceipt returns the receipt of the request settled in the solid state of the smart contract
+func QueryRequestReceipt(host string, scAddress string, reqid *sctransaction.RequestId) (*stateapi.ReceiptResponse, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/sc/receipt/%s/%s", host, scAddress, url.PathEscape(reqid.String())))
+	if err != nil {
+		return nil, err
+	}
+	var receipt stateapi.ReceiptResponse
+	err = json.NewDecoder(resp.Body).Decode(&receipt)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || receipt.Error != "" {
+		return nil, fmt.Errorf("sc/receipt returned code %d: %s", resp.StatusCode, receipt.Error)
+	}
+	return &receipt, nil
+}
+
+// CallView runs the view entry point of the smart contract on the node and returns its results
+func CallView(host string, scAddress string, code sctransaction.RequestCode, params kv.Map) (kv.Map, error) {
+	req := &stateapi.ViewRequest{Params: make([]stateapi.KeyValuePair, 0)}
+	if params != nil {
+		params.ForEachDeterministic(func(key kv.Key, value []byte) bool {
+			req.Params = append(req.Params, stateapi.KeyValuePair{Key: []byte(key), Value: value})
+			return true
+		})
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/sc/view/%s/%d", host, scAddress, uint16(code)), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	var viewResponse stateapi.ViewResponse
+	err = json.NewDecoder(resp.Body).Decode(&viewResponse)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || viewResponse.Error != "" {
+		return nil, fmt.Errorf("sc/view returned code %d: %s", resp.StatusCode, viewResponse.Error)
+	}
+	return toMap(viewResponse.Results), nil
+}
+
 func toBytes(keys []kv.Key) [][]byte {
 	ret := make([][]byte, 0)
 	for _, v := range keys {
@@ -1,8 +1,10 @@
 package commiteeimpl
 
 import (
+	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/committee"
 	"github.com/iotaledger/wasp/packages/committee/consensus"
@@ -32,6 +34,9 @@ type committeeObj struct {
 	stateMgr     committee.StateManager
 	operator     committee.Operator
 	log          *logger.Logger
+	// latest state index evidenced by messages of each peer
+	peerStateIndex      map[uint16]uint32
+	peerStateIndexMutex sync.Mutex
 }
 
 func newCommitteeObj(bootupData *registry.BootupData, log *logger.Logger) committee.Committee {
@@ -76,12 +81,13 @@ func newCommitteeObj(bootupData *registry.BootupData, log *logger.Logger) commit
 	}
 
 	ret := &committeeObj{
-		chMsg:        make(chan interface{}, 100),
-		address:      bootupData.Address,
-		ownerAddress: bootupData.OwnerAddress,
-		color:        bootupData.Color,
-		peers:        make([]*peering.Peer, 0),
-		log:          log.Named(util.Short(bootupData.Address.String())),
+		chMsg:          make(chan interface{}, 100),
+		address:        bootupData.Address,
+		ownerAddress:   bootupData.OwnerAddress,
+		color:          bootupData.Color,
+		peers:          make([]*peering.Peer, 0),
+		peerStateIndex: make(map[uint16]uint32),
+		log:            log.Named(util.Short(bootupData.Address.String())),
 	}
 	if keyExists {
 		ret.ownIndex = dkshare.Index
@@ -90,7 +96,18 @@ func newCommitteeObj(bootupData *registry.BootupData, log *logger.Logger) commit
 		for _, remoteLocation := range bootupData.CommitteeNodes {
 			ret.peers = append(ret.peers, peering.UsePeer(remoteLocation))
 		}
+		if err := pinPeerKeys(ret.peers, bootupData.CommitteePubKeys); err != nil {
+			log.Errorf("can't create committee object for %s: %v", addr.String(), err)
+			for _, p := range ret.peers {
+				if p != nil {
+					peering.StopUsingPeer(p.PeeringId())
+				}
+			}
+			return nil
+		}
 	}
+	// keys of access nodes are not in the bootup data. The connection with the access node is
+	// authenticated only if its key is pinned by a committee the node and the access node are both in
 	for _, remoteLocation := range bootupData.AccessNodes {
 		p := peering.UsePeer(remoteLocation)
 		if p != nil {
@@ -111,6 +128,30 @@ func newCommitteeObj(bootupData *registry.BootupData, log *logger.Logger) commit
 	return ret
 }
 
+// pinPeerKeys pins identity keys of committee peers from the bootup data. Peers are authenticated
+// only with the pinned keys, so the bootup data without keys can't be used by the committee.
+// The key in the position of the own node must be the identity of the node
+func pinPeerKeys(peers []*peering.Peer, pubKeys []ed25519.PublicKey) error {
+	if len(pubKeys) == 0 {
+		return fmt.Errorf("bootup data contains no public keys of committee nodes, peers can't be authenticated")
+	}
+	if len(pubKeys) != len(peers) {
+		return fmt.Errorf("number of committee public keys must be equal to the number of committee nodes")
+	}
+	for i, p := range peers {
+		if p == nil {
+			if pubKeys[i] != peering.MyPublicKey() {
+				return fmt.Errorf("public key %s doesn't match identity of the own node", pubKeys[i].String())
+			}
+			continue
+		}
+		if err := p.PinPublicKey(pubKeys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // iAmInTheCommittee checks if netLocations makes sense
 func iAmInTheCommittee(committeeNodes []string, n, index uint16) bool {
 	if len(committeeNodes) != int(n) {
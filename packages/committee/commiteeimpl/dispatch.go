@@ -15,8 +15,16 @@ func (c *committeeObj) dispatchMessage(msg interface{}) {
 	switch msgt := msg.(type) {
 
 	case *peering.PeerMessage:
-		// receive a message from peer
-		c.processPeerMessage(msgt)
+		// receive a message from peer. The sender index is the index of the peer of the connection,
+		// the one in the message is not trusted
+		senderIndex, ok := c.peerIndex(msgt.SenderPeer)
+		if !ok {
+			c.log.Warnf("message type %d from unknown peer ignored", msgt.MsgType)
+			return
+		}
+		msgCopy := *msgt
+		msgCopy.SenderIndex = senderIndex
+		c.processPeerMessage(&msgCopy)
 
 	case *committee.StateUpdateMsg:
 		// StateUpdateMsg may come from peer and from own consensus operator
@@ -50,6 +58,11 @@ func (c *committeeObj) dispatchMessage(msg interface{}) {
 			c.operator.EventBalancesMsg(msgt)
 		}
 
+	case committee.DKShareRefreshedMsg:
+		if c.operator != nil {
+			c.operator.EventDKShareRefreshedMsg(msgt)
+		}
+
 	case *vm.VMTask:
 		// VM finished working
 		if c.operator != nil {
@@ -69,6 +82,19 @@ func (c *committeeObj) dispatchMessage(msg interface{}) {
 	}
 }
 
+// peerIndex returns the index of the peer among committee and access peers
+func (c *committeeObj) peerIndex(peer *peering.Peer) (uint16, bool) {
+	if peer == nil {
+		return 0, false
+	}
+	for i, p := range c.peers {
+		if p == peer {
+			return uint16(i), true
+		}
+	}
+	return 0, false
+}
+
 func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 
 	rdr := bytes.NewReader(msg.MsgData)
@@ -82,6 +108,7 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 		c.stateMgr.EvidenceStateIndex(msgt.StateIndex)
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
 
 		msgt.SenderIndex = msg.SenderIndex
 
@@ -96,6 +123,7 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 		c.stateMgr.EvidenceStateIndex(msgt.StateIndex)
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
 
 		msgt.SenderIndex = msg.SenderIndex
 
@@ -110,6 +138,7 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 		c.stateMgr.EvidenceStateIndex(msgt.StateIndex)
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
 
 		msgt.SenderIndex = msg.SenderIndex
 		msgt.Timestamp = msg.Timestamp
@@ -125,6 +154,7 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 		c.stateMgr.EvidenceStateIndex(msgt.StateIndex)
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
 
 		msgt.SenderIndex = msg.SenderIndex
 		msgt.Timestamp = msg.Timestamp
@@ -140,6 +170,9 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 
+		// the peer is syncing, it may request batches from the state index on
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
+
 		msgt.SenderIndex = msg.SenderIndex
 
 		c.stateMgr.EventGetBatchMsg(msgt)
@@ -151,6 +184,7 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 		c.stateMgr.EvidenceStateIndex(msgt.StateIndex)
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
 
 		msgt.SenderIndex = msg.SenderIndex
 		c.stateMgr.EventBatchHeaderMsg(msgt)
@@ -162,6 +196,7 @@ func (c *committeeObj) processPeerMessage(msg *peering.PeerMessage) {
 			return
 		}
 		c.stateMgr.EvidenceStateIndex(msgt.StateIndex)
+		c.evidencePeerStateIndex(msg.SenderIndex, msgt.StateIndex)
 
 		msgt.SenderIndex = msg.SenderIndex
 		c.stateMgr.EventStateUpdateMsg(msgt)
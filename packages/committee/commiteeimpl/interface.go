@@ -5,6 +5,7 @@ import (
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/wasp/packages/committee"
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/peering"
 	"github.com/iotaledger/wasp/plugins/publisher"
@@ -191,3 +192,39 @@ func (c *committeeObj) NumPeers() uint16 {
 func (c *committeeObj) committeePeers() []*peering.Peer {
 	return c.peers[:c.size]
 }
+
+// OldestPeerStateIndex returns the smallest of state indices latest evidenced by each of other peers.
+// Peers may still request batches from that state index on.
+// Returns false if some peers were not heard of yet
+func (c *committeeObj) OldestPeerStateIndex() (uint32, bool) {
+	c.peerStateIndexMutex.Lock()
+	defer c.peerStateIndexMutex.Unlock()
+
+	var ret uint32
+	first := true
+	for i := range c.peers {
+		if uint16(i) == c.ownIndex && c.size > 0 {
+			continue
+		}
+		idx, ok := c.peerStateIndex[uint16(i)]
+		if !ok {
+			return 0, false
+		}
+		if first || idx < ret {
+			ret = idx
+			first = false
+		}
+	}
+	return ret, !first
+}
+
+func (c *committeeObj) evidencePeerStateIndex(peerIndex uint16, stateIndex uint32) {
+	c.peerStateIndexMutex.Lock()
+	defer c.peerStateIndexMutex.Unlock()
+
+	c.peerStateIndex[peerIndex] = stateIndex
+}
+
+func (c *committeeObj) ReadCacheStats() (kv.CacheStats, bool) {
+	return c.stateMgr.ReadCacheStats()
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/packages/vm"
 )
@@ -26,6 +27,9 @@ type Committee interface {
 	SetReadyConsensus()
 	Dismiss()
 	IsDismissed() bool
+	OldestPeerStateIndex() (uint32, bool)
+	// statistics of the read cache of state variables. False if the state is not loaded yet
+	ReadCacheStats() (kv.CacheStats, bool)
 }
 
 type StateManager interface {
@@ -36,6 +40,7 @@ type StateManager interface {
 	EventStateTransactionMsg(msg StateTransactionMsg)
 	EventPendingBatchMsg(msg PendingBatchMsg)
 	EventTimerMsg(msg TimerTick)
+	ReadCacheStats() (kv.CacheStats, bool)
 }
 
 type Operator interface {
@@ -48,6 +53,7 @@ type Operator interface {
 	EventStartProcessingBatchMsg(*StartProcessingBatchMsg)
 	EventResultCalculated(*vm.VMTask)
 	EventSignedHashMsg(*SignedHashMsg)
+	EventDKShareRefreshedMsg(DKShareRefreshedMsg)
 	EventTimerMsg(TimerTick)
 }
 
@@ -13,6 +13,9 @@ import (
 )
 
 func (op *operator) EventProcessorReady(msg committee.ProcessorIsReady) {
+	if _, ok := op.upgradeProcessors[msg.ProgramHash]; ok {
+		op.log.Infof("VM processor for the upgrade is ready. Program hash: %s", msg.ProgramHash)
+	}
 	if op.processorReady {
 		return
 	}
@@ -27,7 +30,11 @@ func (op *operator) EventProcessorReady(msg committee.ProcessorIsReady) {
 func (op *operator) EventStateTransitionMsg(msg *committee.StateTransitionMsg) {
 	op.setNewState(msg.StateTransaction, msg.VariableState, msg.Synchronized)
 
-	vh := op.currentState.Hash()
+	vh, err := op.currentState.Hash()
+	if err != nil {
+		op.log.Errorf("can't calculate the state hash: %v", err)
+		return
+	}
 	op.log.Infof("STATE FOR CONSENSUS #%d, synced: %v, leader: %d iAmTheLeader: %v",
 		op.mustStateIndex(), msg.Synchronized, op.peerPermutation.Current(), op.iAmCurrentLeader())
 	op.log.Debugf("STATE FOR CONSENSUS #%d, currentState txid: %s, currentState hash: %s",
@@ -50,18 +57,16 @@ func (op *operator) EventStateTransitionMsg(msg *committee.StateTransitionMsg) {
 		return
 	}
 	progHashStr := progHash.String()
-	op.processorReady = processor.CheckProcessor(progHashStr)
+	if op.programHash != "" && op.programHash != progHashStr {
+		// the smart contract was upgraded
+		op.log.Infof("program hash changed from %s to %s. Unloading old processor", op.programHash, progHashStr)
+		processor.UnloadProcessor(op.programHash, op.committee.Address())
+	}
+	op.programHash = progHashStr
+	op.releaseUpgradeProcessors()
+	op.processorReady = processor.UseProcessor(progHashStr, op.committee.Address())
 	if !op.processorReady {
-		processor.LoadProcessorAsync(progHashStr, func(err error) {
-			if err == nil {
-				op.committee.ReceiveMessage(committee.ProcessorIsReady{
-					ProgramHash: progHashStr,
-				})
-				publisher.Publish("vmready", op.committee.Address().String(), progHashStr)
-			} else {
-				op.log.Warnf("failed to load processor: %v", err)
-			}
-		})
+		op.loadProcessorAsync(progHashStr)
 	}
 
 	op.takeAction()
@@ -245,6 +250,20 @@ func (op *operator) EventSignedHashMsg(msg *committee.SignedHashMsg) {
 	op.takeAction()
 }
 
+// EventDKShareRefreshedMsg replaces the key share with the refreshed one. The address stays the same.
+// Signatures of the batch in progress may be inconsistent if nodes switch to refreshed shares
+// at different moments. Then the batch is not finalized and the requests are processed again
+func (op *operator) EventDKShareRefreshedMsg(msg committee.DKShareRefreshedMsg) {
+	if *msg.DKShare.Address != *op.dkshare.Address || msg.DKShare.Index != op.dkshare.Index {
+		op.log.Errorf("refreshed key share doesn't match the committee")
+		return
+	}
+	op.log.Infof("key share of the committee was refreshed")
+	old := op.dkshare
+	op.dkshare = msg.DKShare
+	old.ErasePrivate()
+}
+
 func (op *operator) EventTimerMsg(msg committee.TimerTick) {
 	if msg%40 == 0 {
 		stateIndex, ok := op.stateIndex()
@@ -0,0 +1,87 @@
+package consensus
+
+import (
+	"github.com/iotaledger/wasp/packages/committee"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/processor"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/plugins/publisher"
+)
+
+// loadProcessorAsync loads the processor of the program hash in the background.
+// The committee is notified with ProcessorIsReady message when it is loaded
+func (op *operator) loadProcessorAsync(progHashStr string) {
+	processor.LoadProcessorAsync(progHashStr, func(err error) {
+		if err == nil {
+			op.committee.ReceiveMessage(committee.ProcessorIsReady{
+				ProgramHash: progHashStr,
+			})
+			publisher.Publish("vmready", op.committee.Address().String(), progHashStr)
+		} else {
+			op.log.Warnf("failed to load processor: %v", err)
+		}
+	})
+}
+
+// upgradeProgramHash returns the program hash the upgrade request moves the smart contract to.
+// Returns false if the request is not authorised by the owner or has no program hash:
+// the VM rejects such requests without the new processor
+func (op *operator) upgradeProgramHash(req *request) (string, bool) {
+	reqRef := sctransaction.RequestRef{Tx: req.reqTx, Index: req.reqId.Index()}
+	if !reqRef.IsAuthorised(op.committee.OwnerAddress()) {
+		return "", false
+	}
+	progHash, ok, err := reqRef.RequestBlock().Args().GetHashValue(vmconst.VarNameProgramHash)
+	if err != nil || !ok {
+		return "", false
+	}
+	return progHash.String(), true
+}
+
+// upgradeProcessorReady checks if the processor of the program hash the upgrade request moves the smart contract to
+// is loaded. If not, loading is started and the request is not selected for the batch until it is loaded.
+// This way the VM of each node runs the upgrade with the processor loaded in advance and the result
+// doesn't depend on loading the program during the batch.
+// The committee is registered as a user of the processor until the request is completed
+func (op *operator) upgradeProcessorReady(req *request) bool {
+	progHash, ok := op.upgradeProgramHash(req)
+	if !ok || progHash == op.programHash {
+		return true
+	}
+	if _, ok := op.upgradeProcessors[progHash]; ok {
+		return processor.CheckProcessor(progHash)
+	}
+	op.upgradeProcessors[progHash] = struct{}{}
+	if processor.UseProcessor(progHash, op.committee.Address()) {
+		return true
+	}
+	op.loadProcessorAsync(progHash)
+	return false
+}
+
+// releaseUpgradeProcessors unregisters the committee from processors not needed by pending upgrade requests
+// and not used by the smart contract. Processors which failed to load are loaded again when their requests
+// are selected next time
+func (op *operator) releaseUpgradeProcessors() {
+	needed := make(map[string]struct{})
+	for _, req := range op.requests {
+		if req.reqTx == nil || req.requestCode() != vmconst.RequestCodeUpgrade {
+			continue
+		}
+		if progHash, ok := op.upgradeProgramHash(req); ok {
+			needed[progHash] = struct{}{}
+		}
+	}
+	for progHash := range op.upgradeProcessors {
+		if _, ok := needed[progHash]; ok {
+			if !processor.CheckProcessor(progHash) {
+				delete(op.upgradeProcessors, progHash)
+			}
+			continue
+		}
+		delete(op.upgradeProcessors, progHash)
+		if progHash != op.programHash {
+			processor.UnloadProcessor(progHash, op.committee.Address())
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
 	"sort"
 	"time"
 )
@@ -287,6 +288,10 @@ func (op *operator) filterNotReadyYet(reqs []*request) []*request {
 			op.log.Debugf("request %s can't be processed: processor not ready", req.reqId.Short())
 			continue
 		}
+		if req.requestCode() == vmconst.RequestCodeUpgrade && !op.upgradeProcessorReady(req) {
+			op.log.Debugf("request %s can't be processed: processor of the upgrade not ready", req.reqId.Short())
+			continue
+		}
 		ret = append(ret, req)
 	}
 	before := len(ret)
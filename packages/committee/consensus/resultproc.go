@@ -48,6 +48,7 @@ func (op *operator) runCalculationsAsync(par runCalculationsParams) {
 		OwnerAddress:    *op.committee.OwnerAddress(),
 		RewardAddress:   par.rewardAddress,
 		MinimumReward:   op.getMinimumReward(),
+		GasLimit:        op.getGasLimit(),
 		Requests:        takeRefs(par.requests),
 		Timestamp:       par.timestamp,
 		VirtualState:    op.currentState,
@@ -29,6 +29,10 @@ type operator struct {
 
 	requestBalancesDeadline time.Time
 	processorReady          bool
+	// program hash of the processor used by the committee. Changes when the smart contract is upgraded
+	programHash string
+	// program hashes of pending upgrade requests. The committee is registered as a user of their processors
+	upgradeProcessors map[string]struct{}
 
 	// notifications with future currentState indices
 	notificationsBacklog []*committee.NotifyReqMsg
@@ -84,6 +88,7 @@ func NewOperator(committee committee.Committee, dkshare *tcrypto.DKShare, log *l
 		committee:           committee,
 		dkshare:             dkshare,
 		requests:            make(map[sctransaction.RequestId]*request),
+		upgradeProcessors:   make(map[string]struct{}),
 		peerPermutation:     util.NewPermutation16(committee.Size(), nil),
 		sentResultsToLeader: make(map[uint16]*sctransaction.Transaction),
 		log:                 log.Named("c"),
@@ -132,6 +137,24 @@ func (op *operator) getRewardAddress() address.Address {
 	return registry.GetRewardAddress(op.committee.Address())
 }
 
+func (op *operator) getGasLimit() int {
+	if _, ok := op.stateIndex(); !ok {
+		return vmconst.DefaultGasLimit
+	}
+	vt, ok, err := op.currentState.Variables().Codec().GetInt64(vmconst.VarNameGasLimit)
+	if err != nil {
+		panic(err)
+	}
+	if !ok {
+		return vmconst.DefaultGasLimit
+	}
+	if vt > vmconst.MaxGasLimit {
+		// limits set before the maximum was introduced
+		return vmconst.MaxGasLimit
+	}
+	return int(vt)
+}
+
 func (op *operator) getMinimumReward() int64 {
 	if _, ok := op.stateIndex(); !ok {
 		return 0
@@ -8,6 +8,7 @@ import (
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/tcrypto"
 	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
 	"github.com/iotaledger/wasp/plugins/peering"
 )
@@ -138,3 +139,9 @@ type PendingBatchMsg struct {
 type ProcessorIsReady struct {
 	ProgramHash string // base58
 }
+
+// key share of the committee was refreshed and committed to the registry. The operator replaces the old one
+// only sent internally within the node
+type DKShareRefreshedMsg struct {
+	DKShare *tcrypto.DKShare
+}
@@ -2,9 +2,12 @@ package statemgr
 
 import (
 	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/wasp/packages/committee"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/nodeconn"
@@ -70,6 +73,8 @@ func (sm *stateManager) checkStateApproval() bool {
 		if sm.solidState != nil {
 			sm.log.Infof("STATE TRANSITION TO #%d. Anchor transaction: %s, batch size: %d",
 				pending.nextState.StateIndex(), sm.nextStateTransaction.ID().String(), pending.batch.Size())
+			cacheStats := pending.nextState.Variables().CacheStats()
+			sm.log.Debugf("STATE TRANSITION. Read cache hits: %d, misses: %d", cacheStats.Hits, cacheStats.Misses)
 			sm.log.Debugf("STATE TRANSITION. AccessState hash: %s, batch essence: %s",
 				varStateHash.String(), pending.batch.EssenceHash().String())
 		} else {
@@ -85,8 +90,13 @@ func (sm *stateManager) checkStateApproval() bool {
 		sm.log.Infof("INITIAL STATE #%d LOADED. AccessState hash: %s, state txid: %s",
 			sm.solidState.StateIndex(), varStateHash.String(), sm.nextStateTransaction.ID().String())
 	}
+	// the state transaction is exported with snapshots of the solid state
+	if err := state.SaveStateTransaction(sm.committee.Address(), sm.nextStateTransaction); err != nil {
+		sm.log.Errorf("failed to save state transaction %s: %v", sm.nextStateTransaction.ID().String(), err)
+	}
 	sm.solidStateValid = true
 	sm.solidState = pending.nextState
+	sm.solidVariables.Store(sm.solidState.Variables())
 
 	saveTx := sm.nextStateTransaction
 
@@ -117,6 +127,11 @@ func (sm *stateManager) checkStateApproval() bool {
 		)
 	}
 
+	if newAddr, rotated := sm.rotatedTo(saveTx); rotated {
+		sm.handOver(newAddr)
+		return true
+	}
+
 	go func() {
 		sm.committee.ReceiveMessage(&committee.StateTransitionMsg{
 			VariableState:    sm.solidState,
@@ -127,6 +142,47 @@ func (sm *stateManager) checkStateApproval() bool {
 	return true
 }
 
+// rotatedTo checks if the state transaction moved the smart contract token to another address
+func (sm *stateManager) rotatedTo(tx *sctransaction.Transaction) (address.Address, bool) {
+	stateAddr, ok, err := tx.StateAddress()
+	if !ok || err != nil || stateAddr == *sm.committee.Address() {
+		return address.Address{}, false
+	}
+	return stateAddr, true
+}
+
+// handOver is called when the solid state is the last state of the smart contract at the address of the committee:
+// the smart contract was rotated to the address of the new committee, which takes over the state.
+// The bootup record of the old address is marked as rotated and the committee is dismissed.
+// The record and the state are kept until the new committee confirms it runs the smart contract
+// (see adm/confirmrotation), so the handover can be completed again from this node
+func (sm *stateManager) handOver(newAddr address.Address) {
+	sm.log.Infof("SMART CONTRACT ROTATED at state #%d to the address %s. Dismissing the committee",
+		sm.solidState.StateIndex(), newAddr.String())
+
+	if err := markRotated(sm.committee.Address(), &newAddr); err != nil {
+		sm.log.Errorf("failed to update bootup record: %v", err)
+	}
+	publisher.Publish("rotated",
+		sm.committee.Address().String(),
+		newAddr.String(),
+		strconv.Itoa(int(sm.solidState.StateIndex())),
+	)
+	sm.committee.Dismiss()
+}
+
+func markRotated(addr, newAddr *address.Address) error {
+	bd, exists, err := registry.GetBootupData(addr)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bootup record of %s not found", addr.String())
+	}
+	bd.RotatedTo = newAddr
+	return registry.SaveBootupData(bd, false)
+}
+
 func (sm *stateManager) requestStateUpdateFromPeerIfNeeded() {
 	if sm.isSynchronized() || sm.solidState == nil {
 		// state is synced, no need for more info
@@ -237,22 +293,32 @@ func (sm *stateManager) addPendingBatch(batch state.Batch) bool {
 		}
 	}
 
-	// include the bach to pending batches map
-	vh := stateToApprove.Hash()
-	pb, ok := sm.pendingBatches[vh]
-	if !ok || pb.batch.StateTransactionId() == niltxid {
-		pb = &pendingBatch{
-			batch:     batch,
-			nextState: stateToApprove,
-		}
-		sm.pendingBatches[vh] = pb
+	// include the bach to pending batches map.
+	// The state of the chain started before the Merkle tree may be anchored by the hash of the chain only,
+	// so the batch is included by each hash the state can be anchored with
+	states, err := state.StatesToApprove(stateToApprove)
+	if err != nil {
+		sm.log.Errorf("can't calculate the state hash: %v", err)
+		return false
 	}
+	var pb *pendingBatch
+	for vh, nextState := range states {
+		p, ok := sm.pendingBatches[vh]
+		if !ok || p.batch.StateTransactionId() == niltxid {
+			p = &pendingBatch{
+				batch:     batch,
+				nextState: nextState,
+			}
+			sm.pendingBatches[vh] = p
+		}
+		pb = p
 
-	sm.log.Debugw("added new pending batch",
-		"state index", pb.batch.StateIndex(),
-		"state hash", vh.String(),
-		"approving tx", pb.batch.StateTransactionId().String(),
-	)
+		sm.log.Debugw("added new pending batch",
+			"state index", p.batch.StateIndex(),
+			"state hash", vh.String(),
+			"approving tx", p.batch.StateTransactionId().String(),
+		)
+	}
 	// request approving transaction from the node. It may also come without request
 	if batch.StateTransactionId() != niltxid {
 		sm.requestStateTransaction(pb)
@@ -3,12 +3,14 @@
 package statemgr
 
 import (
+	"sync/atomic"
 	"time"
 
 	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/committee"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/util"
@@ -35,6 +37,9 @@ type stateManager struct {
 	// it may be nil at bootstrap when origin variable state is calculated
 	solidState state.VirtualState
 
+	// variables of the solid state, kv.BufferedKVStore. Read by other goroutines for statistics of the read cache
+	solidVariables atomic.Value
+
 	// largest state index evidenced by other messages. If this index is more than 1 step ahead
 	// of the solid variable state, it means the state of the smart contract in the current node
 	// falls behind the state of the smart contract, i.e. it is not synced
@@ -95,9 +100,15 @@ func (sm *stateManager) initLoadState() {
 	}
 
 	if stateExists {
+		sm.solidVariables.Store(sm.solidState.Variables())
 		sm.addPendingBatch(batch)
 
-		h := sm.solidState.Hash()
+		h, err := sm.solidState.Hash()
+		if err != nil {
+			sm.log.Error(err)
+			sm.committee.Dismiss()
+			return
+		}
 		txh := batch.StateTransactionId()
 		sm.log.Debugw("solid state state has been loaded",
 			"state index", sm.solidState.StateIndex(),
@@ -114,3 +125,13 @@ func (sm *stateManager) initLoadState() {
 	// open msg queue for the committee
 	sm.committee.SetReadyStateManager()
 }
+
+// ReadCacheStats returns statistics of the read cache of variables of the solid state.
+// Returns false if the solid state is not loaded yet
+func (sm *stateManager) ReadCacheStats() (kv.CacheStats, bool) {
+	vars, ok := sm.solidVariables.Load().(kv.BufferedKVStore)
+	if !ok {
+		return kv.CacheStats{}, false
+	}
+	return vars.CacheStats(), true
+}
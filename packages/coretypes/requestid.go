@@ -0,0 +1,83 @@
+package coretypes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const RequestIdSize = hashing.HashSize + 2
+
+// RequestId is the id of the request: the id of the transaction and the index of the request block in it
+type RequestId [RequestIdSize]byte
+
+func NewRequestId(txid valuetransaction.ID, index uint16) (ret RequestId) {
+	copy(ret[:valuetransaction.IDLength], txid.Bytes())
+	copy(ret[valuetransaction.IDLength:], util.Uint16To2Bytes(index)[:])
+	return
+}
+
+func (rid *RequestId) Bytes() []byte {
+	return rid[:]
+}
+
+func (rid *RequestId) TransactionId() *valuetransaction.ID {
+	var ret valuetransaction.ID
+	copy(ret[:], rid[:valuetransaction.IDLength])
+	return &ret
+}
+
+func (rid *RequestId) Index() uint16 {
+	return util.Uint16From2Bytes(rid[valuetransaction.IDLength:])
+}
+
+func (rid *RequestId) Write(w io.Writer) error {
+	_, err := w.Write(rid.Bytes())
+	return err
+}
+
+func (rid *RequestId) Read(r io.Reader) error {
+	n, err := r.Read(rid[:])
+	if err != nil {
+		return err
+	}
+	if n != RequestIdSize {
+		return errors.New("not enough data for RequestId")
+	}
+	return nil
+}
+
+func (rid *RequestId) String() string {
+	return fmt.Sprintf("[%d]%s", rid.Index(), rid.TransactionId().String())
+}
+
+func (rid *RequestId) Short() string {
+	return rid.String()[:8] + ".."
+}
+
+func NewRequestIdFromString(reqIdStr string) (ret RequestId, err error) {
+	splitStr := strings.Split(reqIdStr, "]")
+	if len(splitStr) != 2 {
+		err = fmt.Errorf("wrong request id string")
+		return
+	}
+	indexStr := splitStr[0][1:]
+	indexInt, err := strconv.Atoi(indexStr)
+	if err != nil {
+		err = fmt.Errorf("wrong request id string")
+		return
+	}
+	index := uint16(indexInt)
+	txid, err := valuetransaction.IDFromBase58(splitStr[1])
+	if err != nil {
+		return
+	}
+	ret = NewRequestId(txid, index)
+	return
+}
@@ -55,6 +55,13 @@ func (l *Array) getElemKey(idx uint16) Key {
 	return ArrayElemKey(l.name, idx)
 }
 
+func (l *Array) getElemPrefix() Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(l.name))
+	buf.WriteByte(arrayElemKeyCode)
+	return Key(buf.Bytes())
+}
+
 func ArrayElemKey(name string, idx uint16) Key {
 	var buf bytes.Buffer
 	buf.Write([]byte(name))
@@ -108,9 +115,12 @@ func (l *Array) len() (uint16, error) {
 	return util.Uint16From2Bytes(v), nil
 }
 
-// adds to the end of the list
+// adds to the end of the list. Panics if the array already has 65535 elements, use Array32 for longer lists
 func (l *Array) Push(value []byte) {
 	size := l.Len()
+	if size == ^uint16(0) {
+		panic("array is full")
+	}
 	k := l.getElemKey(size)
 	l.kv.Set(k, value)
 	l.setSize(size + 1)
@@ -131,11 +141,9 @@ func (a *MustArray) Extend(other *MustArray) {
 	a.array.Extend(&other.array)
 }
 
-// TODO implement with DelPrefix
+// Erase deletes all elements of the array with one DelPrefix, independently of the size of the array
 func (l *Array) Erase() {
-	for i := uint16(0); i < l.Len(); i++ {
-		l.kv.Del(l.getElemKey(i))
-	}
+	l.kv.DelPrefix(l.getElemPrefix())
 	l.setSize(0)
 }
 
@@ -0,0 +1,175 @@
+package kv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// Array32 is the array with 32-bit index. It uses the same layout as Array, so it reads and extends
+// arrays created by Array: the size and indices which fit into uint16 are encoded in 2 bytes,
+// larger ones in 4 bytes. An Array32 with at most 65535 elements can be read by Array
+type Array32 struct {
+	kv        KVStore
+	name      string
+	cachedLen uint32
+}
+
+type MustArray32 struct {
+	array Array32
+}
+
+func newArray32(kv KVStore, name string) (*Array32, error) {
+	ret := &Array32{
+		kv:   kv,
+		name: name,
+	}
+	var err error
+	ret.cachedLen, err = ret.len()
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func newMustArray32(array *Array32) *MustArray32 {
+	return &MustArray32{*array}
+}
+
+func (l *Array32) getSizeKey() Key {
+	return ArraySizeKey(l.name)
+}
+
+func (l *Array32) getElemKey(idx uint32) Key {
+	return Array32ElemKey(l.name, idx)
+}
+
+func (l *Array32) getElemPrefix() Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(l.name))
+	buf.WriteByte(arrayElemKeyCode)
+	return Key(buf.Bytes())
+}
+
+func Array32ElemKey(name string, idx uint32) Key {
+	if idx <= uint32(^uint16(0)) {
+		return ArrayElemKey(name, uint16(idx))
+	}
+	var buf bytes.Buffer
+	buf.Write([]byte(name))
+	buf.WriteByte(arrayElemKeyCode)
+	_ = util.WriteUint32(&buf, idx)
+	return Key(buf.Bytes())
+}
+
+func (l *Array32) setSize(size uint32) {
+	switch {
+	case size == 0:
+		l.kv.Del(l.getSizeKey())
+	case size <= uint32(^uint16(0)):
+		l.kv.Set(l.getSizeKey(), util.Uint16To2Bytes(uint16(size)))
+	default:
+		l.kv.Set(l.getSizeKey(), util.Uint32To4Bytes(size))
+	}
+	l.cachedLen = size
+}
+
+// Len == 0/empty/non-existent are equivalent
+func (l *Array32) Len() uint32 {
+	return l.cachedLen
+}
+
+func (a *MustArray32) Len() uint32 {
+	return a.array.Len()
+}
+
+func (l *Array32) len() (uint32, error) {
+	v, err := l.kv.Get(l.getSizeKey())
+	if err != nil {
+		return 0, err
+	}
+	switch len(v) {
+	case 0:
+		return 0, nil
+	case 2:
+		return uint32(util.Uint16From2Bytes(v)), nil
+	case 4:
+		return util.Uint32From4Bytes(v), nil
+	}
+	return 0, fmt.Errorf("corrupted data: %v", v)
+}
+
+// adds to the end of the list
+func (l *Array32) Push(value []byte) error {
+	size := l.Len()
+	if size == ^uint32(0) {
+		return errors.New("array is full")
+	}
+	l.kv.Set(l.getElemKey(size), value)
+	l.setSize(size + 1)
+	return nil
+}
+
+func (a *MustArray32) Push(value []byte) {
+	if err := a.array.Push(value); err != nil {
+		panic(err)
+	}
+}
+
+func (l *Array32) Extend(other *Array32) error {
+	for i := uint32(0); i < other.Len(); i++ {
+		v, err := other.GetAt(i)
+		if err != nil {
+			return err
+		}
+		if err := l.Push(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *MustArray32) Extend(other *MustArray32) {
+	if err := a.array.Extend(&other.array); err != nil {
+		panic(err)
+	}
+}
+
+// Erase deletes all elements of the array with one DelPrefix, independently of the size of the array
+func (l *Array32) Erase() {
+	l.kv.DelPrefix(l.getElemPrefix())
+	l.setSize(0)
+}
+
+func (a *MustArray32) Erase() {
+	a.array.Erase()
+}
+
+func (l *Array32) GetAt(idx uint32) ([]byte, error) {
+	if idx >= l.Len() {
+		return nil, errors.New("index out of range")
+	}
+	return l.kv.Get(l.getElemKey(idx))
+}
+
+func (a *MustArray32) GetAt(idx uint32) []byte {
+	ret, err := a.array.GetAt(idx)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func (l *Array32) SetAt(idx uint32, value []byte) bool {
+	if idx >= l.Len() {
+		return false
+	}
+	l.kv.Set(l.getElemKey(idx), value)
+	return true
+}
+
+func (a *MustArray32) SetAt(idx uint32, value []byte) bool {
+	return a.array.SetAt(idx, value)
+}
@@ -0,0 +1,68 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArray32ReadsArray(t *testing.T) {
+	vars := NewMap()
+	arr, err := newArray(vars, "testArray")
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		arr.Push([]byte{byte(i)})
+	}
+
+	arr32, err := newArray32(vars, "testArray")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, arr32.Len())
+	v, err := arr32.GetAt(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3}, v)
+
+	assert.NoError(t, arr32.Push([]byte{10}))
+	arr, err = newArray(vars, "testArray")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, arr.Len())
+	v, err = arr.GetAt(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{10}, v)
+}
+
+func TestArray32Beyond16Bit(t *testing.T) {
+	vars := NewMap()
+	arr, err := newArray(vars, "testArray")
+	assert.NoError(t, err)
+	for i := 0; i < int(^uint16(0)); i++ {
+		arr.Push(util.Uint32To4Bytes(uint32(i)))
+	}
+	assert.Panics(t, func() {
+		arr.Push([]byte{0})
+	})
+
+	arr32, err := newArray32(vars, "testArray")
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, arr32.Push(util.Uint32To4Bytes(uint32(arr32.Len()))))
+	}
+	assert.EqualValues(t, 65545, arr32.Len())
+	for _, idx := range []uint32{0, 65534, 65535, 65544} {
+		v, err := arr32.GetAt(idx)
+		assert.NoError(t, err)
+		assert.Equal(t, util.Uint32To4Bytes(idx), v)
+	}
+
+	arr32, err = newArray32(vars, "testArray")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 65545, arr32.Len())
+
+	// the 16-bit array can't be used anymore
+	_, err = newArray(vars, "testArray")
+	assert.Error(t, err)
+
+	arr32.Erase()
+	assert.Zero(t, arr32.Len())
+	assert.Zero(t, len(vars.ToGoMap()))
+}
@@ -1,8 +1,10 @@
 package kv
 
 import (
-	"github.com/stretchr/testify/assert"
 	"testing"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestBasicArray(t *testing.T) {
@@ -47,3 +49,28 @@ func TestBasicArray(t *testing.T) {
 		newMustArray(arr2).GetAt(arr2.Len())
 	})
 }
+
+func TestArrayErase(t *testing.T) {
+	b := NewBufferedKVStore(mapdb.NewMapDB())
+	arr, err := newArray(b, "testArray")
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		arr.Push([]byte{byte(i)})
+	}
+	b.ClearMutations()
+
+	arr.Erase()
+	assert.EqualValues(t, 0, arr.Len())
+	// one mutation for the elements and one for the size
+	assert.Equal(t, 2, b.Mutations().Len())
+
+	arr.Push([]byte("new"))
+	v, err := arr.GetAt(0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []byte("new"), v)
+
+	v, err = b.Get(ArrayElemKey("testArray", 1))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
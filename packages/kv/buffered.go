@@ -10,6 +10,7 @@ import (
 
 // BufferedKVStore represents a KVStore backed by a database. Writes are cached in-memory as
 // a MutationSequence; reads are delegated to the backing database when not cached.
+// Values read from the database are kept in the read cache shared by the store and its clones.
 type BufferedKVStore interface {
 	KVStore
 
@@ -20,6 +21,11 @@ type BufferedKVStore interface {
 
 	Codec() Codec
 
+	// InvalidateCache runs the write to the backing database and invalidates the read cache
+	// atomically. Any write to the database must be done with it
+	InvalidateCache(write func() error) error
+	CacheStats() CacheStats
+
 	// only for testing!
 	DangerouslyDumpToMap() Map
 	// only for testing!
@@ -33,6 +39,11 @@ func (d DBError) Error() string {
 	return d.error.Error()
 }
 
+// NewDBError wraps the error of the database
+func NewDBError(err error) DBError {
+	return DBError{err}
+}
+
 func asDBError(e error) error {
 	if e == nil {
 		return nil
@@ -46,22 +57,38 @@ func asDBError(e error) error {
 type bufferedKVStore struct {
 	db        kvstore.KVStore
 	mutations MutationSequence
+	cache     *readCache
 }
 
 func NewBufferedKVStore(db kvstore.KVStore) BufferedKVStore {
+	return NewBufferedKVStoreWithCacheSize(db, DefaultReadCacheSize)
+}
+
+func NewBufferedKVStoreWithCacheSize(db kvstore.KVStore, cacheSize int) BufferedKVStore {
 	return &bufferedKVStore{
 		db:        db,
 		mutations: NewMutationSequence(),
+		cache:     newReadCache(cacheSize),
 	}
 }
 
+// Clone shares the read cache with the original
 func (b *bufferedKVStore) Clone() BufferedKVStore {
 	return &bufferedKVStore{
 		db:        b.db,
 		mutations: b.mutations.Clone(),
+		cache:     b.cache,
 	}
 }
 
+func (b *bufferedKVStore) InvalidateCache(write func() error) error {
+	return b.cache.invalidate(write)
+}
+
+func (b *bufferedKVStore) CacheStats() CacheStats {
+	return b.cache.getStats()
+}
+
 func (b *bufferedKVStore) Codec() Codec {
 	return NewCodec(b)
 }
@@ -120,16 +147,33 @@ func (b *bufferedKVStore) Del(key Key) {
 	b.mutations.Add(NewMutationDel(key))
 }
 
+// DelPrefix records a single "del prefix" mutation, independently of the number of keys deleted
+func (b *bufferedKVStore) DelPrefix(prefix Key) {
+	b.mutations.DelPrefix(prefix)
+}
+
 func (b *bufferedKVStore) Get(key Key) ([]byte, error) {
 	mut := b.mutations.Latest(key)
 	if mut != nil {
 		return mut.Value(), nil
 	}
+	return b.getFromDb(key)
+}
+
+func (b *bufferedKVStore) getFromDb(key Key) ([]byte, error) {
+	if v, ok := b.cache.getValue(key); ok {
+		return v, nil
+	}
+	generation := b.cache.getGeneration()
 	v, err := b.db.Get(kvstore.Key(key))
 	if err == kvstore.ErrKeyNotFound {
-		return nil, nil
+		v, err = nil, nil
 	}
-	return v, asDBError(err)
+	if err != nil {
+		return nil, asDBError(err)
+	}
+	b.cache.putValue(generation, key, v)
+	return v, nil
 }
 
 func (b *bufferedKVStore) Has(key Key) (bool, error) {
@@ -137,40 +181,66 @@ func (b *bufferedKVStore) Has(key Key) (bool, error) {
 	if mut != nil {
 		return mut.Value() != nil, nil
 	}
-	v, err := b.db.Has(kvstore.Key(key))
-	return v, asDBError(err)
+	v, err := b.getFromDb(key)
+	return v != nil, err
 }
 
 func (b *bufferedKVStore) Iterate(prefix Key, f func(key Key, value []byte) bool) error {
-	seen, done := b.mutations.IterateValues(prefix, f)
-	if done {
+	done := b.mutations.IterateValues(prefix, f)
+	if done || b.db == nil {
 		return nil
 	}
-	realm := len(b.db.Realm())
-	return b.db.Iterate([]byte(prefix), func(key kvstore.Key, value kvstore.Value) bool {
-		k := Key(key[realm:])
-		_, ok := seen[k]
-		if ok {
+	return b.iterateDb(prefix, func(key Key, value []byte) bool {
+		if b.mutations.Latest(key) != nil {
+			// overwritten or deleted
 			return true
 		}
-		return f(k, value)
+		return f(key, value)
 	})
 }
 
 func (b *bufferedKVStore) IterateKeys(prefix Key, f func(key Key) bool) error {
-	seen, done := b.mutations.IterateValues(prefix, func(key Key, value []byte) bool {
+	return b.Iterate(prefix, func(key Key, value []byte) bool {
 		return f(key)
 	})
-	if done {
+}
+
+// iterateDb streams pairs with the prefix from the read cache or from the database.
+// Pairs read from the database are cached only if the iteration wasn't stopped and all of them fit into the cache
+func (b *bufferedKVStore) iterateDb(prefix Key, f func(key Key, value []byte) bool) error {
+	if pairs, ok := b.cache.getPrefix(prefix); ok {
+		for _, p := range pairs {
+			if !f(p.key, p.value) {
+				break
+			}
+		}
 		return nil
 	}
+	generation := b.cache.getGeneration()
+	capacity := b.cache.getCapacity()
 	realm := len(b.db.Realm())
-	return b.db.IterateKeys([]byte(prefix), func(key kvstore.Key) bool {
+	pairs := make([]cachedPair, 0)
+	stopped := false
+	err := b.db.Iterate([]byte(prefix), func(key kvstore.Key, value kvstore.Value) bool {
 		k := Key(key[realm:])
-		_, ok := seen[k]
-		if ok {
-			return true
+		if pairs != nil {
+			if len(pairs) < capacity {
+				pairs = append(pairs, cachedPair{key: k, value: value})
+			} else {
+				pairs = nil
+			}
+		}
+		if !f(k, value) {
+			stopped = true
+			return false
 		}
-		return f(k)
+		return true
 	})
+	if err != nil {
+		return asDBError(err)
+	}
+	if !stopped && pairs != nil {
+		b.cache.putPrefix(generation, prefix, pairs)
+	}
+	return nil
 }
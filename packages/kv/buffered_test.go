@@ -52,3 +52,128 @@ func TestBufferedKVStore(t *testing.T) {
 		m,
 	)
 }
+
+func TestBufferedKVStoreDelPrefix(t *testing.T) {
+	db := mapdb.NewMapDB()
+	db.Set([]byte("abcd"), []byte("v1"))
+	db.Set([]byte("abce"), []byte("v2"))
+	db.Set([]byte("abde"), []byte("v3"))
+
+	b := NewBufferedKVStore(db.WithRealm([]byte("ab")))
+
+	b.DelPrefix(Key([]byte("c")))
+	b.Set(Key([]byte("ce")), []byte("v4"))
+	assert.Equal(t, 2, b.Mutations().Len())
+
+	v, err := b.Get(Key([]byte("cd")))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	ok, err := b.Has(Key([]byte("cd")))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	m := make(map[Key][]byte)
+	err = b.Iterate(EmptyPrefix, func(key Key, value []byte) bool {
+		m[key] = value
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		map[Key][]byte{
+			Key([]byte("ce")): []byte("v4"),
+			Key([]byte("de")): []byte("v3"),
+		},
+		m,
+	)
+	assert.Equal(t, m, b.DangerouslyDumpToMap().ToGoMap())
+}
+
+func TestBufferedKVStoreCache(t *testing.T) {
+	db := mapdb.NewMapDB()
+	db.Set([]byte("abcd"), []byte("v1"))
+	db.Set([]byte("abce"), []byte("v2"))
+	realm := db.WithRealm([]byte("ab"))
+
+	b := NewBufferedKVStore(realm)
+	v, err := b.Get(Key("cd"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 1}, b.CacheStats())
+
+	// the clone shares the cache
+	b2 := b.Clone()
+	v, err = b2.Get(Key("cd"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+	ok, err := b2.Has(Key("cd"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, b.CacheStats())
+
+	n := 0
+	for i := 0; i < 2; i++ {
+		err = b.Iterate(Key("c"), func(key Key, value []byte) bool {
+			n++
+			return true
+		})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, n)
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 2}, b.CacheStats())
+
+	// writing through the cache invalidates it
+	err = b.InvalidateCache(func() error {
+		return realm.Set([]byte("cd"), []byte("v3"))
+	})
+	assert.NoError(t, err)
+	v, err = b2.Get(Key("cd"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v3"), v)
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 3}, b.CacheStats())
+}
+
+func TestBufferedKVStoreCacheCapacity(t *testing.T) {
+	db := mapdb.NewMapDB()
+	b := NewBufferedKVStoreWithCacheSize(db, 2)
+	for _, k := range []Key{"a", "b", "c", "a"} {
+		_, err := b.Get(k)
+		assert.NoError(t, err)
+	}
+	// "a" was evicted by "c"
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 4}, b.CacheStats())
+	_, err := b.Get("c")
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 4}, b.CacheStats())
+}
+
+func TestBufferedKVStoreIterateStops(t *testing.T) {
+	db := mapdb.NewMapDB()
+	for i := 0; i < 10; i++ {
+		db.Set([]byte{'a', byte(i)}, []byte{byte(i)})
+	}
+	b := NewBufferedKVStoreWithCacheSize(db, 5)
+
+	// stopped iteration reads only the beginning and is not cached
+	n := 0
+	err := b.Iterate(Key("a"), func(key Key, value []byte) bool {
+		n++
+		return n < 3
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 1}, b.CacheStats())
+
+	// the prefix with more pairs than the capacity of the cache is streamed, not cached
+	for i := 0; i < 2; i++ {
+		n = 0
+		err = b.Iterate(Key("a"), func(key Key, value []byte) bool {
+			n++
+			return true
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, n)
+	}
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 3}, b.CacheStats())
+}
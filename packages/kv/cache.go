@@ -0,0 +1,164 @@
+package kv
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultReadCacheSize is the default capacity of the read cache of the BufferedKVStore,
+// measured in number of cached key/value pairs. The node sets it with the parameter "database.readCacheSize"
+const DefaultReadCacheSize = 10000
+
+// CacheStats are counters of the read cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// readCache is a bounded LRU cache of values read from the database. It caches single values
+// (including non-existent ones) and results of iterations over prefixes.
+// It is shared by the BufferedKVStore and its clones and must be invalidated whenever
+// the database is written.
+type readCache struct {
+	mutex    sync.Mutex
+	capacity int
+	size     int
+	lru      *list.List
+	values   map[Key]*list.Element
+	prefixes map[Key]*list.Element
+	// incremented on each invalidation. Values read from the database before the invalidation
+	// are not cached after it
+	generation uint64
+	stats      CacheStats
+}
+
+type cachedPair struct {
+	key   Key
+	value []byte
+}
+
+type cacheEntry struct {
+	isPrefix bool
+	key      Key
+	// value of the key, nil if the key does not exist
+	value []byte
+	// all pairs with the prefix
+	pairs []cachedPair
+}
+
+func newReadCache(capacity int) *readCache {
+	return &readCache{
+		capacity: capacity,
+		lru:      list.New(),
+		values:   make(map[Key]*list.Element),
+		prefixes: make(map[Key]*list.Element),
+	}
+}
+
+func (e *cacheEntry) size() int {
+	if e.isPrefix {
+		return len(e.pairs) + 1
+	}
+	return 1
+}
+
+func (c *readCache) index(isPrefix bool) map[Key]*list.Element {
+	if isPrefix {
+		return c.prefixes
+	}
+	return c.values
+}
+
+func (c *readCache) getGeneration() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generation
+}
+
+func (c *readCache) get(isPrefix bool, key Key) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.index(isPrefix)[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry), true
+}
+
+// put caches the entry unless the cache was invalidated after the generation
+func (c *readCache) put(generation uint64, entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation || entry.size() > c.capacity {
+		return
+	}
+	index := c.index(entry.isPrefix)
+	if elem, ok := index[entry.key]; ok {
+		c.remove(elem)
+	}
+	for c.size+entry.size() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+	index[entry.key] = c.lru.PushFront(entry)
+	c.size += entry.size()
+}
+
+func (c *readCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.index(entry.isPrefix), entry.key)
+	c.size -= entry.size()
+}
+
+func (c *readCache) getValue(key Key) ([]byte, bool) {
+	entry, ok := c.get(false, key)
+	if !ok {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *readCache) putValue(generation uint64, key Key, value []byte) {
+	c.put(generation, &cacheEntry{key: key, value: value})
+}
+
+func (c *readCache) getPrefix(prefix Key) ([]cachedPair, bool) {
+	entry, ok := c.get(true, prefix)
+	if !ok {
+		return nil, false
+	}
+	return entry.pairs, true
+}
+
+func (c *readCache) putPrefix(generation uint64, prefix Key, pairs []cachedPair) {
+	c.put(generation, &cacheEntry{isPrefix: true, key: prefix, pairs: pairs})
+}
+
+// invalidate runs the write to the database and clears the cache atomically:
+// readers of the cache wait until both are done
+func (c *readCache) invalidate(write func() error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	err := write()
+	c.lru.Init()
+	c.values = make(map[Key]*list.Element)
+	c.prefixes = make(map[Key]*list.Element)
+	c.size = 0
+	c.generation++
+	return err
+}
+
+func (c *readCache) getCapacity() int {
+	return c.capacity
+}
+
+func (c *readCache) getStats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stats
+}
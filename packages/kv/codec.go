@@ -1,9 +1,12 @@
 package kv
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/util"
 )
@@ -14,8 +17,11 @@ type Codec interface {
 	RCodec
 	WCodec
 	GetArray(Key) (*Array, error)
+	GetArray32(Key) (*Array32, error)
 	GetDictionary(Key) (*Dictionary, error)
-	// TODO GetTimedLog
+	GetTimestampedLog(Key) (*TimestampedLog, error)
+	GetSortedMap(Key) (*SortedMap, error)
+	GetDeque(Key) (*Deque, error)
 }
 
 // MustCodec is like a Codec that automatically panics on error
@@ -23,8 +29,11 @@ type MustCodec interface {
 	MustRCodec
 	WCodec
 	GetArray(Key) *MustArray
+	GetArray32(Key) *MustArray32
 	GetDictionary(Key) *MustDictionary
-	// TODO GetTimedLog
+	GetTimestampedLog(Key) *MustTimestampedLog
+	GetSortedMap(Key) *MustSortedMap
+	GetDeque(Key) *MustDeque
 }
 
 // RCodec is an interface that offers easy conversions between []byte and other types when
@@ -36,6 +45,14 @@ type RCodec interface {
 	GetInt64(key Key) (int64, bool, error)
 	GetAddress(key Key) (*address.Address, bool, error)
 	GetHashValue(key Key) (*hashing.HashValue, bool, error)
+	GetUint16(key Key) (uint16, bool, error)
+	GetUint32(key Key) (uint32, bool, error)
+	GetUint64(key Key) (uint64, bool, error)
+	GetBool(key Key) (bool, bool, error)
+	GetColor(key Key) (*balance.Color, bool, error)
+	GetBalances(key Key) ([]*balance.Balance, bool, error)
+	GetRequestId(key Key) (*coretypes.RequestId, bool, error)
+	GetMap(key Key) (Map, bool, error)
 }
 
 // MustrCodec is like a RCodec that automatically panics on error
@@ -46,17 +63,34 @@ type MustRCodec interface {
 	GetInt64(key Key) (int64, bool)
 	GetAddress(key Key) (*address.Address, bool)
 	GetHashValue(key Key) (*hashing.HashValue, bool)
+	GetUint16(key Key) (uint16, bool)
+	GetUint32(key Key) (uint32, bool)
+	GetUint64(key Key) (uint64, bool)
+	GetBool(key Key) (bool, bool)
+	GetColor(key Key) (*balance.Color, bool)
+	GetBalances(key Key) ([]*balance.Balance, bool)
+	GetRequestId(key Key) (*coretypes.RequestId, bool)
+	GetMap(key Key) (Map, bool)
 }
 
 // WCodec is an interface that offers easy conversions between []byte and other types when
 // manipulating a write-only KVStore
 type WCodec interface {
 	Del(key Key)
+	DelPrefix(prefix Key)
 	Set(key Key, value []byte)
 	SetString(key Key, value string)
 	SetInt64(key Key, value int64)
 	SetAddress(key Key, value *address.Address)
 	SetHashValue(key Key, value *hashing.HashValue)
+	SetUint16(key Key, value uint16)
+	SetUint32(key Key, value uint32)
+	SetUint64(key Key, value uint64)
+	SetBool(key Key, value bool)
+	SetColor(key Key, value *balance.Color)
+	SetBalances(key Key, value []*balance.Balance)
+	SetRequestId(key Key, value *coretypes.RequestId)
+	SetMap(key Key, value Map)
 }
 
 type codec struct {
@@ -87,6 +121,18 @@ func (c mustcodec) GetArray(key Key) *MustArray {
 	return newMustArray(array)
 }
 
+func (c codec) GetArray32(key Key) (*Array32, error) {
+	return newArray32(c, string(key))
+}
+
+func (c mustcodec) GetArray32(key Key) *MustArray32 {
+	array, err := c.codec.GetArray32(key)
+	if err != nil {
+		panic(err)
+	}
+	return newMustArray32(array)
+}
+
 func (c codec) GetDictionary(key Key) (*Dictionary, error) {
 	return newDictionary(c, string(key))
 }
@@ -99,6 +145,42 @@ func (c mustcodec) GetDictionary(key Key) *MustDictionary {
 	return newMustDictionary(d)
 }
 
+func (c codec) GetTimestampedLog(key Key) (*TimestampedLog, error) {
+	return newTimestampedLog(c, string(key))
+}
+
+func (c mustcodec) GetTimestampedLog(key Key) *MustTimestampedLog {
+	tlog, err := c.codec.GetTimestampedLog(key)
+	if err != nil {
+		panic(err)
+	}
+	return newMustTimestampedLog(tlog)
+}
+
+func (c codec) GetSortedMap(key Key) (*SortedMap, error) {
+	return newSortedMap(c, string(key))
+}
+
+func (c mustcodec) GetSortedMap(key Key) *MustSortedMap {
+	m, err := c.codec.GetSortedMap(key)
+	if err != nil {
+		panic(err)
+	}
+	return newMustSortedMap(m)
+}
+
+func (c codec) GetDeque(key Key) (*Deque, error) {
+	return newDeque(c, string(key))
+}
+
+func (c mustcodec) GetDeque(key Key) *MustDeque {
+	d, err := c.codec.GetDeque(key)
+	if err != nil {
+		panic(err)
+	}
+	return newMustDeque(d)
+}
+
 func (c codec) Has(key Key) (bool, error) {
 	return c.kv.Has(key)
 }
@@ -135,6 +217,10 @@ func (c codec) Del(key Key) {
 	c.kv.Del(key)
 }
 
+func (c codec) DelPrefix(prefix Key) {
+	c.kv.DelPrefix(prefix)
+}
+
 func (c codec) Set(key Key, value []byte) {
 	c.kv.Set(key, value)
 }
@@ -229,3 +315,275 @@ func (c mustcodec) GetHashValue(key Key) (*hashing.HashValue, bool) {
 func (c codec) SetHashValue(key Key, h *hashing.HashValue) {
 	c.kv.Set(key, h[:])
 }
+
+func DecodeUint16(b []byte) (uint16, error) {
+	if len(b) != 2 {
+		return 0, fmt.Errorf("variable %v is not an uint16", b)
+	}
+	return util.Uint16From2Bytes(b), nil
+}
+
+func (c codec) GetUint16(key Key) (uint16, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return 0, false, err
+	}
+	n, err := DecodeUint16(b)
+	return n, err == nil, err
+}
+
+func (c mustcodec) GetUint16(key Key) (uint16, bool) {
+	ret, ok, err := c.codec.GetUint16(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetUint16(key Key, value uint16) {
+	c.kv.Set(key, util.Uint16To2Bytes(value))
+}
+
+func DecodeUint32(b []byte) (uint32, error) {
+	if len(b) != 4 {
+		return 0, fmt.Errorf("variable %v is not an uint32", b)
+	}
+	return util.Uint32From4Bytes(b), nil
+}
+
+func (c codec) GetUint32(key Key) (uint32, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return 0, false, err
+	}
+	n, err := DecodeUint32(b)
+	return n, err == nil, err
+}
+
+func (c mustcodec) GetUint32(key Key) (uint32, bool) {
+	ret, ok, err := c.codec.GetUint32(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetUint32(key Key, value uint32) {
+	c.kv.Set(key, util.Uint32To4Bytes(value))
+}
+
+func DecodeUint64(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("variable %v is not an uint64", b)
+	}
+	return util.Uint64From8Bytes(b), nil
+}
+
+func (c codec) GetUint64(key Key) (uint64, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return 0, false, err
+	}
+	n, err := DecodeUint64(b)
+	return n, err == nil, err
+}
+
+func (c mustcodec) GetUint64(key Key) (uint64, bool) {
+	ret, ok, err := c.codec.GetUint64(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetUint64(key Key, value uint64) {
+	c.kv.Set(key, util.Uint64To8Bytes(value))
+}
+
+func EncodeBool(value bool) []byte {
+	if value {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func DecodeBool(b []byte) (bool, error) {
+	if len(b) != 1 || b[0] > 1 {
+		return false, fmt.Errorf("variable %v is not a bool", b)
+	}
+	return b[0] == 1, nil
+}
+
+func (c codec) GetBool(key Key) (bool, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return false, false, err
+	}
+	ret, err := DecodeBool(b)
+	return ret, err == nil, err
+}
+
+func (c mustcodec) GetBool(key Key) (bool, bool) {
+	ret, ok, err := c.codec.GetBool(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetBool(key Key, value bool) {
+	c.kv.Set(key, EncodeBool(value))
+}
+
+func DecodeColor(b []byte) (*balance.Color, error) {
+	if len(b) != balance.ColorLength {
+		return nil, fmt.Errorf("variable %v is not a color", b)
+	}
+	var ret balance.Color
+	copy(ret[:], b)
+	return &ret, nil
+}
+
+func (c codec) GetColor(key Key) (*balance.Color, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return nil, false, err
+	}
+	ret, err := DecodeColor(b)
+	return ret, err == nil, err
+}
+
+func (c mustcodec) GetColor(key Key) (*balance.Color, bool) {
+	ret, ok, err := c.codec.GetColor(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetColor(key Key, value *balance.Color) {
+	c.kv.Set(key, value[:])
+}
+
+// EncodeBalances encodes balances as 'number of balances' || 'color' || 'value' || 'color' || ...
+func EncodeBalances(bals []*balance.Balance) []byte {
+	var buf bytes.Buffer
+	_ = util.WriteUint16(&buf, uint16(len(bals)))
+	for _, bal := range bals {
+		buf.Write(bal.Color[:])
+		_ = util.WriteInt64(&buf, bal.Value)
+	}
+	return buf.Bytes()
+}
+
+func DecodeBalances(b []byte) ([]*balance.Balance, error) {
+	r := bytes.NewReader(b)
+	var size uint16
+	if err := util.ReadUint16(r, &size); err != nil {
+		return nil, fmt.Errorf("variable %v is not a list of balances", b)
+	}
+	if len(b) != 2+int(size)*(balance.ColorLength+8) {
+		return nil, fmt.Errorf("variable %v is not a list of balances", b)
+	}
+	ret := make([]*balance.Balance, size)
+	for i := range ret {
+		var color balance.Color
+		var value int64
+		if err := util.ReadColor(r, &color); err != nil {
+			return nil, err
+		}
+		if err := util.ReadInt64(r, &value); err != nil {
+			return nil, err
+		}
+		ret[i] = balance.New(color, value)
+	}
+	return ret, nil
+}
+
+func (c codec) GetBalances(key Key) ([]*balance.Balance, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return nil, false, err
+	}
+	ret, err := DecodeBalances(b)
+	return ret, err == nil, err
+}
+
+func (c mustcodec) GetBalances(key Key) ([]*balance.Balance, bool) {
+	ret, ok, err := c.codec.GetBalances(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetBalances(key Key, value []*balance.Balance) {
+	c.kv.Set(key, EncodeBalances(value))
+}
+
+func DecodeRequestId(b []byte) (*coretypes.RequestId, error) {
+	if len(b) != coretypes.RequestIdSize {
+		return nil, fmt.Errorf("variable %v is not a request id", b)
+	}
+	var ret coretypes.RequestId
+	copy(ret[:], b)
+	return &ret, nil
+}
+
+func (c codec) GetRequestId(key Key) (*coretypes.RequestId, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return nil, false, err
+	}
+	ret, err := DecodeRequestId(b)
+	return ret, err == nil, err
+}
+
+func (c mustcodec) GetRequestId(key Key) (*coretypes.RequestId, bool) {
+	ret, ok, err := c.codec.GetRequestId(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetRequestId(key Key, value *coretypes.RequestId) {
+	c.kv.Set(key, value[:])
+}
+
+// EncodeMap encodes the whole map as one value
+func EncodeMap(m Map) []byte {
+	var buf bytes.Buffer
+	_ = m.Write(&buf)
+	return buf.Bytes()
+}
+
+func DecodeMap(b []byte) (Map, error) {
+	ret := NewMap()
+	r := bytes.NewReader(b)
+	if err := ret.Read(r); err != nil || r.Len() != 0 {
+		return nil, fmt.Errorf("variable %v is not a map", b)
+	}
+	return ret, nil
+}
+
+func (c codec) GetMap(key Key) (Map, bool, error) {
+	b, err := c.kv.Get(key)
+	if err != nil || b == nil {
+		return nil, false, err
+	}
+	ret, err := DecodeMap(b)
+	return ret, err == nil, err
+}
+
+func (c mustcodec) GetMap(key Key) (Map, bool) {
+	ret, ok, err := c.codec.GetMap(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret, ok
+}
+
+func (c codec) SetMap(key Key, value Map) {
+	c.kv.Set(key, EncodeMap(value))
+}
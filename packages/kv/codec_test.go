@@ -0,0 +1,80 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecTypes(t *testing.T) {
+	vars := NewMap()
+	c := vars.MustCodec()
+
+	c.SetUint16("u16", 0xabcd)
+	c.SetUint32("u32", 0xabcdef01)
+	c.SetUint64("u64", 0xabcdef0123456789)
+	c.SetBool("t", true)
+	c.SetBool("f", false)
+
+	color := balance.Color(*hashing.HashStrings("color"))
+	c.SetColor("color", &color)
+	bals := []*balance.Balance{balance.New(balance.ColorIOTA, 42), balance.New(color, 1)}
+	c.SetBalances("bals", bals)
+
+	reqid := coretypes.NewRequestId(valuetransaction.ID(*hashing.HashStrings("tx")), 3)
+	c.SetRequestId("reqid", &reqid)
+
+	nested := NewMap()
+	nested.Set("a", []byte{1})
+	nested.Set("b", []byte{2, 3})
+	c.SetMap("map", nested)
+
+	u16, ok := c.GetUint16("u16")
+	assert.True(t, ok)
+	assert.EqualValues(t, 0xabcd, u16)
+	u32, ok := c.GetUint32("u32")
+	assert.True(t, ok)
+	assert.EqualValues(t, 0xabcdef01, u32)
+	u64, ok := c.GetUint64("u64")
+	assert.True(t, ok)
+	assert.EqualValues(t, uint64(0xabcdef0123456789), u64)
+
+	b, ok := c.GetBool("t")
+	assert.True(t, ok)
+	assert.True(t, b)
+	b, ok = c.GetBool("f")
+	assert.True(t, ok)
+	assert.False(t, b)
+	_, ok = c.GetBool("none")
+	assert.False(t, ok)
+
+	colorBack, ok := c.GetColor("color")
+	assert.True(t, ok)
+	assert.EqualValues(t, color, *colorBack)
+
+	balsBack, ok := c.GetBalances("bals")
+	assert.True(t, ok)
+	assert.EqualValues(t, bals, balsBack)
+
+	reqidBack, ok := c.GetRequestId("reqid")
+	assert.True(t, ok)
+	assert.EqualValues(t, reqid, *reqidBack)
+
+	nestedBack, ok := c.GetMap("map")
+	assert.True(t, ok)
+	assert.EqualValues(t, nested, nestedBack)
+
+	// wrong encodings are errors
+	_, _, err := vars.Codec().GetUint32("u16")
+	assert.Error(t, err)
+	_, _, err = vars.Codec().GetBool("u16")
+	assert.Error(t, err)
+	_, _, err = vars.Codec().GetBalances("u64")
+	assert.Error(t, err)
+	_, _, err = vars.Codec().GetMap("u64")
+	assert.Error(t, err)
+}
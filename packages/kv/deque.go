@@ -0,0 +1,254 @@
+package kv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// Deque is a double ended queue with 32-bit index. Elements are stored in the ring of 2^32 slots,
+// the size key contains the slot of the front element and the number of elements
+type Deque struct {
+	kv         KVStore
+	name       string
+	cachedHead uint32
+	cachedLen  uint32
+}
+
+type MustDeque struct {
+	deque Deque
+}
+
+const (
+	dequeSizeKeyCode = byte(0)
+	dequeElemKeyCode = byte(1)
+)
+
+func newDeque(kv KVStore, name string) (*Deque, error) {
+	ret := &Deque{
+		kv:   kv,
+		name: name,
+	}
+	if err := ret.loadSize(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func newMustDeque(deque *Deque) *MustDeque {
+	return &MustDeque{*deque}
+}
+
+func (d *Deque) getSizeKey() Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(d.name))
+	buf.WriteByte(dequeSizeKeyCode)
+	return Key(buf.Bytes())
+}
+
+func (d *Deque) getElemPrefix() Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(d.name))
+	buf.WriteByte(dequeElemKeyCode)
+	return Key(buf.Bytes())
+}
+
+// getElemKey returns the key of the element with the index counted from the front
+func (d *Deque) getElemKey(idx uint32) Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(d.name))
+	buf.WriteByte(dequeElemKeyCode)
+	// wraps around
+	_ = util.WriteUint32(&buf, d.cachedHead+idx)
+	return Key(buf.Bytes())
+}
+
+func (d *Deque) setSize(head, size uint32) {
+	if size == 0 {
+		d.kv.Del(d.getSizeKey())
+		d.cachedHead = 0
+		d.cachedLen = 0
+		return
+	}
+	d.cachedHead = head
+	d.cachedLen = size
+	var buf bytes.Buffer
+	_ = util.WriteUint32(&buf, head)
+	_ = util.WriteUint32(&buf, size)
+	d.kv.Set(d.getSizeKey(), buf.Bytes())
+}
+
+func (d *Deque) loadSize() error {
+	v, err := d.kv.Get(d.getSizeKey())
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if len(v) != 8 {
+		return fmt.Errorf("corrupted data: %v", v)
+	}
+	d.cachedHead = util.Uint32From4Bytes(v[:4])
+	d.cachedLen = util.Uint32From4Bytes(v[4:])
+	return nil
+}
+
+// Len == 0/empty/non-existent are equivalent
+func (d *Deque) Len() uint32 {
+	return d.cachedLen
+}
+
+func (d *MustDeque) Len() uint32 {
+	return d.deque.Len()
+}
+
+func (d *Deque) checkNotFull() error {
+	if d.cachedLen == ^uint32(0) {
+		return errors.New("deque is full")
+	}
+	return nil
+}
+
+// PushBack adds the element to the end of the deque
+func (d *Deque) PushBack(value []byte) error {
+	if err := d.checkNotFull(); err != nil {
+		return err
+	}
+	d.kv.Set(d.getElemKey(d.cachedLen), value)
+	d.setSize(d.cachedHead, d.cachedLen+1)
+	return nil
+}
+
+func (d *MustDeque) PushBack(value []byte) {
+	if err := d.deque.PushBack(value); err != nil {
+		panic(err)
+	}
+}
+
+// PushFront adds the element to the front of the deque
+func (d *Deque) PushFront(value []byte) error {
+	if err := d.checkNotFull(); err != nil {
+		return err
+	}
+	d.setSize(d.cachedHead-1, d.cachedLen+1)
+	d.kv.Set(d.getElemKey(0), value)
+	return nil
+}
+
+func (d *MustDeque) PushFront(value []byte) {
+	if err := d.deque.PushFront(value); err != nil {
+		panic(err)
+	}
+}
+
+// PopBack removes and returns the last element
+func (d *Deque) PopBack() ([]byte, error) {
+	if d.cachedLen == 0 {
+		return nil, errors.New("deque is empty")
+	}
+	k := d.getElemKey(d.cachedLen - 1)
+	ret, err := d.kv.Get(k)
+	if err != nil {
+		return nil, err
+	}
+	d.kv.Del(k)
+	d.setSize(d.cachedHead, d.cachedLen-1)
+	return ret, nil
+}
+
+func (d *MustDeque) PopBack() []byte {
+	ret, err := d.deque.PopBack()
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// PopFront removes and returns the first element
+func (d *Deque) PopFront() ([]byte, error) {
+	if d.cachedLen == 0 {
+		return nil, errors.New("deque is empty")
+	}
+	k := d.getElemKey(0)
+	ret, err := d.kv.Get(k)
+	if err != nil {
+		return nil, err
+	}
+	d.kv.Del(k)
+	d.setSize(d.cachedHead+1, d.cachedLen-1)
+	return ret, nil
+}
+
+func (d *MustDeque) PopFront() []byte {
+	ret, err := d.deque.PopFront()
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// Front returns the first element without removing it
+func (d *Deque) Front() ([]byte, error) {
+	return d.GetAt(0)
+}
+
+func (d *MustDeque) Front() []byte {
+	return d.GetAt(0)
+}
+
+// Back returns the last element without removing it
+func (d *Deque) Back() ([]byte, error) {
+	if d.cachedLen == 0 {
+		return nil, errors.New("deque is empty")
+	}
+	return d.GetAt(d.cachedLen - 1)
+}
+
+func (d *MustDeque) Back() []byte {
+	ret, err := d.deque.Back()
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// GetAt returns the element with the index counted from the front
+func (d *Deque) GetAt(idx uint32) ([]byte, error) {
+	if idx >= d.cachedLen {
+		return nil, errors.New("index out of range")
+	}
+	return d.kv.Get(d.getElemKey(idx))
+}
+
+func (d *MustDeque) GetAt(idx uint32) []byte {
+	ret, err := d.deque.GetAt(idx)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func (d *Deque) SetAt(idx uint32, value []byte) bool {
+	if idx >= d.cachedLen {
+		return false
+	}
+	d.kv.Set(d.getElemKey(idx), value)
+	return true
+}
+
+func (d *MustDeque) SetAt(idx uint32, value []byte) bool {
+	return d.deque.SetAt(idx, value)
+}
+
+// Erase deletes all elements of the deque with one DelPrefix, independently of the size of the deque
+func (d *Deque) Erase() {
+	d.kv.DelPrefix(d.getElemPrefix())
+	d.setSize(0, 0)
+}
+
+func (d *MustDeque) Erase() {
+	d.deque.Erase()
+}
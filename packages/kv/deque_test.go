@@ -0,0 +1,63 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeque(t *testing.T) {
+	vars := NewMap()
+	d, err := newDeque(vars, "testDeque")
+	assert.NoError(t, err)
+	assert.Zero(t, d.Len())
+
+	_, err = d.PopFront()
+	assert.Error(t, err)
+	_, err = d.Back()
+	assert.Error(t, err)
+
+	assert.NoError(t, d.PushBack([]byte("b")))
+	assert.NoError(t, d.PushBack([]byte("c")))
+	// the front element goes to the slot before 0
+	assert.NoError(t, d.PushFront([]byte("a")))
+	assert.EqualValues(t, 3, d.Len())
+
+	v, err := d.Front()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), v)
+	v, err = d.Back()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("c"), v)
+	v, err = d.GetAt(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("b"), v)
+	_, err = d.GetAt(3)
+	assert.Error(t, err)
+
+	assert.True(t, d.SetAt(1, []byte("B")))
+	assert.False(t, d.SetAt(3, []byte("D")))
+
+	d2, err := newDeque(vars, "testDeque")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, d2.Len())
+	v, err = d2.GetAt(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), v)
+
+	v, err = d.PopFront()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), v)
+	v, err = d.PopBack()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("c"), v)
+	v, err = d.PopBack()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("B"), v)
+	assert.Zero(t, d.Len())
+	assert.EqualValues(t, 0, len(vars.ToGoMap()))
+
+	assert.NoError(t, d.PushBack([]byte("x")))
+	d.Erase()
+	assert.Zero(t, d.Len())
+}
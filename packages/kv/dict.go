@@ -57,6 +57,7 @@ func (l *Dictionary) getElemKey(key []byte) Key {
 func (l *Dictionary) setSize(size uint32) {
 	if size == 0 {
 		l.kv.Del(l.getSizeKey())
+		l.cachedsize = 0
 		return
 	}
 	l.cachedsize = size
@@ -119,10 +120,22 @@ func (d *Dictionary) HasAt(key []byte) (bool, error) {
 	return d.kv.Has(d.getElemKey(key))
 }
 
+func (d *MustDictionary) HasAt(key []byte) bool {
+	ret, err := d.dict.HasAt(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
 func (d *Dictionary) Len() uint32 {
 	return d.cachedsize
 }
 
+func (d *MustDictionary) Len() uint32 {
+	return d.dict.Len()
+}
+
 func (d *Dictionary) len() (uint32, error) {
 	v, err := d.kv.Get(d.getSizeKey())
 	if err != nil {
@@ -137,9 +150,14 @@ func (d *Dictionary) len() (uint32, error) {
 	return util.Uint32From4Bytes(v), nil
 }
 
+// Erase deletes all elements of the dictionary with one DelPrefix, independently of the size of the dictionary
 func (d *Dictionary) Erase() {
-	// TODO needs DelPrefix method in KVStore
-	panic("implement me")
+	d.kv.DelPrefix(d.getElemKey([]byte{}))
+	d.setSize(0)
+}
+
+func (d *MustDictionary) Erase() {
+	d.dict.Erase()
 }
 
 func (d *Dictionary) Iterate(f func(elemKey []byte, value []byte) bool) error {
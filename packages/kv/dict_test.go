@@ -90,3 +90,27 @@ func TestBasicDict(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, v3, v)
 }
+
+func TestDictErase(t *testing.T) {
+	vars := NewMap()
+	dict, err := newDictionary(vars, "testDict")
+	assert.NoError(t, err)
+
+	dict.SetAt([]byte("k1"), []byte("datum1"))
+	dict.SetAt([]byte("k2"), []byte("datum2"))
+	vars.Set("other", []byte("datum3"))
+
+	dict.Erase()
+	assert.Zero(t, dict.Len())
+
+	ok, err := dict.HasAt([]byte("k1"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	dict.SetAt([]byte("k2"), []byte("datum4"))
+	assert.EqualValues(t, 1, dict.Len())
+
+	ok, err = vars.Has("other")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -19,14 +19,11 @@ func (k Key) HasPrefix(prefix Key) bool {
 type KVStore interface {
 	Set(key Key, value []byte)
 	Del(key Key)
+	// DelPrefix deletes all keys with the prefix
+	DelPrefix(prefix Key)
 	// Get returns the value, or nil if not found
 	Get(key Key) ([]byte, error)
 	Has(key Key) (bool, error)
 	Iterate(prefix Key, f func(key Key, value []byte) bool) error
 	IterateKeys(prefix Key, f func(key Key) bool) error
-
-	// TODO add DelPrefix(prefix []byte)
-	// deletes all keys with the prefix. Currently we don't have a possibility to iterate over keys
-	// and maybe we do not need one in the sandbox. However we need a possibility to efficiently clear arrays,
-	// dictionaries and timestamped logs
 }
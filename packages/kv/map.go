@@ -141,6 +141,14 @@ func (m kvmap) Del(key Key) {
 	delete(m, key)
 }
 
+func (m kvmap) DelPrefix(prefix Key) {
+	for k := range m {
+		if k.HasPrefix(prefix) {
+			delete(m, k)
+		}
+	}
+}
+
 func (m kvmap) Has(key Key) (bool, error) {
 	_, ok := m[key]
 	return ok, nil
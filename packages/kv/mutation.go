@@ -7,7 +7,7 @@ import (
 	"github.com/iotaledger/wasp/packages/util"
 )
 
-// Mutation represents a single "set" or "del" operation over a KVStore
+// Mutation represents a single "set", "del" or "del prefix" operation over a KVStore
 type Mutation interface {
 	Read(io.Reader) error
 	Write(io.Writer) error
@@ -16,7 +16,7 @@ type Mutation interface {
 
 	ApplyTo(kv KVStore)
 
-	// Key returns the key that is mutated
+	// Key returns the key that is mutated (the prefix for "del prefix")
 	Key() Key
 	// Value returns the value after the mutation (nil if deleted)
 	Value() []byte
@@ -38,12 +38,18 @@ type MutationSequence interface {
 	Iterate(func(mut Mutation) bool)
 	// Iterate over the latest mutation recorded for each key
 	IterateLatest(func(key Key, mut Mutation) bool)
-	// Iterate over the latest value recorded for each non-deleted key
-	IterateValues(prefix Key, f func(key Key, value []byte) bool) (map[Key]bool, bool)
+	// Iterate over the latest value recorded for each non-deleted key. Returns true if interrupted
+	IterateValues(prefix Key, f func(key Key, value []byte) bool) bool
+	// Iterate over all prefixes deleted with "del prefix" mutations
+	IterateDelPrefixes(func(prefix Key) bool)
 
+	// Latest returns the latest mutation affecting the key, or nil if the key was not mutated.
+	// If the key was deleted by prefix and not set afterwards, the "del prefix" mutation is returned
 	Latest(key Key) Mutation
 
 	Add(mut Mutation)
+	// DelPrefix adds the mutation which deletes all keys with the prefix
+	DelPrefix(prefix Key)
 
 	ApplyTo(kv KVStore)
 }
@@ -51,17 +57,21 @@ type MutationSequence interface {
 const (
 	mutationMagicSet = iota
 	mutationMagicDel
+	mutationMagicDelPrefix
 )
 
 type mutationSequence struct {
 	muts        []Mutation
 	latestByKey map[Key]*Mutation
+	// "del prefix" mutations in the order they were added
+	delPrefixes []Mutation
 }
 
 func NewMutationSequence() MutationSequence {
 	return &mutationSequence{
 		muts:        make([]Mutation, 0),
 		latestByKey: make(map[Key]*Mutation),
+		delPrefixes: make([]Mutation, 0),
 	}
 }
 
@@ -130,19 +140,25 @@ func (ms *mutationSequence) IterateLatest(f func(Key, Mutation) bool) {
 	}
 }
 
-func (ms *mutationSequence) IterateValues(prefix Key, f func(key Key, value []byte) bool) (map[Key]bool, bool) {
-	seen := make(map[Key]bool)
+func (ms *mutationSequence) IterateValues(prefix Key, f func(key Key, value []byte) bool) bool {
 	for key, mut := range ms.latestByKey {
 		if !key.HasPrefix(prefix) {
 			continue
 		}
-		seen[key] = true
 		v := (*mut).Value()
 		if v != nil && !f(key, v) {
-			return seen, true
+			return true
+		}
+	}
+	return false
+}
+
+func (ms *mutationSequence) IterateDelPrefixes(f func(prefix Key) bool) {
+	for _, mut := range ms.delPrefixes {
+		if !f(mut.Key()) {
+			break
 		}
 	}
-	return seen, false
 }
 
 func (ms *mutationSequence) Len() int {
@@ -151,7 +167,22 @@ func (ms *mutationSequence) Len() int {
 
 func (ms *mutationSequence) Add(mut Mutation) {
 	ms.muts = append(ms.muts, mut)
-	ms.latestByKey[mut.Key()] = &mut
+	if mut.getMagic() != mutationMagicDelPrefix {
+		ms.latestByKey[mut.Key()] = &mut
+		return
+	}
+	// keys mutated before are now covered by the "del prefix" mutation
+	prefix := mut.Key()
+	for key := range ms.latestByKey {
+		if key.HasPrefix(prefix) {
+			delete(ms.latestByKey, key)
+		}
+	}
+	ms.delPrefixes = append(ms.delPrefixes, mut)
+}
+
+func (ms *mutationSequence) DelPrefix(prefix Key) {
+	ms.Add(NewMutationDelPrefix(prefix))
 }
 
 func (ms *mutationSequence) ApplyTo(kv KVStore) {
@@ -162,10 +193,15 @@ func (ms *mutationSequence) ApplyTo(kv KVStore) {
 
 func (ms *mutationSequence) Latest(key Key) Mutation {
 	mut, ok := ms.latestByKey[key]
-	if !ok {
-		return nil
+	if ok {
+		return *mut
+	}
+	for i := len(ms.delPrefixes) - 1; i >= 0; i-- {
+		if key.HasPrefix(ms.delPrefixes[i].Key()) {
+			return ms.delPrefixes[i]
+		}
 	}
-	return *mut
+	return nil
 }
 
 func (ms *mutationSequence) Clone() MutationSequence {
@@ -173,7 +209,9 @@ func (ms *mutationSequence) Clone() MutationSequence {
 	for k, v := range ms.latestByKey {
 		mapClone[k] = v
 	}
-	return &mutationSequence{muts: ms.muts[:], latestByKey: mapClone}
+	delPrefixesClone := make([]Mutation, len(ms.delPrefixes))
+	copy(delPrefixesClone, ms.delPrefixes)
+	return &mutationSequence{muts: ms.muts[:], latestByKey: mapClone, delPrefixes: delPrefixesClone}
 }
 
 type mutationSet struct {
@@ -185,12 +223,18 @@ type mutationDel struct {
 	k Key
 }
 
+type mutationDelPrefix struct {
+	prefix Key
+}
+
 func newFromMagic(magic int) (Mutation, error) {
 	switch magic {
 	case mutationMagicSet:
 		return &mutationSet{}, nil
 	case mutationMagicDel:
 		return &mutationDel{}, nil
+	case mutationMagicDelPrefix:
+		return &mutationDelPrefix{}, nil
 	}
 	return nil, fmt.Errorf("Unknown mutation magic %d", magic)
 }
@@ -279,3 +323,40 @@ func (m *mutationDel) Value() []byte {
 func (m *mutationDel) ApplyTo(kv KVStore) {
 	kv.Del(m.k)
 }
+
+func (m *mutationDelPrefix) getMagic() int {
+	return mutationMagicDelPrefix
+}
+
+func NewMutationDelPrefix(prefix Key) *mutationDelPrefix {
+	return &mutationDelPrefix{prefix: prefix}
+}
+
+func (m *mutationDelPrefix) Write(w io.Writer) error {
+	return util.WriteBytes16(w, []byte(m.prefix))
+}
+
+func (m *mutationDelPrefix) Read(r io.Reader) error {
+	prefix, err := util.ReadBytes16(r)
+	if err != nil {
+		return err
+	}
+	m.prefix = Key(prefix)
+	return nil
+}
+
+func (m *mutationDelPrefix) String() string {
+	return fmt.Sprintf("DEL PREFIX %s", m.prefix)
+}
+
+func (m *mutationDelPrefix) Key() Key {
+	return m.prefix
+}
+
+func (m *mutationDelPrefix) Value() []byte {
+	return nil
+}
+
+func (m *mutationDelPrefix) ApplyTo(kv KVStore) {
+	kv.DelPrefix(m.prefix)
+}
@@ -50,3 +50,53 @@ func TestMutationSequenceMarshaling(t *testing.T) {
 
 	assert.EqualValues(t, util.GetHashValue(ms), util.GetHashValue(ms2))
 }
+
+func TestApplyMutationDelPrefix(t *testing.T) {
+	vars := NewMap()
+	vars.Set("ab1", []byte("v1"))
+	vars.Set("ab2", []byte("v2"))
+	vars.Set("ac1", []byte("v3"))
+
+	mdel := NewMutationDelPrefix("ab")
+	mdel.ApplyTo(vars)
+
+	v, _ := vars.Get("ab1")
+	assert.Nil(t, v)
+	v, _ = vars.Get("ab2")
+	assert.Nil(t, v)
+	v, _ = vars.Get("ac1")
+	assert.Equal(t, []byte("v3"), v)
+}
+
+func TestMutationSequenceDelPrefix(t *testing.T) {
+	ms := NewMutationSequence()
+	ms.Add(NewMutationSet("ab1", []byte("v1")))
+	ms.Add(NewMutationSet("ac1", []byte("v2")))
+	ms.DelPrefix("ab")
+	ms.Add(NewMutationSet("ab2", []byte("v3")))
+
+	assert.Nil(t, ms.Latest("ab1").Value())
+	assert.Nil(t, ms.Latest("ab3").Value())
+	assert.Equal(t, []byte("v3"), ms.Latest("ab2").Value())
+	assert.Equal(t, []byte("v2"), ms.Latest("ac1").Value())
+	assert.Nil(t, ms.Latest("ad1"))
+
+	values := make(map[Key][]byte)
+	ms.IterateValues("a", func(key Key, value []byte) bool {
+		values[key] = value
+		return true
+	})
+	assert.Equal(t, map[Key][]byte{"ac1": []byte("v2"), "ab2": []byte("v3")}, values)
+
+	var buf bytes.Buffer
+	err := ms.Write(&buf)
+	assert.NoError(t, err)
+
+	ms2 := NewMutationSequence()
+	err = ms2.Read(bytes.NewBuffer(buf.Bytes()))
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, util.GetHashValue(ms), util.GetHashValue(ms2))
+	assert.Nil(t, ms2.Latest("ab1").Value())
+	assert.Equal(t, 4, ms2.Len())
+}
@@ -0,0 +1,347 @@
+package kv
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// SortedMap is a dictionary which is iterated in the order of keys (bytewise).
+// Keys of elements are indexed by the trie stored next to the elements: one record per prefix of keys,
+// with flags of the next bytes of keys in the subtree. Iteration walks the trie in the order of bytes,
+// so range and prefix reads only touch nodes along the range
+type SortedMap struct {
+	kv         KVStore
+	name       string
+	cachedsize uint32
+}
+
+type MustSortedMap struct {
+	smap SortedMap
+}
+
+const (
+	sortedMapSizeKeyCode  = byte(0)
+	sortedMapElemKeyCode  = byte(1)
+	sortedMapIndexKeyCode = byte(2)
+)
+
+// sortedMapNode is the node of the index for the prefix of keys. Nodes of empty subtrees are not stored
+type sortedMapNode struct {
+	// the prefix itself is the key of the element
+	isElem bool
+	// bitmap of next bytes after the prefix
+	children [32]byte
+}
+
+func newSortedMap(kv KVStore, name string) (*SortedMap, error) {
+	ret := &SortedMap{
+		kv:   kv,
+		name: name,
+	}
+	var err error
+	ret.cachedsize, err = ret.len()
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func newMustSortedMap(smap *SortedMap) *MustSortedMap {
+	return &MustSortedMap{*smap}
+}
+
+func (m *SortedMap) getSizeKey() Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(m.name))
+	buf.WriteByte(sortedMapSizeKeyCode)
+	return Key(buf.Bytes())
+}
+
+func (m *SortedMap) getElemKey(key []byte) Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(m.name))
+	buf.WriteByte(sortedMapElemKeyCode)
+	buf.Write(key)
+	return Key(buf.Bytes())
+}
+
+func (m *SortedMap) getNodeKey(prefix []byte) Key {
+	var buf bytes.Buffer
+	buf.Write([]byte(m.name))
+	buf.WriteByte(sortedMapIndexKeyCode)
+	buf.Write(prefix)
+	return Key(buf.Bytes())
+}
+
+func (n *sortedMapNode) hasChild(b byte) bool {
+	return n.children[b/8]&(1<<(b%8)) != 0
+}
+
+func (n *sortedMapNode) setChild(b byte, set bool) {
+	if set {
+		n.children[b/8] |= 1 << (b % 8)
+	} else {
+		n.children[b/8] &^= 1 << (b % 8)
+	}
+}
+
+func (n *sortedMapNode) isEmpty() bool {
+	return !n.isElem && n.children == [32]byte{}
+}
+
+func (m *SortedMap) getNode(prefix []byte) (*sortedMapNode, error) {
+	v, err := m.kv.Get(m.getNodeKey(prefix))
+	if err != nil {
+		return nil, err
+	}
+	ret := &sortedMapNode{}
+	if v == nil {
+		return ret, nil
+	}
+	if len(v) != 1+len(ret.children) {
+		return nil, errors.New("corrupted data")
+	}
+	ret.isElem = v[0] != 0
+	copy(ret.children[:], v[1:])
+	return ret, nil
+}
+
+func (m *SortedMap) setNode(prefix []byte, node *sortedMapNode) {
+	if node.isEmpty() {
+		m.kv.Del(m.getNodeKey(prefix))
+		return
+	}
+	v := make([]byte, 1+len(node.children))
+	if node.isElem {
+		v[0] = 1
+	}
+	copy(v[1:], node.children[:])
+	m.kv.Set(m.getNodeKey(prefix), v)
+}
+
+// addToIndex adds the new key to the trie: marks the node of the key and the path to it
+func (m *SortedMap) addToIndex(key []byte) error {
+	node, err := m.getNode(key)
+	if err != nil {
+		return err
+	}
+	node.isElem = true
+	m.setNode(key, node)
+	for i := len(key) - 1; i >= 0; i-- {
+		parent, err := m.getNode(key[:i])
+		if err != nil {
+			return err
+		}
+		if parent.hasChild(key[i]) {
+			// the rest of the path is already marked
+			return nil
+		}
+		parent.setChild(key[i], true)
+		m.setNode(key[:i], parent)
+	}
+	return nil
+}
+
+// removeFromIndex removes the key from the trie together with nodes of subtrees which become empty
+func (m *SortedMap) removeFromIndex(key []byte) error {
+	node, err := m.getNode(key)
+	if err != nil {
+		return err
+	}
+	node.isElem = false
+	m.setNode(key, node)
+	for i := len(key) - 1; i >= 0 && node.isEmpty(); i-- {
+		if node, err = m.getNode(key[:i]); err != nil {
+			return err
+		}
+		node.setChild(key[i], false)
+		m.setNode(key[:i], node)
+	}
+	return nil
+}
+
+func (m *SortedMap) setSize(size uint32) {
+	if size == 0 {
+		m.kv.Del(m.getSizeKey())
+		m.cachedsize = 0
+		return
+	}
+	m.cachedsize = size
+	m.kv.Set(m.getSizeKey(), util.Uint32To4Bytes(size))
+}
+
+func (m *SortedMap) len() (uint32, error) {
+	v, err := m.kv.Get(m.getSizeKey())
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, nil
+	}
+	if len(v) != 4 {
+		return 0, errors.New("corrupted data")
+	}
+	return util.Uint32From4Bytes(v), nil
+}
+
+func (m *SortedMap) Len() uint32 {
+	return m.cachedsize
+}
+
+func (m *MustSortedMap) Len() uint32 {
+	return m.smap.Len()
+}
+
+func (m *SortedMap) GetAt(key []byte) ([]byte, error) {
+	return m.kv.Get(m.getElemKey(key))
+}
+
+func (m *MustSortedMap) GetAt(key []byte) []byte {
+	ret, err := m.smap.GetAt(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func (m *SortedMap) SetAt(key []byte, value []byte) error {
+	ok, err := m.HasAt(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err = m.addToIndex(key); err != nil {
+			return err
+		}
+		m.setSize(m.Len() + 1)
+	}
+	m.kv.Set(m.getElemKey(key), value)
+	return nil
+}
+
+func (m *MustSortedMap) SetAt(key []byte, value []byte) {
+	if err := m.smap.SetAt(key, value); err != nil {
+		panic(err)
+	}
+}
+
+func (m *SortedMap) DelAt(key []byte) error {
+	ok, err := m.HasAt(key)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err = m.removeFromIndex(key); err != nil {
+			return err
+		}
+		m.setSize(m.Len() - 1)
+	}
+	m.kv.Del(m.getElemKey(key))
+	return nil
+}
+
+func (m *MustSortedMap) DelAt(key []byte) {
+	if err := m.smap.DelAt(key); err != nil {
+		panic(err)
+	}
+}
+
+func (m *SortedMap) HasAt(key []byte) (bool, error) {
+	return m.kv.Has(m.getElemKey(key))
+}
+
+func (m *MustSortedMap) HasAt(key []byte) bool {
+	ret, err := m.smap.HasAt(key)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// Erase deletes all elements of the map and the index, independently of the size of the map
+func (m *SortedMap) Erase() {
+	m.kv.DelPrefix(m.getElemKey([]byte{}))
+	m.kv.DelPrefix(m.getNodeKey([]byte{}))
+	m.setSize(0)
+}
+
+func (m *MustSortedMap) Erase() {
+	m.smap.Erase()
+}
+
+// Iterate iterates all entries in the order of keys
+func (m *SortedMap) Iterate(f func(elemKey []byte, value []byte) bool) error {
+	return m.IterateRange(nil, nil, f)
+}
+
+func (m *MustSortedMap) Iterate(f func(elemKey []byte, value []byte) bool) {
+	if err := m.smap.Iterate(f); err != nil {
+		panic(err)
+	}
+}
+
+// IterateRange iterates entries with keys from <= key < to in the order of keys.
+// nil from or to means the range is not limited from that side
+func (m *SortedMap) IterateRange(from, to []byte, f func(elemKey []byte, value []byte) bool) error {
+	_, err := m.iterateNode([]byte{}, from, to, f)
+	return err
+}
+
+func (m *MustSortedMap) IterateRange(from, to []byte, f func(elemKey []byte, value []byte) bool) {
+	if err := m.smap.IterateRange(from, to, f); err != nil {
+		panic(err)
+	}
+}
+
+// IteratePrefix iterates entries with keys starting with the prefix in the order of keys
+func (m *SortedMap) IteratePrefix(prefix []byte, f func(elemKey []byte, value []byte) bool) error {
+	_, err := m.iterateNode(prefix, nil, nil, f)
+	return err
+}
+
+func (m *MustSortedMap) IteratePrefix(prefix []byte, f func(elemKey []byte, value []byte) bool) {
+	if err := m.smap.IteratePrefix(prefix, f); err != nil {
+		panic(err)
+	}
+}
+
+// iterateNode iterates entries of the subtree of the prefix with keys from <= key < to.
+// Subtrees outside of the range are not read. Returns false if the iteration was stopped by f
+func (m *SortedMap) iterateNode(prefix, from, to []byte, f func(elemKey []byte, value []byte) bool) (bool, error) {
+	node, err := m.getNode(prefix)
+	if err != nil {
+		return false, err
+	}
+	if node.isElem && (from == nil || bytes.Compare(prefix, from) >= 0) && (to == nil || bytes.Compare(prefix, to) < 0) {
+		value, err := m.GetAt(prefix)
+		if err != nil {
+			return false, err
+		}
+		if !f(prefix, value) {
+			return false, nil
+		}
+	}
+	for i := 0; i < 256; i++ {
+		if !node.hasChild(byte(i)) {
+			continue
+		}
+		child := make([]byte, len(prefix)+1)
+		copy(child, prefix)
+		child[len(prefix)] = byte(i)
+		// all keys of the subtree are >= child
+		if to != nil && bytes.Compare(child, to) >= 0 {
+			break
+		}
+		// all keys of the subtree are < from
+		if from != nil && bytes.Compare(child, from) < 0 && !bytes.HasPrefix(from, child) {
+			continue
+		}
+		cont, err := m.iterateNode(child, from, to, f)
+		if err != nil || !cont {
+			return cont, err
+		}
+	}
+	return true, nil
+}
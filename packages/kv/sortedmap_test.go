@@ -0,0 +1,133 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedMap(t *testing.T) {
+	vars := NewMap()
+	m, err := newSortedMap(vars, "testMap")
+	assert.NoError(t, err)
+	assert.Zero(t, m.Len())
+
+	for _, k := range []string{"b2", "a1", "c3", "b1", "a2"} {
+		assert.NoError(t, m.SetAt([]byte(k), []byte("v"+k)))
+	}
+	assert.NoError(t, m.SetAt([]byte("b1"), []byte("vb1")))
+	assert.EqualValues(t, 5, m.Len())
+
+	collect := func(iter func(f func(elemKey []byte, value []byte) bool) error) []string {
+		ret := make([]string, 0)
+		assert.NoError(t, iter(func(elemKey []byte, value []byte) bool {
+			assert.Equal(t, "v"+string(elemKey), string(value))
+			ret = append(ret, string(elemKey))
+			return true
+		}))
+		return ret
+	}
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2", "c3"}, collect(m.Iterate))
+	assert.Equal(t, []string{"a2", "b1"}, collect(func(f func(elemKey []byte, value []byte) bool) error {
+		return m.IterateRange([]byte("a2"), []byte("b2"), f)
+	}))
+	assert.Equal(t, []string{"b1", "b2", "c3"}, collect(func(f func(elemKey []byte, value []byte) bool) error {
+		return m.IterateRange([]byte("b"), nil, f)
+	}))
+	assert.Equal(t, []string{"b1", "b2"}, collect(func(f func(elemKey []byte, value []byte) bool) error {
+		return m.IteratePrefix([]byte("b"), f)
+	}))
+
+	assert.NoError(t, m.DelAt([]byte("a1")))
+	assert.NoError(t, m.DelAt([]byte("x")))
+	assert.EqualValues(t, 4, m.Len())
+	assert.Equal(t, []string{"a2", "b1", "b2", "c3"}, collect(m.Iterate))
+
+	m2, err := newSortedMap(vars, "testMap")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, m2.Len())
+
+	m.Erase()
+	assert.Zero(t, m.Len())
+	assert.Equal(t, []string{}, collect(m.Iterate))
+}
+
+// countingKVStore counts reads of the store
+type countingKVStore struct {
+	KVStore
+	reads int
+}
+
+func (c *countingKVStore) Get(key Key) ([]byte, error) {
+	c.reads++
+	return c.KVStore.Get(key)
+}
+
+func (c *countingKVStore) Iterate(prefix Key, f func(key Key, value []byte) bool) error {
+	panic("the sorted map must not scan the store")
+}
+
+// beUint32 encodes the number as the key in the order of numbers
+func beUint32(n uint32) []byte {
+	ret := make([]byte, 4)
+	binary.BigEndian.PutUint32(ret, n)
+	return ret
+}
+
+func TestSortedMapIndex(t *testing.T) {
+	vars := NewMap()
+	store := &countingKVStore{KVStore: vars}
+	m, err := newSortedMap(store, "testMap")
+	assert.NoError(t, err)
+
+	const n = 1000
+	for i := n - 1; i >= 0; i-- {
+		assert.NoError(t, m.SetAt(beUint32(uint32(i*2)), []byte{byte(i)}))
+	}
+	assert.NoError(t, m.SetAt([]byte{}, []byte("empty")))
+	assert.NoError(t, m.SetAt([]byte{0, 0}, []byte("short")))
+
+	prev := []byte(nil)
+	count := 0
+	assert.NoError(t, m.Iterate(func(elemKey []byte, value []byte) bool {
+		if prev != nil {
+			assert.True(t, bytes.Compare(prev, elemKey) < 0)
+		}
+		prev = elemKey
+		count++
+		return true
+	}))
+	assert.EqualValues(t, n+2, count)
+
+	store.reads = 0
+	keys := make([]uint32, 0)
+	assert.NoError(t, m.IterateRange(beUint32(501), beUint32(511), func(elemKey []byte, value []byte) bool {
+		keys = append(keys, binary.BigEndian.Uint32(elemKey))
+		return true
+	}))
+	assert.Equal(t, []uint32{502, 504, 506, 508, 510}, keys)
+	// nodes along the range and values of elements in it, not the whole map
+	assert.Less(t, store.reads, 30)
+
+	store.reads = 0
+	keys = keys[:0]
+	assert.NoError(t, m.IterateRange(nil, nil, func(elemKey []byte, value []byte) bool {
+		if len(elemKey) == 4 {
+			keys = append(keys, binary.BigEndian.Uint32(elemKey))
+		}
+		return len(keys) < 3
+	}))
+	assert.Equal(t, []uint32{0, 2, 4}, keys)
+	assert.Less(t, store.reads, 30)
+
+	// deleted elements are removed from the index
+	assert.NoError(t, m.DelAt([]byte{}))
+	assert.NoError(t, m.DelAt([]byte{0, 0}))
+	for i := 0; i < n; i++ {
+		assert.NoError(t, m.DelAt(beUint32(uint32(i*2))))
+	}
+	assert.Zero(t, m.Len())
+	assert.True(t, vars.IsEmpty())
+}
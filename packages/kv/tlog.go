@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+
 	"github.com/iotaledger/wasp/packages/util"
 )
 
@@ -15,6 +16,10 @@ type TimestampedLog struct {
 	cachedEarliest int64
 }
 
+type MustTimestampedLog struct {
+	tlog TimestampedLog
+}
+
 type LogRecord struct {
 	Index     uint32
 	Timestamp int64
@@ -47,6 +52,10 @@ func newTimestampedLog(kv KVStore, name string) (*TimestampedLog, error) {
 	return ret, nil
 }
 
+func newMustTimestampedLog(tlog *TimestampedLog) *MustTimestampedLog {
+	return &MustTimestampedLog{*tlog}
+}
+
 const (
 	tslSizeKeyCode = byte(0)
 	tslElemKeyCode = byte(1)
@@ -113,10 +122,24 @@ func (l *TimestampedLog) Append(ts int64, data []byte) error {
 	return nil
 }
 
+func (l *MustTimestampedLog) Len() uint32 {
+	return l.tlog.Len()
+}
+
+func (l *MustTimestampedLog) Append(ts int64, data []byte) {
+	if err := l.tlog.Append(ts, data); err != nil {
+		panic(err)
+	}
+}
+
 func (l *TimestampedLog) Latest() int64 {
 	return l.cachedLatest
 }
 
+func (l *MustTimestampedLog) Latest() int64 {
+	return l.tlog.Latest()
+}
+
 func (l *TimestampedLog) latest() (int64, error) {
 	idx := l.Len()
 	if idx == 0 {
@@ -136,6 +159,10 @@ func (l *TimestampedLog) Earliest() int64 {
 	return l.cachedEarliest
 }
 
+func (l *MustTimestampedLog) Earliest() int64 {
+	return l.tlog.Earliest()
+}
+
 func (l *TimestampedLog) earliest() (int64, error) {
 	if l.Len() == 0 {
 		return 0, nil
@@ -212,6 +239,14 @@ func (l *TimestampedLog) TakeTimeSlice(fromTs, toTs int64) (*TimeSlice, error) {
 	}, nil
 }
 
+func (l *MustTimestampedLog) TakeTimeSlice(fromTs, toTs int64) *TimeSlice {
+	ret, err := l.tlog.TakeTimeSlice(fromTs, toTs)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
 func (l *TimestampedLog) findLowerIdx(ts int64, fromIdx, toIdx uint32) (uint32, bool, error) {
 	if fromIdx > toIdx {
 		return 0, false, nil
@@ -317,10 +352,19 @@ func (l *TimestampedLog) findUpperIdx(ts int64, fromIdx, toIdx uint32) (uint32,
 	return l.findUpperIdx(ts, fromIdx, middleIdx)
 }
 
-// TODO not finished
-
+// Erase deletes all records of the log with one DelPrefix, independently of the length of the log
 func (l *TimestampedLog) Erase() {
-	panic("implement me")
+	var buf bytes.Buffer
+	buf.Write([]byte(l.name))
+	buf.WriteByte(tslElemKeyCode)
+	l.kv.DelPrefix(Key(buf.Bytes()))
+	l.setSize(0)
+	l.cachedLatest = 0
+	l.cachedEarliest = 0
+}
+
+func (l *MustTimestampedLog) Erase() {
+	l.tlog.Erase()
 }
 
 func (sl *TimeSlice) IsEmpty() bool {
@@ -346,8 +390,20 @@ func (sl *TimeSlice) Latest() int64 {
 }
 
 func (sl *TimeSlice) LoadSlice() ([]*LogRecord, error) {
-	ret := make([]*LogRecord, 0, sl.NumPoints())
-	for i := sl.firstIdx; i <= sl.lastIdx; i++ {
+	return sl.LoadRecords(0, sl.NumPoints())
+}
+
+// LoadRecords loads at most limit records of the slice, skipping first offset records
+func (sl *TimeSlice) LoadRecords(offset, limit uint32) ([]*LogRecord, error) {
+	n := sl.NumPoints()
+	if offset >= n {
+		return make([]*LogRecord, 0), nil
+	}
+	if limit > n-offset {
+		limit = n - offset
+	}
+	ret := make([]*LogRecord, 0, limit)
+	for i := sl.firstIdx + offset; i < sl.firstIdx+offset+limit; i++ {
 		r, err := sl.tslog.getRecordAtIndex(i)
 		if err != nil {
 			return nil, err
@@ -120,3 +120,80 @@ func TestTlogBig(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, tl.Len(), tslice.NumPoints())
 }
+
+func TestTlogErase(t *testing.T) {
+	vars := NewMap()
+	tl, err := newTimestampedLog(vars, "testTlog")
+	assert.NoError(t, err)
+
+	nowis := time.Now().UnixNano()
+	for i := 0; i < 10; i++ {
+		err = tl.Append(nowis+int64(i), []byte{byte(i)})
+		assert.NoError(t, err)
+	}
+	tl.Erase()
+	assert.Zero(t, tl.Len())
+	assert.Zero(t, tl.Latest())
+	assert.Zero(t, tl.Earliest())
+	assert.True(t, vars.IsEmpty())
+
+	err = tl.Append(nowis-1, []byte("datum"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, tl.Len())
+}
+
+func TestTlogCodec(t *testing.T) {
+	vars := NewMap()
+	tl := NewMustCodec(vars).GetTimestampedLog("testTlog")
+	assert.Zero(t, tl.Len())
+
+	tl.Append(100, []byte("datum1"))
+	tl.Append(200, []byte("datum2"))
+	tl.Append(300, []byte("datum3"))
+	assert.Panics(t, func() {
+		tl.Append(250, []byte("datum4"))
+	})
+
+	tl2, err := NewCodec(vars).GetTimestampedLog("testTlog")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, tl2.Len())
+	assert.EqualValues(t, 100, tl2.Earliest())
+	assert.EqualValues(t, 300, tl2.Latest())
+
+	recs, err := tl.TakeTimeSlice(150, 300).LoadSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(recs))
+	assert.EqualValues(t, 1, recs[0].Index)
+	assert.EqualValues(t, []byte("datum2"), recs[0].Data)
+	assert.EqualValues(t, 300, recs[1].Timestamp)
+
+	recs, err = tl.TakeTimeSlice(400, 500).LoadSlice()
+	assert.NoError(t, err)
+	assert.Empty(t, recs)
+}
+
+func TestTlogLoadRecords(t *testing.T) {
+	vars := NewMap()
+	tl := NewMustCodec(vars).GetTimestampedLog("testTlog")
+	for i := 1; i <= 10; i++ {
+		tl.Append(int64(i*100), []byte{byte(i)})
+	}
+
+	slice := tl.TakeTimeSlice(200, 900)
+	assert.EqualValues(t, 8, slice.NumPoints())
+
+	recs, err := slice.LoadRecords(0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(recs))
+	assert.EqualValues(t, 200, recs[0].Timestamp)
+
+	recs, err = slice.LoadRecords(6, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(recs))
+	assert.EqualValues(t, 800, recs[0].Timestamp)
+	assert.EqualValues(t, 900, recs[1].Timestamp)
+
+	recs, err = slice.LoadRecords(8, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, recs)
+}
@@ -1,6 +1,7 @@
 package parameters
 
 import (
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/plugins/config"
 	flag "github.com/spf13/pflag"
 )
@@ -13,8 +14,18 @@ const (
 	LoggerOutputPaths       = "logger.outputPaths"
 	LoggerDisableEvents     = "logger.disableEvents"
 
-	DatabaseDir      = "database.directory"
-	DatabaseInMemory = "database.inMemory"
+	DatabaseDir              = "database.directory"
+	DatabaseInMemory         = "database.inMemory"
+	DatabaseKeepStateHistory = "database.keepStateHistory"
+	DatabaseReadCacheSize    = "database.readCacheSize"
+
+	DatabasePruningKeepStates  = "database.pruning.keepStates"
+	DatabasePruningKeepMinutes = "database.pruning.keepMinutes"
+	DatabasePruningInterval    = "database.pruning.interval"
+
+	RegistryMasterKeyFile   = "registry.masterKeyFile"
+	RegistryMasterKeyPhrase = "registry.passphrase"
+	RegistryAuditLog        = "registry.auditLog"
 
 	WebAPIBindAddress = "webapi.bindAddress"
 
@@ -41,6 +52,15 @@ func init() {
 
 	flag.String(DatabaseDir, "waspdb", "path to the database folder")
 	flag.Bool(DatabaseInMemory, false, "whether the database is only kept in memory and not persisted")
+	flag.Bool(DatabaseKeepStateHistory, false, "whether to keep the history of state variables to query past states")
+	flag.Int(DatabaseReadCacheSize, kv.DefaultReadCacheSize, "capacity of the read cache of state variables of each smart contract, in key/value pairs")
+	flag.Int(DatabasePruningKeepStates, 0, "number of latest batches of each smart contract kept in the database. 0 means no limit")
+	flag.Int(DatabasePruningKeepMinutes, 0, "batches of smart contracts younger than that are kept in the database. 0 means no limit")
+	flag.Int(DatabasePruningInterval, 60, "interval in seconds between pruning runs")
+
+	flag.String(RegistryMasterKeyFile, "", "file with the secret of the master key, which encrypts private keys of the node in the database")
+	flag.String(RegistryMasterKeyPhrase, "", "passphrase of the master key, which encrypts private keys of the node in the database")
+	flag.String(RegistryAuditLog, "registry-audit.log", "file where exports and imports of key shares are appended")
 
 	flag.String(WebAPIBindAddress, "127.0.0.1:8080", "the bind address for the web API")
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
 	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/database"
@@ -21,6 +22,13 @@ type BootupData struct {
 	Color          balance.Color   // origin tx hash
 	CommitteeNodes []string        // "host_addr:port"
 	AccessNodes    []string        // "host_addr:port"
+	// identity keys of committee nodes in the same order as CommitteeNodes.
+	// Peers must prove possession of the pinned key during the handshake, peers with other keys are rejected.
+	// Records stored before keys were introduced have none and must be replaced to run the committee
+	CommitteePubKeys []ed25519.PublicKey
+	// new address of the smart contract if it was handed over to another committee. The committee of the record
+	// is not activated anymore, the record and the state are kept until the new committee confirms the handover
+	RotatedTo *address.Address
 }
 
 func dbkeyBootupData(addr *address.Address) []byte {
@@ -35,6 +43,9 @@ func SaveBootupData(bd *BootupData, overwrite bool) error {
 	if bd.Color == balance.ColorNew || bd.Color == balance.ColorIOTA {
 		return fmt.Errorf("can't be IOTA or New color")
 	}
+	if len(bd.CommitteePubKeys) != 0 && len(bd.CommitteePubKeys) != len(bd.CommitteeNodes) {
+		return fmt.Errorf("number of committee public keys must be equal to the number of committee nodes")
+	}
 
 	if overwrite {
 		exist, err := database.GetRegistryPartition().Has(dbkeyBootupData(&bd.Address))
@@ -70,6 +81,12 @@ func GetBootupData(addr *address.Address) (*BootupData, bool, error) {
 	return ret, true, nil
 }
 
+// DeleteBootupData removes the bootup record, so the committee is not activated by the node anymore.
+// It is done when the new committee confirmed it runs the smart contract handed over to it
+func DeleteBootupData(addr *address.Address) error {
+	return database.GetRegistryPartition().Delete(dbkeyBootupData(addr))
+}
+
 func GetBootupRecords() ([]*BootupData, error) {
 	db := database.GetRegistryPartition()
 	ret := make([]*BootupData, 0)
@@ -102,6 +119,22 @@ func (bd *BootupData) Write(w io.Writer) error {
 	if err := util.WriteStrings16(w, bd.AccessNodes); err != nil {
 		return err
 	}
+	if err := util.WriteUint16(w, uint16(len(bd.CommitteePubKeys))); err != nil {
+		return err
+	}
+	for i := range bd.CommitteePubKeys {
+		if _, err := w.Write(bd.CommitteePubKeys[i][:]); err != nil {
+			return err
+		}
+	}
+	if err := util.WriteBoolByte(w, bd.RotatedTo != nil); err != nil {
+		return err
+	}
+	if bd.RotatedTo != nil {
+		if _, err := w.Write(bd.RotatedTo[:]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -122,5 +155,35 @@ func (bd *BootupData) Read(r io.Reader) error {
 	if bd.AccessNodes, err = util.ReadStrings16(r); err != nil {
 		return err
 	}
+	var numKeys uint16
+	if err = util.ReadUint16(r, &numKeys); err != nil {
+		if err == io.EOF {
+			// record was written before public keys were introduced
+			return nil
+		}
+		return err
+	}
+	if numKeys > 0 {
+		bd.CommitteePubKeys = make([]ed25519.PublicKey, numKeys)
+		for i := range bd.CommitteePubKeys {
+			if _, err = io.ReadFull(r, bd.CommitteePubKeys[i][:]); err != nil {
+				return err
+			}
+		}
+	}
+	var rotated bool
+	if err = util.ReadBoolByte(r, &rotated); err != nil {
+		if err == io.EOF {
+			// record was written before rotation was introduced
+			return nil
+		}
+		return err
+	}
+	if rotated {
+		bd.RotatedTo = new(address.Address)
+		if err = util.ReadAddress(r, bd.RotatedTo); err != nil {
+			return err
+		}
+	}
 	return nil
 }
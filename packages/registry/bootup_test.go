@@ -0,0 +1,46 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootupDataRotated(t *testing.T) {
+	bd := &BootupData{
+		Address:        address.Random(),
+		OwnerAddress:   address.Random(),
+		Color:          balance.Color{1, 2, 3},
+		CommitteeNodes: []string{"127.0.0.1:4000", "127.0.0.1:4001"},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, bd.Write(&buf))
+	back := new(BootupData)
+	assert.NoError(t, back.Read(bytes.NewReader(buf.Bytes())))
+	assert.Nil(t, back.RotatedTo)
+
+	newAddr := address.Random()
+	bd.RotatedTo = &newAddr
+	buf.Reset()
+	assert.NoError(t, bd.Write(&buf))
+	back = new(BootupData)
+	assert.NoError(t, back.Read(bytes.NewReader(buf.Bytes())))
+	assert.EqualValues(t, newAddr, *back.RotatedTo)
+	assert.EqualValues(t, bd.CommitteeNodes, back.CommitteeNodes)
+
+	// record written before public keys and rotation were introduced
+	buf.Reset()
+	buf.Write(bd.Address[:])
+	buf.Write(bd.OwnerAddress[:])
+	buf.Write(bd.Color[:])
+	assert.NoError(t, util.WriteStrings16(&buf, bd.CommitteeNodes))
+	assert.NoError(t, util.WriteStrings16(&buf, nil))
+	back = new(BootupData)
+	assert.NoError(t, back.Read(bytes.NewReader(buf.Bytes())))
+	assert.Nil(t, back.RotatedTo)
+	assert.EqualValues(t, bd.Address, back.Address)
+}
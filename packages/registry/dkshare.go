@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/wasp/packages/tcrypto"
 	"github.com/iotaledger/wasp/plugins/database"
 	"go.dedis.ch/kyber/v3"
@@ -16,30 +17,125 @@ func CommitDKShare(ks *tcrypto.DKShare, pubKeys []kyber.Point) error {
 	return SaveDKShareToRegistry(ks)
 }
 
+// CommitRefreshedDKShare finalizes the refreshed key share and saves it next to the old key share
+// with the same address. The old key share stays in use until all nodes committed their refreshed key shares
+// and the refreshed one is activated by ActivateRefreshedDKShare. Until then the refresh can be abandoned
+// with DeleteRefreshedDKShare
+func CommitRefreshedDKShare(ks *tcrypto.DKShare, pubKeys []kyber.Point) error {
+	if err := ks.FinalizeDKS(pubKeys); err != nil {
+		return err
+	}
+	old, exists, err := GetDKShare(ks.Address)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("key share with address %s does not exist", ks.Address.String())
+	}
+	defer old.ErasePrivate()
+	if old.N != ks.N || old.T != ks.T || old.Index != ks.Index {
+		return fmt.Errorf("refreshed key share is not compatible with the old one")
+	}
+	data, err := marshalDKShare(dbkeyRefreshed(ks.Address), ks)
+	if err != nil {
+		return err
+	}
+	return database.GetRegistryPartition().Set(dbkeyRefreshed(ks.Address), data)
+}
+
+// ActivateRefreshedDKShare replaces the key share with the refreshed one saved by CommitRefreshedDKShare
+// and returns it. The old key share is deleted. It remains in database files until they are compacted,
+// so the garbage collection of the database is started right away
+func ActivateRefreshedDKShare(addr *address.Address) (*tcrypto.DKShare, error) {
+	dbase := database.GetRegistryPartition()
+	data, err := dbase.Get(dbkeyRefreshed(addr))
+	if err == kvstore.ErrKeyNotFound {
+		return nil, fmt.Errorf("refreshed key share with address %s does not exist", addr.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	ks, err := unmarshalDKShare(dbkeyRefreshed(addr), data, false)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = marshalDKShare(dbkey(addr), ks); err != nil {
+		return nil, err
+	}
+	batch := dbase.Batched()
+	if err = batch.Set(dbkey(addr), data); err != nil {
+		batch.Cancel()
+		return nil, err
+	}
+	if err = batch.Delete(dbkeyRefreshed(addr)); err != nil {
+		batch.Cancel()
+		return nil, err
+	}
+	if err = batch.Commit(); err != nil {
+		return nil, err
+	}
+	database.RunGC()
+	return ks, nil
+}
+
+// DeleteRefreshedDKShare deletes the refreshed key share if the refresh failed. The old key share stays in use
+func DeleteRefreshedDKShare(addr *address.Address) error {
+	if err := database.GetRegistryPartition().Delete(dbkeyRefreshed(addr)); err != nil {
+		return err
+	}
+	database.RunGC()
+	return nil
+}
+
 func dbkey(addr *address.Address) []byte {
 	return database.MakeKey(database.ObjectTypeDistributedKeyData, addr.Bytes())
 }
 
+func dbkeyRefreshed(addr *address.Address) []byte {
+	return database.MakeKey(database.ObjectTypeRefreshedDKShare, addr.Bytes())
+}
+
 func SaveDKShareToRegistry(ks *tcrypto.DKShare) error {
+	return saveDKShare(ks, false)
+}
+
+func saveDKShare(ks *tcrypto.DKShare, overwrite bool) error {
 	if !ks.Committed {
 		return fmt.Errorf("uncommited DK share: can't be saved to the registry")
 	}
 	dbase := database.GetRegistryPartition()
-	exists, err := dbase.Has(dbkey(ks.Address))
+	if !overwrite {
+		exists, err := dbase.Has(dbkey(ks.Address))
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("attempt to overwrite existing DK key share")
+		}
+	}
+
+	data, err := marshalDKShare(dbkey(ks.Address), ks)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("attempt to overwrite existing DK key share")
-	}
+	return dbase.Set(dbkey(ks.Address), data)
+}
 
+// marshalDKShare returns the key share data to be stored under the key
+func marshalDKShare(key []byte, ks *tcrypto.DKShare) ([]byte, error) {
 	var buf bytes.Buffer
+	if err := ks.Write(&buf); err != nil {
+		return nil, err
+	}
+	return sealPrivate(key, buf.Bytes())
+}
 
-	err = ks.Write(&buf)
+func unmarshalDKShare(key []byte, data []byte, maskPrivate bool) (*tcrypto.DKShare, error) {
+	data, err := openPrivate(key, data)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return dbase.Set(dbkey(ks.Address), buf.Bytes())
+	return tcrypto.UnmarshalDKShare(data, maskPrivate)
 }
 
 func GetDKShare(addr *address.Address) (*tcrypto.DKShare, bool, error) {
@@ -60,9 +156,5 @@ func LoadDKShare(addr *address.Address, maskPrivate bool) (*tcrypto.DKShare, err
 	if err != nil {
 		return nil, err
 	}
-	ret, err := tcrypto.UnmarshalDKShare(data, maskPrivate)
-	if err != nil {
-		return nil, err
-	}
-	return ret, nil
+	return unmarshalDKShare(dbkey(addr), data, maskPrivate)
 }
@@ -0,0 +1,51 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// the node has one persistent ed25519 identity key.
+// Peers prove possession of it during the peering handshake
+
+func dbkeyNodeIdentity() []byte {
+	return database.MakeKey(database.ObjectTypeNodeIdentity)
+}
+
+// GetNodeIdentity returns the identity key of the node.
+// The key is generated and saved to the registry when called the first time
+func GetNodeIdentity() (ed25519.PrivateKey, error) {
+	db := database.GetRegistryPartition()
+	data, err := db.Get(dbkeyNodeIdentity())
+	if err == kvstore.ErrKeyNotFound {
+		_, privateKey, err := ed25519.GenerateKey()
+		if err != nil {
+			return ed25519.PrivateKey{}, err
+		}
+		if err = saveNodeIdentity(privateKey.Bytes()); err != nil {
+			return ed25519.PrivateKey{}, err
+		}
+		return privateKey, nil
+	}
+	if err != nil {
+		return ed25519.PrivateKey{}, err
+	}
+	if data, err = openPrivate(dbkeyNodeIdentity(), data); err != nil {
+		return ed25519.PrivateKey{}, err
+	}
+	ret, err, _ := ed25519.PrivateKeyFromBytes(data)
+	if err != nil {
+		return ed25519.PrivateKey{}, fmt.Errorf("corrupted node identity: %v", err)
+	}
+	return ret, nil
+}
+
+func saveNodeIdentity(data []byte) error {
+	sealed, err := sealPrivate(dbkeyNodeIdentity(), data)
+	if err != nil {
+		return err
+	}
+	return database.GetRegistryPartition().Set(dbkeyNodeIdentity(), sealed)
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/plugins/database"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// private keys of the node (the identity key and the key shares) are encrypted in the registry
+// with the node master key. The master key is derived from the passphrase or from the content of the key file
+// when the node starts. The salt and the encrypted check value are stored in the registry,
+// so the wrong passphrase is detected at startup.
+// Records saved before the master key was set are plaintext. They are encrypted by SetMasterKey,
+// which is the only reader of plaintext records. When the master key is set, plaintext records are rejected.
+// The node with key shares doesn't start without the master key
+
+const masterKeySaltSize = 32
+
+var (
+	// nil if the master key is not set
+	masterKey cipher.AEAD
+	// prefix of encrypted records. Plaintext key shares start with the address version byte, which is never 0xff
+	sealedPrefix   = []byte{0xff, 'w', 'e', 'n', 'c'}
+	masterKeyCheck = []byte("wasp master key check")
+)
+
+func dbkeyMasterKeyCheck() []byte {
+	return database.MakeKey(database.ObjectTypeMasterKeyCheck)
+}
+
+// IsEncrypted returns true if the master key was set in the registry before
+func IsEncrypted() (bool, error) {
+	return database.GetRegistryPartition().Has(dbkeyMasterKeyCheck())
+}
+
+// HasMasterKey returns true if the master key is set. Private keys are accepted only encrypted then
+func HasMasterKey() bool {
+	return masterKey != nil
+}
+
+// HasDKShares returns true if there are key shares in the registry
+func HasDKShares() (bool, error) {
+	ret := false
+	for _, prefix := range []byte{database.ObjectTypeDistributedKeyData, database.ObjectTypeRefreshedDKShare} {
+		err := database.GetRegistryPartition().IterateKeys([]byte{prefix}, func(key kvstore.Key) bool {
+			ret = true
+			return false
+		})
+		if err != nil || ret {
+			return ret, err
+		}
+	}
+	return false, nil
+}
+
+// SetMasterKey derives the master key from the secret and encrypts the private keys in the registry with it.
+// Returns error if the registry was encrypted with another secret
+func SetMasterKey(secret []byte) error {
+	if len(secret) == 0 {
+		return errors.New("empty master key secret")
+	}
+	db := database.GetRegistryPartition()
+	rec, err := db.Get(dbkeyMasterKeyCheck())
+	exists := err == nil
+	if err != nil && err != kvstore.ErrKeyNotFound {
+		return err
+	}
+	salt := make([]byte, masterKeySaltSize)
+	if exists {
+		if len(rec) < masterKeySaltSize {
+			return errors.New("corrupted master key record")
+		}
+		copy(salt, rec[:masterKeySaltSize])
+	} else if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := scrypt.Key(secret, salt, 1<<15, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		check, err := open(aead, dbkeyMasterKeyCheck(), rec[masterKeySaltSize:])
+		if err != nil || !bytes.Equal(check, masterKeyCheck) {
+			return errors.New("wrong master key")
+		}
+	} else {
+		sealed, err := seal(aead, dbkeyMasterKeyCheck(), masterKeyCheck)
+		if err != nil {
+			return err
+		}
+		if err = db.Set(dbkeyMasterKeyCheck(), append(salt, sealed...)); err != nil {
+			return err
+		}
+	}
+	masterKey = aead
+	return encryptPlaintextRecords()
+}
+
+// encryptPlaintextRecords encrypts private keys saved before the master key was set
+func encryptPlaintextRecords() error {
+	db := database.GetRegistryPartition()
+	data, err := db.Get(dbkeyNodeIdentity())
+	if err != nil && err != kvstore.ErrKeyNotFound {
+		return err
+	}
+	if err == nil && len(data) == ed25519.PrivateKeySize {
+		if err = saveNodeIdentity(data); err != nil {
+			return err
+		}
+		log.Infof("node identity key was encrypted with the master key")
+	}
+	// key shares in use and refreshed ones, which are not activated yet
+	plaintextKeys := make([][]byte, 0)
+	plaintext := make([][]byte, 0)
+	for _, prefix := range []byte{database.ObjectTypeDistributedKeyData, database.ObjectTypeRefreshedDKShare} {
+		err = db.Iterate([]byte{prefix}, func(key kvstore.Key, value kvstore.Value) bool {
+			if !isSealed(value) {
+				plaintextKeys = append(plaintextKeys, key)
+				plaintext = append(plaintext, value)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for i, data := range plaintext {
+		ks, err := tcrypto.UnmarshalDKShare(data, false)
+		if err != nil {
+			return fmt.Errorf("corrupted key share in the registry: %v", err)
+		}
+		sealed, err := marshalDKShare(plaintextKeys[i], ks)
+		ks.ErasePrivate()
+		if err != nil {
+			return err
+		}
+		if err = db.Set(plaintextKeys[i], sealed); err != nil {
+			return err
+		}
+		log.Infof("key share %s was encrypted with the master key", ks.Address.String())
+	}
+	return nil
+}
+
+func isSealed(data []byte) bool {
+	return bytes.HasPrefix(data, sealedPrefix)
+}
+
+// sealPrivate encrypts private data stored under the key with the master key.
+// Data is stored plaintext if the master key is not set
+func sealPrivate(key []byte, data []byte) ([]byte, error) {
+	if masterKey == nil {
+		return data, nil
+	}
+	return seal(masterKey, key, data)
+}
+
+// openPrivate decrypts private data stored under the key. Plaintext data is returned as is
+// only if the master key is not set
+func openPrivate(key []byte, data []byte) ([]byte, error) {
+	if !isSealed(data) {
+		if masterKey != nil {
+			return nil, errors.New("plaintext private data in the registry is rejected, the master key is set")
+		}
+		return data, nil
+	}
+	if masterKey == nil {
+		return nil, errors.New("private data in the registry is encrypted, but the master key is not set")
+	}
+	return open(masterKey, key, data)
+}
+
+// the record is bound to its key, so encrypted records can't be swapped in the database
+func seal(aead cipher.AEAD, key []byte, data []byte) ([]byte, error) {
+	ret := make([]byte, len(sealedPrefix)+aead.NonceSize(), len(sealedPrefix)+aead.NonceSize()+len(data)+aead.Overhead())
+	copy(ret, sealedPrefix)
+	if _, err := rand.Read(ret[len(sealedPrefix):]); err != nil {
+		return nil, err
+	}
+	return aead.Seal(ret, ret[len(sealedPrefix):], data, key), nil
+}
+
+func open(aead cipher.AEAD, key []byte, data []byte) ([]byte, error) {
+	if !isSealed(data) || len(data) < len(sealedPrefix)+aead.NonceSize() {
+		return nil, errors.New("not an encrypted record")
+	}
+	data = data[len(sealedPrefix):]
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], key)
+}
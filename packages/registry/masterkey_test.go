@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestSealOpen(t *testing.T) {
+	aead, err := chacha20poly1305.New(make([]byte, chacha20poly1305.KeySize))
+	assert.NoError(t, err)
+	data := []byte("private key")
+
+	sealed, err := seal(aead, []byte("key1"), data)
+	assert.NoError(t, err)
+	assert.True(t, isSealed(sealed))
+	assert.NotContains(t, string(sealed), string(data))
+
+	opened, err := open(aead, []byte("key1"), sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, opened)
+
+	// the record can't be moved to another key
+	_, err = open(aead, []byte("key2"), sealed)
+	assert.Error(t, err)
+
+	_, err = open(aead, []byte("key1"), data)
+	assert.Error(t, err)
+}
+
+func TestOpenPrivatePlaintext(t *testing.T) {
+	data := []byte("private key")
+	// plaintext records saved before the master key was set
+	opened, err := openPrivate([]byte("key1"), data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, opened)
+
+	aead, err := chacha20poly1305.New(make([]byte, chacha20poly1305.KeySize))
+	assert.NoError(t, err)
+	masterKey = aead
+	defer func() { masterKey = nil }()
+
+	sealed, err := sealPrivate([]byte("key1"), data)
+	assert.NoError(t, err)
+	opened, err = openPrivate([]byte("key1"), sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, opened)
+
+	// plaintext records are rejected when the master key is set
+	_, err = openPrivate([]byte("key1"), data)
+	assert.Error(t, err)
+}
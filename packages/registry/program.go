@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/schema"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/database"
 	"github.com/iotaledger/wasp/plugins/publisher"
@@ -22,6 +23,8 @@ type ProgramMetadata struct {
 	VMType string
 	// description any text
 	Description string
+	// schema of the program, optional
+	Schema *schema.Schema
 }
 
 func dbkeyProgramMetadata(progHash *hashing.HashValue) []byte {
@@ -103,6 +106,14 @@ func (md *ProgramMetadata) Write(w io.Writer) error {
 	if err := util.WriteString16(w, md.Description); err != nil {
 		return err
 	}
+	if err := util.WriteBoolByte(w, md.Schema != nil); err != nil {
+		return err
+	}
+	if md.Schema != nil {
+		if err := md.Schema.Write(w); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -117,5 +128,19 @@ func (md *ProgramMetadata) Read(r io.Reader) error {
 	if md.Description, err = util.ReadString16(r); err != nil {
 		return err
 	}
+	var hasSchema bool
+	if err = util.ReadBoolByte(r, &hasSchema); err != nil {
+		if err == io.EOF {
+			// record was written before schemas were introduced
+			return nil
+		}
+		return err
+	}
+	if hasSchema {
+		md.Schema = &schema.Schema{}
+		if err = md.Schema.Read(r); err != nil {
+			return err
+		}
+	}
 	return nil
 }
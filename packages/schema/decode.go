@@ -0,0 +1,159 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/mr-tron/base58"
+)
+
+// Decoding turns binary values into values which marshal to human readable JSON:
+// numbers and booleans as they are, strings as text, addresses, hashes and request ids
+// in their string form, colors and raw bytes in base58
+
+type BalanceJson struct {
+	Color   string `json:"color"` // base58
+	Balance int64  `json:"balance"`
+}
+
+// Decode decodes the value of the type
+func (t ValueType) Decode(b []byte) (interface{}, error) {
+	if err := t.Validate(b); err != nil {
+		return nil, err
+	}
+	switch t {
+	case ValueTypeBytes:
+		return base58.Encode(b), nil
+	case ValueTypeString:
+		return string(b), nil
+	case ValueTypeInt64:
+		return kv.DecodeInt64(b)
+	case ValueTypeUint8:
+		return b[0], nil
+	case ValueTypeUint16:
+		return kv.DecodeUint16(b)
+	case ValueTypeUint32:
+		return kv.DecodeUint32(b)
+	case ValueTypeUint64:
+		return kv.DecodeUint64(b)
+	case ValueTypeBool:
+		return kv.DecodeBool(b)
+	case ValueTypeAddress:
+		addr, _, err := address.FromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		return addr.String(), nil
+	case ValueTypeHash:
+		h, err := hashing.HashValueFromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		return h.String(), nil
+	case ValueTypeColor:
+		return base58.Encode(b), nil
+	case ValueTypeBalances:
+		bals, err := kv.DecodeBalances(b)
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]BalanceJson, len(bals))
+		for i, bal := range bals {
+			ret[i] = BalanceJson{Color: base58.Encode(bal.Color[:]), Balance: bal.Value}
+		}
+		return ret, nil
+	case ValueTypeRequestId:
+		reqid, err := kv.DecodeRequestId(b)
+		if err != nil {
+			return nil, err
+		}
+		return reqid.String(), nil
+	case ValueTypeMap:
+		m, err := kv.DecodeMap(b)
+		if err != nil {
+			return nil, err
+		}
+		ret := make(map[string]string)
+		m.ForEach(func(key kv.Key, value []byte) bool {
+			ret[string(key)] = base58.Encode(value)
+			return true
+		})
+		return ret, nil
+	}
+	return nil, fmt.Errorf("can't decode value type '%s'", t)
+}
+
+// DecodeValue decodes the value of the variable, or the element of the collection
+func (sv *StateVar) DecodeValue(b []byte) (interface{}, error) {
+	if sv.Type != ValueTypeStruct {
+		return sv.Type.Decode(b)
+	}
+	r := bytes.NewReader(b)
+	ret := make(map[string]interface{})
+	for _, f := range sv.Fields {
+		fb, err := readField(r, f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %v", f.Name, err)
+		}
+		if ret[f.Name], err = f.Type.Decode(fb); err != nil {
+			return nil, fmt.Errorf("field '%s': %v", f.Name, err)
+		}
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d unexpected bytes after the last field", r.Len())
+	}
+	return ret, nil
+}
+
+// DecodeKey decodes the key of the dictionary entry
+func (sv *StateVar) DecodeKey(b []byte) (interface{}, error) {
+	if sv.KeyType == "" {
+		return ValueTypeBytes.Decode(b)
+	}
+	return sv.KeyType.Decode(b)
+}
+
+func readField(r io.Reader, t ValueType) ([]byte, error) {
+	var size int
+	switch t {
+	case ValueTypeString, ValueTypeBytes:
+		ret, err := util.ReadBytes16(r)
+		if err != nil {
+			return nil, err
+		}
+		if ret == nil {
+			ret = []byte{}
+		}
+		return ret, nil
+	case ValueTypeUint8, ValueTypeBool:
+		size = 1
+	case ValueTypeUint16:
+		size = 2
+	case ValueTypeUint32:
+		size = 4
+	case ValueTypeInt64, ValueTypeUint64:
+		size = 8
+	case ValueTypeAddress:
+		size = address.Length
+	case ValueTypeHash:
+		size = hashing.HashSize
+	case ValueTypeColor:
+		size = balance.ColorLength
+	case ValueTypeRequestId:
+		size = coretypes.RequestIdSize
+	default:
+		return nil, fmt.Errorf("value type '%s' is not allowed in struct", t)
+	}
+	ret := make([]byte, size)
+	if _, err := io.ReadFull(r, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
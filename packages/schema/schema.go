@@ -0,0 +1,404 @@
+// schema declares entry points, request arguments and state variables of the smart contract.
+// The schema is optional. It is published by the processor and stored with the program metadata,
+// so that clients can discover and validate arguments without hard coded constants
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// ValueType is the encoding of the value, as written by the kv codec
+type ValueType string
+
+const (
+	ValueTypeBytes     = ValueType("bytes")
+	ValueTypeString    = ValueType("string")
+	ValueTypeInt64     = ValueType("int64")
+	ValueTypeUint8     = ValueType("uint8")
+	ValueTypeUint16    = ValueType("uint16")
+	ValueTypeUint32    = ValueType("uint32")
+	ValueTypeUint64    = ValueType("uint64")
+	ValueTypeBool      = ValueType("bool")
+	ValueTypeAddress   = ValueType("address")
+	ValueTypeHash      = ValueType("hash")
+	ValueTypeColor     = ValueType("color")
+	ValueTypeBalances  = ValueType("balances")
+	ValueTypeRequestId = ValueType("requestid")
+	ValueTypeMap       = ValueType("map")
+	// binary record, a sequence of fields. Only allowed for state variables
+	ValueTypeStruct = ValueType("struct")
+)
+
+// CollectionType is the kind of the state variable. Empty means scalar value
+type CollectionType string
+
+const (
+	CollectionScalar = CollectionType("")
+	// kv.Array or kv.Array32, they share the layout
+	CollectionArray = CollectionType("array")
+	CollectionDict  = CollectionType("dict")
+	CollectionTLog  = CollectionType("tlog")
+	// sorted map and deque, see kv.SortedMap and kv.Deque
+	CollectionSortedMap = CollectionType("sortedmap")
+	CollectionDeque     = CollectionType("deque")
+)
+
+// Arg is the argument of the request
+type Arg struct {
+	Name     string    `json:"name"`
+	Type     ValueType `json:"type"`
+	Optional bool      `json:"optional"`
+}
+
+// EntryPoint describes the request code accepted by the smart contract
+type EntryPoint struct {
+	Name      string                    `json:"name"`
+	Code      sctransaction.RequestCode `json:"code"`
+	Protected bool                      `json:"protected"`
+	Args      []Arg                     `json:"args"`
+}
+
+// Field is the field of the struct value. Fields are encoded one after another, strings and bytes
+// with the uint16 length prefix
+type Field struct {
+	Name string    `json:"name"`
+	Type ValueType `json:"type"`
+}
+
+// StateVar describes the state variable. For collections Type is the type of elements.
+// If IsPrefix is true, the descriptor applies to all scalar keys starting with Key
+type StateVar struct {
+	Key        string         `json:"key"`
+	IsPrefix   bool           `json:"is_prefix"`
+	Type       ValueType      `json:"type"`
+	Collection CollectionType `json:"collection"`
+	// fields of the struct type
+	Fields []Field `json:"fields,omitempty"`
+	// type of keys of the dictionary or sorted map. Empty means bytes
+	KeyType     ValueType `json:"key_type,omitempty"`
+	Description string    `json:"description"`
+}
+
+type Schema struct {
+	Name        string       `json:"name"`
+	EntryPoints []EntryPoint `json:"entry_points"`
+	StateVars   []StateVar   `json:"state_vars"`
+}
+
+// Validate checks if the value is a valid encoding of the type
+func (t ValueType) Validate(b []byte) error {
+	var err error
+	switch t {
+	case ValueTypeBytes, ValueTypeString:
+	case ValueTypeInt64:
+		_, err = kv.DecodeInt64(b)
+	case ValueTypeUint16:
+		_, err = kv.DecodeUint16(b)
+	case ValueTypeUint32:
+		_, err = kv.DecodeUint32(b)
+	case ValueTypeUint64:
+		_, err = kv.DecodeUint64(b)
+	case ValueTypeUint8:
+		if len(b) != 1 {
+			err = fmt.Errorf("variable %v is not an uint8", b)
+		}
+	case ValueTypeBool:
+		_, err = kv.DecodeBool(b)
+	case ValueTypeAddress:
+		if len(b) != address.Length {
+			err = fmt.Errorf("variable %v is not an address", b)
+		}
+	case ValueTypeHash:
+		if len(b) != hashing.HashSize {
+			err = fmt.Errorf("variable %v is not a hash", b)
+		}
+	case ValueTypeColor:
+		_, err = kv.DecodeColor(b)
+	case ValueTypeBalances:
+		_, err = kv.DecodeBalances(b)
+	case ValueTypeRequestId:
+		_, err = kv.DecodeRequestId(b)
+	case ValueTypeMap:
+		_, err = kv.DecodeMap(b)
+	case ValueTypeStruct:
+		err = fmt.Errorf("struct value %v can't be validated without fields", b)
+	default:
+		err = fmt.Errorf("unknown value type '%s'", t)
+	}
+	return err
+}
+
+// EntryPoint returns the declaration of the request code
+func (s *Schema) EntryPoint(code sctransaction.RequestCode) (*EntryPoint, bool) {
+	for i := range s.EntryPoints {
+		if s.EntryPoints[i].Code == code {
+			return &s.EntryPoints[i], true
+		}
+	}
+	return nil, false
+}
+
+// StateVar returns the descriptor of the state key: the one declared for the exact key,
+// otherwise the one with the longest matching prefix
+func (s *Schema) StateVar(key kv.Key) (*StateVar, bool) {
+	var ret *StateVar
+	for i := range s.StateVars {
+		sv := &s.StateVars[i]
+		switch {
+		case !sv.IsPrefix && string(key) == sv.Key:
+			return sv, true
+		case sv.IsPrefix && len(key) >= len(sv.Key) && string(key[:len(sv.Key)]) == sv.Key:
+			if ret == nil || len(sv.Key) > len(ret.Key) {
+				ret = sv
+			}
+		}
+	}
+	return ret, ret != nil
+}
+
+// ValidateArgs checks the arguments of the request against the declaration of the entry point.
+// Reserved request codes which are not declared are processed by the VM itself and are not checked
+func (s *Schema) ValidateArgs(code sctransaction.RequestCode, args kv.Map) error {
+	ep, ok := s.EntryPoint(code)
+	if !ok {
+		if code.IsReserved() {
+			return nil
+		}
+		return fmt.Errorf("request code %s is not declared by the schema of '%s'", code.String(), s.Name)
+	}
+	if ep.Protected != code.IsProtected() {
+		return fmt.Errorf("entry point '%s': request code %s, protected flag mismatch", ep.Name, code.String())
+	}
+	declared := make(map[kv.Key]bool)
+	for _, arg := range ep.Args {
+		declared[kv.Key(arg.Name)] = true
+		var value []byte
+		var err error
+		if args != nil {
+			value, err = args.Get(kv.Key(arg.Name))
+			if err != nil {
+				return err
+			}
+		}
+		if value == nil {
+			if !arg.Optional {
+				return fmt.Errorf("entry point '%s': missing argument '%s'", ep.Name, arg.Name)
+			}
+			continue
+		}
+		if err := arg.Type.Validate(value); err != nil {
+			return fmt.Errorf("entry point '%s', argument '%s': %v", ep.Name, arg.Name, err)
+		}
+	}
+	if args == nil {
+		return nil
+	}
+	var err error
+	args.ForEach(func(key kv.Key, value []byte) bool {
+		if !declared[key] {
+			err = fmt.Errorf("entry point '%s': unknown argument '%s'", ep.Name, string(key))
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+func (s *Schema) Write(w io.Writer) error {
+	if err := util.WriteString16(w, s.Name); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, uint16(len(s.EntryPoints))); err != nil {
+		return err
+	}
+	for i := range s.EntryPoints {
+		if err := s.EntryPoints[i].Write(w); err != nil {
+			return err
+		}
+	}
+	if err := util.WriteUint16(w, uint16(len(s.StateVars))); err != nil {
+		return err
+	}
+	for i := range s.StateVars {
+		if err := s.StateVars[i].Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) Read(r io.Reader) error {
+	var err error
+	if s.Name, err = util.ReadString16(r); err != nil {
+		return err
+	}
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	s.EntryPoints = make([]EntryPoint, n)
+	for i := range s.EntryPoints {
+		if err := s.EntryPoints[i].Read(r); err != nil {
+			return err
+		}
+	}
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	s.StateVars = make([]StateVar, n)
+	for i := range s.StateVars {
+		if err := s.StateVars[i].Read(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ep *EntryPoint) Write(w io.Writer) error {
+	if err := util.WriteString16(w, ep.Name); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, uint16(ep.Code)); err != nil {
+		return err
+	}
+	if err := util.WriteBoolByte(w, ep.Protected); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, uint16(len(ep.Args))); err != nil {
+		return err
+	}
+	for _, arg := range ep.Args {
+		if err := util.WriteString16(w, arg.Name); err != nil {
+			return err
+		}
+		if err := util.WriteString16(w, string(arg.Type)); err != nil {
+			return err
+		}
+		if err := util.WriteBoolByte(w, arg.Optional); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ep *EntryPoint) Read(r io.Reader) error {
+	var err error
+	if ep.Name, err = util.ReadString16(r); err != nil {
+		return err
+	}
+	var code uint16
+	if err := util.ReadUint16(r, &code); err != nil {
+		return err
+	}
+	ep.Code = sctransaction.RequestCode(code)
+	if err := util.ReadBoolByte(r, &ep.Protected); err != nil {
+		return err
+	}
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	ep.Args = make([]Arg, n)
+	for i := range ep.Args {
+		if ep.Args[i].Name, err = util.ReadString16(r); err != nil {
+			return err
+		}
+		t, err := util.ReadString16(r)
+		if err != nil {
+			return err
+		}
+		ep.Args[i].Type = ValueType(t)
+		if err := util.ReadBoolByte(r, &ep.Args[i].Optional); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sv *StateVar) Write(w io.Writer) error {
+	if err := util.WriteString16(w, sv.Key); err != nil {
+		return err
+	}
+	if err := util.WriteBoolByte(w, sv.IsPrefix); err != nil {
+		return err
+	}
+	if err := util.WriteString16(w, string(sv.Type)); err != nil {
+		return err
+	}
+	if err := util.WriteString16(w, string(sv.Collection)); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, uint16(len(sv.Fields))); err != nil {
+		return err
+	}
+	for _, f := range sv.Fields {
+		if err := util.WriteString16(w, f.Name); err != nil {
+			return err
+		}
+		if err := util.WriteString16(w, string(f.Type)); err != nil {
+			return err
+		}
+	}
+	if err := util.WriteString16(w, string(sv.KeyType)); err != nil {
+		return err
+	}
+	return util.WriteString16(w, sv.Description)
+}
+
+func (sv *StateVar) Read(r io.Reader) error {
+	var err error
+	if sv.Key, err = util.ReadString16(r); err != nil {
+		return err
+	}
+	if err := util.ReadBoolByte(r, &sv.IsPrefix); err != nil {
+		return err
+	}
+	t, err := util.ReadString16(r)
+	if err != nil {
+		return err
+	}
+	sv.Type = ValueType(t)
+	c, err := util.ReadString16(r)
+	if err != nil {
+		return err
+	}
+	sv.Collection = CollectionType(c)
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	if n > 0 {
+		sv.Fields = make([]Field, n)
+	}
+	for i := range sv.Fields {
+		if sv.Fields[i].Name, err = util.ReadString16(r); err != nil {
+			return err
+		}
+		if t, err = util.ReadString16(r); err != nil {
+			return err
+		}
+		sv.Fields[i].Type = ValueType(t)
+	}
+	if t, err = util.ReadString16(r); err != nil {
+		return err
+	}
+	sv.KeyType = ValueType(t)
+	sv.Description, err = util.ReadString16(r)
+	return err
+}
+
+func FromBytes(data []byte) (*Schema, error) {
+	ret := &Schema{}
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,126 @@
+package schema
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSchema = &Schema{
+	Name: "test",
+	EntryPoints: []EntryPoint{
+		{
+			Name: "a",
+			Code: sctransaction.RequestCode(1),
+			Args: []Arg{
+				{Name: "n", Type: ValueTypeInt64},
+				{Name: "h", Type: ValueTypeHash, Optional: true},
+			},
+		},
+		{
+			Name:      "b",
+			Code:      sctransaction.RequestCode(2 | sctransaction.RequestCodeProtected),
+			Protected: true,
+		},
+	},
+	StateVars: []StateVar{
+		{Key: "x", Type: ValueTypeUint32},
+		{Key: "d", IsPrefix: true, Type: ValueTypeBytes, Collection: CollectionDict},
+		{Key: "dd", IsPrefix: true, Type: ValueTypeString},
+	},
+}
+
+func TestValidateArgs(t *testing.T) {
+	args := kv.NewMap()
+	args.Codec().SetInt64("n", 5)
+	assert.NoError(t, testSchema.ValidateArgs(1, args))
+
+	args.Codec().SetHashValue("h", hashing.HashStrings("test"))
+	assert.NoError(t, testSchema.ValidateArgs(1, args))
+
+	args.Codec().SetString("h", "wrong")
+	assert.Error(t, testSchema.ValidateArgs(1, args))
+
+	args = kv.NewMap()
+	assert.Error(t, testSchema.ValidateArgs(1, args))
+	assert.Error(t, testSchema.ValidateArgs(1, nil))
+
+	args.Codec().SetInt64("n", 5)
+	args.Codec().SetInt64("unknown", 5)
+	assert.Error(t, testSchema.ValidateArgs(1, args))
+
+	assert.NoError(t, testSchema.ValidateArgs(sctransaction.RequestCode(2|sctransaction.RequestCodeProtected), nil))
+	// not declared
+	assert.Error(t, testSchema.ValidateArgs(2, nil))
+	assert.Error(t, testSchema.ValidateArgs(3, nil))
+	// reserved codes are not checked
+	assert.NoError(t, testSchema.ValidateArgs(sctransaction.RequestCode(sctransaction.RequestCodeReserved|1), nil))
+}
+
+func TestStateVar(t *testing.T) {
+	sv, ok := testSchema.StateVar("x")
+	assert.True(t, ok)
+	assert.Equal(t, ValueTypeUint32, sv.Type)
+
+	_, ok = testSchema.StateVar("xx")
+	assert.False(t, ok)
+
+	sv, ok = testSchema.StateVar("d1")
+	assert.True(t, ok)
+	assert.Equal(t, CollectionDict, sv.Collection)
+
+	sv, ok = testSchema.StateVar("dd1")
+	assert.True(t, ok)
+	assert.Equal(t, ValueTypeString, sv.Type)
+}
+
+func TestSchemaBytes(t *testing.T) {
+	data, err := util.Bytes(testSchema)
+	assert.NoError(t, err)
+	back, err := FromBytes(data)
+	assert.NoError(t, err)
+	assert.Equal(t, testSchema.Name, back.Name)
+	assert.EqualValues(t, testSchema.StateVars, back.StateVars)
+	dataBack, err := util.Bytes(back)
+	assert.NoError(t, err)
+	assert.Equal(t, data, dataBack)
+}
+
+func TestDecode(t *testing.T) {
+	v, err := ValueTypeInt64.Decode(util.Uint64To8Bytes(5))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, v)
+
+	_, err = ValueTypeUint32.Decode([]byte{1})
+	assert.Error(t, err)
+
+	h := hashing.HashStrings("test")
+	v, err = ValueTypeHash.Decode(h[:])
+	assert.NoError(t, err)
+	assert.Equal(t, h.String(), v)
+
+	sv := &StateVar{
+		Type: ValueTypeStruct,
+		Fields: []Field{
+			{Name: "n", Type: ValueTypeUint16},
+			{Name: "s", Type: ValueTypeString},
+			{Name: "b", Type: ValueTypeBool},
+		},
+	}
+	var buf bytes.Buffer
+	_ = util.WriteUint16(&buf, 7)
+	_ = util.WriteString16(&buf, "abc")
+	buf.Write(kv.EncodeBool(true))
+	v, err = sv.DecodeValue(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"n": uint16(7), "s": "abc", "b": true}, v)
+
+	buf.WriteByte(0)
+	_, err = sv.DecodeValue(buf.Bytes())
+	assert.Error(t, err)
+}
@@ -28,7 +28,10 @@ func NewOriginTransaction(par NewOriginTransactionParams) (*sctransaction.Transa
 	if err := originState.ApplyBatch(state.MustNewOriginBatch(nil)); err != nil {
 		return nil, err
 	}
-	stateHash := originState.Hash()
+	stateHash, err := originState.Hash()
+	if err != nil {
+		return nil, err
+	}
 	if err := txb.CreateOriginStateBlock(&stateHash, &par.Address); err != nil {
 		return nil, err
 	}
@@ -1,23 +1,28 @@
 package sctransaction
 
 import (
-	"errors"
 	"fmt"
 	"io"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
-	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/util"
 )
 
-const RequestIdSize = hashing.HashSize + 2
+const RequestIdSize = coretypes.RequestIdSize
 
-type RequestId [RequestIdSize]byte
+type RequestId = coretypes.RequestId
+
+func NewRequestId(txid valuetransaction.ID, index uint16) RequestId {
+	return coretypes.NewRequestId(txid, index)
+}
+
+func NewRequestIdFromString(reqIdStr string) (RequestId, error) {
+	return coretypes.NewRequestIdFromString(reqIdStr)
+}
 
 // FIXME timelock uint32 ref Year 2038 problem https://en.wikipedia.org/wiki/Year_2038_problem
 // signed int32 can store values uo to 03:14:07 UTC on 19 January 2038
@@ -60,6 +65,7 @@ func (req *RequestBlock) Clone() *RequestBlock {
 		return nil
 	}
 	ret := NewRequestBlock(req.address, req.reqCode)
+	ret.timelock = req.timelock
 	ret.args = req.args.Clone()
 	return ret
 }
@@ -100,27 +106,6 @@ func (req *RequestBlock) String(reqId *RequestId) string {
 		reqId.Short(), req.Address().String(), req.reqCode.String(), req.timelock, req.args.String())
 }
 
-func NewRequestIdFromString(reqIdStr string) (ret RequestId, err error) {
-	splitStr := strings.Split(reqIdStr, "]")
-	if len(splitStr) != 2 {
-		err = fmt.Errorf("wrong request id string")
-		return
-	}
-	indexStr := splitStr[0][1:]
-	indexInt, err := strconv.Atoi(indexStr)
-	if err != nil {
-		err = fmt.Errorf("wrong request id string")
-		return
-	}
-	index := uint16(indexInt)
-	txid, err := valuetransaction.IDFromBase58(splitStr[1])
-	if err != nil {
-		return
-	}
-	ret = NewRequestId(txid, index)
-	return
-}
-
 // encoding
 
 func (req *RequestBlock) Write(w io.Writer) error {
@@ -159,50 +144,6 @@ func (req *RequestBlock) Read(r io.Reader) error {
 	return nil
 }
 
-func NewRequestId(txid valuetransaction.ID, index uint16) (ret RequestId) {
-	copy(ret[:valuetransaction.IDLength], txid.Bytes())
-	copy(ret[valuetransaction.IDLength:], util.Uint16To2Bytes(index)[:])
-	return
-}
-
-func (rid *RequestId) Bytes() []byte {
-	return rid[:]
-}
-
-func (rid *RequestId) TransactionId() *valuetransaction.ID {
-	var ret valuetransaction.ID
-	copy(ret[:], rid[:valuetransaction.IDLength])
-	return &ret
-}
-
-func (rid *RequestId) Index() uint16 {
-	return util.Uint16From2Bytes(rid[valuetransaction.IDLength:])
-}
-
-func (rid *RequestId) Write(w io.Writer) error {
-	_, err := w.Write(rid.Bytes())
-	return err
-}
-
-func (rid *RequestId) Read(r io.Reader) error {
-	n, err := r.Read(rid[:])
-	if err != nil {
-		return err
-	}
-	if n != RequestIdSize {
-		return errors.New("not enough data for RequestId")
-	}
-	return nil
-}
-
-func (rid *RequestId) String() string {
-	return fmt.Sprintf("[%d]%s", rid.Index(), rid.TransactionId().String())
-}
-
-func (rid *RequestId) Short() string {
-	return rid.String()[:8] + ".."
-}
-
 // request ref
 
 func (ref *RequestRef) RequestBlock() *RequestBlock {
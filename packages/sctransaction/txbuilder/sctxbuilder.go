@@ -140,6 +140,11 @@ func (txb *Builder) AddRequestBlockWithTransfer(reqBlk *sctransaction.RequestBlo
 	return nil
 }
 
+// RequestBlocks returns request blocks added so far
+func (txb *Builder) RequestBlocks() []*sctransaction.RequestBlock {
+	return txb.requestBlocks
+}
+
 func (txb *Builder) Build(useAllInputs bool) (*sctransaction.Transaction, error) {
 	return sctransaction.NewTransaction(
 		txb.Builder.Build(useAllInputs),
@@ -60,3 +60,41 @@ func TestColor(t *testing.T) {
 
 	assert.Equal(t, txb2.GetInputBalance(color), int64(5))
 }
+
+func TestMoveAll(t *testing.T) {
+	addr2 := utxodb.GetAddress(2)
+	addr3 := utxodb.GetAddress(3)
+
+	outs := utxodb.GetAddressOutputs(utxodb.GetGenesisAddress())
+	txb, err := NewFromOutputBalances(outs)
+	assert.NoError(t, err)
+	err = txb.MintColor(addr2, balance.ColorIOTA, 10)
+	assert.NoError(t, err)
+	err = txb.MoveToAddress(addr2, balance.ColorIOTA, 5)
+	assert.NoError(t, err)
+	tx := txb.Build(false)
+	tx.Sign(utxodb.GetGenesisSigScheme())
+	err = utxodb.AddTransaction(tx)
+	assert.NoError(t, err)
+	color := (balance.Color)(tx.ID())
+
+	txb2, err := NewFromOutputBalances(utxodb.GetAddressOutputs(addr2))
+	assert.NoError(t, err)
+	txb3, err := NewFromOutputBalances(utxodb.GetAddressOutputs(addr3))
+	assert.NoError(t, err)
+	iotas := txb2.GetInputBalance(balance.ColorIOTA) + txb3.GetInputBalance(balance.ColorIOTA)
+	err = txb2.MoveToAddress(addr2, color, 1)
+	assert.NoError(t, err)
+	txb2.MoveAllToAddress(addr2, addr3)
+
+	tx2 := txb2.Build(false)
+	tx2.Sign(utxodb.GetSigScheme(addr2))
+	err = utxodb.AddTransaction(tx2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, len(utxodb.GetAddressOutputs(addr2)))
+	txb3, err = NewFromOutputBalances(utxodb.GetAddressOutputs(addr3))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), txb3.GetInputBalance(color))
+	assert.Equal(t, iotas, txb3.GetInputBalance(balance.ColorIOTA))
+}
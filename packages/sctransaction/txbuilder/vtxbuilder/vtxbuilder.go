@@ -353,6 +353,36 @@ func (vtxb *Builder) MintColorFromTransaction(targetAddr address.Address, col ba
 	return nil
 }
 
+// MoveAllToAddress redirects to the target address all outputs to the address 'fromAddr'
+// and moves there all not consumed balances of inputs from 'fromAddr'
+func (vtxb *Builder) MoveAllToAddress(fromAddr, targetAddr address.Address) {
+	if vtxb.finalized {
+		panic("using finalized transaction builder")
+	}
+	for i := range vtxb.inputBalancesByOutput {
+		if vtxb.inputBalancesByOutput[i].outputId.Address() != fromAddr {
+			continue
+		}
+		for _, bal := range vtxb.inputBalancesByOutput[i].reminder {
+			if bal.Value == 0 {
+				continue
+			}
+			vtxb.inputBalancesByOutput[i].consumed = addAmount(vtxb.inputBalancesByOutput[i].consumed, bal.Color, bal.Value)
+			vtxb.addToOutputs(targetAddr, bal.Color, bal.Value)
+			bal.Value = 0
+		}
+	}
+	if fromAddr == targetAddr {
+		return
+	}
+	if cmap, ok := vtxb.outputBalances[fromAddr]; ok {
+		for col, b := range cmap {
+			vtxb.addToOutputs(targetAddr, col, b)
+		}
+		delete(vtxb.outputBalances, fromAddr)
+	}
+}
+
 // Build build the final value transaction: not signed and without data payload
 
 func (vtxb *Builder) Build(useAllInputs bool) *valuetransaction.Transaction {
@@ -14,6 +14,13 @@ import (
 	"io"
 )
 
+// batches written by older nodes contain state updates of version 0 without version byte.
+// The flag in the size field tells that state updates of the batch are versioned
+const (
+	batchVersionedFlag = uint16(0x8000)
+	MaxBatchSize       = int(batchVersionedFlag - 1)
+)
+
 type batch struct {
 	stateIndex   uint32
 	stateTxId    valuetransaction.ID
@@ -25,6 +32,9 @@ func NewBatch(stateUpdates []StateUpdate) (Batch, error) {
 	if len(stateUpdates) == 0 {
 		return nil, fmt.Errorf("batch can't be empty")
 	}
+	if len(stateUpdates) > MaxBatchSize {
+		return nil, fmt.Errorf("batch is too large")
+	}
 	for i, su := range stateUpdates {
 		for j := i + 1; j < len(stateUpdates); j++ {
 			if *su.RequestId() == *stateUpdates[j].RequestId() {
@@ -129,7 +139,7 @@ func (b *batch) writeEssence(w io.Writer) error {
 	if err := util.WriteUint32(w, b.stateIndex); err != nil {
 		return err
 	}
-	if err := util.WriteUint16(w, uint16(len(b.stateUpdates))); err != nil {
+	if err := util.WriteUint16(w, uint16(len(b.stateUpdates))|batchVersionedFlag); err != nil {
 		return err
 	}
 	for _, su := range b.stateUpdates {
@@ -158,10 +168,16 @@ func (b *batch) readEssence(r io.Reader) error {
 	if err := util.ReadUint16(r, &size); err != nil {
 		return err
 	}
+	versioned := size&batchVersionedFlag != 0
+	size &^= batchVersionedFlag
 	b.stateUpdates = make([]StateUpdate, size)
 	var err error
 	for i := range b.stateUpdates {
-		b.stateUpdates[i], err = NewStateUpdateRead(r)
+		if versioned {
+			b.stateUpdates[i], err = NewStateUpdateRead(r)
+		} else {
+			b.stateUpdates[i], err = newStateUpdateReadV0(r)
+		}
 		if err != nil {
 			return err
 		}
@@ -174,7 +190,11 @@ func dbkeyBatch(stateIndex uint32) []byte {
 }
 
 func LoadBatch(addr *address.Address, stateIndex uint32) (Batch, error) {
-	data, err := database.GetPartition(addr).Get(dbkeyBatch(stateIndex))
+	return loadBatch(database.GetPartition(addr), stateIndex)
+}
+
+func loadBatch(db kvstore.KVStore, stateIndex uint32) (Batch, error) {
+	data, err := db.Get(dbkeyBatch(stateIndex))
 	if err == kvstore.ErrKeyNotFound {
 		return nil, nil
 	}
@@ -1,12 +1,14 @@
 package state
 
 import (
+	"bytes"
+	"math"
 	"testing"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/wasp/packages/hashing"
-	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/stretchr/testify/assert"
 )
@@ -68,3 +70,65 @@ func TestBatchMarshaling(t *testing.T) {
 
 	assert.EqualValues(t, util.GetHashValue(batch1), util.GetHashValue(batch2))
 }
+
+func TestStateUpdateMarshaling(t *testing.T) {
+	txid1 := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid1 := sctransaction.NewRequestId(txid1, 3)
+	su1 := NewStateUpdate(&reqid1).WithTimestamp(42).WithGasUsed(1234).WithError("failed")
+	su1.Mutations().Add(kv.NewMutationSet("k", []byte{1}))
+	fields := kv.NewMap()
+	fields.Set("f", []byte{2})
+	su1.AddEvent(NewEvent("ev", fields))
+	su1.Results().Set("r", []byte{3})
+
+	b, err := util.Bytes(su1)
+	assert.NoError(t, err)
+
+	su2, err := NewStateUpdateRead(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.EqualValues(t, reqid1, *su2.RequestId())
+	assert.EqualValues(t, 42, su2.Timestamp())
+	assert.EqualValues(t, 1234, su2.GasUsed())
+	assert.Equal(t, 1, len(su2.Events()))
+	assert.Equal(t, "ev", su2.Events()[0].Name)
+	assert.Equal(t, []byte{2}, su2.Events()[0].Fields.ToGoMap()["f"])
+	assert.Equal(t, []byte{3}, su2.Results().ToGoMap()["r"])
+	assert.Equal(t, "failed", su2.Error())
+	assert.EqualValues(t, util.GetHashValue(su1), util.GetHashValue(su2))
+}
+
+func TestStateUpdateTooManyEvents(t *testing.T) {
+	txid1 := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid1 := sctransaction.NewRequestId(txid1, 3)
+	su := NewStateUpdate(&reqid1)
+	for i := 0; i <= math.MaxUint16; i++ {
+		su.AddEvent(NewEvent("ev", kv.NewMap()))
+	}
+	_, err := util.Bytes(su)
+	assert.Error(t, err)
+}
+
+func TestReadBatchV0(t *testing.T) {
+	txid1 := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid1 := sctransaction.NewRequestId(txid1, 3)
+	mut := kv.NewMutationSet("k", []byte{1})
+
+	// batch in the format of older nodes: no versioned flag in the size and no version of state updates
+	var buf bytes.Buffer
+	_ = util.WriteUint32(&buf, 5)
+	_ = util.WriteUint16(&buf, 1)
+	buf.Write(reqid1[:])
+	muts := kv.NewMutationSequence()
+	muts.Add(mut)
+	assert.NoError(t, muts.Write(&buf))
+	_ = util.WriteUint64(&buf, 42)
+	buf.Write(txid1[:])
+
+	b, err := BatchFromBytes(buf.Bytes())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, b.StateIndex())
+	assert.EqualValues(t, 1, b.Size())
+	assert.EqualValues(t, 42, b.Timestamp())
+	assert.EqualValues(t, txid1, b.StateTransactionId())
+	assert.EqualValues(t, reqid1, *b.RequestIds()[0])
+}
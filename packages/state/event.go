@@ -0,0 +1,183 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// Event is a structured event emitted by the smart contract: name and fields
+type Event struct {
+	Name   string
+	Fields kv.Map
+}
+
+// EventRecord is the event stored with the solid state
+type EventRecord struct {
+	StateIndex uint32
+	BatchIndex uint16
+	RequestId  sctransaction.RequestId
+	// index of the event among events of the request
+	Index uint16
+	Event *Event
+}
+
+func NewEvent(name string, fields kv.Map) *Event {
+	if fields == nil {
+		fields = kv.NewMap()
+	}
+	return &Event{
+		Name:   name,
+		Fields: fields,
+	}
+}
+
+func (ev *Event) Write(w io.Writer) error {
+	if err := util.WriteString16(w, ev.Name); err != nil {
+		return err
+	}
+	return ev.Fields.Write(w)
+}
+
+func (ev *Event) Read(r io.Reader) error {
+	var err error
+	if ev.Name, err = util.ReadString16(r); err != nil {
+		return err
+	}
+	ev.Fields = kv.NewMap()
+	return ev.Fields.Read(r)
+}
+
+// db key of the event is 'state index' || 'batch index' || 'index of the event', all big endian,
+// so events of a range of state indices are under a few common prefixes (see stateIndexRangePrefixes).
+// The value is 'request id' || 'event'.
+// Events stored by older versions under ObjectTypeEvent are not loaded
+func dbkeyEvent(stateIndex uint32, batchIndex uint16, index uint16) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint32(key[0:4], stateIndex)
+	binary.BigEndian.PutUint16(key[4:6], batchIndex)
+	binary.BigEndian.PutUint16(key[6:8], index)
+	return database.MakeKey(database.ObjectTypeEventByStateIndex, key[:])
+}
+
+// eventsToDb collects keys and values of events of the batch
+func eventsToDb(b Batch) ([][]byte, [][]byte, error) {
+	keys := make([][]byte, 0)
+	values := make([][]byte, 0)
+	var err error
+	b.ForEach(func(batchIndex uint16, su StateUpdate) bool {
+		for i, ev := range su.Events() {
+			var buf bytes.Buffer
+			buf.Write(su.RequestId()[:])
+			if err = ev.Write(&buf); err != nil {
+				return false
+			}
+			keys = append(keys, dbkeyEvent(b.StateIndex(), batchIndex, uint16(i)))
+			values = append(values, buf.Bytes())
+		}
+		return true
+	})
+	return keys, values, err
+}
+
+// LoadEvents loads events of the smart contract emitted in states fromIndex..toIndex inclusive.
+// If name is not empty, only events with that name are returned.
+// Events are sorted in the order of emission
+func LoadEvents(scAddress *address.Address, name string, fromIndex, toIndex uint32) ([]*EventRecord, error) {
+	return loadEvents(getSCPartition(scAddress), name, fromIndex, toIndex)
+}
+
+func loadEvents(db kvstore.KVStore, name string, fromIndex, toIndex uint32) ([]*EventRecord, error) {
+	ret := make([]*EventRecord, 0)
+	stateIndexBin, err := db.Get(database.MakeKey(database.ObjectTypeSolidStateIndex))
+	if err == kvstore.ErrKeyNotFound {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if solidIndex := util.Uint32From4Bytes(stateIndexBin); toIndex > solidIndex {
+		toIndex = solidIndex
+	}
+	if fromIndex > toIndex {
+		return ret, nil
+	}
+	// keys returned by Iterate include the realm
+	realm := len(db.Realm())
+	for _, prefix := range stateIndexRangePrefixes(fromIndex, toIndex) {
+		err = db.Iterate(database.MakeKey(database.ObjectTypeEventByStateIndex, prefix), func(key kvstore.Key, value kvstore.Value) bool {
+			var rec *EventRecord
+			if rec, err = decodeEventRecord(key[realm+1:], value); err != nil {
+				return false
+			}
+			if name == "" || rec.Event.Name == name {
+				ret = append(ret, rec)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].StateIndex != ret[j].StateIndex {
+			return ret[i].StateIndex < ret[j].StateIndex
+		}
+		if ret[i].BatchIndex != ret[j].BatchIndex {
+			return ret[i].BatchIndex < ret[j].BatchIndex
+		}
+		return ret[i].Index < ret[j].Index
+	})
+	return ret, nil
+}
+
+// stateIndexRangePrefixes returns the minimal set of prefixes of big endian state indices
+// which cover exactly the range from..to inclusive
+func stateIndexRangePrefixes(from, to uint32) [][]byte {
+	ret := make([][]byte, 0)
+	for idx := uint64(from); idx <= uint64(to); {
+		// the largest block of 256^k indices which starts at idx and fits into the range
+		k := 0
+		for k < 4 {
+			size := uint64(1) << (8 * uint(k+1))
+			if idx%size != 0 || idx+size-1 > uint64(to) {
+				break
+			}
+			k++
+		}
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], uint32(idx))
+		ret = append(ret, buf[:4-k])
+		idx += uint64(1) << (8 * uint(k))
+	}
+	return ret
+}
+
+func decodeEventRecord(key []byte, value []byte) (*EventRecord, error) {
+	if len(key) != 8 {
+		return nil, fmt.Errorf("wrong key of the event record")
+	}
+	ret := &EventRecord{
+		StateIndex: binary.BigEndian.Uint32(key[0:4]),
+		BatchIndex: binary.BigEndian.Uint16(key[4:6]),
+		Index:      binary.BigEndian.Uint16(key[6:8]),
+		Event:      &Event{},
+	}
+	r := bytes.NewReader(value)
+	if err := ret.RequestId.Read(r); err != nil {
+		return nil, err
+	}
+	if err := ret.Event.Read(r); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/parameters"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// The history of state variables is optional and is kept as undo records:
+// the undo record of the state index N is the mutation sequence which turns variables
+// of the state N back into variables of the state N-1.
+// Variables as of the state index are the solid variables with undo records applied backwards
+
+func keepStateHistory() bool {
+	return parameters.GetBool(parameters.DatabaseKeepStateHistory)
+}
+
+func dbkeyStateUndo(stateIndex uint32) []byte {
+	return database.MakeKey(database.ObjectTypeStateHistory, util.Uint32To4Bytes(stateIndex))
+}
+
+// undoToDb creates the undo record of the variables which are about to be committed.
+// The mutation sequence is limited to MaxUint16 mutations, so the undo record of more variables
+// consists of several sequences written one after another
+func undoToDb(db kvstore.KVStore, stateIndex uint32, varKeys []kv.Key) ([]byte, []byte, error) {
+	var buf bytes.Buffer
+	for i := 0; i == 0 || i < len(varKeys); i += util.MaxUint16 {
+		end := i + util.MaxUint16
+		if end > len(varKeys) {
+			end = len(varKeys)
+		}
+		undo := kv.NewMutationSequence()
+		for _, k := range varKeys[i:end] {
+			v, err := db.Get(dbkeyStateVariable(k))
+			switch {
+			case err == kvstore.ErrKeyNotFound:
+				undo.Add(kv.NewMutationDel(k))
+			case err != nil:
+				return nil, nil, err
+			default:
+				undo.Add(kv.NewMutationSet(k, v))
+			}
+		}
+		if err := undo.Write(&buf); err != nil {
+			return nil, nil, err
+		}
+	}
+	return dbkeyStateUndo(stateIndex), buf.Bytes(), nil
+}
+
+// readUndo reads all mutation sequences of the undo record
+func readUndo(data []byte) (kv.MutationSequence, error) {
+	ret := kv.NewMutationSequence()
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		if err := ret.Read(r); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// LoadVariablesAt returns variables of the smart contract as they were in the state with the index.
+// Returns false if the state index is beyond the solid state.
+// Returns error if the history wasn't kept for the state index.
+// The returned store is for reading only: its uncommitted mutations are undo records
+func LoadVariablesAt(scAddress *address.Address, stateIndex uint32) (kv.BufferedKVStore, bool, error) {
+	return loadVariablesAt(getSCPartition(scAddress), stateIndex)
+}
+
+func loadVariablesAt(db kvstore.KVStore, stateIndex uint32) (kv.BufferedKVStore, bool, error) {
+	stateIndexBin, err := db.Get(database.MakeKey(database.ObjectTypeSolidStateIndex))
+	if err == kvstore.ErrKeyNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	solidIndex := util.Uint32From4Bytes(stateIndexBin)
+	if stateIndex > solidIndex {
+		return nil, false, nil
+	}
+	ret := kv.NewBufferedKVStoreWithCacheSize(subRealm(db, []byte{database.ObjectTypeStateVariable}), readCacheSize())
+	for idx := solidIndex; idx > stateIndex; idx-- {
+		data, err := db.Get(dbkeyStateUndo(idx))
+		if err == kvstore.ErrKeyNotFound {
+			return nil, false, fmt.Errorf("history of the state #%d is not available", idx-1)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		undo, err := readUndo(data)
+		if err != nil {
+			return nil, false, err
+		}
+		undo.ApplyTo(ret)
+	}
+	return ret, true, nil
+}
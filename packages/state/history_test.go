@@ -0,0 +1,96 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/goshimmer/packages/database"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/parameters"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadVariablesAt(t *testing.T) {
+	config.Node.Set(parameters.DatabaseKeepStateHistory, true)
+	defer config.Node.Set(parameters.DatabaseKeepStateHistory, false)
+
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+	addr := address.Random()
+	vs := NewVirtualState(partition, &addr)
+
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	commit := func(stateIndex uint32, muts ...kv.Mutation) {
+		reqid := sctransaction.NewRequestId(txid, uint16(stateIndex))
+		su := NewStateUpdate(&reqid)
+		for _, mut := range muts {
+			su.Mutations().Add(mut)
+		}
+		batch, err := NewBatch([]StateUpdate{su})
+		assert.NoError(t, err)
+		batch.WithStateIndex(stateIndex)
+		assert.NoError(t, vs.ApplyBatch(batch))
+		assert.NoError(t, vs.CommitToDb(batch))
+	}
+	commit(0, kv.NewMutationSet("x", []byte{0}))
+	commit(1, kv.NewMutationSet("x", []byte{1}), kv.NewMutationSet("a1", []byte{1}), kv.NewMutationSet("a2", []byte{2}))
+	commit(2, kv.NewMutationDel("x"), kv.NewMutationDelPrefix("a"))
+
+	get := func(stateIndex uint32, key kv.Key) []byte {
+		vars, exist, err := loadVariablesAt(partition, stateIndex)
+		assert.NoError(t, err)
+		assert.True(t, exist)
+		v, err := vars.Get(key)
+		assert.NoError(t, err)
+		return v
+	}
+	assert.Equal(t, []byte{0}, get(0, "x"))
+	assert.Nil(t, get(0, "a1"))
+	assert.Equal(t, []byte{1}, get(1, "x"))
+	assert.Equal(t, []byte{2}, get(1, "a2"))
+	assert.Nil(t, get(2, "x"))
+	assert.Nil(t, get(2, "a1"))
+
+	_, exist, err := loadVariablesAt(partition, 3)
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	// history is not kept for the state #3
+	config.Node.Set(parameters.DatabaseKeepStateHistory, false)
+	commit(3, kv.NewMutationSet("x", []byte{3}))
+	_, _, err = loadVariablesAt(partition, 2)
+	assert.Error(t, err)
+	assert.Equal(t, []byte{3}, get(3, "x"))
+}
+
+func TestUndoOfManyVariables(t *testing.T) {
+	db := mapdb.NewMapDB()
+	n := util.MaxUint16 + 100
+	keys := make([]kv.Key, n)
+	for i := range keys {
+		keys[i] = kv.Key(fmt.Sprintf("k%d", i))
+		if i%2 == 0 {
+			assert.NoError(t, db.Set(dbkeyStateVariable(keys[i]), []byte{byte(i)}))
+		}
+	}
+	_, data, err := undoToDb(db, 1, keys)
+	assert.NoError(t, err)
+
+	undo, err := readUndo(data)
+	assert.NoError(t, err)
+	assert.Equal(t, n, undo.Len())
+
+	vars := kv.NewMap()
+	undo.ApplyTo(vars)
+	v, err := vars.Get(keys[n-1])
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{byte(n - 1)}, v)
+	assert.Equal(t, (n+1)/2, len(vars.ToGoMap()))
+}
@@ -0,0 +1,237 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// State variables are committed in a sparse Merkle tree of depth 256.
+// The path of the variable in the tree is the hash of its key.
+// To avoid hashing 256 levels for each variable:
+//   - the hash of the empty subtree is NilHash
+//   - the hash of the subtree with exactly one variable is the hash of the leaf
+//   - the hash of any other subtree is hash(1 || left || right)
+// The hash of the leaf is hash(0 || path || hash(value))
+
+const (
+	merkleLeafPrefix = byte(0)
+	merkleNodePrefix = byte(1)
+)
+
+type merkleLeaf struct {
+	path      hashing.HashValue
+	valueHash hashing.HashValue
+}
+
+// MerkleProof proves the value of one state variable against the Merkle root.
+// If the variable does not exist, the proof shows the path ends in the empty subtree
+// or in the leaf of another variable
+type MerkleProof struct {
+	// hashes of the siblings along the path from the root down
+	Siblings []hashing.HashValue
+	// the leaf of another variable at the end of the path. Only for non-existing variables
+	OtherLeafPath      *hashing.HashValue
+	OtherLeafValueHash *hashing.HashValue
+}
+
+func (l *merkleLeaf) hash() hashing.HashValue {
+	return *hashing.HashData([]byte{merkleLeafPrefix}, l.path[:], l.valueHash[:])
+}
+
+func merkleNodeHash(left, right *hashing.HashValue) hashing.HashValue {
+	return *hashing.HashData([]byte{merkleNodePrefix}, left[:], right[:])
+}
+
+func merklePath(key kv.Key) hashing.HashValue {
+	return *hashing.HashData([]byte(key))
+}
+
+// bit of the path at the depth, starting from the most significant bit
+func pathBit(path *hashing.HashValue, depth int) bool {
+	return path[depth/8]&(0x80>>uint(depth%8)) != 0
+}
+
+func loadMerkleLeaves(vars kv.KVStore) ([]*merkleLeaf, error) {
+	ret := make([]*merkleLeaf, 0)
+	err := vars.Iterate(kv.EmptyPrefix, func(key kv.Key, value []byte) bool {
+		ret = append(ret, &merkleLeaf{
+			path:      merklePath(key),
+			valueHash: *hashing.HashData(value),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return bytes.Compare(ret[i].path[:], ret[j].path[:]) < 0
+	})
+	return ret, nil
+}
+
+// splits sorted leaves by the bit at depth
+func splitMerkleLeaves(leaves []*merkleLeaf, depth int) ([]*merkleLeaf, []*merkleLeaf) {
+	i := sort.Search(len(leaves), func(i int) bool {
+		return pathBit(&leaves[i].path, depth)
+	})
+	return leaves[:i], leaves[i:]
+}
+
+func merkleSubtreeHash(leaves []*merkleLeaf, depth int) hashing.HashValue {
+	switch len(leaves) {
+	case 0:
+		return *hashing.NilHash
+	case 1:
+		return leaves[0].hash()
+	}
+	left, right := splitMerkleLeaves(leaves, depth)
+	lh := merkleSubtreeHash(left, depth+1)
+	rh := merkleSubtreeHash(right, depth+1)
+	return merkleNodeHash(&lh, &rh)
+}
+
+// MerkleRoot calculates the root of the sparse Merkle tree of all variables in the store, such as the snapshot.
+// The virtual state keeps its tree stored and updates it with mutations instead
+func MerkleRoot(vars kv.KVStore) (hashing.HashValue, error) {
+	leaves, err := loadMerkleLeaves(vars)
+	if err != nil {
+		return hashing.HashValue{}, err
+	}
+	return merkleSubtreeHash(leaves, 0), nil
+}
+
+// NewMerkleProof creates the proof of the value of the variable with the key
+func NewMerkleProof(vars kv.KVStore, key kv.Key) (*MerkleProof, error) {
+	leaves, err := loadMerkleLeaves(vars)
+	if err != nil {
+		return nil, err
+	}
+	path := merklePath(key)
+	ret := &MerkleProof{
+		Siblings: make([]hashing.HashValue, 0),
+	}
+	for depth := 0; len(leaves) > 1; depth++ {
+		left, right := splitMerkleLeaves(leaves, depth)
+		if pathBit(&path, depth) {
+			ret.Siblings = append(ret.Siblings, merkleSubtreeHash(left, depth+1))
+			leaves = right
+		} else {
+			ret.Siblings = append(ret.Siblings, merkleSubtreeHash(right, depth+1))
+			leaves = left
+		}
+	}
+	if len(leaves) == 1 && leaves[0].path != path {
+		ret.OtherLeafPath = &leaves[0].path
+		ret.OtherLeafValueHash = &leaves[0].valueHash
+	}
+	return ret, nil
+}
+
+// Verify checks if the proof proves the value of the variable against the Merkle root.
+// nil value means the variable does not exist
+func (p *MerkleProof) Verify(root *hashing.HashValue, key kv.Key, value []byte) bool {
+	if len(p.Siblings) > 8*hashing.HashSize {
+		return false
+	}
+	path := merklePath(key)
+	var h hashing.HashValue
+	switch {
+	case value != nil:
+		if p.OtherLeafPath != nil {
+			return false
+		}
+		h = (&merkleLeaf{path: path, valueHash: *hashing.HashData(value)}).hash()
+
+	case p.OtherLeafPath != nil:
+		if p.OtherLeafValueHash == nil || *p.OtherLeafPath == path {
+			return false
+		}
+		// the other leaf must be at the end of the same path
+		for depth := range p.Siblings {
+			if pathBit(&path, depth) != pathBit(p.OtherLeafPath, depth) {
+				return false
+			}
+		}
+		h = (&merkleLeaf{path: *p.OtherLeafPath, valueHash: *p.OtherLeafValueHash}).hash()
+
+	default:
+		h = *hashing.NilHash
+	}
+	for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+		if pathBit(&path, depth) {
+			h = merkleNodeHash(&p.Siblings[depth], &h)
+		} else {
+			h = merkleNodeHash(&h, &p.Siblings[depth])
+		}
+	}
+	return h == *root
+}
+
+// StateHashFromMerkleRoot returns the state hash, as anchored in the state transaction,
+// from the hash of the chain of state updates and the Merkle root of the variables.
+// The state without variables (e.g. the origin state) has the hash of the chain, so the origin
+// transaction doesn't depend on the commitment
+func StateHashFromMerkleRoot(chainHash, merkleRoot *hashing.HashValue) hashing.HashValue {
+	if *merkleRoot == *hashing.NilHash {
+		return *chainHash
+	}
+	return *hashing.HashData(chainHash[:], merkleRoot[:])
+}
+
+func (p *MerkleProof) Write(w io.Writer) error {
+	if err := util.WriteUint16(w, uint16(len(p.Siblings))); err != nil {
+		return err
+	}
+	for i := range p.Siblings {
+		if err := p.Siblings[i].Write(w); err != nil {
+			return err
+		}
+	}
+	if err := util.WriteBoolByte(w, p.OtherLeafPath != nil); err != nil {
+		return err
+	}
+	if p.OtherLeafPath == nil {
+		return nil
+	}
+	if err := p.OtherLeafPath.Write(w); err != nil {
+		return err
+	}
+	return p.OtherLeafValueHash.Write(w)
+}
+
+func (p *MerkleProof) Read(r io.Reader) error {
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	if int(n) > 8*hashing.HashSize {
+		return errors.New("wrong number of siblings in the Merkle proof")
+	}
+	p.Siblings = make([]hashing.HashValue, n)
+	for i := range p.Siblings {
+		if err := p.Siblings[i].Read(r); err != nil {
+			return err
+		}
+	}
+	var hasOther bool
+	if err := util.ReadBoolByte(r, &hasOther); err != nil {
+		return err
+	}
+	if !hasOther {
+		p.OtherLeafPath = nil
+		p.OtherLeafValueHash = nil
+		return nil
+	}
+	p.OtherLeafPath = new(hashing.HashValue)
+	p.OtherLeafValueHash = new(hashing.HashValue)
+	if err := p.OtherLeafPath.Read(r); err != nil {
+		return err
+	}
+	return p.OtherLeafValueHash.Read(r)
+}
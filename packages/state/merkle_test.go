@@ -0,0 +1,307 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	vars1 := kv.NewMap()
+	vars2 := kv.NewMap()
+
+	root, err := MerkleRoot(vars1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, *hashing.NilHash, root)
+
+	for i := 0; i < 100; i++ {
+		vars1.Set(kv.Key(fmt.Sprintf("k%d", i)), []byte{byte(i)})
+		vars2.Set(kv.Key(fmt.Sprintf("k%d", 99-i)), []byte{byte(99 - i)})
+	}
+	root1, err := MerkleRoot(vars1)
+	assert.NoError(t, err)
+	root2, err := MerkleRoot(vars2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, root1, root2)
+
+	vars2.Set("k5", []byte{0})
+	root2, err = MerkleRoot(vars2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, root1, root2)
+}
+
+func TestMerkleProof(t *testing.T) {
+	vars := kv.NewMap()
+	for i := 0; i < 100; i++ {
+		vars.Set(kv.Key(fmt.Sprintf("k%d", i)), []byte{byte(i)})
+	}
+	root, err := MerkleRoot(vars)
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		key := kv.Key(fmt.Sprintf("k%d", i))
+		proof, err := NewMerkleProof(vars, key)
+		assert.NoError(t, err)
+		assert.True(t, proof.Verify(&root, key, []byte{byte(i)}))
+		assert.False(t, proof.Verify(&root, key, []byte{byte(i + 1)}))
+		assert.False(t, proof.Verify(&root, key, nil))
+	}
+
+	// non-existing keys
+	for i := 100; i < 200; i++ {
+		key := kv.Key(fmt.Sprintf("k%d", i))
+		proof, err := NewMerkleProof(vars, key)
+		assert.NoError(t, err)
+		assert.True(t, proof.Verify(&root, key, nil))
+		assert.False(t, proof.Verify(&root, key, []byte{0}))
+	}
+
+	proof, err := NewMerkleProof(vars, "k7")
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	err = proof.Write(&buf)
+	assert.NoError(t, err)
+	proofBack := &MerkleProof{}
+	err = proofBack.Read(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.True(t, proofBack.Verify(&root, "k7", []byte{7}))
+}
+
+func TestMerkleProofSingleVariable(t *testing.T) {
+	vars := kv.NewMap()
+	vars.Set("k", []byte("v"))
+	root, err := MerkleRoot(vars)
+	assert.NoError(t, err)
+
+	proof, err := NewMerkleProof(vars, "k")
+	assert.NoError(t, err)
+	assert.Empty(t, proof.Siblings)
+	assert.True(t, proof.Verify(&root, "k", []byte("v")))
+
+	proof, err = NewMerkleProof(vars, "x")
+	assert.NoError(t, err)
+	assert.True(t, proof.Verify(&root, "x", nil))
+	assert.False(t, proof.Verify(&root, "k", nil))
+}
+
+func TestStateHashCommitsToMerkleRoot(t *testing.T) {
+	addr := address.Random()
+	vs := NewVirtualState(mapdb.NewMapDB(), &addr)
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid := sctransaction.NewRequestId(txid, 0)
+	su := NewStateUpdate(&reqid)
+	su.Mutations().Add(kv.NewMutationSet("x", []byte{1}))
+	batch, err := NewBatch([]StateUpdate{su})
+	assert.NoError(t, err)
+	err = vs.ApplyBatch(batch)
+	assert.NoError(t, err)
+
+	root, err := vs.MerkleRoot()
+	assert.NoError(t, err)
+	chainHash := vs.ChainHash()
+	stateHash := mustHash(t, vs)
+	assert.EqualValues(t, StateHashFromMerkleRoot(&chainHash, &root), stateHash)
+
+	proof, err := vs.MerkleProof("x")
+	assert.NoError(t, err)
+	assert.True(t, proof.Verify(&root, "x", []byte{1}))
+
+	// modification of variables changes the state hash
+	vs.Variables().Set("x", []byte{2})
+	assert.NotEqual(t, stateHash, mustHash(t, vs))
+	assert.EqualValues(t, chainHash, vs.ChainHash())
+}
+
+func TestStoredMerkleTree(t *testing.T) {
+	addr := address.Random()
+	db := mapdb.NewMapDB()
+	vs := NewVirtualState(db, &addr)
+	vars := kv.NewMap()
+
+	for stateIndex := uint32(0); stateIndex < 10; stateIndex++ {
+		txid := (transaction.ID)(*hashing.HashData(util.Uint32To4Bytes(stateIndex)))
+		reqid := sctransaction.NewRequestId(txid, 0)
+		su := NewStateUpdate(&reqid)
+		for i := 0; i < 50; i++ {
+			key := kv.Key(fmt.Sprintf("k%d", (int(stateIndex)*37+i*13)%200))
+			if i%3 == 0 {
+				su.Mutations().Add(kv.NewMutationDel(key))
+				vars.Del(key)
+			} else {
+				value := []byte(fmt.Sprintf("v%d.%d", stateIndex, i))
+				su.Mutations().Add(kv.NewMutationSet(key, value))
+				vars.Set(key, value)
+			}
+		}
+		if stateIndex == 5 {
+			su.Mutations().Add(kv.NewMutationDelPrefix("k1"))
+			vars.DelPrefix("k1")
+		}
+		batch, err := NewBatch([]StateUpdate{su})
+		assert.NoError(t, err)
+		batch.WithStateIndex(stateIndex)
+		assert.NoError(t, vs.ApplyBatch(batch))
+
+		// the root of the stored tree with mutations is the same as of all variables
+		expected, err := MerkleRoot(vars)
+		assert.NoError(t, err)
+		root, err := vs.MerkleRoot()
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, root)
+
+		assert.NoError(t, vs.CommitToDb(batch))
+		root, err = vs.MerkleRoot()
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, root)
+
+		// the stored tree
+		vsLoaded, _, exist, err := loadSolidState(db, &addr)
+		assert.NoError(t, err)
+		assert.True(t, exist)
+		root, err = vsLoaded.MerkleRoot()
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, root)
+	}
+
+	vsLoaded, _, _, err := loadSolidState(db, &addr)
+	assert.NoError(t, err)
+	root, err := vsLoaded.MerkleRoot()
+	assert.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		key := kv.Key(fmt.Sprintf("k%d", i))
+		proof, err := vsLoaded.MerkleProof(key)
+		assert.NoError(t, err)
+		value, _ := vars.Get(key)
+		assert.True(t, proof.Verify(&root, key, value))
+	}
+
+	// the tree of variables stored by older versions is built from all variables
+	var treeKeys [][]byte
+	err = db.IterateKeys([]byte{database.ObjectTypeMerkleNode}, func(key kvstore.Key) bool {
+		treeKeys = append(treeKeys, key)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, treeKeys)
+
+	// no stale nodes are stored
+	fresh := newMerkleTree(nil)
+	assert.NoError(t, fresh.update(merkleChangesOfMap(vars)))
+	freshKeys, freshValues := fresh.write()
+	expectedNodes := make(map[string][]byte)
+	for i := range freshKeys {
+		if freshValues[i] != nil {
+			expectedNodes[string(freshKeys[i])] = freshValues[i]
+		}
+	}
+	assert.Equal(t, len(expectedNodes), len(treeKeys))
+	for _, k := range treeKeys {
+		v, err := db.Get(k)
+		assert.NoError(t, err)
+		assert.EqualValues(t, expectedNodes[string(k)], v)
+	}
+	for _, k := range treeKeys {
+		assert.NoError(t, db.Delete(k))
+	}
+	vsLoaded, _, _, err = loadSolidState(db, &addr)
+	assert.NoError(t, err)
+	rootRebuilt, err := vsLoaded.MerkleRoot()
+	assert.NoError(t, err)
+	assert.EqualValues(t, root, rootRebuilt)
+}
+
+func TestLegacySolidState(t *testing.T) {
+	addr := address.Random()
+	txid := (transaction.ID)(*hashing.HashStrings("legacy"))
+	newBatch := func(stateIndex uint32) Batch {
+		reqid := sctransaction.NewRequestId(txid, uint16(stateIndex))
+		su := NewStateUpdate(&reqid)
+		su.Mutations().Add(kv.NewMutationSet(kv.Key(fmt.Sprintf("k%d", stateIndex)), util.Uint32To4Bytes(stateIndex)))
+		batch, err := NewBatch([]StateUpdate{su})
+		assert.NoError(t, err)
+		batch.WithStateIndex(stateIndex)
+		return batch
+	}
+	batches := make([]Batch, 6)
+	for i := range batches {
+		batches[i] = newBatch(uint32(i))
+	}
+
+	// the node before the Merkle tree anchored states #0..#2 with the hash of the chain
+	db := mapdb.NewMapDB()
+	vs := NewVirtualState(db, &addr)
+	anchors := make([]hashing.HashValue, 0, len(batches))
+	for _, b := range batches[:3] {
+		assert.NoError(t, vs.ApplyBatch(b))
+		assert.NoError(t, vs.CommitToDb(b))
+		anchors = append(anchors, vs.ChainHash())
+	}
+	// the solid state is stored in the old format, without the tree of variables
+	var legacy bytes.Buffer
+	legacy.Write(util.Uint32To4Bytes(vs.StateIndex()))
+	legacy.Write(util.Uint64To8Bytes(uint64(vs.Timestamp())))
+	legacy.Write(anchors[2][:])
+	assert.NoError(t, db.Set(database.MakeKey(database.ObjectTypeSolidState), legacy.Bytes()))
+	assert.NoError(t, db.DeletePrefix([]byte{database.ObjectTypeMerkleNode}))
+
+	solid, _, exist, err := loadSolidState(db, &addr)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.EqualValues(t, anchors[2], mustHash(t, solid))
+	assert.EqualValues(t, 3, solid.MerkleFrom())
+
+	// the next state is anchored with the Merkle root
+	assert.NoError(t, solid.ApplyBatch(batches[3]))
+	states, err := StatesToApprove(solid)
+	assert.NoError(t, err)
+	assert.Len(t, states, 2)
+	root, err := solid.MerkleRoot()
+	assert.NoError(t, err)
+	chainHash := solid.ChainHash()
+	anchor := StateHashFromMerkleRoot(&chainHash, &root)
+	assert.Contains(t, states, anchor)
+	assert.Contains(t, states, chainHash)
+	anchors = append(anchors, anchor)
+	assert.NoError(t, solid.CommitToDb(batches[3]))
+
+	// after the activation states have the only hash
+	solid, _, _, err = loadSolidState(db, &addr)
+	assert.NoError(t, err)
+	assert.EqualValues(t, anchor, mustHash(t, solid))
+	for _, b := range batches[4:] {
+		assert.NoError(t, solid.ApplyBatch(b))
+		states, err = StatesToApprove(solid)
+		assert.NoError(t, err)
+		assert.Len(t, states, 1)
+		assert.NoError(t, solid.CommitToDb(b))
+		anchors = append(anchors, mustHash(t, solid))
+	}
+
+	// the node syncs the chain from scratch by selecting the state anchored in each state transaction
+	synced := NewVirtualState(mapdb.NewMapDB(), &addr)
+	var next VirtualState = synced
+	for i, b := range batches {
+		assert.NoError(t, next.ApplyBatch(b))
+		states, err := StatesToApprove(next)
+		assert.NoError(t, err)
+		var ok bool
+		next, ok = states[anchors[i]]
+		if !assert.True(t, ok, "state #%d doesn't match the anchor", i) {
+			return
+		}
+		assert.NoError(t, next.CommitToDb(b))
+	}
+	assert.EqualValues(t, 3, next.MerkleFrom())
+	assert.EqualValues(t, anchors[5], mustHash(t, next))
+}
@@ -0,0 +1,309 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// The sparse Merkle tree of variables of the solid state is stored in the partition of the smart contract
+// together with the variables, one record per node. The node is identified by its depth and by the prefix
+// of paths of that length. Empty subtrees are not stored, the subtree with one variable is stored as the leaf.
+// The tree of the virtual state is the stored tree updated by the uncommitted mutations of variables:
+// only nodes along paths of mutated variables are loaded and hashed again
+
+const (
+	merkleNodeTypeLeaf  = byte(0)
+	merkleNodeTypeInner = byte(1)
+)
+
+// merkleNode is the root of the non-empty subtree. nil means the empty subtree
+type merkleNode struct {
+	// not nil if the subtree contains exactly one variable
+	leaf *merkleLeaf
+	hash hashing.HashValue
+}
+
+// merkleChange is the new value hash of the variable or deletion of it
+type merkleChange struct {
+	path      hashing.HashValue
+	valueHash *hashing.HashValue
+}
+
+// merkleTree is the stored tree with updated nodes on top of it
+type merkleTree struct {
+	// nil if the tree is not stored
+	db kvstore.KVStore
+	// updated nodes by db key. nil means the node is deleted
+	updated map[string]*merkleNode
+}
+
+func newMerkleLeafNode(leaf *merkleLeaf) *merkleNode {
+	return &merkleNode{leaf: leaf, hash: leaf.hash()}
+}
+
+func (n *merkleNode) nodeHash() *hashing.HashValue {
+	if n == nil {
+		return hashing.NilHash
+	}
+	return &n.hash
+}
+
+func (n *merkleNode) Bytes() []byte {
+	var buf bytes.Buffer
+	if n.leaf != nil {
+		buf.WriteByte(merkleNodeTypeLeaf)
+		buf.Write(n.leaf.path[:])
+		buf.Write(n.leaf.valueHash[:])
+	} else {
+		buf.WriteByte(merkleNodeTypeInner)
+		buf.Write(n.hash[:])
+	}
+	return buf.Bytes()
+}
+
+func merkleNodeFromBytes(data []byte) (*merkleNode, error) {
+	switch {
+	case len(data) == 1+2*hashing.HashSize && data[0] == merkleNodeTypeLeaf:
+		leaf := &merkleLeaf{}
+		copy(leaf.path[:], data[1:1+hashing.HashSize])
+		copy(leaf.valueHash[:], data[1+hashing.HashSize:])
+		return newMerkleLeafNode(leaf), nil
+
+	case len(data) == 1+hashing.HashSize && data[0] == merkleNodeTypeInner:
+		ret := &merkleNode{}
+		copy(ret.hash[:], data[1:])
+		return ret, nil
+	}
+	return nil, errors.New("wrong Merkle tree node data")
+}
+
+// key of the node of the subtree at depth, which contains the path.
+// It consists of the depth and the first depth bits of the path
+func dbkeyMerkleNode(depth int, path *hashing.HashValue) []byte {
+	prefix := make([]byte, (depth+7)/8)
+	copy(prefix, path[:])
+	if depth%8 != 0 {
+		prefix[len(prefix)-1] &= byte(0xff << uint(8-depth%8))
+	}
+	return database.MakeKey(database.ObjectTypeMerkleNode, util.Uint16To2Bytes(uint16(depth)), prefix)
+}
+
+func newMerkleTree(db kvstore.KVStore) *merkleTree {
+	return &merkleTree{
+		db:      db,
+		updated: make(map[string]*merkleNode),
+	}
+}
+
+func (t *merkleTree) getNode(depth int, path *hashing.HashValue) (*merkleNode, error) {
+	key := dbkeyMerkleNode(depth, path)
+	if node, ok := t.updated[string(key)]; ok {
+		return node, nil
+	}
+	if t.db == nil {
+		return nil, nil
+	}
+	data, err := t.db.Get(key)
+	if err == kvstore.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return merkleNodeFromBytes(data)
+}
+
+func (t *merkleTree) setNode(depth int, path *hashing.HashValue, node *merkleNode) {
+	t.updated[string(dbkeyMerkleNode(depth, path))] = node
+}
+
+// isStored is false if the tree of existing variables was never stored, i.e. by older versions of the node
+func (t *merkleTree) isStored() (bool, error) {
+	if t.db == nil {
+		return false, nil
+	}
+	return t.db.Has(dbkeyMerkleNode(0, hashing.NilHash))
+}
+
+func (t *merkleTree) root() (hashing.HashValue, error) {
+	node, err := t.getNode(0, hashing.NilHash)
+	if err != nil {
+		return hashing.HashValue{}, err
+	}
+	return *node.nodeHash(), nil
+}
+
+// update applies changes to the tree
+func (t *merkleTree) update(changes []*merkleChange) error {
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].path[:], changes[j].path[:]) < 0
+	})
+	root, err := t.getNode(0, hashing.NilHash)
+	if err != nil {
+		return err
+	}
+	_, err = t.updateSubtree(0, root, changes)
+	return err
+}
+
+// updateSubtree applies sorted changes to the subtree at depth and returns the new root of the subtree.
+// All changes are in the subtree
+func (t *merkleTree) updateSubtree(depth int, node *merkleNode, changes []*merkleChange) (*merkleNode, error) {
+	if len(changes) == 0 {
+		return node, nil
+	}
+	path := &changes[0].path
+	var left, right *merkleNode
+	var err error
+	switch {
+	case node != nil && node.leaf == nil:
+		// inner node, children are stored
+		if left, err = t.getNode(depth+1, childPath(path, depth, false)); err != nil {
+			return nil, err
+		}
+		if right, err = t.getNode(depth+1, childPath(path, depth, true)); err != nil {
+			return nil, err
+		}
+
+	default:
+		// empty subtree or the leaf
+		ret, ok := applyToLeaf(node, changes)
+		if ok {
+			t.setNode(depth, path, ret)
+			return ret, nil
+		}
+		// more than one variable in the subtree: the leaf goes down
+		if node != nil {
+			if pathBit(&node.leaf.path, depth) {
+				right = node
+			} else {
+				left = node
+			}
+		}
+	}
+	i := sort.Search(len(changes), func(i int) bool {
+		return pathBit(&changes[i].path, depth)
+	})
+	if left, err = t.updateSubtree(depth+1, left, changes[:i]); err != nil {
+		return nil, err
+	}
+	if right, err = t.updateSubtree(depth+1, right, changes[i:]); err != nil {
+		return nil, err
+	}
+	var ret *merkleNode
+	switch {
+	case left == nil && right == nil:
+	case left == nil && right.leaf != nil:
+		// the only variable of the subtree goes up
+		ret = right
+		t.setNode(depth+1, childPath(path, depth, true), nil)
+	case right == nil && left.leaf != nil:
+		ret = left
+		t.setNode(depth+1, childPath(path, depth, false), nil)
+	default:
+		// the leaf may have gone down from this node without changes
+		if left != nil && left.leaf != nil {
+			t.setNode(depth+1, childPath(path, depth, false), left)
+		}
+		if right != nil && right.leaf != nil {
+			t.setNode(depth+1, childPath(path, depth, true), right)
+		}
+		ret = &merkleNode{hash: merkleNodeHash(left.nodeHash(), right.nodeHash())}
+	}
+	t.setNode(depth, path, ret)
+	return ret, nil
+}
+
+// applyToLeaf applies changes to the empty subtree or to the subtree with one variable.
+// Returns false if the result has more than one variable
+func applyToLeaf(node *merkleNode, changes []*merkleChange) (*merkleNode, bool) {
+	leaves := make([]*merkleLeaf, 0, 2)
+	changed := false
+	for _, ch := range changes {
+		if node != nil && node.leaf.path == ch.path {
+			changed = true
+		}
+		if ch.valueHash != nil {
+			leaves = append(leaves, &merkleLeaf{path: ch.path, valueHash: *ch.valueHash})
+		}
+	}
+	if node != nil && !changed {
+		leaves = append(leaves, node.leaf)
+	}
+	switch len(leaves) {
+	case 0:
+		return nil, true
+	case 1:
+		return newMerkleLeafNode(leaves[0]), true
+	}
+	return nil, false
+}
+
+// childPath returns the path in the child subtree of the node at depth
+func childPath(path *hashing.HashValue, depth int, right bool) *hashing.HashValue {
+	ret := *path
+	if right {
+		ret[depth/8] |= 0x80 >> uint(depth%8)
+	} else {
+		ret[depth/8] &^= 0x80 >> uint(depth%8)
+	}
+	return &ret
+}
+
+// proof creates the proof of the value of the variable with the path
+func (t *merkleTree) proof(path *hashing.HashValue) (*MerkleProof, error) {
+	ret := &MerkleProof{
+		Siblings: make([]hashing.HashValue, 0),
+	}
+	node, err := t.getNode(0, path)
+	if err != nil {
+		return nil, err
+	}
+	for depth := 0; node != nil && node.leaf == nil; depth++ {
+		sibling, err := t.getNode(depth+1, childPath(path, depth, !pathBit(path, depth)))
+		if err != nil {
+			return nil, err
+		}
+		ret.Siblings = append(ret.Siblings, *sibling.nodeHash())
+		if node, err = t.getNode(depth+1, path); err != nil {
+			return nil, err
+		}
+	}
+	if node != nil && node.leaf.path != *path {
+		ret.OtherLeafPath = &node.leaf.path
+		ret.OtherLeafValueHash = &node.leaf.valueHash
+	}
+	return ret, nil
+}
+
+// write returns keys and values of updated nodes to be stored. nil value deletes the node
+func (t *merkleTree) write() ([][]byte, [][]byte) {
+	keys := make([][]byte, 0, len(t.updated))
+	values := make([][]byte, 0, len(t.updated))
+	for k, node := range t.updated {
+		keys = append(keys, []byte(k))
+		if node == nil {
+			values = append(values, nil)
+		} else {
+			values = append(values, node.Bytes())
+		}
+	}
+	return keys, values
+}
+
+// merkleChangesOfMap makes changes to set all variables of the map
+func merkleChangesOfMap(vars kv.Map) []*merkleChange {
+	ret := make([]*merkleChange, 0)
+	vars.ForEach(func(key kv.Key, value []byte) bool {
+		ret = append(ret, &merkleChange{path: merklePath(key), valueHash: hashing.HashData(value)})
+		return true
+	})
+	return ret
+}
@@ -0,0 +1,178 @@
+package state
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/parameters"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// Pruning removes old batches together with data which can't be used without them:
+// processed request markers, receipts of requests and undo records of the state history.
+// Variables of the solid state and events are never pruned.
+// The index of the earliest batch which wasn't pruned is stored in the partition
+
+// maximum number of batches pruned in one call, to keep db transactions small
+const maxBatchesPrunedAtOnce = 1000
+
+// RetentionPolicy defines which batches are kept. A batch is kept if it is among KeepStates
+// latest batches or if it is younger than KeepTime. Zero value means no limit of that kind.
+// If both are zero, nothing is pruned
+type RetentionPolicy struct {
+	KeepStates uint32
+	KeepTime   time.Duration
+}
+
+func (p *RetentionPolicy) IsEnabled() bool {
+	return p.KeepStates > 0 || p.KeepTime > 0
+}
+
+func (p *RetentionPolicy) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, p.KeepStates); err != nil {
+		return err
+	}
+	return util.WriteInt64(w, int64(p.KeepTime))
+}
+
+func (p *RetentionPolicy) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &p.KeepStates); err != nil {
+		return err
+	}
+	var keepTime int64
+	if err := util.ReadInt64(r, &keepTime); err != nil {
+		return err
+	}
+	p.KeepTime = time.Duration(keepTime)
+	return nil
+}
+
+// NodeRetentionPolicy is the retention policy of smart contracts without their own policy
+func NodeRetentionPolicy() *RetentionPolicy {
+	return &RetentionPolicy{
+		KeepStates: uint32(parameters.GetInt(parameters.DatabasePruningKeepStates)),
+		KeepTime:   time.Duration(parameters.GetInt(parameters.DatabasePruningKeepMinutes)) * time.Minute,
+	}
+}
+
+func dbkeyRetentionPolicy() []byte {
+	return database.MakeKey(database.ObjectTypeRetentionPolicy)
+}
+
+func dbkeyPrunedStateIndex() []byte {
+	return database.MakeKey(database.ObjectTypePrunedStateIndex)
+}
+
+// SaveRetentionPolicy sets the retention policy of the smart contract. nil means node's policy
+func SaveRetentionPolicy(scAddress *address.Address, policy *RetentionPolicy) error {
+	db := getSCPartition(scAddress)
+	if policy == nil {
+		return db.Delete(dbkeyRetentionPolicy())
+	}
+	data, err := util.Bytes(policy)
+	if err != nil {
+		return err
+	}
+	return db.Set(dbkeyRetentionPolicy(), data)
+}
+
+// LoadRetentionPolicy returns the retention policy of the smart contract.
+// If the smart contract has no policy of its own, returns the node's policy and false
+func LoadRetentionPolicy(scAddress *address.Address) (*RetentionPolicy, bool, error) {
+	data, err := getSCPartition(scAddress).Get(dbkeyRetentionPolicy())
+	if err == kvstore.ErrKeyNotFound {
+		return NodeRetentionPolicy(), false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	ret := &RetentionPolicy{}
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, false, err
+	}
+	return ret, true, nil
+}
+
+// PruneBatches removes batches of the smart contract which are out of the retention policy.
+// Batches with state index >= keepFromIndex are kept in any case: the caller uses it to keep
+// batches which may still be requested by peers. The solid batch is never pruned.
+// Returns number of pruned batches
+func PruneBatches(scAddress *address.Address, policy *RetentionPolicy, keepFromIndex uint32) (int, error) {
+	return pruneBatches(getSCPartition(scAddress), policy, keepFromIndex, time.Now())
+}
+
+func pruneBatches(db kvstore.KVStore, policy *RetentionPolicy, keepFromIndex uint32, now time.Time) (int, error) {
+	if !policy.IsEnabled() {
+		return 0, nil
+	}
+	stateIndexBin, err := db.Get(database.MakeKey(database.ObjectTypeSolidStateIndex))
+	if err == kvstore.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	solidIndex := util.Uint32From4Bytes(stateIndexBin)
+
+	limit := solidIndex
+	if keepFromIndex < limit {
+		limit = keepFromIndex
+	}
+	if policy.KeepStates > 0 {
+		if solidIndex+1 < policy.KeepStates {
+			return 0, nil
+		}
+		if idx := solidIndex + 1 - policy.KeepStates; idx < limit {
+			limit = idx
+		}
+	}
+	first := uint32(0)
+	prunedBin, err := db.Get(dbkeyPrunedStateIndex())
+	switch {
+	case err == kvstore.ErrKeyNotFound:
+	case err != nil:
+		return 0, err
+	default:
+		first = util.Uint32From4Bytes(prunedBin)
+	}
+	if first+maxBatchesPrunedAtOnce < limit {
+		limit = first + maxBatchesPrunedAtOnce
+	}
+	keepAfter := now.Add(-policy.KeepTime).UnixNano()
+
+	keys := make([][]byte, 0)
+	values := make([][]byte, 0)
+	idx := first
+	for ; idx < limit; idx++ {
+		batch, err := loadBatch(db, idx)
+		if err != nil {
+			return 0, err
+		}
+		if batch != nil {
+			if policy.KeepTime > 0 && batch.Timestamp() > keepAfter {
+				// timestamps of batches are increasing, all next batches are kept too
+				break
+			}
+			for _, rid := range batch.RequestIds() {
+				keys = append(keys, dbkeyRequest(rid), dbkeyReceipt(rid))
+				values = append(values, nil, nil)
+			}
+		}
+		// the state idx can't be restored without undo record idx+1
+		keys = append(keys, dbkeyBatch(idx), dbkeyStateUndo(idx), dbkeyStateUndo(idx+1))
+		values = append(values, nil, nil, nil)
+	}
+	if idx == first {
+		return 0, nil
+	}
+	keys = append(keys, dbkeyPrunedStateIndex())
+	values = append(values, util.Uint32To4Bytes(idx))
+	if err := util.DbSetMulti(db, keys, values); err != nil {
+		return 0, err
+	}
+	return int(idx - first), nil
+}
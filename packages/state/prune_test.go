@@ -0,0 +1,101 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/goshimmer/packages/database"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/parameters"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneBatches(t *testing.T) {
+	config.Node.Set(parameters.DatabaseKeepStateHistory, true)
+	defer config.Node.Set(parameters.DatabaseKeepStateHistory, false)
+
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+	addr := address.Random()
+	vs := NewVirtualState(partition, &addr)
+
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqids := make([]sctransaction.RequestId, 10)
+	for i := range reqids {
+		reqids[i] = sctransaction.NewRequestId(txid, uint16(i))
+		su := NewStateUpdate(&reqids[i]).WithTimestamp(time.Now().UnixNano())
+		su.Mutations().Add(kv.NewMutationSet("x", util.Uint32To4Bytes(uint32(i))))
+		batch, err := NewBatch([]StateUpdate{su})
+		assert.NoError(t, err)
+		batch.WithStateIndex(uint32(i))
+		assert.NoError(t, vs.ApplyBatch(batch))
+		assert.NoError(t, vs.CommitToDb(batch))
+	}
+	hasBatch := func(idx int) bool {
+		has, err := partition.Has(dbkeyBatch(uint32(idx)))
+		assert.NoError(t, err)
+		return has
+	}
+	hasMarker := func(idx int) bool {
+		has, err := partition.Has(dbkeyRequest(&reqids[idx]))
+		assert.NoError(t, err)
+		return has
+	}
+
+	// disabled policy
+	n, err := pruneBatches(partition, &RetentionPolicy{}, 100, time.Now())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	// batches requested by peers are kept
+	n, err = pruneBatches(partition, &RetentionPolicy{KeepStates: 3}, 4, time.Now())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, n)
+	assert.False(t, hasBatch(3))
+	assert.True(t, hasBatch(4))
+
+	n, err = pruneBatches(partition, &RetentionPolicy{KeepStates: 3}, 100, time.Now())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, i >= 7, hasBatch(i))
+		assert.Equal(t, i >= 7, hasMarker(i))
+	}
+	_, exist, err := loadRequestReceipt(partition, &reqids[6])
+	assert.NoError(t, err)
+	assert.False(t, exist)
+	_, exist, err = loadRequestReceipt(partition, &reqids[7])
+	assert.NoError(t, err)
+	assert.True(t, exist)
+
+	// history of retained states remains available
+	vars, exist, err := loadVariablesAt(partition, 7)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	v, err := vars.Get("x")
+	assert.NoError(t, err)
+	assert.Equal(t, util.Uint32To4Bytes(7), v)
+	_, _, err = loadVariablesAt(partition, 6)
+	assert.Error(t, err)
+
+	// young batches are kept
+	n, err = pruneBatches(partition, &RetentionPolicy{KeepTime: time.Hour}, 100, time.Now())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	// the solid batch is never pruned
+	n, err = pruneBatches(partition, &RetentionPolicy{KeepTime: time.Hour}, 100, time.Now().Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.True(t, hasBatch(9))
+
+	_, _, exist, err = loadSolidState(partition, &addr)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+}
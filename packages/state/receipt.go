@@ -0,0 +1,96 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// RequestReceipt is the outcome of the request settled in the solid state
+type RequestReceipt struct {
+	RequestId          sctransaction.RequestId
+	StateIndex         uint32
+	BatchIndex         uint16
+	StateTransactionId valuetransaction.ID
+	Timestamp          int64
+	GasUsed            int
+	// panic message. Empty string means success
+	Error   string
+	Events  []*Event
+	Results kv.Map
+}
+
+// db key of the receipt is the request id, the value is 'state index' || 'batch index'
+// The rest of the receipt is taken from the state update stored with the batch
+func dbkeyReceipt(reqid *sctransaction.RequestId) []byte {
+	return database.MakeKey(database.ObjectTypeRequestReceipt, reqid[:])
+}
+
+func receiptsToDb(b Batch) ([][]byte, [][]byte) {
+	keys := make([][]byte, 0, b.Size())
+	values := make([][]byte, 0, b.Size())
+	b.ForEach(func(batchIndex uint16, su StateUpdate) bool {
+		keys = append(keys, dbkeyReceipt(su.RequestId()))
+		values = append(values, append(util.Uint32To4Bytes(b.StateIndex()), util.Uint16To2Bytes(batchIndex)...))
+		return true
+	})
+	return keys, values
+}
+
+// LoadRequestReceipt loads the receipt of the request processed by the smart contract.
+// Returns false if the request is not settled in the solid state
+func LoadRequestReceipt(scAddress *address.Address, reqid *sctransaction.RequestId) (*RequestReceipt, bool, error) {
+	return loadRequestReceipt(getSCPartition(scAddress), reqid)
+}
+
+func loadRequestReceipt(db kvstore.KVStore, reqid *sctransaction.RequestId) (*RequestReceipt, bool, error) {
+	ref, err := db.Get(dbkeyReceipt(reqid))
+	if err == kvstore.ErrKeyNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(ref) != 6 {
+		return nil, false, fmt.Errorf("inconsistency: wrong receipt record of request %s", reqid.String())
+	}
+	stateIndex := util.Uint32From4Bytes(ref[:4])
+	batchIndex := util.Uint16From2Bytes(ref[4:])
+
+	batchData, err := db.Get(dbkeyBatch(stateIndex))
+	if err != nil {
+		return nil, false, fmt.Errorf("loading batch #%d: %v", stateIndex, err)
+	}
+	batch, err := BatchFromBytes(batchData)
+	if err != nil {
+		return nil, false, fmt.Errorf("loading batch #%d: %v", stateIndex, err)
+	}
+	var su StateUpdate
+	batch.ForEach(func(idx uint16, stateUpd StateUpdate) bool {
+		if idx == batchIndex {
+			su = stateUpd
+			return false
+		}
+		return true
+	})
+	if su == nil || *su.RequestId() != *reqid {
+		return nil, false, fmt.Errorf("inconsistency: request %s not found in batch #%d", reqid.String(), stateIndex)
+	}
+	return &RequestReceipt{
+		RequestId:          *reqid,
+		StateIndex:         stateIndex,
+		BatchIndex:         batchIndex,
+		StateTransactionId: batch.StateTransactionId(),
+		Timestamp:          su.Timestamp(),
+		GasUsed:            su.GasUsed(),
+		Error:              su.Error(),
+		Events:             su.Events(),
+		Results:            su.Results(),
+	}, true, nil
+}
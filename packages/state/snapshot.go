@@ -0,0 +1,312 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// Snapshot is the solid state of the smart contract: all variables together with the
+// solid batch and the state index. It is used to bootstrap a node without replaying batches.
+// Records of processed requests, events and receipts of earlier batches, as well as
+// the state history, are not part of the snapshot.
+// The snapshot contains the state transaction which anchors the solid state. The state is verified
+// against the state hash in it. The transaction itself is checked on the ledger by the state manager
+// after the smart contract is activated
+type Snapshot struct {
+	SCAddress  address.Address
+	StateIndex uint32
+	Timestamp  int64
+	// hash of the chain of state updates
+	ChainHash        hashing.HashValue
+	Batch            Batch
+	Variables        kv.Map
+	StateTransaction *sctransaction.Transaction
+}
+
+// SaveStateTransaction stores the state transaction which anchors the solid state of the smart contract.
+// It is exported with the snapshot
+func SaveStateTransaction(scAddress *address.Address, tx *sctransaction.Transaction) error {
+	return saveStateTransaction(getSCPartition(scAddress), tx)
+}
+
+func saveStateTransaction(db kvstore.KVStore, tx *sctransaction.Transaction) error {
+	defer lockPartition(db)()
+	return db.Set(database.MakeKey(database.ObjectTypeStateTransaction), tx.Bytes())
+}
+
+func loadStateTransaction(db kvstore.KVStore) (*sctransaction.Transaction, bool, error) {
+	data, err := db.Get(database.MakeKey(database.ObjectTypeStateTransaction))
+	if err == kvstore.ErrKeyNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	tx, err := sctransaction.NewFromBytes(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return tx, true, nil
+}
+
+// lockPartition locks the partition of the node database against commits of the solid state
+// and returns the unlock function. Stores other than the partition, e.g. in tests, are not locked
+func lockPartition(db kvstore.KVStore) func() {
+	if part, ok := db.(*database.Partition); ok {
+		part.Lock()
+		return part.Unlock
+	}
+	return func() {}
+}
+
+// rlockPartition is lockPartition for readers of the solid state
+func rlockPartition(db kvstore.KVStore) func() {
+	if part, ok := db.(*database.Partition); ok {
+		part.RLock()
+		return part.RUnlock
+	}
+	return func() {}
+}
+
+// ExportSnapshot takes the snapshot of the solid state of the smart contract.
+// Returns false if the solid state does not exist
+func ExportSnapshot(scAddress *address.Address) (*Snapshot, bool, error) {
+	return exportSnapshot(getSCPartition(scAddress), scAddress)
+}
+
+func exportSnapshot(db kvstore.KVStore, scAddress *address.Address) (*Snapshot, bool, error) {
+	// the solid state can't change while variables are read
+	defer rlockPartition(db)()
+
+	vs, batch, exist, err := loadSolidState(db, scAddress)
+	if err != nil || !exist {
+		return nil, exist, err
+	}
+	tx, ok, err := loadStateTransaction(db)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok || tx.ID() != batch.StateTransactionId() {
+		return nil, false, fmt.Errorf("state transaction of the solid state #%d is not stored yet", vs.StateIndex())
+	}
+	vars := kv.NewMap()
+	err = vs.Variables().Iterate(kv.EmptyPrefix, func(key kv.Key, value []byte) bool {
+		vars.Set(key, value)
+		return true
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &Snapshot{
+		SCAddress:        *scAddress,
+		StateIndex:       vs.StateIndex(),
+		Timestamp:        vs.Timestamp(),
+		ChainHash:        vs.ChainHash(),
+		Batch:            batch,
+		Variables:        vars,
+		StateTransaction: tx,
+	}, true, nil
+}
+
+// StateHash calculates the hash of the state in the snapshot, the one anchored in the state transaction
+func (s *Snapshot) StateHash() (hashing.HashValue, error) {
+	root, err := MerkleRoot(s.Variables)
+	if err != nil {
+		return hashing.HashValue{}, err
+	}
+	return StateHashFromMerkleRoot(&s.ChainHash, &root), nil
+}
+
+// Verify checks consistency of the snapshot and if it is the state anchored in the state transaction
+// of the snapshot: the state hash is calculated from variables and compared with the one in the transaction
+func (s *Snapshot) Verify() error {
+	if s.Batch == nil || s.Variables == nil || s.StateTransaction == nil {
+		return errors.New("incomplete snapshot")
+	}
+	if s.Batch.StateIndex() != s.StateIndex {
+		return fmt.Errorf("inconsistent snapshot: state index is #%d, batch index is #%d",
+			s.StateIndex, s.Batch.StateIndex())
+	}
+	txid := s.StateTransaction.ID()
+	if txid != s.Batch.StateTransactionId() {
+		return fmt.Errorf("inconsistent snapshot: state transaction is %s, the batch is anchored by %s",
+			txid.String(), s.Batch.StateTransactionId().String())
+	}
+	stateBlock, ok := s.StateTransaction.State()
+	if !ok {
+		return fmt.Errorf("transaction %s is not a state transaction", txid.String())
+	}
+	if stateBlock.StateIndex() != s.StateIndex {
+		return fmt.Errorf("inconsistent snapshot: state index is #%d, state transaction anchors #%d",
+			s.StateIndex, stateBlock.StateIndex())
+	}
+	stateAddr, ok, err := s.StateTransaction.StateAddress()
+	if err != nil {
+		return err
+	}
+	if !ok || stateAddr != s.SCAddress {
+		return fmt.Errorf("state transaction %s does not anchor the state at %s", txid.String(), s.SCAddress.String())
+	}
+	h, err := s.StateHash()
+	if err != nil {
+		return err
+	}
+	if h != stateBlock.StateHash() {
+		expected := stateBlock.StateHash()
+		if expected == s.ChainHash {
+			return fmt.Errorf("state #%d is anchored without the Merkle root, the snapshot can't be verified", s.StateIndex)
+		}
+		return fmt.Errorf("state hash of the snapshot is %s, state transaction anchors %s", h.String(), expected.String())
+	}
+	return nil
+}
+
+// ImportSnapshot verifies the snapshot and stores it as the solid state of the smart contract.
+// Only allowed if the node has no solid state of the smart contract yet
+func ImportSnapshot(snapshot *Snapshot) error {
+	return importSnapshot(getSCPartition(&snapshot.SCAddress), snapshot)
+}
+
+func importSnapshot(db kvstore.KVStore, snapshot *Snapshot) error {
+	if err := snapshot.Verify(); err != nil {
+		return err
+	}
+	defer lockPartition(db)()
+
+	has, err := db.Has(database.MakeKey(database.ObjectTypeSolidStateIndex))
+	if err != nil {
+		return err
+	}
+	if has {
+		return fmt.Errorf("solid state of %s already exists", snapshot.SCAddress.String())
+	}
+	vs := NewVirtualState(db, &snapshot.SCAddress)
+	vs.stateIndex = snapshot.StateIndex
+	vs.timestamp = snapshot.Timestamp
+	vs.stateHash = snapshot.ChainHash
+	vs.merkleFrom = snapshot.StateIndex
+
+	varStateData, err := util.Bytes(vs)
+	if err != nil {
+		return err
+	}
+	batchData, err := util.Bytes(snapshot.Batch)
+	if err != nil {
+		return err
+	}
+	keys := [][]byte{
+		database.MakeKey(database.ObjectTypeSolidState),
+		dbkeyBatch(snapshot.StateIndex),
+		database.MakeKey(database.ObjectTypeSolidStateIndex),
+	}
+	values := [][]byte{varStateData, batchData, util.Uint32To4Bytes(snapshot.StateIndex)}
+
+	keys = append(keys, database.MakeKey(database.ObjectTypeStateTransaction))
+	values = append(values, snapshot.StateTransaction.Bytes())
+
+	// earlier batches are not in the snapshot, there is nothing to prune before the state index
+	keys = append(keys, dbkeyPrunedStateIndex())
+	values = append(values, util.Uint32To4Bytes(snapshot.StateIndex))
+
+	for _, rid := range snapshot.Batch.RequestIds() {
+		keys = append(keys, dbkeyRequest(rid))
+		values = append(values, []byte{0})
+	}
+	evKeys, evValues, err := eventsToDb(snapshot.Batch)
+	if err != nil {
+		return err
+	}
+	keys = append(keys, evKeys...)
+	values = append(values, evValues...)
+
+	rcptKeys, rcptValues := receiptsToDb(snapshot.Batch)
+	keys = append(keys, rcptKeys...)
+	values = append(values, rcptValues...)
+
+	snapshot.Variables.ForEach(func(key kv.Key, value []byte) bool {
+		keys = append(keys, dbkeyStateVariable(key))
+		values = append(values, value)
+		return true
+	})
+
+	// the Merkle tree of variables is stored together with them
+	tree := newMerkleTree(nil)
+	if err = tree.update(merkleChangesOfMap(snapshot.Variables)); err != nil {
+		return err
+	}
+	treeKeys, treeValues := tree.write()
+	keys = append(keys, treeKeys...)
+	values = append(values, treeValues...)
+
+	return util.DbSetMulti(db, keys, values)
+}
+
+func (s *Snapshot) Write(w io.Writer) error {
+	if _, err := w.Write(s.SCAddress[:]); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, s.StateIndex); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, s.Timestamp); err != nil {
+		return err
+	}
+	if err := s.ChainHash.Write(w); err != nil {
+		return err
+	}
+	if err := s.Batch.Write(w); err != nil {
+		return err
+	}
+	if err := s.Variables.Write(w); err != nil {
+		return err
+	}
+	return util.WriteBytes32(w, s.StateTransaction.Bytes())
+}
+
+func (s *Snapshot) Read(r io.Reader) error {
+	if err := util.ReadAddress(r, &s.SCAddress); err != nil {
+		return err
+	}
+	if err := util.ReadUint32(r, &s.StateIndex); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &s.Timestamp); err != nil {
+		return err
+	}
+	if err := s.ChainHash.Read(r); err != nil {
+		return err
+	}
+	b := &batch{}
+	if err := b.Read(r); err != nil {
+		return err
+	}
+	s.Batch = b
+	s.Variables = kv.NewMap()
+	if err := s.Variables.Read(r); err != nil {
+		return err
+	}
+	data, err := util.ReadBytes32(r)
+	if err != nil {
+		return err
+	}
+	s.StateTransaction, err = sctransaction.NewFromBytes(data)
+	return err
+}
+
+func SnapshotFromBytes(data []byte) (*Snapshot, error) {
+	ret := &Snapshot{}
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,122 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/goshimmer/packages/database"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	tmpdb, _ := database.NewMemDB()
+	partition1 := tmpdb.NewStore().WithRealm([]byte("1"))
+	partition2 := tmpdb.NewStore().WithRealm([]byte("2"))
+	addr := address.Random()
+
+	_, exist, err := exportSnapshot(partition1, &addr)
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	vs := NewVirtualState(partition1, &addr)
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	for i := uint32(0); i < 3; i++ {
+		reqid := sctransaction.NewRequestId(txid, uint16(i))
+		su := NewStateUpdate(&reqid)
+		su.Mutations().Add(kv.NewMutationSet(kv.Key("x"), util.Uint32To4Bytes(i)))
+		su.Mutations().Add(kv.NewMutationSet(kv.Key(string([]byte{'k', byte(i)})), []byte{byte(i)}))
+		batch, err := NewBatch([]StateUpdate{su})
+		assert.NoError(t, err)
+		batch.WithStateIndex(i)
+		assert.NoError(t, vs.ApplyBatch(batch))
+		stateTx := newTestStateTx(t, &addr, i, mustHash(t, vs))
+		batch.WithStateTransaction(stateTx.ID())
+		assert.NoError(t, vs.CommitToDb(batch))
+
+		if i < 2 {
+			// the state transaction is required for the snapshot
+			_, _, err = exportSnapshot(partition1, &addr)
+			assert.Error(t, err)
+		}
+		assert.NoError(t, saveStateTransaction(partition1, stateTx))
+	}
+	stateHash := mustHash(t, vs)
+
+	snapshot, exist, err := exportSnapshot(partition1, &addr)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.NoError(t, snapshot.Verify())
+
+	data, err := util.Bytes(snapshot)
+	assert.NoError(t, err)
+	snapshotBack, err := SnapshotFromBytes(data)
+	assert.NoError(t, err)
+	assert.EqualValues(t, snapshot.SCAddress, snapshotBack.SCAddress)
+	assert.EqualValues(t, snapshot.StateIndex, snapshotBack.StateIndex)
+
+	assert.EqualValues(t, snapshot.StateTransaction.ID(), snapshotBack.StateTransaction.ID())
+
+	// the state hash is always checked against the state transaction
+	wrongVars := kv.NewMap()
+	snapshotBack.Variables.ForEach(func(key kv.Key, value []byte) bool {
+		wrongVars.Set(key, value)
+		return true
+	})
+	wrongVars.Set("x", []byte("wrong"))
+	wrong := *snapshotBack
+	wrong.Variables = wrongVars
+	assert.Error(t, importSnapshot(partition2, &wrong))
+
+	wrong = *snapshotBack
+	wrong.StateTransaction = newTestStateTx(t, &addr, 2, *hashing.HashStrings("wrong"))
+	assert.Error(t, importSnapshot(partition2, &wrong))
+
+	wrong = *snapshotBack
+	wrong.StateTransaction = nil
+	assert.Error(t, importSnapshot(partition2, &wrong))
+
+	assert.NoError(t, importSnapshot(partition2, snapshotBack))
+	vsImported, batch, exist, err := loadSolidState(partition2, &addr)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.EqualValues(t, 2, vsImported.StateIndex())
+	assert.EqualValues(t, 2, batch.StateIndex())
+	assert.EqualValues(t, stateHash, mustHash(t, vsImported))
+	v, err := vsImported.Variables().Get("x")
+	assert.NoError(t, err)
+	assert.Equal(t, util.Uint32To4Bytes(2), v)
+
+	// import is allowed only into the fresh partition
+	assert.Error(t, importSnapshot(partition2, snapshotBack))
+
+	// the imported state can be exported again
+	_, exist, err = exportSnapshot(partition2, &addr)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+}
+
+// newTestStateTx creates the state transaction which moves the smart contract token to the address
+// and anchors the state
+func newTestStateTx(t *testing.T, addr *address.Address, stateIndex uint32, stateHash hashing.HashValue) *sctransaction.Transaction {
+	color := (balance.Color)(*hashing.HashStrings("test color"))
+	inputTxid := (transaction.ID)(*hashing.HashStrings("test input"))
+	vtx := transaction.New(
+		transaction.NewInputs(transaction.NewOutputID(*addr, inputTxid)),
+		transaction.NewOutputs(map[address.Address][]*balance.Balance{
+			*addr: {balance.New(color, 1)},
+		}),
+	)
+	tx, err := sctransaction.NewTransaction(vtx, sctransaction.NewStateBlock(sctransaction.NewStateBlockParams{
+		Color:      color,
+		StateIndex: stateIndex,
+		StateHash:  stateHash,
+	}), nil)
+	assert.NoError(t, err)
+	return tx
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/parameters"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm/vmconst"
@@ -20,15 +21,28 @@ type virtualState struct {
 	stateIndex uint32
 	timestamp  int64
 	empty      bool
-	stateHash  hashing.HashValue
-	variables  kv.BufferedKVStore
+	// hash of the chain of state updates
+	stateHash hashing.HashValue
+	variables kv.BufferedKVStore
+	// cached Merkle root of variables and the stored tree updated by mutations.
+	// Valid while the number of mutations is merkleRootMuts
+	merkleRoot     *hashing.HashValue
+	merkleTree     *merkleTree
+	merkleRootMuts int
+	// state index from which state hashes of the chain commit to the Merkle root of variables.
+	// States before it were anchored by nodes without the Merkle tree, with the hash of the chain only
+	merkleFrom uint32
 }
 
+// solidStateVersion is written after the fields of the solid state stored by nodes before the Merkle tree.
+// The stored solid state without it is anchored by the hash of the chain
+const solidStateVersion = byte(1)
+
 func NewVirtualState(db kvstore.KVStore, scAddress *address.Address) *virtualState {
 	return &virtualState{
 		scAddress: *scAddress,
 		db:        db,
-		variables: kv.NewBufferedKVStore(subRealm(db, []byte{database.ObjectTypeStateVariable})),
+		variables: kv.NewBufferedKVStoreWithCacheSize(subRealm(db, []byte{database.ObjectTypeStateVariable}), readCacheSize()),
 		empty:     true,
 	}
 }
@@ -41,6 +55,14 @@ func getSCPartition(scAddress *address.Address) kvstore.KVStore {
 	return database.GetPartition(scAddress)
 }
 
+// readCacheSize is the capacity of the read cache of variables. The default is used if the node is not configured
+func readCacheSize() int {
+	if ret := parameters.GetInt(parameters.DatabaseReadCacheSize); ret > 0 {
+		return ret
+	}
+	return kv.DefaultReadCacheSize
+}
+
 func subRealm(db kvstore.KVStore, realm []byte) kvstore.KVStore {
 	if db == nil {
 		return nil
@@ -57,6 +79,11 @@ func (vs *virtualState) Clone() VirtualState {
 		empty:      vs.empty,
 		stateHash:  vs.stateHash,
 		variables:  vs.variables.Clone(),
+		merkleRoot: vs.merkleRoot,
+		merkleTree: vs.merkleTree,
+		// the clone has the same mutations
+		merkleRootMuts: vs.merkleRootMuts,
+		merkleFrom:     vs.merkleFrom,
 	}
 }
 
@@ -86,8 +113,7 @@ func (vs *virtualState) StateIndex() uint32 {
 }
 
 func (vs *virtualState) ApplyStateIndex(stateIndex uint32) {
-	vh := vs.Hash()
-	vs.stateHash = *hashing.HashData(vh.Bytes(), util.Uint32To4Bytes(stateIndex))
+	vs.stateHash = *hashing.HashData(vs.stateHash.Bytes(), util.Uint32To4Bytes(stateIndex))
 	vs.empty = false
 	vs.stateIndex = stateIndex
 }
@@ -120,16 +146,166 @@ func (vs *virtualState) ApplyBatch(batch Batch) error {
 func (vs *virtualState) ApplyStateUpdate(stateUpd StateUpdate) {
 	stateUpd.Mutations().ApplyTo(vs.Variables())
 	vs.timestamp = stateUpd.Timestamp()
-	vh := vs.Hash()
 	sh := util.GetHashValue(stateUpd)
-	vs.stateHash = *hashing.HashData(vh.Bytes(), sh.Bytes(), util.Uint64To8Bytes(uint64(vs.timestamp)))
+	vs.stateHash = *hashing.HashData(vs.stateHash.Bytes(), sh.Bytes(), util.Uint64To8Bytes(uint64(vs.timestamp)))
 	vs.empty = false
 }
 
-func (vs *virtualState) Hash() hashing.HashValue {
+func (vs *virtualState) Hash() (hashing.HashValue, error) {
+	if vs.empty {
+		return *hashing.NilHash, nil
+	}
+	if vs.stateIndex < vs.merkleFrom {
+		return vs.stateHash, nil
+	}
+	root, err := vs.MerkleRoot()
+	if err != nil {
+		return hashing.HashValue{}, err
+	}
+	return StateHashFromMerkleRoot(&vs.stateHash, &root), nil
+}
+
+func (vs *virtualState) ChainHash() hashing.HashValue {
 	return vs.stateHash
 }
 
+func (vs *virtualState) MerkleFrom() uint32 {
+	return vs.merkleFrom
+}
+
+func (vs *virtualState) WithoutMerkleRoot() (VirtualState, bool) {
+	if vs.empty || vs.stateIndex != vs.merkleFrom {
+		return nil, false
+	}
+	ret := vs.Clone().(*virtualState)
+	ret.merkleFrom = vs.stateIndex + 1
+	return ret, true
+}
+
+// StatesToApprove returns the state by hashes it can be anchored with in the state transaction.
+// Nodes before the Merkle tree anchored states with the hash of the chain. The chain may continue
+// to be anchored this way until the first state anchored with the Merkle root
+func StatesToApprove(vs VirtualState) (map[hashing.HashValue]VirtualState, error) {
+	h, err := vs.Hash()
+	if err != nil {
+		return nil, err
+	}
+	ret := map[hashing.HashValue]VirtualState{h: vs}
+	if legacy, ok := vs.WithoutMerkleRoot(); ok {
+		lh, err := legacy.Hash()
+		if err != nil {
+			return nil, err
+		}
+		// if variables are empty, both hashes are equal. The activation is deferred while they can't be distinguished
+		ret[lh] = legacy
+	}
+	return ret, nil
+}
+
+// MerkleRoot is calculated when needed for the first time after variables are modified.
+// The stored tree is updated by mutations of variables
+func (vs *virtualState) MerkleRoot() (hashing.HashValue, error) {
+	if err := vs.updateMerkleTree(); err != nil {
+		return hashing.HashValue{}, err
+	}
+	return *vs.merkleRoot, nil
+}
+
+// MerkleProof creates the proof of the value of the variable with the key against the Merkle root
+func (vs *virtualState) MerkleProof(key kv.Key) (*MerkleProof, error) {
+	if err := vs.updateMerkleTree(); err != nil {
+		return nil, err
+	}
+	path := merklePath(key)
+	return vs.merkleTree.proof(&path)
+}
+
+func (vs *virtualState) updateMerkleTree() error {
+	if vs.merkleRootValid() {
+		return nil
+	}
+	tree := newMerkleTree(vs.db)
+	changes, err := vs.merkleChanges(tree)
+	if err != nil {
+		return err
+	}
+	if err = tree.update(changes); err != nil {
+		return err
+	}
+	root, err := tree.root()
+	if err != nil {
+		return err
+	}
+	vs.merkleRoot = &root
+	vs.merkleTree = tree
+	vs.merkleRootMuts = vs.variables.Mutations().Len()
+	return nil
+}
+
+// merkleChanges returns changes of the stored tree by mutations of variables.
+// If the tree was not stored yet, all variables are added to the tree
+func (vs *virtualState) merkleChanges(tree *merkleTree) ([]*merkleChange, error) {
+	ret := make([]*merkleChange, 0)
+	add := func(key kv.Key, value []byte) {
+		ch := &merkleChange{path: merklePath(key)}
+		if value != nil {
+			ch.valueHash = hashing.HashData(value)
+		}
+		ret = append(ret, ch)
+	}
+	stored, err := tree.isStored()
+	if err != nil {
+		return nil, err
+	}
+	if !stored && vs.db != nil {
+		err = vs.variables.Iterate(kv.EmptyPrefix, func(key kv.Key, value []byte) bool {
+			add(key, value)
+			return true
+		})
+		return ret, err
+	}
+	keys, err := vs.mutatedKeys()
+	if err != nil {
+		return nil, err
+	}
+	for k := range keys {
+		value, err := vs.variables.Get(k)
+		if err != nil {
+			return nil, err
+		}
+		add(k, value)
+	}
+	return ret, nil
+}
+
+// mutatedKeys returns keys of all variables modified by mutations,
+// including stored keys deleted by "del prefix" mutations
+func (vs *virtualState) mutatedKeys() (map[kv.Key]bool, error) {
+	ret := make(map[kv.Key]bool)
+	vs.variables.Mutations().IterateLatest(func(k kv.Key, mut kv.Mutation) bool {
+		ret[k] = true
+		return true
+	})
+	if vs.db == nil {
+		return ret, nil
+	}
+	var err error
+	realm := len(vs.db.Realm())
+	vs.variables.Mutations().IterateDelPrefixes(func(prefix kv.Key) bool {
+		err = vs.db.IterateKeys(dbkeyStateVariable(prefix), func(key kvstore.Key) bool {
+			ret[kv.Key(key[realm+1:])] = true
+			return true
+		})
+		return err == nil
+	})
+	return ret, err
+}
+
+// any modification of variables adds a mutation
+func (vs *virtualState) merkleRootValid() bool {
+	return vs.merkleRoot != nil && vs.merkleRootMuts == vs.variables.Mutations().Len()
+}
+
 func (vs *virtualState) Write(w io.Writer) error {
 	if _, err := w.Write(util.Uint32To4Bytes(vs.stateIndex)); err != nil {
 		return err
@@ -140,7 +316,10 @@ func (vs *virtualState) Write(w io.Writer) error {
 	if _, err := w.Write(vs.stateHash.Bytes()); err != nil {
 		return err
 	}
-	return nil
+	if _, err := w.Write([]byte{solidStateVersion}); err != nil {
+		return err
+	}
+	return util.WriteUint32(w, vs.merkleFrom)
 }
 
 func (vs *virtualState) Read(r io.Reader) error {
@@ -155,11 +334,30 @@ func (vs *virtualState) Read(r io.Reader) error {
 	if _, err := r.Read(vs.stateHash[:]); err != nil {
 		return err
 	}
-	return nil
+	// only non-empty states are stored
+	vs.empty = false
+
+	var version [1]byte
+	_, err := r.Read(version[:])
+	if err == io.EOF {
+		// stored by the node before the Merkle tree. The next state is anchored with the Merkle root
+		vs.merkleFrom = vs.stateIndex + 1
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if version[0] != solidStateVersion {
+		return fmt.Errorf("unsupported version of the solid state: %d", version[0])
+	}
+	return util.ReadUint32(r, &vs.merkleFrom)
 }
 
 // saves variable state to db atomically with the batch of state updates and records of processed requests
 func (vs *virtualState) CommitToDb(b Batch) error {
+	// readers of the solid state, i.e. the snapshot export, wait for the commit
+	defer lockPartition(vs.db)()
+
 	batchData, err := util.Bytes(b)
 	if err != nil {
 		return err
@@ -184,20 +382,67 @@ func (vs *virtualState) CommitToDb(b Batch) error {
 		values = append(values, []byte{0})
 	}
 
-	// store uncommitted mutations
-	vs.variables.Mutations().IterateLatest(func(k kv.Key, mut kv.Mutation) bool {
+	// store events emitted by requests of the batch
+	evKeys, evValues, err := eventsToDb(b)
+	if err != nil {
+		return err
+	}
+	keys = append(keys, evKeys...)
+	values = append(values, evValues...)
+
+	// store references to state updates of requests for receipts
+	rcptKeys, rcptValues := receiptsToDb(b)
+	keys = append(keys, rcptKeys...)
+	values = append(values, rcptValues...)
+
+	// store uncommitted mutations. If mutation is MutationDel or the key is deleted by "del prefix" mutation,
+	// the value is nil and the key is deleted
+	written, err := vs.mutatedKeys()
+	if err != nil {
+		return err
+	}
+	for k := range written {
+		v, err := vs.variables.Get(k)
+		if err != nil {
+			return err
+		}
 		keys = append(keys, dbkeyStateVariable(k))
+		values = append(values, v)
+	}
 
-		// if mutation is MutationDel, mut.Value() = nil and the key is deleted
-		values = append(values, mut.Value())
-		return true
-	})
+	// store updated nodes of the Merkle tree
+	if err = vs.updateMerkleTree(); err != nil {
+		return err
+	}
+	treeKeys, treeValues := vs.merkleTree.write()
+	keys = append(keys, treeKeys...)
+	values = append(values, treeValues...)
+
+	// store the undo record to be able to query the previous state
+	if keepStateHistory() {
+		varKeys := make([]kv.Key, 0, len(written))
+		for k := range written {
+			varKeys = append(varKeys, k)
+		}
+		undoKey, undoValue, err := undoToDb(vs.db, b.StateIndex(), varKeys)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, undoKey)
+		values = append(values, undoValue)
+	}
 
-	err = util.DbSetMulti(vs.db, keys, values)
+	// cached reads of variables become invalid with the write
+	err = vs.variables.InvalidateCache(func() error {
+		return util.DbSetMulti(vs.db, keys, values)
+	})
 	if err != nil {
 		return err
 	}
+	// committing doesn't change variables, the Merkle root remains valid. The tree is now stored
 	vs.variables.ClearMutations()
+	vs.merkleTree = newMerkleTree(vs.db)
+	vs.merkleRootMuts = 0
 	return nil
 }
 
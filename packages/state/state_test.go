@@ -17,12 +17,12 @@ import (
 func TestVariableStateBasic(t *testing.T) {
 	addr := address.Random()
 	vs1 := NewVirtualState(mapdb.NewMapDB(), &addr)
-	h1 := vs1.Hash()
+	h1 := mustHash(t, vs1)
 	assert.Equal(t, h1 == *hashing.NilHash, true)
 	assert.Equal(t, vs1.StateIndex(), uint32(0))
 
 	vs2 := vs1.Clone()
-	h2 := vs2.Hash()
+	h2 := mustHash(t, vs2)
 	assert.EqualValues(t, h1, h2)
 	assert.EqualValues(t, vs1.StateIndex(), vs1.StateIndex())
 
@@ -34,12 +34,12 @@ func TestVariableStateBasic(t *testing.T) {
 	vs2.Variables().Codec().SetString("kuku", "A")
 	vs2.Variables().Codec().SetInt64("num", int64(123))
 
-	assert.EqualValues(t, vs1.Hash(), vs2.Hash())
+	assert.EqualValues(t, mustHash(t, vs1), mustHash(t, vs2))
 
 	vs3 := vs1.Clone()
 	vs4 := vs2.Clone()
 
-	assert.EqualValues(t, vs3.Hash(), vs4.Hash())
+	assert.EqualValues(t, mustHash(t, vs3), mustHash(t, vs4))
 }
 
 func TestApply(t *testing.T) {
@@ -129,7 +129,7 @@ func TestApply2(t *testing.T) {
 
 	assert.EqualValues(t, vs1.StateIndex(), vs2.StateIndex())
 
-	assert.EqualValues(t, vs1.Hash(), vs2.Hash())
+	assert.EqualValues(t, mustHash(t, vs1), mustHash(t, vs2))
 }
 
 func TestApply3(t *testing.T) {
@@ -154,7 +154,7 @@ func TestApply3(t *testing.T) {
 	err = vs2.ApplyBatch(batch)
 	assert.NoError(t, err)
 
-	assert.EqualValues(t, vs1.Hash(), vs2.Hash())
+	assert.EqualValues(t, mustHash(t, vs1), mustHash(t, vs2))
 }
 
 func TestCommit(t *testing.T) {
@@ -196,7 +196,7 @@ func TestCommit(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.EqualValues(t, util.GetHashValue(batch1), util.GetHashValue(batch1_2))
-	assert.EqualValues(t, vs1.Hash(), vs1_2.Hash())
+	assert.EqualValues(t, mustHash(t, vs1), mustHash(t, vs1_2))
 
 	v, _ = vs1_2.Variables().Get(kv.Key([]byte("x")))
 	assert.Equal(t, []byte{1}, v)
@@ -227,3 +227,171 @@ func TestCommit(t *testing.T) {
 	v, _ = partition.Get(dbkeyStateVariable(kv.Key([]byte("x"))))
 	assert.Nil(t, v)
 }
+
+func TestCommitDelPrefix(t *testing.T) {
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid1 := sctransaction.NewRequestId(txid, 0)
+	su1 := NewStateUpdate(&reqid1)
+	su1.Mutations().Add(kv.NewMutationSet("ab1", []byte{1}))
+	su1.Mutations().Add(kv.NewMutationSet("ab2", []byte{2}))
+	su1.Mutations().Add(kv.NewMutationSet("ac1", []byte{3}))
+
+	batch1, err := NewBatch([]StateUpdate{su1})
+	assert.NoError(t, err)
+
+	addr := address.Random()
+	vs := NewVirtualState(partition, &addr)
+	err = vs.ApplyBatch(batch1)
+	assert.NoError(t, err)
+	err = vs.CommitToDb(batch1)
+	assert.NoError(t, err)
+
+	reqid2 := sctransaction.NewRequestId(txid, 1)
+	su2 := NewStateUpdate(&reqid2)
+	su2.Mutations().DelPrefix("ab")
+	su2.Mutations().Add(kv.NewMutationSet("ab2", []byte{4}))
+
+	batch2, err := NewBatch([]StateUpdate{su2})
+	assert.NoError(t, err)
+	batch2.WithStateIndex(1)
+	err = vs.ApplyBatch(batch2)
+	assert.NoError(t, err)
+
+	v, _ := vs.Variables().Get("ab1")
+	assert.Nil(t, v)
+
+	err = vs.CommitToDb(batch2)
+	assert.NoError(t, err)
+
+	v, _ = partition.Get(dbkeyStateVariable("ab1"))
+	assert.Nil(t, v)
+	v, _ = partition.Get(dbkeyStateVariable("ab2"))
+	assert.Equal(t, []byte{4}, v)
+	v, _ = partition.Get(dbkeyStateVariable("ac1"))
+	assert.Equal(t, []byte{3}, v)
+}
+
+func TestEvents(t *testing.T) {
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid1 := sctransaction.NewRequestId(txid, 0)
+	reqid2 := sctransaction.NewRequestId(txid, 1)
+	su1 := NewStateUpdate(&reqid1)
+	su1.AddEvent(NewEvent("a", nil))
+	su1.AddEvent(NewEvent("b", nil))
+	su2 := NewStateUpdate(&reqid2)
+	fields := kv.NewMap()
+	fields.Codec().SetInt64("n", 42)
+	su2.AddEvent(NewEvent("a", fields))
+
+	batch, err := NewBatch([]StateUpdate{su1, su2})
+	assert.NoError(t, err)
+
+	addr := address.Random()
+	vs := NewVirtualState(partition, &addr)
+	assert.NoError(t, vs.ApplyBatch(batch))
+	assert.NoError(t, vs.CommitToDb(batch))
+
+	recs, err := loadEvents(partition, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(recs))
+	assert.Equal(t, "a", recs[0].Event.Name)
+	assert.Equal(t, "b", recs[1].Event.Name)
+	assert.EqualValues(t, 1, recs[1].Index)
+	assert.Equal(t, reqid2, recs[2].RequestId)
+	assert.EqualValues(t, 1, recs[2].BatchIndex)
+	n, ok, err := recs[2].Event.Fields.Codec().GetInt64("n")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.EqualValues(t, 42, n)
+
+	recs, err = loadEvents(partition, "a", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(recs))
+
+	recs, err = loadEvents(partition, "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(recs))
+
+	su3 := NewStateUpdate(&reqid1)
+	su3.AddEvent(NewEvent("c", nil))
+	batch, err = NewBatch([]StateUpdate{su3})
+	assert.NoError(t, err)
+	batch.WithStateIndex(1)
+	assert.NoError(t, vs.ApplyBatch(batch))
+	assert.NoError(t, vs.CommitToDb(batch))
+
+	recs, err = loadEvents(partition, "", 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(recs))
+	assert.Equal(t, "c", recs[0].Event.Name)
+	assert.EqualValues(t, 1, recs[0].StateIndex)
+
+	recs, err = loadEvents(partition, "", 0, 0xffffffff)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(recs))
+	assert.Equal(t, "c", recs[3].Event.Name)
+}
+
+func TestStateIndexRangePrefixes(t *testing.T) {
+	assert.Equal(t, [][]byte{{}}, stateIndexRangePrefixes(0, 0xffffffff))
+	assert.Equal(t, [][]byte{{0, 0, 0, 5}}, stateIndexRangePrefixes(5, 5))
+	assert.Equal(t, [][]byte{{0, 0, 1}}, stateIndexRangePrefixes(256, 511))
+	assert.Equal(t, [][]byte{{0, 0, 0, 255}, {0, 0, 1}, {0, 0, 2, 0}}, stateIndexRangePrefixes(255, 512))
+	assert.Equal(t, [][]byte{{0, 0, 0xff, 0xff}, {0, 1}}, stateIndexRangePrefixes(0xffff, 0x1ffff))
+}
+
+func TestRequestReceipt(t *testing.T) {
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+
+	txid := (transaction.ID)(*hashing.HashStrings("test string 1"))
+	reqid1 := sctransaction.NewRequestId(txid, 0)
+	reqid2 := sctransaction.NewRequestId(txid, 1)
+	su1 := NewStateUpdate(&reqid1).WithGasUsed(100)
+	su1.Results().Set("r", []byte{1})
+	su1.AddEvent(NewEvent("a", nil))
+	su2 := NewStateUpdate(&reqid2).WithError("failed")
+
+	stateTxId := (transaction.ID)(*hashing.HashStrings("state tx"))
+	batch, err := NewBatch([]StateUpdate{su1, su2})
+	assert.NoError(t, err)
+	batch.WithStateTransaction(stateTxId)
+
+	addr := address.Random()
+	vs := NewVirtualState(partition, &addr)
+	assert.NoError(t, vs.ApplyBatch(batch))
+	assert.NoError(t, vs.CommitToDb(batch))
+
+	rcpt, exist, err := loadRequestReceipt(partition, &reqid1)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, stateTxId, rcpt.StateTransactionId)
+	assert.EqualValues(t, 0, rcpt.BatchIndex)
+	assert.EqualValues(t, 100, rcpt.GasUsed)
+	assert.Equal(t, "", rcpt.Error)
+	assert.Equal(t, 1, len(rcpt.Events))
+	assert.Equal(t, []byte{1}, rcpt.Results.ToGoMap()["r"])
+
+	rcpt, exist, err = loadRequestReceipt(partition, &reqid2)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.EqualValues(t, 1, rcpt.BatchIndex)
+	assert.Equal(t, "failed", rcpt.Error)
+
+	reqid3 := sctransaction.NewRequestId(txid, 2)
+	_, exist, err = loadRequestReceipt(partition, &reqid3)
+	assert.NoError(t, err)
+	assert.False(t, exist)
+}
+
+func mustHash(t *testing.T, vs VirtualState) hashing.HashValue {
+	h, err := vs.Hash()
+	assert.NoError(t, err)
+	return h
+}
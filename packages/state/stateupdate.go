@@ -3,17 +3,27 @@ package state
 import (
 	"fmt"
 	"io"
+	"math"
 
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/util"
 )
 
+// stateUpdateVersion is written before the serialized state update.
+// Version 0 is the original format without version byte: request id, mutations and timestamp.
+// State updates of version 0 are only found in batches stored by older nodes, see batch.readEssence
+const stateUpdateVersion = byte(1)
+
 type stateUpdate struct {
 	batchIndex uint16
 	requestId  sctransaction.RequestId
 	timestamp  int64
+	gasUsed    int
 	mutations  kv.MutationSequence
+	events     []*Event
+	results    kv.Map
+	err        string
 }
 
 func NewStateUpdate(reqid *sctransaction.RequestId) StateUpdate {
@@ -24,6 +34,7 @@ func NewStateUpdate(reqid *sctransaction.RequestId) StateUpdate {
 	return &stateUpdate{
 		requestId: req,
 		mutations: kv.NewMutationSequence(),
+		results:   kv.NewMap(),
 	}
 }
 
@@ -32,14 +43,22 @@ func NewStateUpdateRead(r io.Reader) (StateUpdate, error) {
 	return ret, ret.Read(r)
 }
 
+func newStateUpdateReadV0(r io.Reader) (StateUpdate, error) {
+	ret := NewStateUpdate(nil).(*stateUpdate)
+	return ret, ret.readV0(r)
+}
+
 // StateUpdate
 
 func (su *stateUpdate) Clear() {
 	su.mutations = kv.NewMutationSequence()
+	su.events = nil
+	su.results = kv.NewMap()
 }
 
 func (su *stateUpdate) String() string {
-	ret := fmt.Sprintf("reqid: %s, ts: %d, muts: [%s]", su.requestId.String(), su.Timestamp(), su.mutations)
+	ret := fmt.Sprintf("reqid: %s, ts: %d, gas: %d, muts: [%s], events: %d, err: '%s'",
+		su.requestId.String(), su.Timestamp(), su.gasUsed, su.mutations, len(su.events), su.err)
 	return ret
 }
 
@@ -52,6 +71,15 @@ func (su *stateUpdate) WithTimestamp(ts int64) StateUpdate {
 	return su
 }
 
+func (su *stateUpdate) GasUsed() int {
+	return su.gasUsed
+}
+
+func (su *stateUpdate) WithGasUsed(gas int) StateUpdate {
+	su.gasUsed = gas
+	return su
+}
+
 func (su *stateUpdate) RequestId() *sctransaction.RequestId {
 	return &su.requestId
 }
@@ -60,17 +88,99 @@ func (su *stateUpdate) Mutations() kv.MutationSequence {
 	return su.mutations
 }
 
+func (su *stateUpdate) Events() []*Event {
+	return su.events
+}
+
+func (su *stateUpdate) AddEvent(ev *Event) {
+	su.events = append(su.events, ev)
+}
+
+func (su *stateUpdate) Results() kv.Map {
+	return su.results
+}
+
+func (su *stateUpdate) Error() string {
+	return su.err
+}
+
+func (su *stateUpdate) WithError(err string) StateUpdate {
+	su.err = err
+	return su
+}
+
 func (su *stateUpdate) Write(w io.Writer) error {
+	if _, err := w.Write([]byte{stateUpdateVersion}); err != nil {
+		return err
+	}
 	if _, err := w.Write(su.requestId[:]); err != nil {
 		return err
 	}
 	if err := su.mutations.Write(w); err != nil {
 		return err
 	}
-	return util.WriteUint64(w, uint64(su.timestamp))
+	if err := util.WriteUint64(w, uint64(su.timestamp)); err != nil {
+		return err
+	}
+	if err := util.WriteUint64(w, uint64(su.gasUsed)); err != nil {
+		return err
+	}
+	if len(su.events) > math.MaxUint16 {
+		return fmt.Errorf("too many events in the state update: %d", len(su.events))
+	}
+	if err := util.WriteUint16(w, uint16(len(su.events))); err != nil {
+		return err
+	}
+	for _, ev := range su.events {
+		if err := ev.Write(w); err != nil {
+			return err
+		}
+	}
+	if err := su.results.Write(w); err != nil {
+		return err
+	}
+	return util.WriteString16(w, su.err)
 }
 
 func (su *stateUpdate) Read(r io.Reader) error {
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return err
+	}
+	if version[0] != stateUpdateVersion {
+		return fmt.Errorf("unsupported version of the state update: %d", version[0])
+	}
+	if err := su.readV0(r); err != nil {
+		return err
+	}
+	var gas uint64
+	if err := util.ReadUint64(r, &gas); err != nil {
+		return err
+	}
+	su.gasUsed = int(gas)
+	var numEvents uint16
+	if err := util.ReadUint16(r, &numEvents); err != nil {
+		return err
+	}
+	su.events = nil
+	for i := uint16(0); i < numEvents; i++ {
+		ev := &Event{}
+		if err := ev.Read(r); err != nil {
+			return err
+		}
+		su.events = append(su.events, ev)
+	}
+	su.results = kv.NewMap()
+	if err := su.results.Read(r); err != nil {
+		return err
+	}
+	var err error
+	su.err, err = util.ReadString16(r)
+	return err
+}
+
+// readV0 reads the part of the state update which is common with the version 0
+func (su *stateUpdate) readV0(r io.Reader) error {
 	if _, err := r.Read(su.requestId[:]); err != nil {
 		return err
 	}
@@ -26,9 +26,22 @@ type VirtualState interface {
 	ApplyBatch(Batch) error
 	// commit means saving virtual state to sc db, making it persistent (solid)
 	CommitToDb(batch Batch) error
-	// return hash of the variable state. It is a root of the Merkle chain of all
-	// state updates starting from the origin
-	Hash() hashing.HashValue
+	// return hash of the variable state. It commits to both the chain of all
+	// state updates starting from the origin and the Merkle root of variables.
+	// States before MerkleFrom have the hash of the chain
+	Hash() (hashing.HashValue, error)
+	// hash of the chain of all state updates starting from the origin
+	ChainHash() hashing.HashValue
+	// state index from which state hashes of the chain commit to the Merkle root.
+	// States before it were anchored by nodes without the Merkle tree
+	MerkleFrom() uint32
+	// clone of the state anchored with the hash of the chain only. Possible only for the first state
+	// with the Merkle root, i.e. if the previous state was anchored without it too
+	WithoutMerkleRoot() (VirtualState, bool)
+	// root of the sparse Merkle tree of variables. Proofs of values of variables are verified against it
+	MerkleRoot() (hashing.HashValue, error)
+	// proof of the value of the variable against the Merkle root
+	MerkleProof(key kv.Key) (*MerkleProof, error)
 	// the storage of variable/value pairs
 	Variables() kv.BufferedKVStore
 	Clone() VirtualState
@@ -45,6 +58,17 @@ type StateUpdate interface {
 	RequestId() *sctransaction.RequestId
 	Timestamp() int64
 	WithTimestamp(int64) StateUpdate
+	// gas burned by the request
+	GasUsed() int
+	WithGasUsed(int) StateUpdate
+	// events emitted by the request
+	Events() []*Event
+	AddEvent(*Event)
+	// values returned by the request
+	Results() kv.Map
+	// panic message if the request failed. Empty string means success
+	Error() string
+	WithError(string) StateUpdate
 	// the payload of variables/values
 	String() string
 	Mutations() kv.MutationSequence
@@ -54,6 +54,9 @@ type DKShare struct {
 	Aggregated bool              // true after DKG
 	Committed  bool              // true after DKG
 	PriShares  []*share.PriShare // nil after DKG
+	// public commitments to coefficients of the own random polynomial. Other nodes verify
+	// private shares against them. nil after DKG
+	Commits []kyber.Point
 }
 
 func ValidateDKSParams(t, n, index uint16) error {
@@ -79,22 +82,76 @@ func NewRndDKShare(t, n, index uint16) (*DKShare, error) {
 	// create private shares of the random polynomial
 	// with index n corresponds to p(n+1)
 	shares := priPoly.Shares(int(n))
+	_, commits := priPoly.Commit(nil).Info()
 	ret := &DKShare{
 		Suite:     suite,
 		N:         n,
 		T:         t,
 		Index:     index,
 		PriShares: shares,
+		Commits:   commits,
 	}
 	return ret, nil
 }
 
+// NewRefreshingDKShare starts the proactive refresh of the committed key share.
+// It generates random polynomial with zero secret. Shares of such polynomials of all nodes are added
+// to the private keys, so all private and public key shares change, while the master public key
+// and the address remain the same. The new key share goes through the same AggregateDKS and FinalizeDKS
+func (ks *DKShare) NewRefreshingDKShare() (*DKShare, error) {
+	if !ks.Committed || ks.priKey == nil {
+		return nil, errors.New("only committed key share with private key can be refreshed")
+	}
+	priPoly := share.NewPriPoly(ks.Suite.G2(), int(ks.T), ks.Suite.G2().Scalar().Zero(), ks.Suite.RandomStream())
+	_, commits := priPoly.Commit(nil).Info()
+	return &DKShare{
+		Suite:        ks.Suite,
+		N:            ks.N,
+		T:            ks.T,
+		Index:        ks.Index,
+		Address:      ks.Address,
+		PubKeyMaster: ks.PubKeyMaster,
+		priKey:       ks.Suite.G2().Scalar().Set(ks.priKey),
+		PriShares:    priPoly.Shares(int(ks.N)),
+		Commits:      commits,
+	}, nil
+}
+
+// ErasePrivate overwrites the private key in memory. The key share can't be used for signing after that
+func (ks *DKShare) ErasePrivate() {
+	if ks.priKey != nil {
+		ks.priKey.Zero()
+		ks.priKey = nil
+	}
+	ks.PriShares = nil
+}
+
+// VerifyPriShare checks the private share received from the node against the public commitments
+// to the polynomial of the node (Feldman VSS). When the key share is refreshed, the polynomial
+// must have zero secret, otherwise the master key would change
+func (ks *DKShare) VerifyPriShare(from uint16, commits []kyber.Point, priShare kyber.Scalar) error {
+	if len(commits) != int(ks.T) {
+		return fmt.Errorf("wrong number of commitments from the node #%d", from)
+	}
+	if ks.Address != nil && !commits[0].Equal(ks.Suite.G2().Point().Null()) {
+		return fmt.Errorf("polynomial of the node #%d doesn't have zero secret", from)
+	}
+	pubPoly := share.NewPubPoly(ks.Suite.G2(), nil, commits)
+	if !pubPoly.Check(&share.PriShare{I: int(ks.Index), V: priShare}) {
+		return fmt.Errorf("private share from the node #%d doesn't match its commitments", from)
+	}
+	return nil
+}
+
 func (ks *DKShare) AggregateDKS(priShares []kyber.Scalar) error {
 	if ks.Aggregated {
 		return errors.New("already Aggregated")
 	}
 	// aggregate (add up) secret shares
-	ks.priKey = ks.Suite.G2().Scalar().Zero()
+	// the refreshing key share starts from the old private key
+	if ks.priKey == nil {
+		ks.priKey = ks.Suite.G2().Scalar().Zero()
+	}
 	for i, pshare := range priShares {
 		if uint16(i) == ks.Index {
 			ks.priKey = ks.priKey.Add(ks.priKey, ks.PriShares[ks.Index].V)
@@ -119,6 +176,18 @@ func (ks *DKShare) FinalizeDKS(pubKeys []kyber.Point) error {
 		return err
 	}
 	pubKeyMaster := ks.PubPoly.Commit()
+	// public keys of all nodes must be on the same polynomial. Otherwise some node dealt
+	// private shares which are not on one polynomial
+	for i, pk := range ks.PubKeys {
+		if !ks.PubPoly.Eval(i).V.Equal(pk) {
+			return fmt.Errorf("inconsistent public key share of the node #%d", i)
+		}
+	}
+	// refreshed key share: the master public key must remain the same
+	if ks.Address != nil && !pubKeyMaster.Equal(ks.PubKeyMaster) {
+		return errors.New("refreshed key share changes the master public key")
+	}
+	ks.PubKeyMaster = pubKeyMaster
 	pubKeyBin, err := pubKeyMaster.MarshalBinary()
 	if err != nil {
 		return err
@@ -128,6 +197,7 @@ func (ks *DKShare) FinalizeDKS(pubKeys []kyber.Point) error {
 	ks.Address = &a
 
 	ks.PriShares = nil // not needed anymore
+	ks.Commits = nil
 	ks.Committed = true
 	return nil
 }
@@ -2,6 +2,7 @@ package tcrypto
 
 import (
 	"github.com/magiconair/properties/assert"
+	"go.dedis.ch/kyber/v3"
 	"testing"
 )
 
@@ -26,3 +27,102 @@ func TestNewRandomDKShare(t *testing.T) {
 	_, err = NewRndDKShare(4, 5, 6)
 	assert.Equal(t, err != nil, true)
 }
+
+// runs aggregation and finalization between key shares of all nodes in memory
+func exchangeShares(t *testing.T, shares []*DKShare) {
+	for _, ks := range shares {
+		priShares := make([]kyber.Scalar, len(shares))
+		for j := range shares {
+			priShares[j] = shares[j].PriShares[ks.Index].V
+			assert.Equal(t, ks.VerifyPriShare(uint16(j), shares[j].Commits, priShares[j]), nil)
+		}
+		assert.Equal(t, ks.AggregateDKS(priShares), nil)
+	}
+	pubKeys := make([]kyber.Point, len(shares))
+	for i, ks := range shares {
+		pubKeys[i] = ks.PubKeyOwn
+	}
+	for _, ks := range shares {
+		assert.Equal(t, ks.FinalizeDKS(pubKeys), nil)
+	}
+}
+
+func signWithShares(t *testing.T, shares []*DKShare, data []byte) {
+	sigShares := make([][]byte, 0, len(shares))
+	for _, ks := range shares {
+		sigShare, err := ks.SignShare(data)
+		assert.Equal(t, err, nil)
+		sigShares = append(sigShares, sigShare)
+	}
+	sig, err := shares[0].RecoverFullSignature(sigShares, data)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sig.Address(), *shares[0].Address)
+}
+
+func TestRefreshDKShare(t *testing.T) {
+	const n, threshold = 4, 3
+	data := []byte("data to sign")
+
+	shares := make([]*DKShare, n)
+	for i := range shares {
+		ks, err := NewRndDKShare(threshold, n, uint16(i))
+		assert.Equal(t, err, nil)
+		shares[i] = ks
+	}
+	exchangeShares(t, shares)
+	addr := *shares[0].Address
+	signWithShares(t, shares, data)
+
+	refreshed := make([]*DKShare, n)
+	for i := range refreshed {
+		ks, err := shares[i].NewRefreshingDKShare()
+		assert.Equal(t, err, nil)
+		refreshed[i] = ks
+	}
+	exchangeShares(t, refreshed)
+	for i := range refreshed {
+		assert.Equal(t, *refreshed[i].Address, addr)
+		assert.Equal(t, refreshed[i].PubKeyOwn.Equal(shares[i].PubKeyOwn), false)
+	}
+	signWithShares(t, refreshed, data)
+
+	// old and refreshed shares can't be combined
+	mixed := append([]*DKShare{shares[0]}, refreshed[1:]...)
+	sigShares := make([][]byte, 0, n)
+	for _, ks := range mixed {
+		sigShare, err := ks.SignShare(data)
+		assert.Equal(t, err, nil)
+		sigShares = append(sigShares, sigShare)
+	}
+	_, err := refreshed[0].RecoverFullSignature(sigShares[:threshold], data)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestVerifyPriShare(t *testing.T) {
+	const n, threshold = 4, 3
+
+	shares := make([]*DKShare, n)
+	for i := range shares {
+		ks, err := NewRndDKShare(threshold, n, uint16(i))
+		assert.Equal(t, err, nil)
+		shares[i] = ks
+	}
+	// share of another node or of another polynomial doesn't match the commitments
+	assert.Equal(t, shares[1].VerifyPriShare(0, shares[0].Commits, shares[0].PriShares[2].V) != nil, true)
+	assert.Equal(t, shares[1].VerifyPriShare(0, shares[2].Commits, shares[0].PriShares[1].V) != nil, true)
+	assert.Equal(t, shares[1].VerifyPriShare(0, shares[0].Commits[1:], shares[0].PriShares[1].V) != nil, true)
+
+	exchangeShares(t, shares)
+	refreshed := make([]*DKShare, n)
+	for i := range refreshed {
+		ks, err := shares[i].NewRefreshingDKShare()
+		assert.Equal(t, err, nil)
+		refreshed[i] = ks
+	}
+	assert.Equal(t, refreshed[1].VerifyPriShare(0, refreshed[0].Commits, refreshed[0].PriShares[1].V), nil)
+
+	// polynomial with non-zero secret can't be used for the refresh
+	ks, err := NewRndDKShare(threshold, n, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, refreshed[1].VerifyPriShare(0, ks.Commits, ks.PriShares[1].V) != nil, true)
+}
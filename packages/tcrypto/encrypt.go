@@ -0,0 +1,30 @@
+package tcrypto
+
+import (
+	"crypto/sha256"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"go.dedis.ch/kyber/v3/encrypt/ecies"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+)
+
+// private data, such as exported key shares, is encrypted for the ed25519 identity key of the recipient node
+// with ECIES over the same edwards25519 curve: ephemeral DH key + AES-GCM
+
+var edSuite = edwards25519.NewBlakeSHA256Ed25519()
+
+// EncryptForPubKey encrypts data, so it can only be decrypted with the private key of the public key
+func EncryptForPubKey(pubKey ed25519.PublicKey, data []byte) ([]byte, error) {
+	point := edSuite.Point()
+	if err := point.UnmarshalBinary(pubKey.Bytes()); err != nil {
+		return nil, err
+	}
+	return ecies.Encrypt(edSuite, point, data, sha256.New)
+}
+
+// DecryptWithPrivateKey decrypts data encrypted with EncryptForPubKey
+func DecryptWithPrivateKey(privateKey ed25519.PrivateKey, data []byte) ([]byte, error) {
+	// the scalar is derived from the seed the same way as in ed25519
+	scalar, _, _ := edSuite.NewKeyAndSeedWithInput(privateKey.Bytes()[:ed25519.SeedSize])
+	defer scalar.Zero()
+	return ecies.Decrypt(edSuite, scalar, data, sha256.New)
+}
@@ -0,0 +1,27 @@
+package tcrypto
+
+import (
+	"testing"
+
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptForPubKey(t *testing.T) {
+	pubKey, privateKey, err := ed25519.GenerateKey()
+	assert.NoError(t, err)
+	data := []byte("private key share")
+
+	enc, err := EncryptForPubKey(pubKey, data)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(enc), string(data))
+
+	dec, err := DecryptWithPrivateKey(privateKey, enc)
+	assert.NoError(t, err)
+	assert.Equal(t, data, dec)
+
+	_, otherKey, err := ed25519.GenerateKey()
+	assert.NoError(t, err)
+	_, err = DecryptWithPrivateKey(otherKey, enc)
+	assert.Error(t, err)
+}
@@ -1,7 +1,9 @@
 package builtin
 
 import (
+	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm/vmconst"
 	"github.com/iotaledger/wasp/packages/vm/vmtypes"
 )
@@ -15,6 +17,9 @@ var Processor = builtinProcessor{
 	vmconst.RequestCodeInit:             initRequest,
 	vmconst.RequestCodeSetMinimumReward: setMinimumReward,
 	vmconst.RequestCodeSetDescription:   setDescription,
+	vmconst.RequestCodeSetGasLimit:      setGasLimit,
+	vmconst.RequestCodeUpgrade:          upgradeRequest,
+	vmconst.RequestCodeRotateCommittee:  rotateRequest,
 }
 
 func (v *builtinProcessor) GetEntryPoint(code sctransaction.RequestCode) (vmtypes.EntryPoint, bool) {
@@ -29,8 +34,8 @@ func (ep builtinEntryPoint) Run(ctx vmtypes.Sandbox) {
 	ep(ctx)
 }
 
-func (v builtinEntryPoint) WithGasLimit(_ int) vmtypes.EntryPoint {
-	return v
+func (v builtinEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(v, gas)
 }
 
 func stub(ctx vmtypes.Sandbox, text string) {
@@ -78,7 +83,58 @@ func initRequest(ctx vmtypes.Sandbox) {
 		return
 	}
 	ctx.GetWaspLog().Debugf("initRequest: Setting program hash to %s.", progHash.String())
+	setProgramHash(ctx, progHash)
+}
+
+// upgradeRequest moves the smart contract to the new program hash. The state is kept.
+// Loading of the new processor and running its migration entry point is done by the VM runner
+func upgradeRequest(ctx vmtypes.Sandbox) {
+	stub(ctx, "upgradeRequest")
+	progHash, ok, err := ctx.AccessRequest().Args().GetHashValue(vmconst.VarNameProgramHash)
+	if err != nil {
+		ctx.GetWaspLog().Errorf("upgradeRequest: Could not read request argument: %s", err.Error())
+		return
+	}
+	if !ok {
+		ctx.GetWaspLog().Debugf("upgradeRequest: program hash not set.")
+		return
+	}
+	if oldHash, ok := ctx.AccessState().GetHashValue(vmconst.VarNameProgramHash); ok && *oldHash == *progHash {
+		ctx.GetWaspLog().Debugf("upgradeRequest: program hash %s is already set.", progHash.String())
+		return
+	}
+	ctx.GetWaspLog().Infof("upgradeRequest: Setting program hash to %s.", progHash.String())
+	setProgramHash(ctx, progHash)
+}
+
+// rotateRequest approves the new address of the smart contract, controlled by the new committee.
+// Moving the smart contract token and all funds to the new address is done by the VM runner.
+// Committee nodes hand the state over to the new committee when the state transaction is confirmed
+func rotateRequest(ctx vmtypes.Sandbox) {
+	stub(ctx, "rotateRequest")
+	newAddr, ok, err := ctx.AccessRequest().Args().GetAddress(vmconst.VarNameSCAddress)
+	if err != nil {
+		ctx.GetWaspLog().Errorf("rotateRequest: Could not read request argument: %s", err.Error())
+		return
+	}
+	if !ok {
+		ctx.GetWaspLog().Debugf("rotateRequest: new address not set.")
+		return
+	}
+	if *newAddr == *ctx.GetSCAddress() {
+		ctx.GetWaspLog().Debugf("rotateRequest: smart contract is already at address %s.", newAddr.String())
+		return
+	}
+	ctx.GetWaspLog().Infof("rotateRequest: rotating smart contract to address %s.", newAddr.String())
+	ctx.AccessState().SetAddress(vmconst.VarNameSCAddress, newAddr)
+}
+
+// setProgramHash sets the program hash and appends it to the history
+func setProgramHash(ctx vmtypes.Sandbox, progHash *hashing.HashValue) {
 	ctx.AccessState().SetHashValue(vmconst.VarNameProgramHash, progHash)
+	// the update is settled in the next state
+	rec := append(util.Uint32To4Bytes(ctx.GetStateIndex()+1), progHash[:]...)
+	ctx.AccessState().GetArray(vmconst.VarNameProgramHashHistory).Push(rec)
 }
 
 func setMinimumReward(ctx vmtypes.Sandbox) {
@@ -88,6 +144,20 @@ func setMinimumReward(ctx vmtypes.Sandbox) {
 	}
 }
 
+// setGasLimit sets the gas limit of one request. Values above vmconst.MaxGasLimit are clamped
+func setGasLimit(ctx vmtypes.Sandbox) {
+	stub(ctx, "setGasLimit")
+	v, ok, _ := ctx.AccessRequest().Args().GetInt64("value")
+	if !ok || v <= 0 {
+		return
+	}
+	if v > vmconst.MaxGasLimit {
+		ctx.GetWaspLog().Debugf("setGasLimit: %d exceeds maximum, set to %d.", v, vmconst.MaxGasLimit)
+		v = vmconst.MaxGasLimit
+	}
+	ctx.AccessState().SetInt64(vmconst.VarNameGasLimit, v)
+}
+
 func setDescription(ctx vmtypes.Sandbox) {
 	stub(ctx, "setDescription")
 	if v, ok, _ := ctx.AccessRequest().Args().GetString("value"); ok && v != "" {
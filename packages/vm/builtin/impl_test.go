@@ -0,0 +1,92 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/sandbox"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/stretchr/testify/assert"
+)
+
+func programHashArgs(h *hashing.HashValue) kv.Map {
+	args := kv.NewMap()
+	args.Codec().SetHashValue(vmconst.VarNameProgramHash, h)
+	return args
+}
+
+func TestUpgrade(t *testing.T) {
+	oldHash := hashing.RandomHash(nil)
+	newHash := hashing.RandomHash(nil)
+	sb := sandbox.NewMockedSandbox()
+	owner := *sb.GetOwnerAddress()
+
+	args := programHashArgs(oldHash)
+	args.Codec().SetAddress(vmconst.VarNameOwnerAddress, &owner)
+	sb.WithRequest(vmconst.RequestCodeInit, owner, args, nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeInit]))
+
+	sb.WithRequest(vmconst.RequestCodeUpgrade, owner, programHashArgs(newHash), nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeUpgrade]))
+
+	h, ok := sb.State().GetHashValue(vmconst.VarNameProgramHash)
+	assert.True(t, ok)
+	assert.Equal(t, *newHash, *h)
+
+	history := sb.State().GetArray(vmconst.VarNameProgramHashHistory)
+	assert.EqualValues(t, 2, history.Len())
+	assert.Equal(t, append(util.Uint32To4Bytes(1), oldHash[:]...), history.GetAt(0))
+	assert.Equal(t, newHash[:], history.GetAt(1)[4:])
+
+	// upgrade to the same program hash does nothing
+	sb.WithRequest(vmconst.RequestCodeUpgrade, owner, programHashArgs(newHash), nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeUpgrade]))
+	assert.Equal(t, 0, sb.Mutations().Len())
+}
+
+func TestRotate(t *testing.T) {
+	sb := sandbox.NewMockedSandbox()
+	owner := *sb.GetOwnerAddress()
+	newAddr := address.Random()
+
+	args := kv.NewMap()
+	args.Codec().SetAddress(vmconst.VarNameSCAddress, &newAddr)
+	sb.WithRequest(vmconst.RequestCodeRotateCommittee, owner, args, nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeRotateCommittee]))
+
+	addr, ok := sb.State().GetAddress(vmconst.VarNameSCAddress)
+	assert.True(t, ok)
+	assert.Equal(t, newAddr, *addr)
+
+	// rotation to the current address does nothing
+	args = kv.NewMap()
+	args.Codec().SetAddress(vmconst.VarNameSCAddress, sb.GetSCAddress())
+	sb.WithRequest(vmconst.RequestCodeRotateCommittee, owner, args, nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeRotateCommittee]))
+	assert.Equal(t, 0, sb.Mutations().Len())
+}
+
+func TestSetGasLimit(t *testing.T) {
+	sb := sandbox.NewMockedSandbox()
+	owner := *sb.GetOwnerAddress()
+
+	args := kv.NewMap()
+	args.Codec().SetInt64("value", 5000)
+	sb.WithRequest(vmconst.RequestCodeSetGasLimit, owner, args, nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeSetGasLimit]))
+	v, ok := sb.State().GetInt64(vmconst.VarNameGasLimit)
+	assert.True(t, ok)
+	assert.EqualValues(t, 5000, v)
+
+	// limits above the maximum are clamped
+	args = kv.NewMap()
+	args.Codec().SetInt64("value", vmconst.MaxGasLimit+1)
+	sb.WithRequest(vmconst.RequestCodeSetGasLimit, owner, args, nil)
+	assert.NoError(t, sb.Run(Processor[vmconst.RequestCodeSetGasLimit]))
+	v, ok = sb.State().GetInt64(vmconst.VarNameGasLimit)
+	assert.True(t, ok)
+	assert.EqualValues(t, vmconst.MaxGasLimit, v)
+}
@@ -79,8 +79,8 @@ func (ep fairAuctionEntryPoint) Run(ctx vmtypes.Sandbox) {
 	ep(ctx)
 }
 
-func (ep fairAuctionEntryPoint) WithGasLimit(_ int) vmtypes.EntryPoint {
-	return ep
+func (ep fairAuctionEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(ep, gas)
 }
 
 type AuctionInfo struct {
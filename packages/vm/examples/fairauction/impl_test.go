@@ -0,0 +1,178 @@
+package fairauction
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/sandbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func run(t *testing.T, sb *sandbox.MockedSandbox, code sctransaction.RequestCode) {
+	ep, ok := GetProcessor().GetEntryPoint(code)
+	assert.True(t, ok)
+	assert.NoError(t, sb.Run(ep))
+}
+
+func colorArgs(col balance.Color) kv.Map {
+	args := kv.NewMap()
+	args.Codec().SetHashValue(VarReqAuctionColor, (*hashing.HashValue)(&col))
+	return args
+}
+
+func auctionInfo(t *testing.T, sb *sandbox.MockedSandbox, col balance.Color) *AuctionInfo {
+	data := sb.State().GetDictionary(VarStateAuctions).GetAt(col.Bytes())
+	if data == nil {
+		return nil
+	}
+	ai := &AuctionInfo{}
+	assert.NoError(t, ai.Read(bytes.NewReader(data)))
+	return ai
+}
+
+func runStartAuction(t *testing.T, sb *sandbox.MockedSandbox, seller address.Address, col balance.Color) {
+	args := colorArgs(col)
+	args.Codec().SetInt64(VarReqStartAuctionMinimumBid, 1000)
+	args.Codec().SetInt64(VarReqStartAuctionDurationMinutes, 10)
+	args.Codec().SetString(VarReqStartAuctionDescription, "test lot")
+	sb.WithRequest(RequestStartAuction, seller, args, map[balance.Color]int64{
+		balance.ColorIOTA: 100,
+		col:               10,
+	})
+	run(t, sb, RequestStartAuction)
+}
+
+func TestStartAuction(t *testing.T) {
+	seller := address.Random()
+	col := (balance.Color)(*hashing.RandomHash(nil))
+	sb := sandbox.NewMockedSandbox().WithTimestamp(int64(1000 * 1e9))
+
+	runStartAuction(t, sb, seller, col)
+
+	ai := auctionInfo(t, sb, col)
+	assert.NotNil(t, ai)
+	assert.Equal(t, seller, ai.AuctionOwner)
+	assert.EqualValues(t, 10, ai.NumTokens)
+	assert.EqualValues(t, 1000, ai.MinimumBid)
+	assert.EqualValues(t, 100, ai.TotalDeposit)
+	assert.EqualValues(t, OwnerMarginDefault, ai.OwnerMargin)
+	assert.Equal(t, "test lot", ai.Description)
+	assert.Contains(t, sb.Published(), "startAuction: success")
+
+	// finalization is time locked for the duration of the auction
+	reqs := sb.SentRequests()
+	assert.Equal(t, 1, len(reqs))
+	assert.Equal(t, RequestFinalizeAuction, reqs[0].RequestCode())
+	assert.EqualValues(t, 1000+10*60, reqs[0].Timelock())
+	colh, ok, err := reqs[0].Args().GetHashValue(VarReqAuctionColor)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, col, (balance.Color)(*colh))
+
+	// second auction for the same color is refused, tokens for sale are returned
+	runStartAuction(t, sb, seller, col)
+	assert.Contains(t, sb.Published(), "startAuction: exit 6")
+	assert.EqualValues(t, 10, sb.Outputs(&seller)[col])
+	assert.Equal(t, 0, len(sb.SentRequests()))
+}
+
+func TestAuctionWithWinner(t *testing.T) {
+	seller := address.Random()
+	bidder1 := address.Random()
+	bidder2 := address.Random()
+	col := (balance.Color)(*hashing.RandomHash(nil))
+	sb := sandbox.NewMockedSandbox()
+	scAddress := *sb.GetSCAddress()
+	owner := *sb.GetOwnerAddress()
+
+	runStartAuction(t, sb, seller, col)
+
+	sb.WithRequest(RequestPlaceBid, bidder1, colorArgs(col), map[balance.Color]int64{balance.ColorIOTA: 1500})
+	run(t, sb, RequestPlaceBid)
+	sb.WithRequest(RequestPlaceBid, bidder2, colorArgs(col), map[balance.Color]int64{balance.ColorIOTA: 1200})
+	run(t, sb, RequestPlaceBid)
+	// rise of the bid
+	sb.WithRequest(RequestPlaceBid, bidder2, colorArgs(col), map[balance.Color]int64{balance.ColorIOTA: 100})
+	run(t, sb, RequestPlaceBid)
+
+	ai := auctionInfo(t, sb, col)
+	assert.Equal(t, 2, len(ai.Bids))
+	assert.EqualValues(t, 1500, ai.Bids[0].Total)
+	assert.EqualValues(t, 1300, ai.Bids[1].Total)
+
+	// finalize request is accepted only from the smart contract itself
+	sb.WithRequest(RequestFinalizeAuction, seller, colorArgs(col), nil)
+	run(t, sb, RequestFinalizeAuction)
+	assert.Equal(t, 0, sb.Mutations().Len())
+
+	sb.WithBalances(map[balance.Color]int64{
+		balance.ColorIOTA: 100 + 1500 + 1300,
+		col:               10,
+	}).WithRequest(RequestFinalizeAuction, scAddress, colorArgs(col), nil)
+	run(t, sb, RequestFinalizeAuction)
+
+	ownerFee := int64(1500 * OwnerMarginDefault / 1000)
+	assert.EqualValues(t, 10, sb.Outputs(&bidder1)[col])
+	assert.EqualValues(t, 0, sb.Outputs(&bidder1)[balance.ColorIOTA])
+	assert.EqualValues(t, 1300, sb.Outputs(&bidder2)[balance.ColorIOTA])
+	assert.EqualValues(t, 1500+100-ownerFee, sb.Outputs(&seller)[balance.ColorIOTA])
+	assert.EqualValues(t, ownerFee, sb.Outputs(&owner)[balance.ColorIOTA])
+	assert.Nil(t, auctionInfo(t, sb, col))
+}
+
+func TestAuctionWithoutWinner(t *testing.T) {
+	seller := address.Random()
+	bidder := address.Random()
+	col := (balance.Color)(*hashing.RandomHash(nil))
+	sb := sandbox.NewMockedSandbox()
+	scAddress := *sb.GetSCAddress()
+
+	runStartAuction(t, sb, seller, col)
+
+	// bid below minimum
+	sb.WithRequest(RequestPlaceBid, bidder, colorArgs(col), map[balance.Color]int64{balance.ColorIOTA: 500})
+	run(t, sb, RequestPlaceBid)
+
+	sb.WithBalances(map[balance.Color]int64{
+		balance.ColorIOTA: 100 + 500,
+		col:               10,
+	}).WithRequest(RequestFinalizeAuction, scAddress, colorArgs(col), nil)
+	run(t, sb, RequestFinalizeAuction)
+
+	ownerFee := int64(1000 * OwnerMarginDefault / 1000)
+	assert.EqualValues(t, 10, sb.Outputs(&seller)[col])
+	assert.EqualValues(t, 100-ownerFee, sb.Outputs(&seller)[balance.ColorIOTA])
+	assert.EqualValues(t, 500, sb.Outputs(&bidder)[balance.ColorIOTA])
+	assert.Nil(t, auctionInfo(t, sb, col))
+}
+
+func TestPlaceBidNoAuction(t *testing.T) {
+	bidder := address.Random()
+	col := (balance.Color)(*hashing.RandomHash(nil))
+	sb := sandbox.NewMockedSandbox().
+		WithRequest(RequestPlaceBid, bidder, colorArgs(col), map[balance.Color]int64{balance.ColorIOTA: 500})
+
+	run(t, sb, RequestPlaceBid)
+
+	// everything is refunded
+	assert.Equal(t, 0, sb.Mutations().Len())
+	assert.EqualValues(t, 500, sb.Outputs(&bidder)[balance.ColorIOTA])
+	assert.Contains(t, sb.Published(), "placeBid: exit 4")
+}
+
+func TestSetOwnerMargin(t *testing.T) {
+	sb := sandbox.NewMockedSandbox()
+	args := kv.NewMap()
+	args.Codec().SetInt64(VarReqOwnerMargin, 1000)
+	sb.WithRequest(RequestSetOwnerMargin, *sb.GetOwnerAddress(), args, nil)
+	run(t, sb, RequestSetOwnerMargin)
+
+	margin, ok := sb.State().GetInt64(VarStateOwnerMarginPromille)
+	assert.True(t, ok)
+	assert.EqualValues(t, OwnerMarginMax, margin)
+}
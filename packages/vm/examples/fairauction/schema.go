@@ -0,0 +1,51 @@
+package fairauction
+
+import (
+	"github.com/iotaledger/wasp/packages/schema"
+)
+
+var contractSchema = &schema.Schema{
+	Name: "FairAuction",
+	EntryPoints: []schema.EntryPoint{
+		{Name: "initSC", Code: RequestInitSC},
+		{
+			Name: "startAuction",
+			Code: RequestStartAuction,
+			Args: []schema.Arg{
+				{Name: VarReqAuctionColor, Type: schema.ValueTypeHash},
+				{Name: VarReqStartAuctionDescription, Type: schema.ValueTypeString, Optional: true},
+				{Name: VarReqStartAuctionDurationMinutes, Type: schema.ValueTypeInt64, Optional: true},
+				{Name: VarReqStartAuctionMinimumBid, Type: schema.ValueTypeInt64, Optional: true},
+			},
+		},
+		{
+			Name: "finalizeAuction",
+			Code: RequestFinalizeAuction,
+			Args: []schema.Arg{{Name: VarReqAuctionColor, Type: schema.ValueTypeHash}},
+		},
+		{
+			Name: "placeBid",
+			Code: RequestPlaceBid,
+			Args: []schema.Arg{{Name: VarReqAuctionColor, Type: schema.ValueTypeHash}},
+		},
+		{
+			Name:      "setOwnerMargin",
+			Code:      RequestSetOwnerMargin,
+			Protected: true,
+			Args:      []schema.Arg{{Name: VarReqOwnerMargin, Type: schema.ValueTypeInt64}},
+		},
+	},
+	StateVars: []schema.StateVar{
+		{
+			Key:         VarStateAuctions,
+			Type:        schema.ValueTypeBytes,
+			Collection:  schema.CollectionDict,
+			Description: "color => encoded AuctionInfo",
+		},
+		{Key: VarStateOwnerMarginPromille, Type: schema.ValueTypeInt64, Description: "owner margin in promilles"},
+	},
+}
+
+func (v fairAuctionProcessor) GetSchema() *schema.Schema {
+	return contractSchema
+}
@@ -35,6 +35,8 @@ type fairRouletteProcessor map[sctransaction.RequestCode]fairRouletteEntryPoint
 
 type fairRouletteEntryPoint func(ctx vmtypes.Sandbox)
 
+type fairRouletteViewEntryPoint func(ctx vmtypes.SandboxView)
+
 const (
 	// request to place the bet. Public
 	RequestPlaceBet = sctransaction.RequestCode(uint16(1))
@@ -55,6 +57,16 @@ var entryPoints = fairRouletteProcessor{
 	RequestSetPlayPeriod:     setPlayPeriod,
 }
 
+const (
+	// view returns statistics of the player
+	ViewPlayerStats = sctransaction.RequestCode(uint16(1))
+)
+
+// read-only view entry points
+var viewEntryPoints = map[sctransaction.RequestCode]fairRouletteViewEntryPoint{
+	ViewPlayerStats: viewPlayerStats,
+}
+
 const (
 	ProgramHash = "FNT6snmmEM28duSg7cQomafbJ5fs596wtuNRn18wfaAz"
 
@@ -65,9 +77,9 @@ const (
 	DefaultPlaySecondsAfterFirstBet = 120
 
 	/// State variables
-	// state array to store all current bets
+	// state array (32-bit) to store all current bets
 	StateVarBets = "bets"
-	// state array to store locked bets
+	// state array (32-bit) to store locked bets
 	StateVarLockedBets = "lockedBets"
 	// state variable to store last winning color. Just for information
 	StateVarLastWinningColor = "lastWinningColor"
@@ -86,6 +98,13 @@ const (
 	ReqVarColor = "color"
 	// specify play period in seconds
 	ReqVarPlayPeriodSec = "playPeriod"
+
+	/// View parameters and results
+	// address of the player
+	ViewParamPlayer = "player"
+	// number of bets and wins of the player
+	ViewResultBets = "bets"
+	ViewResultWins = "wins"
 )
 
 type BetInfo struct {
@@ -109,9 +128,17 @@ func (f fairRouletteProcessor) GetEntryPoint(code sctransaction.RequestCode) (vm
 	return ep, ok
 }
 
-// WithGasLimit: not implemented, has no effect
-func (f fairRouletteEntryPoint) WithGasLimit(i int) vmtypes.EntryPoint {
-	return f
+func (f fairRouletteProcessor) GetViewEntryPoint(code sctransaction.RequestCode) (vmtypes.ViewEntryPoint, bool) {
+	ep, ok := viewEntryPoints[code]
+	return ep, ok
+}
+
+func (f fairRouletteViewEntryPoint) Run(ctx vmtypes.SandboxView) {
+	f(ctx)
+}
+
+func (f fairRouletteEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(f, gas)
 }
 
 func (f fairRouletteEntryPoint) Run(ctx vmtypes.Sandbox) {
@@ -129,7 +156,7 @@ func placeBet(ctx vmtypes.Sandbox) {
 	// if there are some bets locked, save the entropy derived immediately from it.
 	// it is not predictable at the moment of locking and this saving makes it not playable later
 	// entropy saved this way is derived (hashed) from the locking transaction hash
-	if state.GetArray(StateVarLockedBets).Len() > 0 {
+	if state.GetArray32(StateVarLockedBets).Len() > 0 {
 		_, ok := state.GetHashValue(StateVarEntropyFromLocking)
 		if !ok {
 			ehv := ctx.GetEntropy()
@@ -159,7 +186,7 @@ func placeBet(ctx vmtypes.Sandbox) {
 		ctx.Publish("wrong request, no Color specified")
 		return
 	}
-	firstBet := state.GetArray(StateVarBets).Len() == 0
+	firstBet := state.GetArray32(StateVarBets).Len() == 0
 
 	reqid := ctx.AccessRequest().ID()
 	betInfo := &BetInfo{
@@ -170,7 +197,7 @@ func placeBet(ctx vmtypes.Sandbox) {
 	}
 
 	// save the bet info in the array
-	state.GetArray(StateVarBets).Push(encodeBetInfo(betInfo))
+	state.GetArray32(StateVarBets).Push(encodeBetInfo(betInfo))
 
 	ctx.Publishf("Place bet: player: %s sum: %d color: %d req: %s", sender.String(), sum, col, reqid.Short())
 
@@ -226,9 +253,9 @@ func lockBets(ctx vmtypes.Sandbox) {
 	}
 	state := ctx.AccessState()
 	// append all current bets to the locked bets array
-	lockedBets := state.GetArray(StateVarLockedBets)
-	lockedBets.Extend(state.GetArray(StateVarBets))
-	state.GetArray(StateVarBets).Erase()
+	lockedBets := state.GetArray32(StateVarLockedBets)
+	lockedBets.Extend(state.GetArray32(StateVarBets))
+	state.GetArray32(StateVarBets).Erase()
 
 	numLockedBets := lockedBets.Len()
 	ctx.Publishf("lockBets: num = %d", numLockedBets)
@@ -251,7 +278,7 @@ func playAndDistribute(ctx vmtypes.Sandbox) {
 	}
 	state := ctx.AccessState()
 
-	lockedBetsArray := state.GetArray(StateVarLockedBets)
+	lockedBetsArray := state.GetArray32(StateVarLockedBets)
 	numLockedBets := lockedBetsArray.Len()
 	if numLockedBets == 0 {
 		// nothing to play. Should not happen
@@ -280,7 +307,7 @@ func playAndDistribute(ctx vmtypes.Sandbox) {
 	totalLockedAmount := int64(0)
 	lockedBets := make([]*BetInfo, numLockedBets)
 	for i := range lockedBets {
-		bi, err := DecodeBetInfo(lockedBetsArray.GetAt(uint16(i)))
+		bi, err := DecodeBetInfo(lockedBetsArray.GetAt(uint32(i)))
 		if err != nil {
 			// inconsistency. Even more sad
 			panic(err)
@@ -507,3 +534,17 @@ func withPlayerStats(ctx vmtypes.Sandbox, player *address.Address, f func(ps *Pl
 
 	return nil
 }
+
+// viewPlayerStats returns number of bets and wins of the player
+func viewPlayerStats(ctx vmtypes.SandboxView) {
+	player, ok, err := ctx.Params().GetAddress(ViewParamPlayer)
+	if err != nil || !ok {
+		ctx.Panic(fmt.Errorf("parameter '%s' is missing or wrong", ViewParamPlayer))
+	}
+	stats, err := DecodePlayerStats(ctx.AccessState().GetDictionary(StateVarPlayerStats).GetAt(player.Bytes()))
+	if err != nil {
+		ctx.Panic(err)
+	}
+	ctx.AccessResults().SetInt64(ViewResultBets, int64(stats.Bets))
+	ctx.AccessResults().SetInt64(ViewResultWins, int64(stats.Wins))
+}
@@ -0,0 +1,225 @@
+package fairroulette
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/sandbox"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func run(t *testing.T, sb *sandbox.MockedSandbox, code sctransaction.RequestCode) {
+	ep, ok := GetProcessor().GetEntryPoint(code)
+	assert.True(t, ok)
+	assert.NoError(t, sb.Run(ep))
+}
+
+func colorArg(col int64) kv.Map {
+	args := kv.NewMap()
+	args.Codec().SetInt64(ReqVarColor, col)
+	return args
+}
+
+// entropy which makes 'col' the winning color
+func entropyForColor(col byte) hashing.HashValue {
+	var ret hashing.HashValue
+	ret[0] = col
+	return ret
+}
+
+func TestPlaceBet(t *testing.T) {
+	player := address.Random()
+	sb := sandbox.NewMockedSandbox().
+		WithTimestamp(int64(1000*1e9)).
+		WithRequest(RequestPlaceBet, player, colorArg(2), map[balance.Color]int64{balance.ColorIOTA: 1000})
+
+	run(t, sb, RequestPlaceBet)
+
+	bets := sb.State().GetArray32(StateVarBets)
+	assert.EqualValues(t, 1, bets.Len())
+	bi, err := DecodeBetInfo(bets.GetAt(0))
+	assert.NoError(t, err)
+	assert.Equal(t, player, bi.Player)
+	assert.EqualValues(t, 1000, bi.Sum)
+	assert.EqualValues(t, 2, bi.Color)
+
+	stats, err := DecodePlayerStats(sb.State().GetDictionary(StateVarPlayerStats).GetAt(player.Bytes()))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Bets)
+
+	// first bet sends time locked request to lock bets
+	reqs := sb.SentRequests()
+	assert.Equal(t, 1, len(reqs))
+	assert.Equal(t, RequestLockBets, reqs[0].RequestCode())
+	assert.EqualValues(t, 1000+DefaultPlaySecondsAfterFirstBet, reqs[0].Timelock())
+	assert.Contains(t, sb.Published(), "placeBet")
+
+	// second bet does not send request
+	sb.WithRequest(RequestPlaceBet, player, colorArg(3), map[balance.Color]int64{balance.ColorIOTA: 500})
+	run(t, sb, RequestPlaceBet)
+	assert.EqualValues(t, 2, sb.State().GetArray32(StateVarBets).Len())
+	assert.Equal(t, 0, len(sb.SentRequests()))
+}
+
+func TestViewPlayerStats(t *testing.T) {
+	player := address.Random()
+	sb := sandbox.NewMockedSandbox().
+		WithRequest(RequestPlaceBet, player, colorArg(2), map[balance.Color]int64{balance.ColorIOTA: 1000})
+	run(t, sb, RequestPlaceBet)
+
+	ep, ok := GetProcessor().(vmtypes.ViewProcessor).GetViewEntryPoint(ViewPlayerStats)
+	assert.True(t, ok)
+	params := kv.NewMap()
+	params.Codec().SetAddress(ViewParamPlayer, &player)
+	res, err := sb.RunView(ep, params)
+	assert.NoError(t, err)
+	bets, _, _ := res.Codec().GetInt64(ViewResultBets)
+	wins, _, _ := res.Codec().GetInt64(ViewResultWins)
+	assert.EqualValues(t, 1, bets)
+	assert.EqualValues(t, 0, wins)
+
+	_, err = sb.RunView(ep, nil)
+	assert.Error(t, err)
+}
+
+func TestPlaceBetNoColor(t *testing.T) {
+	sb := sandbox.NewMockedSandbox().
+		WithRequest(RequestPlaceBet, address.Random(), nil, map[balance.Color]int64{balance.ColorIOTA: 1000})
+
+	run(t, sb, RequestPlaceBet)
+
+	assert.Equal(t, 0, sb.Mutations().Len())
+	assert.Contains(t, sb.Published(), "wrong request, no Color specified")
+}
+
+func TestLockBetsNotFromSelf(t *testing.T) {
+	sb := sandbox.NewMockedSandbox().
+		WithRequest(RequestPlaceBet, address.Random(), colorArg(1), map[balance.Color]int64{balance.ColorIOTA: 1000})
+	run(t, sb, RequestPlaceBet)
+
+	sb.WithRequest(RequestLockBets, address.Random(), nil, nil)
+	run(t, sb, RequestLockBets)
+
+	assert.Equal(t, 0, sb.Mutations().Len())
+	assert.Equal(t, 0, len(sb.SentRequests()))
+}
+
+func TestPlayAndDistribute(t *testing.T) {
+	player1 := address.Random()
+	player2 := address.Random()
+	player3 := address.Random()
+
+	sb := sandbox.NewMockedSandbox()
+	scAddress := *sb.GetSCAddress()
+
+	sb.WithRequest(RequestPlaceBet, player1, colorArg(2), map[balance.Color]int64{balance.ColorIOTA: 1000})
+	run(t, sb, RequestPlaceBet)
+	sb.WithRequest(RequestPlaceBet, player2, colorArg(2), map[balance.Color]int64{balance.ColorIOTA: 3000})
+	run(t, sb, RequestPlaceBet)
+	sb.WithRequest(RequestPlaceBet, player3, colorArg(4), map[balance.Color]int64{balance.ColorIOTA: 4000})
+	run(t, sb, RequestPlaceBet)
+
+	// sending request takes 1 iota for the request token
+	sb.WithBalances(map[balance.Color]int64{balance.ColorIOTA: 1}).
+		WithRequest(RequestLockBets, scAddress, nil, nil)
+	run(t, sb, RequestLockBets)
+	assert.EqualValues(t, 0, sb.State().GetArray32(StateVarBets).Len())
+	assert.EqualValues(t, 3, sb.State().GetArray32(StateVarLockedBets).Len())
+	reqs := sb.SentRequests()
+	assert.Equal(t, 1, len(reqs))
+	assert.Equal(t, RequestPlayAndDistribute, reqs[0].RequestCode())
+
+	sb.WithBalances(map[balance.Color]int64{balance.ColorIOTA: 8000}).
+		WithEntropy(entropyForColor(2)).
+		WithRequest(RequestPlayAndDistribute, scAddress, nil, nil)
+	run(t, sb, RequestPlayAndDistribute)
+
+	lastWinningColor, ok := sb.State().GetInt64(StateVarLastWinningColor)
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, lastWinningColor)
+	assert.EqualValues(t, 0, sb.State().GetArray32(StateVarLockedBets).Len())
+
+	// total is distributed proportionally to winning bets
+	assert.EqualValues(t, 2000, sb.Outputs(&player1)[balance.ColorIOTA])
+	assert.EqualValues(t, 6000, sb.Outputs(&player2)[balance.ColorIOTA])
+	assert.EqualValues(t, 0, sb.Outputs(&player3)[balance.ColorIOTA])
+
+	winsPerColor := sb.State().GetArray(StateArrayWinsPerColor)
+	assert.EqualValues(t, NumColors, winsPerColor.Len())
+	assert.EqualValues(t, 1, util.Uint32From4Bytes(winsPerColor.GetAt(2)))
+
+	stats, err := DecodePlayerStats(sb.State().GetDictionary(StateVarPlayerStats).GetAt(player2.Bytes()))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Bets)
+	assert.EqualValues(t, 1, stats.Wins)
+}
+
+func TestPlayAndDistributeNobodyWins(t *testing.T) {
+	player := address.Random()
+	sb := sandbox.NewMockedSandbox()
+	scAddress := *sb.GetSCAddress()
+
+	sb.WithRequest(RequestPlaceBet, player, colorArg(1), map[balance.Color]int64{balance.ColorIOTA: 1000})
+	run(t, sb, RequestPlaceBet)
+	// sending request takes 1 iota for the request token
+	sb.WithBalances(map[balance.Color]int64{balance.ColorIOTA: 1}).
+		WithRequest(RequestLockBets, scAddress, nil, nil)
+	run(t, sb, RequestLockBets)
+
+	sb.WithBalances(map[balance.Color]int64{balance.ColorIOTA: 1000}).
+		WithEntropy(entropyForColor(3)).
+		WithRequest(RequestPlayAndDistribute, scAddress, nil, nil)
+	run(t, sb, RequestPlayAndDistribute)
+
+	assert.EqualValues(t, 0, sb.Outputs(&player)[balance.ColorIOTA])
+	assert.EqualValues(t, 1000, sb.Outputs(&scAddress)[balance.ColorIOTA])
+}
+
+func TestSetPlayPeriod(t *testing.T) {
+	sb := sandbox.NewMockedSandbox()
+	args := kv.NewMap()
+	args.Codec().SetInt64(ReqVarPlayPeriodSec, 5)
+	sb.WithRequest(RequestSetPlayPeriod, *sb.GetOwnerAddress(), args, nil)
+	run(t, sb, RequestSetPlayPeriod)
+	// less than minimum is ignored
+	assert.Equal(t, 0, sb.Mutations().Len())
+
+	args.Codec().SetInt64(ReqVarPlayPeriodSec, 30)
+	sb.WithRequest(RequestSetPlayPeriod, *sb.GetOwnerAddress(), args, nil)
+	run(t, sb, RequestSetPlayPeriod)
+	period, ok := sb.State().GetInt64(ReqVarPlayPeriodSec)
+	assert.True(t, ok)
+	assert.EqualValues(t, 30, period)
+}
+
+func TestSchemaDecode(t *testing.T) {
+	player := address.Random()
+	sb := sandbox.NewMockedSandbox().
+		WithTimestamp(int64(1000*1e9)).
+		WithRequest(RequestPlaceBet, player, colorArg(2), map[balance.Color]int64{balance.ColorIOTA: 1000})
+	assert.NoError(t, contractSchema.ValidateArgs(RequestPlaceBet, colorArg(2)))
+	run(t, sb, RequestPlaceBet)
+
+	sv, ok := contractSchema.StateVar(StateVarBets)
+	assert.True(t, ok)
+	bet, err := sv.DecodeValue(sb.State().GetArray32(StateVarBets).GetAt(0))
+	assert.NoError(t, err)
+	assert.Equal(t, player.String(), bet.(map[string]interface{})["player"])
+	assert.EqualValues(t, 1000, bet.(map[string]interface{})["sum"])
+	assert.EqualValues(t, 2, bet.(map[string]interface{})["color"])
+
+	sv, ok = contractSchema.StateVar(StateVarPlayerStats)
+	assert.True(t, ok)
+	key, err := sv.DecodeKey(player.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, player.String(), key)
+	stats, err := sv.DecodeValue(sb.State().GetDictionary(StateVarPlayerStats).GetAt(player.Bytes()))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, stats.(map[string]interface{})["bets"])
+}
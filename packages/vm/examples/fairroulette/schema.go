@@ -0,0 +1,73 @@
+package fairroulette
+
+import (
+	"github.com/iotaledger/wasp/packages/schema"
+)
+
+// encoding of BetInfo
+var betInfoFields = []schema.Field{
+	{Name: "player", Type: schema.ValueTypeAddress},
+	{Name: "reqId", Type: schema.ValueTypeRequestId},
+	{Name: "sum", Type: schema.ValueTypeInt64},
+	{Name: "color", Type: schema.ValueTypeUint8},
+}
+
+var contractSchema = &schema.Schema{
+	Name: "FairRoulette",
+	EntryPoints: []schema.EntryPoint{
+		{
+			Name: "placeBet",
+			Code: RequestPlaceBet,
+			Args: []schema.Arg{{Name: ReqVarColor, Type: schema.ValueTypeInt64}},
+		},
+		{Name: "lockBets", Code: RequestLockBets},
+		{Name: "playAndDistribute", Code: RequestPlayAndDistribute},
+		{
+			Name:      "setPlayPeriod",
+			Code:      RequestSetPlayPeriod,
+			Protected: true,
+			Args:      []schema.Arg{{Name: ReqVarPlayPeriodSec, Type: schema.ValueTypeInt64}},
+		},
+	},
+	StateVars: []schema.StateVar{
+		{
+			Key:         StateVarBets,
+			Type:        schema.ValueTypeStruct,
+			Fields:      betInfoFields,
+			Collection:  schema.CollectionArray,
+			Description: "current bets",
+		},
+		{
+			Key:         StateVarLockedBets,
+			Type:        schema.ValueTypeStruct,
+			Fields:      betInfoFields,
+			Collection:  schema.CollectionArray,
+			Description: "locked bets",
+		},
+		{Key: StateVarLastWinningColor, Type: schema.ValueTypeInt64},
+		{Key: StateVarEntropyFromLocking, Type: schema.ValueTypeHash},
+		{Key: StateVarNextPlayTimestamp, Type: schema.ValueTypeInt64, Description: "nanoseconds"},
+		{Key: ReqVarPlayPeriodSec, Type: schema.ValueTypeInt64, Description: "play period in seconds"},
+		{
+			Key:         StateArrayWinsPerColor,
+			Type:        schema.ValueTypeUint32,
+			Collection:  schema.CollectionArray,
+			Description: "number of wins per color",
+		},
+		{
+			Key:  StateVarPlayerStats,
+			Type: schema.ValueTypeStruct,
+			Fields: []schema.Field{
+				{Name: "bets", Type: schema.ValueTypeUint32},
+				{Name: "wins", Type: schema.ValueTypeUint32},
+			},
+			Collection:  schema.CollectionDict,
+			KeyType:     schema.ValueTypeAddress,
+			Description: "statistics per player",
+		},
+	},
+}
+
+func (f fairRouletteProcessor) GetSchema() *schema.Schema {
+	return contractSchema
+}
@@ -39,7 +39,7 @@ func (proc incCounterProcessor) GetEntryPoint(rc sctransaction.RequestCode) (vmt
 }
 
 func (ep incEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
-	return ep
+	return vmtypes.WithGasLimit(ep, gas)
 }
 
 func (ep incEntryPoint) Run(ctx vmtypes.Sandbox) {
@@ -37,8 +37,8 @@ func (ep logscEntryPoint) Run(ctx vmtypes.Sandbox) {
 	ep(ctx)
 }
 
-func (v logscEntryPoint) WithGasLimit(_ int) vmtypes.EntryPoint {
-	return v
+func (v logscEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(v, gas)
 }
 
 const logArrayKey = kv.Key("log")
@@ -31,6 +31,6 @@ func (v nilProcessor) Run(ctx vmtypes.Sandbox) {
 	)
 }
 
-func (v nilProcessor) WithGasLimit(_ int) vmtypes.EntryPoint {
-	return v
+func (v nilProcessor) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(v, gas)
 }
@@ -36,6 +36,6 @@ func (v wasmVMPocProcessor) Run(ctx vmtypes.Sandbox) {
 		ctx.AccessRequest().Code().String(), reqId.String(), ctx.GetTimestamp()))
 }
 
-func (v wasmVMPocProcessor) WithGasLimit(_ int) vmtypes.EntryPoint {
-	return v
+func (v wasmVMPocProcessor) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(v, gas)
 }
@@ -2,12 +2,14 @@ package processor
 
 import (
 	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/parameters"
 	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/packages/util/sema"
 	"github.com/iotaledger/wasp/packages/vm/examples"
 	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+	_ "github.com/iotaledger/wasp/packages/vm/wasmtimevm"
 	"io/ioutil"
 	"net/url"
 	"path"
@@ -22,7 +24,10 @@ type processorInstance struct {
 // TODO implement multiple workers/instances per program hash. Currently only one
 
 var (
-	processors      = make(map[string]processorInstance)
+	processors = make(map[string]processorInstance)
+	// addresses of committees which use the processor, by program hash.
+	// The same program may be run by several committees
+	processorUsers  = make(map[string]map[address.Address]struct{})
 	processorsMutex sync.RWMutex
 )
 
@@ -31,21 +36,65 @@ var (
 // possibly, locates Wasm program code in the file system, in IPFS etc
 func LoadProcessorAsync(programHash string, onFinish func(err error)) {
 	go func() {
-		proc, err := loadProcessor(programHash)
-		if err != nil {
-			onFinish(err)
-			return
-		}
+		onFinish(LoadProcessor(programHash))
+	}()
+}
 
-		processorsMutex.Lock()
-		processors[programHash] = processorInstance{
-			Processor: proc,
-			timedLock: sema.New(),
-		}
-		processorsMutex.Unlock()
+// LoadProcessor creates and registers processor for program hash synchronously
+func LoadProcessor(programHash string) error {
+	proc, err := loadProcessor(programHash)
+	if err != nil {
+		return err
+	}
+	if err := saveSchema(programHash, proc); err != nil {
+		return err
+	}
 
-		onFinish(nil)
-	}()
+	processorsMutex.Lock()
+	defer processorsMutex.Unlock()
+
+	if _, ok := processors[programHash]; ok {
+		// loaded concurrently. The instance may be acquired at the moment, so it is not replaced
+		return nil
+	}
+	processors[programHash] = processorInstance{
+		Processor: proc,
+		timedLock: sema.New(),
+	}
+	return nil
+}
+
+// UseProcessor registers the committee as a user of the processor of the program hash.
+// The processor is not unloaded while it has users.
+// Returns true if the processor is loaded
+func UseProcessor(programHash string, user *address.Address) bool {
+	processorsMutex.Lock()
+	defer processorsMutex.Unlock()
+
+	users, ok := processorUsers[programHash]
+	if !ok {
+		users = make(map[address.Address]struct{})
+		processorUsers[programHash] = users
+	}
+	users[*user] = struct{}{}
+	_, ok = processors[programHash]
+	return ok
+}
+
+// UnloadProcessor removes the committee from users of the processor of the program hash.
+// The processor is removed from the registered ones when it has no users left.
+// The instance acquired at the moment remains valid until released
+func UnloadProcessor(programHash string, user *address.Address) {
+	processorsMutex.Lock()
+	defer processorsMutex.Unlock()
+
+	users := processorUsers[programHash]
+	delete(users, *user)
+	if len(users) > 0 {
+		return
+	}
+	delete(processorUsers, programHash)
+	delete(processors, programHash)
 }
 
 // loadProcessor creates processor instance
@@ -58,28 +107,28 @@ func loadProcessor(progHashStr string) (vmtypes.Processor, error) {
 		return proc, nil
 	}
 	progHash, err := hashing.HashValueFromBase58(progHashStr)
-	binaryCode, exist, err := registry.GetProgramCode(&progHash)
-
-	md, exist, err := registry.GetProgramMetadata(&progHash)
 	if err != nil {
 		return nil, err
 	}
+	md, exist, err := registry.GetProgramMetadata(&progHash)
 	if err != nil {
 		return nil, err
 	}
-	if exist {
-		return vmtypes.FromBinaryCode(md.VMType, binaryCode)
+	if !exist {
+		return nil, fmt.Errorf("no metadata for program hash %s", progHashStr)
 	}
-
-	if exist {
-		binaryCode, err := loadBinaryCode(md.Location, &progHash)
+	binaryCode, exist, err := registry.GetProgramCode(&progHash)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		binaryCode, err = loadBinaryCode(md.Location, &progHash)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load program's binary data from location %s, program hash = %s",
 				md.Location, progHashStr)
 		}
-		return vmtypes.FromBinaryCode(md.VMType, binaryCode)
 	}
-	return nil, fmt.Errorf("no metadata for program hash %s", progHashStr)
+	return vmtypes.FromBinaryCode(md.VMType, binaryCode)
 }
 
 // loads binary code of the VM, possibly from remote location
@@ -0,0 +1,30 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/vm/examples/vmnil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnloadSharedProcessor(t *testing.T) {
+	committee1 := address.Random()
+	committee2 := address.Random()
+
+	assert.False(t, UseProcessor(vmnil.ProgramHash, &committee1))
+	assert.NoError(t, LoadProcessor(vmnil.ProgramHash))
+	assert.True(t, UseProcessor(vmnil.ProgramHash, &committee2))
+
+	// the processor is still used by the second committee
+	UnloadProcessor(vmnil.ProgramHash, &committee1)
+	assert.True(t, CheckProcessor(vmnil.ProgramHash))
+	_, err := Acquire(vmnil.ProgramHash)
+	assert.NoError(t, err)
+	Release(vmnil.ProgramHash)
+
+	UnloadProcessor(vmnil.ProgramHash, &committee2)
+	assert.False(t, CheckProcessor(vmnil.ProgramHash))
+	_, err = Acquire(vmnil.ProgramHash)
+	assert.Error(t, err)
+}
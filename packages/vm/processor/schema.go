@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/schema"
+	"github.com/iotaledger/wasp/packages/vm/examples"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// GetSchema returns the schema of the program: the one stored in the program metadata or,
+// if there is none, the one published by the loaded or built-in processor.
+// Returns false if the schema is not known on the node
+func GetSchema(progHash *hashing.HashValue) (*schema.Schema, bool, error) {
+	md, exist, err := registry.GetProgramMetadata(progHash)
+	if err != nil {
+		return nil, false, err
+	}
+	if exist && md.Schema != nil {
+		return md.Schema, true, nil
+	}
+	ret, ok := getProcessorSchema(progHash.String())
+	return ret, ok, nil
+}
+
+func getProcessorSchema(programHash string) (*schema.Schema, bool) {
+	processorsMutex.RLock()
+	inst, ok := processors[programHash]
+	processorsMutex.RUnlock()
+
+	var proc vmtypes.Processor
+	if ok {
+		proc = inst.Processor
+	} else if proc, ok = examples.LoadProcessor(programHash); !ok {
+		return nil, false
+	}
+	sp, ok := proc.(vmtypes.SchemaProcessor)
+	if !ok {
+		return nil, false
+	}
+	ret := sp.GetSchema()
+	return ret, ret != nil
+}
+
+// saveSchema stores the schema published by the processor into the program metadata,
+// if the metadata exists and doesn't contain a schema yet
+func saveSchema(progHashStr string, proc vmtypes.Processor) error {
+	sp, ok := proc.(vmtypes.SchemaProcessor)
+	if !ok {
+		return nil
+	}
+	sch := sp.GetSchema()
+	if sch == nil {
+		return nil
+	}
+	progHash, err := hashing.HashValueFromBase58(progHashStr)
+	if err != nil {
+		return err
+	}
+	md, exist, err := registry.GetProgramMetadata(&progHash)
+	if err != nil || !exist || md.Schema != nil {
+		return err
+	}
+	md.Schema = sch
+	return registry.SaveProgramMetadata(md)
+}
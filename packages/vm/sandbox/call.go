@@ -0,0 +1,113 @@
+package sandbox
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// ErrReadOnlyCall is the panic raised by the smart contract called by another smart contract
+// when it attempts to modify its state or account. They are modified only by batches of its own committee
+var ErrReadOnlyCall = vmtypes.ContractPanic("call: the called smart contract can't modify its state or account")
+
+// Call runs the entry point of the target smart contract within the same request.
+// The target is the smart contract itself or another smart contract run by the same committee.
+// The smart contract itself is called in the nested scope over the state with updates of the caller.
+// Other smart contracts are called over their solid state and can't modify their state or account:
+// the state of each smart contract is committed by the state transaction of its own committee.
+// Calls back to the smart contract of the batch from other smart contracts are not allowed.
+// Protected and reserved entry points can't be called.
+// Running out of gas in the nested call is not recovered: it fails the whole request
+func (vctx *sandbox) Call(targetAddress *address.Address, reqCode sctransaction.RequestCode, args kv.Map) (kv.Map, error) {
+	vctx.BurnGas(vmconst.GasPerCall)
+	if vctx.callDepth >= vmconst.MaxCallDepth {
+		return nil, fmt.Errorf("call: maximum call depth %d exceeded", vmconst.MaxCallDepth)
+	}
+	if reqCode.IsReserved() || reqCode.IsProtected() {
+		return nil, fmt.Errorf("call: can't call protected entry point %s", reqCode.String())
+	}
+	callCtx := *vctx.VMContext
+	readOnly := vctx.readOnly
+	if *targetAddress == vctx.Address {
+		callCtx.VirtualState = vctx.VirtualState.Clone()
+		callCtx.VirtualState.ApplyStateUpdate(vctx.StateUpdate)
+	} else {
+		if *targetAddress == vctx.batchAddress {
+			return nil, fmt.Errorf("call: smart contract %s can't be called back", targetAddress.String())
+		}
+		if vctx.LoadCallTarget == nil {
+			return nil, fmt.Errorf("call: smart contract %s can't be called from this request", targetAddress.String())
+		}
+		target, ok, err := vctx.LoadCallTarget(targetAddress)
+		if err != nil {
+			panic(kv.NewDBError(err))
+		}
+		if !ok {
+			return nil, fmt.Errorf("call: smart contract %s is not run by the committee", targetAddress.String())
+		}
+		callCtx.Address = *targetAddress
+		callCtx.OwnerAddress = target.OwnerAddress
+		callCtx.ProgramHash = target.ProgramHash
+		callCtx.VirtualState = target.VirtualState
+		callCtx.Processor = target.Processor
+		readOnly = true
+	}
+	if callCtx.Processor == nil {
+		return nil, fmt.Errorf("call: processor of the smart contract is not available")
+	}
+	entryPoint, ok := callCtx.Processor.GetEntryPoint(reqCode)
+	if !ok {
+		return nil, fmt.Errorf("call: can't find entry point for request code %s", reqCode.String())
+	}
+	return vctx.runCall(&callCtx, entryPoint, reqCode, args, readOnly)
+}
+
+func (vctx *sandbox) runCall(callCtx *vm.VMContext, entryPoint vmtypes.EntryPoint, reqCode sctransaction.RequestCode, args kv.Map, readOnly bool) (ret kv.Map, err error) {
+	// nested scope: the called entry point collects its own updates
+	callCtx.StateUpdate = state.NewStateUpdate(vctx.RequestRef.RequestId()).WithTimestamp(vctx.Timestamp)
+	callCtx.TxBuilder = vctx.TxBuilder.Clone()
+
+	callee := &sandbox{
+		VMContext:      callCtx,
+		saveTxBuilder:  callCtx.TxBuilder.Clone(),
+		requestWrapper: newCallRequest(vctx.requestWrapper.ID(), reqCode, args, vctx.Address),
+		stateWrapper:   &stateWrapper{callCtx.VirtualState, callCtx.StateUpdate},
+		gas:            vctx.gas,
+		callDepth:      vctx.callDepth + 1,
+		batchAddress:   vctx.batchAddress,
+		readOnly:       readOnly,
+		numEvents:      vctx.numEvents,
+	}
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if r == vmtypes.ErrOutOfGas || vmtypes.IsDBError(r) {
+			panic(r)
+		}
+		// nothing is merged into the caller's scope.
+		// The error is seen by the program, so it must be the same on all nodes
+		ret, err = nil, fmt.Errorf("call: entry point %s failed: %v", reqCode.String(), vmtypes.RecordedError(r))
+	}()
+
+	entryPoint.Run(callee)
+
+	// merging the scope of the call into the scope of the caller.
+	// Read only calls leave nothing to merge but results
+	callCtx.StateUpdate.Mutations().Iterate(func(mut kv.Mutation) bool {
+		vctx.StateUpdate.Mutations().Add(mut)
+		return true
+	})
+	for _, ev := range callCtx.StateUpdate.Events() {
+		vctx.StateUpdate.AddEvent(ev)
+	}
+	vctx.TxBuilder = callCtx.TxBuilder
+	return callCtx.StateUpdate.Results(), nil
+}
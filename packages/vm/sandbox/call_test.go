@@ -0,0 +1,262 @@
+package sandbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+	"github.com/stretchr/testify/assert"
+)
+
+type funcEntryPoint func(ctx vmtypes.Sandbox)
+
+func (ep funcEntryPoint) Run(ctx vmtypes.Sandbox) {
+	ep(ctx)
+}
+
+func (ep funcEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(ep, gas)
+}
+
+// entry points by request code
+type mapProcessor map[sctransaction.RequestCode]vmtypes.EntryPoint
+
+func (p mapProcessor) GetEntryPoint(code sctransaction.RequestCode) (vmtypes.EntryPoint, bool) {
+	ep, ok := p[code]
+	return ep, ok
+}
+
+func TestCall(t *testing.T) {
+	target := address.Random()
+	sb := NewMockedSandbox().WithBalances(map[balance.Color]int64{balance.ColorIOTA: 100})
+	scAddress := *sb.GetSCAddress()
+
+	callee := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		// sees updates of the caller
+		a, _ := ctx.AccessState().GetInt64("a")
+		x, _, _ := ctx.AccessRequest().Args().GetInt64("x")
+		assert.True(t, ctx.AccessRequest().IsAuthorisedByAddress(&scAddress))
+		ctx.AccessState().SetInt64("b", a+x)
+		ctx.AccessOwnAccount().MoveTokens(&target, &balance.ColorIOTA, 10)
+		ctx.AccessResults().SetInt64("r", a*x)
+		ctx.Event("callee", nil)
+	})
+	caller := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("a", 3)
+		ctx.Event("caller", nil)
+		args := kv.NewMap()
+		args.Codec().SetInt64("x", 5)
+		res, err := ctx.Call(ctx.GetSCAddress(), 1, args)
+		assert.NoError(t, err)
+		r, ok, _ := res.Codec().GetInt64("r")
+		assert.True(t, ok)
+		assert.EqualValues(t, 15, r)
+	})
+	sb.WithProcessor(mapProcessor{1: callee})
+	assert.NoError(t, sb.Run(caller))
+
+	b, ok := sb.State().GetInt64("b")
+	assert.True(t, ok)
+	assert.EqualValues(t, 8, b)
+	assert.EqualValues(t, 2, sb.Mutations().Len())
+	assert.EqualValues(t, 10, sb.Outputs(&target)[balance.ColorIOTA])
+	assert.Equal(t, 2, len(sb.Events()))
+	assert.Equal(t, "caller", sb.Events()[0].Name)
+	assert.Equal(t, "callee", sb.Events()[1].Name)
+}
+
+func TestCallRollback(t *testing.T) {
+	target := address.Random()
+	sb := NewMockedSandbox().WithBalances(map[balance.Color]int64{balance.ColorIOTA: 100})
+
+	callee := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("b", 1)
+		ctx.AccessOwnAccount().MoveTokens(&target, &balance.ColorIOTA, 10)
+		ctx.Event("callee", nil)
+		ctx.Panic(errors.New("failed"))
+	})
+	caller := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("a", 1)
+		_, err := ctx.Call(ctx.GetSCAddress(), 1, nil)
+		assert.Error(t, err)
+	})
+	sb.WithProcessor(mapProcessor{1: callee})
+	assert.NoError(t, sb.Run(caller))
+
+	// failed call is rolled back, the caller is not
+	_, ok := sb.State().GetInt64("b")
+	assert.False(t, ok)
+	_, ok = sb.State().GetInt64("a")
+	assert.True(t, ok)
+	assert.EqualValues(t, 0, sb.Outputs(&target)[balance.ColorIOTA])
+	assert.Equal(t, 0, len(sb.Events()))
+
+	// rollback of the caller rolls back the successful call
+	callee = funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("b", 1)
+		ctx.AccessOwnAccount().MoveTokens(&target, &balance.ColorIOTA, 10)
+	})
+	caller = funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		_, err := ctx.Call(ctx.GetSCAddress(), 1, nil)
+		assert.NoError(t, err)
+		ctx.Panic(errors.New("failed"))
+	})
+	sb.WithRequest(1, address.Random(), nil, nil).WithProcessor(mapProcessor{1: callee})
+	assert.Error(t, sb.Run(caller))
+	assert.EqualValues(t, 0, sb.Mutations().Len())
+	assert.EqualValues(t, 0, sb.Outputs(&target)[balance.ColorIOTA])
+}
+
+func TestCallOutOfGas(t *testing.T) {
+	callee := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("b", 1)
+	})
+	sb := NewMockedSandbox().WithProcessor(mapProcessor{1: callee})
+	sb.SetGasLimit(vmconst.GasPerCall + vmconst.GasPerStateWrite)
+
+	assert.PanicsWithValue(t, vmtypes.ErrOutOfGas, func() {
+		_, _ = sb.Call(sb.GetSCAddress(), 1, nil)
+	})
+}
+
+func TestCallWrongTarget(t *testing.T) {
+	sb := NewMockedSandbox().WithProcessor(mapProcessor{1: funcEntryPoint(func(ctx vmtypes.Sandbox) {})})
+
+	// smart contracts not run by the committee can't be called
+	_, err := sb.Call(sb.GetOwnerAddress(), 1, nil)
+	assert.Error(t, err)
+
+	_, err = sb.Call(sb.GetSCAddress(), vmconst.RequestCodeInit, nil)
+	assert.Error(t, err)
+
+	_, err = sb.Call(sb.GetSCAddress(), 2, nil)
+	assert.Error(t, err)
+
+	_, err = sb.Call(sb.GetSCAddress(), 1, nil)
+	assert.NoError(t, err)
+
+	sb.callDepth = vmconst.MaxCallDepth
+	_, err = sb.Call(sb.GetSCAddress(), 1, nil)
+	assert.Error(t, err)
+}
+
+func TestCallPanics(t *testing.T) {
+	dbErr := kv.NewDBError(errors.New("node local failure"))
+	callee := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.Panic(dbErr)
+	})
+	sb := NewMockedSandbox().WithProcessor(mapProcessor{
+		1: callee,
+		2: funcEntryPoint(func(ctx vmtypes.Sandbox) {
+			ctx.Panic("program error")
+		}),
+		3: funcEntryPoint(func(ctx vmtypes.Sandbox) {
+			var m map[string]int
+			m["x"] = 1
+		}),
+	})
+
+	// database errors are not errors of the program, they abort the call
+	assert.PanicsWithValue(t, dbErr, func() {
+		_, _ = sb.Call(sb.GetSCAddress(), 1, nil)
+	})
+
+	// errors seen by the program are the same on all nodes
+	_, err := sb.Call(sb.GetSCAddress(), 2, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "program error")
+
+	_, err = sb.Call(sb.GetSCAddress(), 3, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), vmtypes.ErrUnexpectedPanic.Error())
+	assert.NotContains(t, err.Error(), "nil map")
+}
+
+func TestCallOtherContract(t *testing.T) {
+	target := address.Random()
+	targetState := state.NewVirtualState(mapdb.NewMapDB(), &target)
+	targetState.Variables().Codec().SetInt64("v", 7)
+
+	sb := NewMockedSandbox().WithBalances(map[balance.Color]int64{balance.ColorIOTA: 100})
+	scAddress := *sb.GetSCAddress()
+	sb.WithCallTarget(target, targetState, mapProcessor{
+		1: funcEntryPoint(func(ctx vmtypes.Sandbox) {
+			assert.Equal(t, target, *ctx.GetSCAddress())
+			v, _ := ctx.AccessState().GetInt64("v")
+			ctx.AccessResults().SetInt64("r", v*2)
+		}),
+		2: funcEntryPoint(func(ctx vmtypes.Sandbox) {
+			ctx.AccessState().SetInt64("v", 1)
+		}),
+		3: funcEntryPoint(func(ctx vmtypes.Sandbox) {
+			ctx.AccessOwnAccount().MoveTokens(&scAddress, &balance.ColorIOTA, 1)
+		}),
+		4: funcEntryPoint(func(ctx vmtypes.Sandbox) {
+			assert.True(t, ctx.AccessRequest().IsAuthorisedByAddress(&scAddress))
+			// calls back to the smart contract of the batch are not allowed
+			_, err := ctx.Call(&scAddress, 1, nil)
+			assert.Error(t, err)
+			// the called smart contract calls itself
+			res, err := ctx.Call(&target, 1, nil)
+			assert.NoError(t, err)
+			r, _, _ := res.Codec().GetInt64("r")
+			ctx.AccessResults().SetInt64("r", r)
+		}),
+	})
+
+	caller := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("a", 1)
+
+		res, err := ctx.Call(&target, 1, nil)
+		assert.NoError(t, err)
+		r, ok, _ := res.Codec().GetInt64("r")
+		assert.True(t, ok)
+		assert.EqualValues(t, 14, r)
+
+		// state and account of the called smart contract can't be modified
+		_, err = ctx.Call(&target, 2, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrReadOnlyCall.Error())
+		_, err = ctx.Call(&target, 3, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrReadOnlyCall.Error())
+
+		res, err = ctx.Call(&target, 4, nil)
+		assert.NoError(t, err)
+		r, _, _ = res.Codec().GetInt64("r")
+		assert.EqualValues(t, 14, r)
+	})
+	assert.NoError(t, sb.Run(caller))
+
+	assert.EqualValues(t, 1, sb.Mutations().Len())
+	v, _, _ := targetState.Variables().Codec().GetInt64("v")
+	assert.EqualValues(t, 7, v)
+}
+
+func TestTooManyEvents(t *testing.T) {
+	callee := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.Event("callee", nil)
+	})
+	sb := NewMockedSandbox().WithProcessor(mapProcessor{1: callee})
+
+	// events of nested calls are counted too
+	caller := funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		for i := 0; i < vmconst.MaxEventsPerRequest-1; i++ {
+			ctx.Event("caller", nil)
+		}
+		_, err := ctx.Call(ctx.GetSCAddress(), 1, nil)
+		assert.NoError(t, err)
+		ctx.Event("caller", nil)
+	})
+	err := sb.Run(caller)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrTooManyEvents.Error())
+	assert.Equal(t, 0, len(sb.Events()))
+}
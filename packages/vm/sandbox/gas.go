@@ -0,0 +1,81 @@
+package sandbox
+
+import (
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// gasMeter is shared by the sandbox of the request and sandboxes of its nested calls
+type gasMeter struct {
+	limit int
+	used  int
+}
+
+func (g *gasMeter) burn(gas int) {
+	g.used += gas
+	if g.limit > 0 && g.used > g.limit {
+		g.used = g.limit
+		panic(vmtypes.ErrOutOfGas)
+	}
+}
+
+// vmtypes.GasMeter implementation
+
+func (vctx *sandbox) SetGasLimit(limit int) {
+	vctx.gas.limit = limit
+}
+
+func (vctx *sandbox) BurnGas(gas int) {
+	vctx.gas.burn(gas)
+}
+
+func (vctx *sandbox) GasUsed() int {
+	return vctx.gas.used
+}
+
+// meteredState charges gas for each access to the state
+type meteredState struct {
+	kv    kv.KVStore
+	meter vmtypes.GasMeter
+}
+
+func (s *meteredState) Has(key kv.Key) (bool, error) {
+	s.meter.BurnGas(vmconst.GasPerStateRead)
+	return s.kv.Has(key)
+}
+
+func (s *meteredState) Get(key kv.Key) ([]byte, error) {
+	s.meter.BurnGas(vmconst.GasPerStateRead)
+	return s.kv.Get(key)
+}
+
+func (s *meteredState) Iterate(prefix kv.Key, f func(key kv.Key, value []byte) bool) error {
+	return s.kv.Iterate(prefix, func(key kv.Key, value []byte) bool {
+		s.meter.BurnGas(vmconst.GasPerStateRead)
+		return f(key, value)
+	})
+}
+
+func (s *meteredState) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
+	return s.kv.IterateKeys(prefix, func(key kv.Key) bool {
+		s.meter.BurnGas(vmconst.GasPerStateRead)
+		return f(key)
+	})
+}
+
+func (s *meteredState) Set(key kv.Key, value []byte) {
+	s.meter.BurnGas(vmconst.GasPerStateWrite + vmconst.GasPerByteWritten*(len(key)+len(value)))
+	s.kv.Set(key, value)
+}
+
+func (s *meteredState) Del(key kv.Key) {
+	s.meter.BurnGas(vmconst.GasPerStateWrite + vmconst.GasPerByteWritten*len(key))
+	s.kv.Del(key)
+}
+
+// DelPrefix is charged as a single write, independently of the number of keys deleted
+func (s *meteredState) DelPrefix(prefix kv.Key) {
+	s.meter.BurnGas(vmconst.GasPerStateWrite + vmconst.GasPerByteWritten*len(prefix))
+	s.kv.DelPrefix(prefix)
+}
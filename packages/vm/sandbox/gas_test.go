@@ -0,0 +1,53 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeteredState(t *testing.T) {
+	addr := address.Random()
+	sb := &sandbox{
+		gas: &gasMeter{},
+		stateWrapper: &stateWrapper{
+			virtualState: state.NewVirtualState(mapdb.NewMapDB(), &addr),
+			stateUpdate:  state.NewStateUpdate(nil),
+		},
+	}
+	s := &meteredState{kv: sb.stateWrapper, meter: sb}
+
+	s.Set("x", []byte{1})
+	assert.Equal(t, vmconst.GasPerStateWrite+2*vmconst.GasPerByteWritten, sb.GasUsed())
+
+	v, err := s.Get("x")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, v)
+	assert.Equal(t, vmconst.GasPerStateWrite+2*vmconst.GasPerByteWritten+vmconst.GasPerStateRead, sb.GasUsed())
+
+	sb.SetGasLimit(sb.GasUsed() + vmconst.GasPerStateRead)
+	_, _ = s.Has("x")
+	assert.PanicsWithValue(t, vmtypes.ErrOutOfGas, func() {
+		_, _ = s.Has("x")
+	})
+	assert.Equal(t, sb.gas.limit, sb.GasUsed())
+}
+
+type nopEntryPoint struct{}
+
+func (ep nopEntryPoint) Run(ctx vmtypes.Sandbox) {}
+
+func (ep nopEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(ep, gas)
+}
+
+func TestWithGasLimit(t *testing.T) {
+	sb := &sandbox{gas: &gasMeter{}}
+	nopEntryPoint{}.WithGasLimit(10).WithGasLimit(100).Run(sb)
+	assert.Equal(t, 100, sb.gas.limit)
+}
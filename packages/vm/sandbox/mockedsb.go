@@ -2,88 +2,285 @@ package sandbox
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/sctransaction/txbuilder"
+	"github.com/iotaledger/wasp/packages/sctransaction/txbuilder/vtxbuilder"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
 	"github.com/iotaledger/wasp/packages/vm/vmtypes"
 )
 
+// MockedSandbox is the Sandbox for unit testing of processors without Wasp nodes and Goshimmer.
+// It is the real sandbox over the in-memory virtual state and the transaction builder with preset
+// balances of the smart contract. The request, timestamp and entropy are set by the test.
+// After the call, state mutations, token moves, sent requests and published messages
+// can be inspected.
+//
+// Each setter starts a new call: the state update of the previous call is applied to the virtual state
+// and the transaction builder is created anew from the preset balances.
 type MockedSandbox struct {
-	kv kv.KVStore
+	*sandbox
+	virtualState state.VirtualState
+	scAddress    address.Address
+	ownerAddress address.Address
+	timestamp    int64
+	entropy      hashing.HashValue
+	balancesTxId valuetransaction.ID
+	balances     map[balance.Color]int64
+	reqCode      sctransaction.RequestCode
+	reqSender    address.Address
+	reqArgs      kv.Map
+	reqTransfer  map[balance.Color]int64
+	processor    vmtypes.Processor
+	callTargets  map[address.Address]*vm.CallTarget
+	published    []string
+	log          *logger.Logger
 }
 
-func NewMockedSandbox() vmtypes.Sandbox {
-	return &MockedSandbox{
-		kv: kv.NewMap(),
+// NewMockedSandbox creates mocked sandbox with random addresses of the smart contract and the owner
+// and empty balances. The request is the request with code 0 sent by the owner
+func NewMockedSandbox() *MockedSandbox {
+	scAddress := address.Random()
+	ownerAddress := address.Random()
+	ret := &MockedSandbox{
+		virtualState: state.NewVirtualState(mapdb.NewMapDB(), &scAddress),
+		scAddress:    scAddress,
+		ownerAddress: ownerAddress,
+		timestamp:    time.Now().UnixNano(),
+		entropy:      *hashing.RandomHash(nil),
+		balancesTxId: (valuetransaction.ID)(*hashing.RandomHash(nil)),
+		balances:     make(map[balance.Color]int64),
+		reqSender:    ownerAddress,
+		reqArgs:      kv.NewMap(),
+		reqTransfer:  make(map[balance.Color]int64),
+		callTargets:  make(map[address.Address]*vm.CallTarget),
+		log:          logger.NewExampleLogger("MockedSandbox"),
 	}
+	ret.reset()
+	return ret
+}
+
+func (m *MockedSandbox) WithOwnerAddress(addr address.Address) *MockedSandbox {
+	m.ownerAddress = addr
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) IsOriginState() bool {
-	panic("implement me")
+func (m *MockedSandbox) WithTimestamp(ts int64) *MockedSandbox {
+	m.timestamp = ts
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) GetSCAddress() *address.Address {
-	panic("implement me")
+func (m *MockedSandbox) WithEntropy(h hashing.HashValue) *MockedSandbox {
+	m.entropy = h
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) GetOwnerAddress() *address.Address {
-	panic("implement me")
+// WithBalances sets balances of the smart contract account, not including tokens sent with the request
+func (m *MockedSandbox) WithBalances(bals map[balance.Color]int64) *MockedSandbox {
+	m.balances = bals
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) GetTimestamp() int64 {
-	panic("implement me")
+// WithProcessor sets the processor of the smart contract. Sandbox.Call finds the called entry points in it
+func (m *MockedSandbox) WithProcessor(proc vmtypes.Processor) *MockedSandbox {
+	m.processor = proc
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) GetEntropy() hashing.HashValue {
-	panic("implement me")
+// WithCallTarget adds another smart contract run by the same committee. Sandbox.Call runs its entry points
+// over the state 'vs'
+func (m *MockedSandbox) WithCallTarget(addr address.Address, vs state.VirtualState, proc vmtypes.Processor) *MockedSandbox {
+	m.callTargets[addr] = &vm.CallTarget{
+		OwnerAddress: m.ownerAddress,
+		VirtualState: vs,
+		Processor:    proc,
+	}
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) Panic(v interface{}) {
-	panic("implement me")
+// WithRequest sets the request. The request transaction is sent from the 'sender' address and
+// transfers 'transfer' tokens plus the request token to the smart contract
+func (m *MockedSandbox) WithRequest(code sctransaction.RequestCode, sender address.Address, args kv.Map, transfer map[balance.Color]int64) *MockedSandbox {
+	if args == nil {
+		args = kv.NewMap()
+	}
+	if transfer == nil {
+		transfer = make(map[balance.Color]int64)
+	}
+	m.reqCode = code
+	m.reqSender = sender
+	m.reqArgs = args
+	m.reqTransfer = transfer
+	m.reset()
+	return m
 }
 
-func (m *MockedSandbox) Rollback() {
-	panic("implement me")
+// Run calls the entry point the way the VM does: panic is recovered and the call is rolled back.
+// Returns the recovered panic or nil
+func (m *MockedSandbox) Run(ep vmtypes.EntryPoint) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic: %v", r)
+			m.Rollback()
+		}
+	}()
+	ep.Run(m)
+	return nil
 }
 
-func (m *MockedSandbox) AccessRequest() vmtypes.RequestAccess {
-	panic("implement me")
+// RunView runs the view entry point against the state as seen after the call
+func (m *MockedSandbox) RunView(ep vmtypes.ViewEntryPoint, params kv.Map) (kv.Map, error) {
+	vs := m.virtualState.Clone()
+	vs.ApplyStateUpdate(m.StateUpdate)
+	return RunView(ep, NewSandboxView(&m.scAddress, vs, params, vmconst.DefaultGasLimit, m.log))
 }
 
-func (m *MockedSandbox) AccessState() kv.MustCodec {
-	return kv.NewMustCodec(m.kv)
+// State returns the state as seen by the call, including its mutations
+func (m *MockedSandbox) State() kv.MustCodec {
+	return m.stateWrapper.MustCodec()
 }
 
-func (m *MockedSandbox) AccessOwnAccount() vmtypes.AccountAccess {
-	panic("implement me")
+// Mutations returns mutations of the state made by the call
+func (m *MockedSandbox) Mutations() kv.MutationSequence {
+	return m.StateUpdate.Mutations()
 }
 
-func (m *MockedSandbox) SendRequest(par vmtypes.NewRequestParams) bool {
-	panic("implement me")
+// Outputs returns tokens moved to the address by the call.
+// For the address of the smart contract it includes remainders of the consumed inputs
+func (m *MockedSandbox) Outputs(addr *address.Address) map[balance.Color]int64 {
+	ret := make(map[balance.Color]int64)
+	vtx := m.TxBuilder.Clone().BuildValueTransactionOnly(false)
+	vtx.Outputs().ForEach(func(a address.Address, bals []*balance.Balance) bool {
+		if a == *addr {
+			for _, b := range bals {
+				ret[b.Color] += b.Value
+			}
+		}
+		return true
+	})
+	return ret
 }
 
-func (m *MockedSandbox) SendRequestToSelf(reqCode sctransaction.RequestCode, args kv.Map) bool {
-	panic("implement me")
+// SentRequests returns requests sent by the call
+func (m *MockedSandbox) SentRequests() []*sctransaction.RequestBlock {
+	return m.TxBuilder.RequestBlocks()
 }
 
-func (m *MockedSandbox) SendRequestToSelfWithDelay(reqCode sctransaction.RequestCode, args kv.Map, deferForSec uint32) bool {
-	panic("implement me")
+// Events returns events emitted by the call
+func (m *MockedSandbox) Events() []*state.Event {
+	return m.StateUpdate.Events()
+}
+
+// Results returns values returned by the call
+func (m *MockedSandbox) Results() kv.Map {
+	return m.StateUpdate.Results()
+}
+
+// Published returns messages published by the call
+func (m *MockedSandbox) Published() []string {
+	return m.published
 }
 
 func (m *MockedSandbox) Publish(msg string) {
-	fmt.Printf("MockedSandbox.Publish: %s\n", msg)
+	m.published = append(m.published, msg)
 }
 
 func (m *MockedSandbox) Publishf(format string, args ...interface{}) {
-	fmt.Printf("MockedSandbox.Publish: "+format+"\n", args...)
+	m.Publish(fmt.Sprintf(format, args...))
 }
 
-func (m *MockedSandbox) GetWaspLog() *logger.Logger {
-	panic("implement me")
+func (m *MockedSandbox) reset() {
+	if m.sandbox != nil {
+		m.virtualState.ApplyStateUpdate(m.StateUpdate)
+	}
+	reqTx, err := m.requestTransaction()
+	if err != nil {
+		panic(err)
+	}
+	txb, err := m.txBuilder(reqTx)
+	if err != nil {
+		panic(err)
+	}
+	reqRef := sctransaction.RequestRef{Tx: reqTx, Index: 0}
+	m.sandbox = NewSandbox(&vm.VMContext{
+		Address:      m.scAddress,
+		OwnerAddress: m.ownerAddress,
+		Entropy:      m.entropy,
+		TxBuilder:    txb,
+		Timestamp:    m.timestamp,
+		VirtualState: m.virtualState,
+		RequestRef:   reqRef,
+		StateUpdate:  state.NewStateUpdate(reqRef.RequestId()).WithTimestamp(m.timestamp),
+		Processor:    m.processor,
+		LoadCallTarget: func(addr *address.Address) (*vm.CallTarget, bool, error) {
+			target, ok := m.callTargets[*addr]
+			return target, ok, nil
+		},
+		Log: m.log,
+	}).(*sandbox)
+	m.published = nil
+}
+
+// requestTransaction creates the request transaction. It is not signed
+func (m *MockedSandbox) requestTransaction() (*sctransaction.Transaction, error) {
+	senderBalances := []*balance.Balance{balance.New(balance.ColorIOTA, 1)}
+	for col, amount := range m.reqTransfer {
+		senderBalances = append(senderBalances, balance.New(col, amount))
+	}
+	senderTxId := (valuetransaction.ID)(*hashing.HashData(m.balancesTxId[:], m.reqSender[:]))
+	vtxb, err := vtxbuilder.NewFromAddressBalances(&m.reqSender, map[valuetransaction.ID][]*balance.Balance{
+		senderTxId: senderBalances,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for col, amount := range m.reqTransfer {
+		if err = vtxb.MoveToAddress(m.scAddress, col, amount); err != nil {
+			return nil, err
+		}
+	}
+	// request token
+	if err = vtxb.MintColor(m.scAddress, balance.ColorIOTA, 1); err != nil {
+		return nil, err
+	}
+	reqBlock := sctransaction.NewRequestBlock(m.scAddress, m.reqCode)
+	reqBlock.SetArgs(m.reqArgs)
+	return sctransaction.NewTransaction(vtxb.Build(false), nil, []*sctransaction.RequestBlock{reqBlock})
 }
 
-func (m *MockedSandbox) DumpAccount() string {
-	panic("implement me")
+// txBuilder creates the transaction builder over preset balances and outputs of the request transaction
+func (m *MockedSandbox) txBuilder(reqTx *sctransaction.Transaction) (*txbuilder.Builder, error) {
+	addressBalances := make(map[valuetransaction.ID][]*balance.Balance)
+	if len(m.balances) > 0 {
+		bals := make([]*balance.Balance, 0, len(m.balances))
+		for col, amount := range m.balances {
+			bals = append(bals, balance.New(col, amount))
+		}
+		addressBalances[m.balancesTxId] = bals
+	}
+	reqBals, _ := reqTx.OutputBalancesByAddress(&m.scAddress)
+	for _, b := range reqBals {
+		col := b.Color
+		if col == balance.ColorNew {
+			col = (balance.Color)(reqTx.ID())
+		}
+		addressBalances[reqTx.ID()] = append(addressBalances[reqTx.ID()], balance.New(col, b.Value))
+	}
+	return txbuilder.NewFromAddressBalances(&m.scAddress, addressBalances)
 }
@@ -52,3 +52,44 @@ func (r *requestWrapper) Senders() []address.Address {
 
 	return ret
 }
+
+// access to the arguments of the synchronous call.
+// The caller is the smart contract itself, so the call is authorised by its address
+type callRequest struct {
+	id     sctransaction.RequestId
+	code   sctransaction.RequestCode
+	args   kv.Map
+	caller address.Address
+}
+
+func newCallRequest(id sctransaction.RequestId, code sctransaction.RequestCode, args kv.Map, caller address.Address) *callRequest {
+	if args == nil {
+		args = kv.NewMap()
+	}
+	return &callRequest{
+		id:     id,
+		code:   code,
+		args:   args.Clone(),
+		caller: caller,
+	}
+}
+
+func (r *callRequest) ID() sctransaction.RequestId {
+	return r.id
+}
+
+func (r *callRequest) Code() sctransaction.RequestCode {
+	return r.code
+}
+
+func (r *callRequest) Args() kv.RCodec {
+	return r.args.Codec()
+}
+
+func (r *callRequest) IsAuthorisedByAddress(addr *address.Address) bool {
+	return *addr == r.caller
+}
+
+func (r *callRequest) Senders() []address.Address {
+	return []address.Address{r.caller}
+}
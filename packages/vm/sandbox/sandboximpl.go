@@ -9,27 +9,49 @@ import (
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/sctransaction/txbuilder"
+	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
 	"github.com/iotaledger/wasp/packages/vm/vmtypes"
 	"github.com/iotaledger/wasp/plugins/publisher"
 )
 
-// TODO implement mocked sandbox for testing
-
 type sandbox struct {
 	*vm.VMContext
 	saveTxBuilder  *txbuilder.Builder // for rollback
-	requestWrapper *requestWrapper
+	requestWrapper vmtypes.RequestAccess
 	stateWrapper   *stateWrapper
+	gas            *gasMeter
+	// depth of the nested synchronous call. 0 for the request
+	callDepth int
+	// address of the smart contract of the batch
+	batchAddress address.Address
+	// true in calls to other smart contracts: they can't modify their state or account
+	readOnly bool
+	// number of events emitted by the request, shared with nested calls
+	numEvents *int
 }
 
+// ErrTooManyEvents is the panic raised when the request emits more than vmconst.MaxEventsPerRequest events
+var ErrTooManyEvents = vmtypes.ContractPanic(fmt.Sprintf("event: more than %d events emitted by the request", vmconst.MaxEventsPerRequest))
+
 func NewSandbox(vctx *vm.VMContext) vmtypes.Sandbox {
 	return &sandbox{
 		VMContext:      vctx,
 		saveTxBuilder:  vctx.TxBuilder.Clone(),
 		requestWrapper: &requestWrapper{&vctx.RequestRef},
 		stateWrapper:   &stateWrapper{vctx.VirtualState, vctx.StateUpdate},
+		gas:            &gasMeter{},
+		batchAddress:   vctx.Address,
+		numEvents:      new(int),
+	}
+}
+
+// checkWritable panics if the smart contract can't modify its state or account in the call
+func (vctx *sandbox) checkWritable() {
+	if vctx.readOnly {
+		panic(ErrReadOnlyCall)
 	}
 }
 
@@ -40,7 +62,7 @@ func (vctx *sandbox) IsOriginState() bool {
 }
 
 func (vctx *sandbox) Panic(v interface{}) {
-	panic(v)
+	panic(vmtypes.NewContractPanic(v))
 }
 
 func (vctx *sandbox) Rollback() {
@@ -56,6 +78,10 @@ func (vctx *sandbox) GetOwnerAddress() *address.Address {
 	return &vctx.OwnerAddress
 }
 
+func (vctx *sandbox) GetStateIndex() uint32 {
+	return vctx.VirtualState.StateIndex()
+}
+
 func (vctx *sandbox) GetTimestamp() int64 {
 	return vctx.Timestamp
 }
@@ -79,7 +105,32 @@ func (vctx *sandbox) AccessRequest() vmtypes.RequestAccess {
 }
 
 func (vctx *sandbox) AccessState() kv.MustCodec {
-	return vctx.stateWrapper.MustCodec()
+	if vctx.readOnly {
+		return kv.NewMustCodec(&meteredState{kv: &readOnlyState{vctx.stateWrapper, ErrReadOnlyCall}, meter: vctx})
+	}
+	return kv.NewMustCodec(&meteredState{kv: vctx.stateWrapper, meter: vctx})
+}
+
+func (vctx *sandbox) AccessResults() kv.MustCodec {
+	return vctx.StateUpdate.Results().MustCodec()
+}
+
+func (vctx *sandbox) Event(name string, fields kv.Map) {
+	vctx.checkWritable()
+	if *vctx.numEvents >= vmconst.MaxEventsPerRequest {
+		panic(ErrTooManyEvents)
+	}
+	*vctx.numEvents++
+	if fields == nil {
+		fields = kv.NewMap()
+	}
+	size := len(name)
+	fields.ForEach(func(key kv.Key, value []byte) bool {
+		size += len(key) + len(value)
+		return true
+	})
+	vctx.BurnGas(vmconst.GasPerEvent + vmconst.GasPerByteWritten*size)
+	vctx.StateUpdate.AddEvent(state.NewEvent(name, fields.Clone()))
 }
 
 func (vctx *sandbox) AccessOwnAccount() vmtypes.AccountAccess {
@@ -87,6 +138,8 @@ func (vctx *sandbox) AccessOwnAccount() vmtypes.AccountAccess {
 }
 
 func (vctx *sandbox) SendRequest(par vmtypes.NewRequestParams) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerRequestSent)
 	if par.IncludeReward > 0 {
 		availableIotas := vctx.TxBuilder.GetInputBalance(balance.ColorIOTA)
 		if par.IncludeReward+1 > availableIotas {
@@ -23,13 +23,13 @@ func (s *stateWrapper) Has(name kv.Key) (bool, error) {
 }
 
 func (s *stateWrapper) Iterate(prefix kv.Key, f func(key kv.Key, value []byte) bool) error {
-	seen, done := s.stateUpdate.Mutations().IterateValues(prefix, f)
+	done := s.stateUpdate.Mutations().IterateValues(prefix, f)
 	if done {
 		return nil
 	}
 	return s.virtualState.Variables().Iterate(prefix, func(key kv.Key, value []byte) bool {
-		_, ok := seen[key]
-		if ok {
+		if s.stateUpdate.Mutations().Latest(key) != nil {
+			// overwritten or deleted
 			return true
 		}
 		return f(key, value)
@@ -37,15 +37,15 @@ func (s *stateWrapper) Iterate(prefix kv.Key, f func(key kv.Key, value []byte) b
 }
 
 func (s *stateWrapper) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
-	seen, done := s.stateUpdate.Mutations().IterateValues(prefix, func(key kv.Key, value []byte) bool {
+	done := s.stateUpdate.Mutations().IterateValues(prefix, func(key kv.Key, value []byte) bool {
 		return f(key)
 	})
 	if done {
 		return nil
 	}
 	return s.virtualState.Variables().IterateKeys(prefix, func(key kv.Key) bool {
-		_, ok := seen[key]
-		if ok {
+		if s.stateUpdate.Mutations().Latest(key) != nil {
+			// overwritten or deleted
 			return true
 		}
 		return f(key)
@@ -64,6 +64,10 @@ func (s *stateWrapper) Del(name kv.Key) {
 	s.stateUpdate.Mutations().Add(kv.NewMutationDel(name))
 }
 
+func (s *stateWrapper) DelPrefix(prefix kv.Key) {
+	s.stateUpdate.Mutations().DelPrefix(prefix)
+}
+
 func (s *stateWrapper) Set(name kv.Key, value []byte) {
 	s.stateUpdate.Mutations().Add(kv.NewMutationSet(name, value))
 }
@@ -3,6 +3,7 @@ package sandbox
 import (
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
 )
 
 func (vctx *sandbox) AvailableBalance(col *balance.Color) int64 {
@@ -10,14 +11,20 @@ func (vctx *sandbox) AvailableBalance(col *balance.Color) int64 {
 }
 
 func (vctx *sandbox) MoveTokens(targetAddr *address.Address, col *balance.Color, amount int64) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerTokenMove)
 	return vctx.TxBuilder.MoveToAddress(*targetAddr, *col, amount) == nil
 }
 
 func (vctx *sandbox) EraseColor(targetAddr *address.Address, col *balance.Color, amount int64) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerTokenMove)
 	return vctx.TxBuilder.EraseColor(*targetAddr, *col, amount) == nil
 }
 
 func (vctx *sandbox) HarvestFees(amount int64) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerTokenMove)
 	available := vctx.TxBuilder.GetInputBalance(balance.ColorIOTA)
 	if available < amount {
 		amount = available
@@ -30,14 +37,20 @@ func (vctx *sandbox) AvailableBalanceFromRequest(col *balance.Color) int64 {
 }
 
 func (vctx *sandbox) MoveTokensFromRequest(targetAddr *address.Address, col *balance.Color, amount int64) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerTokenMove)
 	return vctx.TxBuilder.MoveToAddressFromTransaction(*targetAddr, *col, amount, vctx.RequestRef.Tx.ID()) == nil
 }
 
 func (vctx *sandbox) EraseColorFromRequest(targetAddr *address.Address, col *balance.Color, amount int64) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerTokenMove)
 	return vctx.TxBuilder.EraseColorFromTransaction(*targetAddr, *col, amount, vctx.RequestRef.Tx.ID()) == nil
 }
 
 func (vctx *sandbox) HarvestFeesFromRequest(amount int64) bool {
+	vctx.checkWritable()
+	vctx.BurnGas(vmconst.GasPerTokenMove)
 	txid := vctx.RequestRef.Tx.ID()
 	available := vctx.TxBuilder.GetInputBalanceFromTransaction(balance.ColorIOTA, txid)
 	if available < amount {
@@ -0,0 +1,119 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// sandboxView is the read-only sandbox for view entry points.
+// It is not part of the consensus, however gas is metered to limit the load of the node
+type sandboxView struct {
+	scAddress    address.Address
+	virtualState state.VirtualState
+	params       kv.Map
+	results      kv.Map
+	gas          *gasMeter
+	log          *logger.Logger
+}
+
+// ErrReadOnlyState is the panic raised by the view attempting to modify the state
+var ErrReadOnlyState = errors.New("view can't modify the state")
+
+func NewSandboxView(scAddress *address.Address, virtualState state.VirtualState, params kv.Map, gasLimit int, log *logger.Logger) vmtypes.SandboxView {
+	if params == nil {
+		params = kv.NewMap()
+	}
+	return &sandboxView{
+		scAddress:    *scAddress,
+		virtualState: virtualState,
+		params:       params,
+		results:      kv.NewMap(),
+		gas:          &gasMeter{limit: gasLimit},
+		log:          log,
+	}
+}
+
+// RunView runs the view entry point in the sandbox and returns its results.
+// Panic of the view is returned as an error
+func RunView(ep vmtypes.ViewEntryPoint, ctx vmtypes.SandboxView) (ret kv.Map, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret, err = nil, fmt.Errorf("view failed: %v", r)
+		}
+	}()
+	ep.Run(ctx)
+	return ctx.(*sandboxView).results, nil
+}
+
+func (v *sandboxView) GetSCAddress() *address.Address {
+	return &v.scAddress
+}
+
+func (v *sandboxView) GetTimestamp() int64 {
+	return v.virtualState.Timestamp()
+}
+
+func (v *sandboxView) GetStateIndex() uint32 {
+	return v.virtualState.StateIndex()
+}
+
+func (v *sandboxView) Panic(p interface{}) {
+	panic(p)
+}
+
+func (v *sandboxView) Params() kv.RCodec {
+	return v.params.Codec()
+}
+
+func (v *sandboxView) AccessState() kv.MustCodec {
+	return kv.NewMustCodec(&meteredState{
+		kv:    &readOnlyState{v.virtualState.Variables(), ErrReadOnlyState},
+		meter: v,
+	})
+}
+
+func (v *sandboxView) AccessResults() kv.MustCodec {
+	return v.results.MustCodec()
+}
+
+func (v *sandboxView) GetWaspLog() *logger.Logger {
+	return v.log
+}
+
+// vmtypes.GasMeter implementation
+
+func (v *sandboxView) SetGasLimit(limit int) {
+	v.gas.limit = limit
+}
+
+func (v *sandboxView) BurnGas(gas int) {
+	v.gas.burn(gas)
+}
+
+func (v *sandboxView) GasUsed() int {
+	return v.gas.used
+}
+
+// readOnlyState panics with the err value on any attempt to write
+type readOnlyState struct {
+	kv.KVStore
+	err error
+}
+
+func (s *readOnlyState) Set(key kv.Key, value []byte) {
+	panic(s.err)
+}
+
+func (s *readOnlyState) Del(key kv.Key) {
+	panic(s.err)
+}
+
+func (s *readOnlyState) DelPrefix(prefix kv.Key) {
+	panic(s.err)
+}
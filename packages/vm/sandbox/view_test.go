@@ -0,0 +1,45 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+	"github.com/stretchr/testify/assert"
+)
+
+type funcViewEntryPoint func(ctx vmtypes.SandboxView)
+
+func (ep funcViewEntryPoint) Run(ctx vmtypes.SandboxView) {
+	ep(ctx)
+}
+
+func TestView(t *testing.T) {
+	sb := NewMockedSandbox()
+	assert.NoError(t, sb.Run(funcEntryPoint(func(ctx vmtypes.Sandbox) {
+		ctx.AccessState().SetInt64("a", 3)
+	})))
+
+	view := funcViewEntryPoint(func(ctx vmtypes.SandboxView) {
+		a, _ := ctx.AccessState().GetInt64("a")
+		x, _, _ := ctx.Params().GetInt64("x")
+		ctx.AccessResults().SetInt64("r", a*x)
+	})
+	params := kv.NewMap()
+	params.Codec().SetInt64("x", 5)
+	res, err := sb.RunView(view, params)
+	assert.NoError(t, err)
+	r, ok, _ := res.Codec().GetInt64("r")
+	assert.True(t, ok)
+	assert.EqualValues(t, 15, r)
+}
+
+func TestViewReadOnly(t *testing.T) {
+	sb := NewMockedSandbox()
+	_, err := sb.RunView(funcViewEntryPoint(func(ctx vmtypes.SandboxView) {
+		ctx.AccessState().SetInt64("a", 1)
+	}), nil)
+	assert.Error(t, err)
+	_, ok := sb.State().GetInt64("a")
+	assert.False(t, ok)
+}
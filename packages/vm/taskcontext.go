@@ -25,6 +25,7 @@ type VMTask struct {
 	OwnerAddress  address.Address
 	RewardAddress address.Address
 	MinimumReward int64
+	GasLimit      int
 	Requests      []sctransaction.RequestRef
 	Timestamp     int64
 	VirtualState  state.VirtualState // input immutable
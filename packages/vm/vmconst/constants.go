@@ -9,10 +9,45 @@ const (
 	RequestCodeInit             = sctransaction.RequestCode(uint16(1) | sctransaction.RequestCodeProtectedReserved)
 	RequestCodeSetMinimumReward = sctransaction.RequestCode(uint16(2) | sctransaction.RequestCodeProtectedReserved)
 	RequestCodeSetDescription   = sctransaction.RequestCode(uint16(3) | sctransaction.RequestCodeProtectedReserved)
+	RequestCodeSetGasLimit      = sctransaction.RequestCode(uint16(4) | sctransaction.RequestCodeProtectedReserved)
+	// moves the smart contract to the new program hash, keeping the state
+	RequestCodeUpgrade = sctransaction.RequestCode(uint16(5) | sctransaction.RequestCodeProtectedReserved)
+	// hands the smart contract over to the committee of the new address, keeping the state
+	RequestCodeRotateCommittee = sctransaction.RequestCode(uint16(6) | sctransaction.RequestCodeProtectedReserved)
 )
 
 const (
 	VarNameOwnerAddress  = "$owneraddr$"
 	VarNameProgramHash   = "$proghash$"
 	VarNameMinimumReward = "$minreward$"
+	VarNameGasLimit      = "$gaslimit$"
+	// array of program hashes the smart contract was running, tagged with state index.
+	// Each element is 'state index' (4 bytes) || 'program hash' (32 bytes)
+	VarNameProgramHashHistory = "$proghashhist$"
+	// address of the smart contract after it was rotated to the new committee.
+	// Not set if the smart contract is at its origin address
+	VarNameSCAddress = "$scaddr$"
 )
+
+// gas costs. They are part of the consensus: all nodes must charge the same gas
+const (
+	// gas limit of one request if it is not set in the state of the smart contract
+	DefaultGasLimit = 1000000
+	// upper bound of the gas limit the owner can set. Larger values are clamped
+	MaxGasLimit = 10000000
+
+	GasPerStateRead   = 10
+	GasPerStateWrite  = 20
+	GasPerByteWritten = 1
+	GasPerTokenMove   = 50
+	GasPerRequestSent = 100
+	GasPerInstruction = 1
+	GasPerCall        = 100
+	GasPerEvent       = 50
+)
+
+// maximum depth of nested synchronous calls
+const MaxCallDepth = 8
+
+// maximum number of events emitted by one request, including its nested calls
+const MaxEventsPerRequest = 1000
@@ -7,6 +7,7 @@ import (
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/sctransaction/txbuilder"
 	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
 )
 
 // context of one VM call (for one request)
@@ -22,6 +23,8 @@ type VMContext struct {
 	RewardAddress address.Address
 	// minimum reward
 	MinimumReward int64
+	// maximum gas a request may burn
+	GasLimit int
 	// deterministic source of entropy. Equal the the hash of the previous
 	Entropy hashing.HashValue
 	// tx builder to build the final transaction
@@ -30,10 +33,26 @@ type VMContext struct {
 	Timestamp int64
 	// initial state of the call
 	VirtualState state.VirtualState
+	// processor of the smart contract, used by synchronous calls. Nil for builtin requests
+	Processor vmtypes.Processor
+	// loads other smart contracts for synchronous calls. Returns false if the smart contract can't be called.
+	// Nil if only the smart contract itself can be called
+	LoadCallTarget func(addr *address.Address) (*CallTarget, bool, error)
 	// set for each call
 	RequestRef sctransaction.RequestRef
 	// IsEmpty state update upon call, result of the call.
 	StateUpdate state.StateUpdate
+	// new address of the smart contract if it was rotated by one of requests of the batch. Nil otherwise
+	RotationAddress *address.Address
 	// log
 	Log *logger.Logger
 }
+
+// CallTarget is another smart contract run by the same committee, called synchronously from the request
+type CallTarget struct {
+	OwnerAddress address.Address
+	ProgramHash  hashing.HashValue
+	// solid state of the smart contract. The call can't modify it
+	VirtualState state.VirtualState
+	Processor    vmtypes.Processor
+}
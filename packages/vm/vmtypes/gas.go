@@ -0,0 +1,53 @@
+package vmtypes
+
+import "errors"
+
+// ErrOutOfGas is the panic value when the call exceeds its gas budget.
+// The panic is recovered by the VM runner and the call is rolled back
+var ErrOutOfGas = errors.New("out of gas")
+
+// GasMeter is implemented by the Sandbox which meters consumption of gas.
+// Sandbox charges gas for state access, token moves and outgoing requests,
+// the VM may charge additionally for executed instructions
+type GasMeter interface {
+	// SetGasLimit sets gas budget of the call. 0 means no limit
+	SetGasLimit(limit int)
+	// BurnGas consumes gas. Panics with ErrOutOfGas when the budget is exceeded
+	BurnGas(gas int)
+	GasUsed() int
+}
+
+type gasLimitedEntryPoint struct {
+	entryPoint EntryPoint
+	limit      int
+}
+
+// WithGasLimit wraps the entry point. The wrapped entry point sets the gas budget
+// of the Sandbox before the call
+func WithGasLimit(ep EntryPoint, limit int) EntryPoint {
+	if gl, ok := ep.(*gasLimitedEntryPoint); ok {
+		ep = gl.entryPoint
+	}
+	return &gasLimitedEntryPoint{
+		entryPoint: ep,
+		limit:      limit,
+	}
+}
+
+func (ep *gasLimitedEntryPoint) WithGasLimit(limit int) EntryPoint {
+	return WithGasLimit(ep.entryPoint, limit)
+}
+
+func (ep *gasLimitedEntryPoint) Run(ctx Sandbox) {
+	if meter, ok := ctx.(GasMeter); ok {
+		meter.SetGasLimit(ep.limit)
+	}
+	ep.entryPoint.Run(ctx)
+}
+
+// BurnGas charges gas if the Sandbox meters it
+func BurnGas(ctx Sandbox, gas int) {
+	if meter, ok := ctx.(GasMeter); ok {
+		meter.BurnGas(gas)
+	}
+}
@@ -0,0 +1,53 @@
+package vmtypes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/kv"
+)
+
+// ContractPanic is the panic value of Sandbox.Panic. The message is set by the program,
+// so it is the same on all nodes and it is recorded in the state update
+type ContractPanic string
+
+func (p ContractPanic) Error() string {
+	return string(p)
+}
+
+// ErrUnexpectedPanic is recorded in the state update instead of other panics of the program.
+// Their messages are not guaranteed to be the same on all nodes
+var ErrUnexpectedPanic = errors.New("unexpected panic in the smart contract")
+
+// ErrProcessorNotAvailable is recorded in the state update when the processor of the program can't be acquired.
+// Reasons of the failure are specific to the node, so they are not recorded
+var ErrProcessorNotAvailable = errors.New("processor of the program is not available")
+
+// NewContractPanic makes the panic value of Sandbox.Panic. Errors of the database are not errors of the program:
+// they are passed as they are and abort the whole batch
+func NewContractPanic(v interface{}) interface{} {
+	if IsDBError(v) {
+		return v
+	}
+	return ContractPanic(fmt.Sprintf("%v", v))
+}
+
+// IsDBError returns true if the recovered panic value is the failure of the database of the node
+func IsDBError(r interface{}) bool {
+	_, ok := r.(kv.DBError)
+	return ok
+}
+
+// RecordedError returns the error recorded in the state update for the recovered panic value of the program.
+// Must not be called for database errors
+func RecordedError(r interface{}) error {
+	switch r := r.(type) {
+	case ContractPanic:
+		return r
+	case error:
+		if r == ErrOutOfGas || r == ErrProcessorNotAvailable {
+			return r
+		}
+	}
+	return ErrUnexpectedPanic
+}
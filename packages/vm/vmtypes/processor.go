@@ -2,6 +2,7 @@
 package vmtypes
 
 import (
+	"github.com/iotaledger/wasp/packages/schema"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 )
 
@@ -22,3 +23,15 @@ type EntryPoint interface {
 	WithGasLimit(int) EntryPoint
 	Run(ctx Sandbox)
 }
+
+// MigrationProcessor is implemented by processors which have the migration entry point.
+// It is run once against the existing state when the smart contract is upgraded to the processor
+type MigrationProcessor interface {
+	GetMigrationEntryPoint() (EntryPoint, bool)
+}
+
+// SchemaProcessor is implemented by processors which publish the schema of their entry points and state.
+// The schema is saved with the program metadata when the processor is loaded
+type SchemaProcessor interface {
+	GetSchema() *schema.Schema
+}
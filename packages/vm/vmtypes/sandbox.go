@@ -17,6 +17,8 @@ type Sandbox interface {
 	GetSCAddress() *address.Address
 	GetOwnerAddress() *address.Address
 	GetTimestamp() int64
+	// index of the state the request is run against. Updates of the request are settled in the next state index
+	GetStateIndex() uint32
 	GetEntropy() hashing.HashValue // 32 bytes of deterministic and unpredictably random data
 
 	// Same as panic(), but added as a Sandbox method to emphasize that it's ok to panic from a SC.
@@ -39,6 +41,21 @@ type Sandbox interface {
 	SendRequestToSelf(reqCode sctransaction.RequestCode, args kv.Map) bool
 	// Send request to itself with timelock for some seconds after the current timestamp
 	SendRequestToSelfWithDelay(reqCode sctransaction.RequestCode, args kv.Map, deferForSec uint32) bool
+	// Call synchronously calls the entry point of the smart contract itself or of another smart contract
+	// run by the same committee within the request.
+	// The call of the smart contract itself runs in a nested scope: its state updates and token moves are merged
+	// into the ones of the caller on success and rolled back on failure. Rollback of the caller rolls back the call too.
+	// Other smart contracts are called over their solid state: each smart contract has its own state, committed
+	// by its own committee, so the called smart contract can't modify its state or account.
+	// Use SendRequest to update other smart contracts.
+	// Returns values set by the called entry point through AccessResults
+	Call(targetAddress *address.Address, reqCode sctransaction.RequestCode, args kv.Map) (kv.Map, error)
+	// values returned to the caller of the entry point
+	AccessResults() kv.MustCodec
+	// Event emits the structured event. Events are stored with the state update of the request
+	// and can be queried by the name. Rollback discards events emitted by the call.
+	// The request panics when it emits more than vmconst.MaxEventsPerRequest events
+	Event(name string, fields kv.Map)
 	// for testing
 	// Publish "vmmsg" message through Publisher
 	Publish(msg string)
@@ -0,0 +1,37 @@
+package vmtypes
+
+import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+)
+
+// ViewProcessor is implemented by processors which expose read-only view entry points.
+// View codes are independent from request codes
+type ViewProcessor interface {
+	GetViewEntryPoint(code sctransaction.RequestCode) (ViewEntryPoint, bool)
+}
+
+// ViewEntryPoint is run against the solid state on a single node, without consensus and
+// without the transaction. It can't modify the state, it only returns values through AccessResults
+type ViewEntryPoint interface {
+	Run(ctx SandboxView)
+}
+
+// SandboxView is the read-only Sandbox given to the view entry point
+type SandboxView interface {
+	GetSCAddress() *address.Address
+	// timestamp of the solid state
+	GetTimestamp() int64
+	// index of the solid state
+	GetStateIndex() uint32
+	Panic(v interface{})
+	// parameters of the call
+	Params() kv.RCodec
+	// read-only access to the state. Any attempt to write panics
+	AccessState() kv.MustCodec
+	// values returned to the caller of the view
+	AccessResults() kv.MustCodec
+	GetWaspLog() *logger.Logger
+}
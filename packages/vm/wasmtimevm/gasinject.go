@@ -0,0 +1,677 @@
+package wasmtimevm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Instruction metering.
+// wasmtime has no deterministic instruction counter, so the binary code is instrumented before it is compiled:
+// the program imports 'wasp.gas(i32)' and every function body is split into linear segments, each ending with
+// a control flow instruction or a call. Every segment is prepended with a call which charges the number of
+// instructions in the segment. The charge is static, so the same execution path always costs the same gas,
+// and a loop pays for each iteration because its body starts with a charge.
+
+const (
+	gasFunctionName = "gas"
+
+	sectionCustom   = 0
+	sectionType     = 1
+	sectionImport   = 2
+	sectionGlobal   = 6
+	sectionExport   = 7
+	sectionStart    = 8
+	sectionElement  = 9
+	sectionCode     = 10
+	externalKindFun = 0x00
+)
+
+type wasmSection struct {
+	id   byte
+	data []byte
+}
+
+type wasmReader struct {
+	data []byte
+	pos  int
+}
+
+var errUnexpectedEnd = errors.New("unexpected end of wasm binary")
+
+// injectGasMetering returns the instrumented binary code
+func injectGasMetering(binaryCode []byte) ([]byte, error) {
+	if len(binaryCode) < 8 || !bytes.Equal(binaryCode[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a wasm binary")
+	}
+	sections, err := readSections(binaryCode[8:])
+	if err != nil {
+		return nil, err
+	}
+	inj := &gasInjector{}
+	ret := bytes.NewBuffer(append([]byte(nil), binaryCode[:8]...))
+	hasType, hasImport := false, false
+	for _, s := range sections {
+		if s.id == sectionType {
+			hasType = true
+		}
+		if s.id == sectionImport {
+			hasImport = true
+		}
+	}
+	for _, s := range sections {
+		if s.id > sectionType && !hasType {
+			data, _ := inj.rewriteTypes(nil)
+			writeSection(ret, sectionType, data)
+			hasType = true
+		}
+		if s.id > sectionImport && !hasImport {
+			data, _ := inj.rewriteImports(nil)
+			writeSection(ret, sectionImport, data)
+			hasImport = true
+		}
+		data := s.data
+		switch s.id {
+		case sectionCustom:
+			// function names would be inconsistent after the renumbering
+			name, err := newWasmReader(s.data).readName()
+			if err != nil || name == "name" {
+				continue
+			}
+		case sectionType:
+			data, err = inj.rewriteTypes(s.data)
+		case sectionImport:
+			data, err = inj.rewriteImports(s.data)
+		case sectionGlobal:
+			data, err = inj.rewriteGlobals(s.data)
+		case sectionExport:
+			data, err = inj.rewriteExports(s.data)
+		case sectionStart:
+			data, err = inj.rewriteStart(s.data)
+		case sectionElement:
+			data, err = inj.rewriteElements(s.data)
+		case sectionCode:
+			data, err = inj.rewriteCode(s.data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		writeSection(ret, s.id, data)
+	}
+	if !hasType {
+		data, _ := inj.rewriteTypes(nil)
+		writeSection(ret, sectionType, data)
+	}
+	if !hasImport {
+		data, _ := inj.rewriteImports(nil)
+		writeSection(ret, sectionImport, data)
+	}
+	return ret.Bytes(), nil
+}
+
+func readSections(data []byte) ([]wasmSection, error) {
+	r := newWasmReader(data)
+	ret := make([]wasmSection, 0)
+	for !r.eof() {
+		id, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.readU32()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.readBytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, wasmSection{id: id, data: content})
+	}
+	return ret, nil
+}
+
+func writeSection(w *bytes.Buffer, id byte, data []byte) {
+	w.WriteByte(id)
+	w.Write(encodeU32(uint32(len(data))))
+	w.Write(data)
+}
+
+type gasInjector struct {
+	gasTypeIdx     uint32
+	gasFunctionIdx uint32
+}
+
+// remapFunction takes into account the new function import, which shifts all functions defined in the module
+func (inj *gasInjector) remapFunction(idx uint32) uint32 {
+	if idx >= inj.gasFunctionIdx {
+		return idx + 1
+	}
+	return idx
+}
+
+func (inj *gasInjector) rewriteTypes(data []byte) ([]byte, error) {
+	var n uint32
+	var rest []byte
+	if data != nil {
+		r := newWasmReader(data)
+		var err error
+		if n, err = r.readU32(); err != nil {
+			return nil, err
+		}
+		rest = r.rest()
+	}
+	inj.gasTypeIdx = n
+	var buf bytes.Buffer
+	buf.Write(encodeU32(n + 1))
+	buf.Write(rest)
+	// (func (param i32))
+	buf.Write([]byte{0x60, 0x01, 0x7f, 0x00})
+	return buf.Bytes(), nil
+}
+
+func (inj *gasInjector) rewriteImports(data []byte) ([]byte, error) {
+	var n, numFunctions uint32
+	var rest []byte
+	if data != nil {
+		r := newWasmReader(data)
+		var err error
+		if n, err = r.readU32(); err != nil {
+			return nil, err
+		}
+		start := r.pos
+		for i := uint32(0); i < n; i++ {
+			kind, err := r.skipImport()
+			if err != nil {
+				return nil, err
+			}
+			if kind == externalKindFun {
+				numFunctions++
+			}
+		}
+		rest = data[start:]
+	}
+	inj.gasFunctionIdx = numFunctions
+	var buf bytes.Buffer
+	buf.Write(encodeU32(n + 1))
+	buf.Write(rest)
+	buf.Write(encodeName(hostModuleName))
+	buf.Write(encodeName(gasFunctionName))
+	buf.WriteByte(externalKindFun)
+	buf.Write(encodeU32(inj.gasTypeIdx))
+	return buf.Bytes(), nil
+}
+
+func (inj *gasInjector) rewriteGlobals(data []byte) ([]byte, error) {
+	r := newWasmReader(data)
+	n, err := r.readU32()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(encodeU32(n))
+	for i := uint32(0); i < n; i++ {
+		// value type and mutability
+		b, err := r.readBytes(2)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		if err = inj.copyExpr(r, &buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (inj *gasInjector) rewriteExports(data []byte) ([]byte, error) {
+	r := newWasmReader(data)
+	n, err := r.readU32()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(encodeU32(n))
+	for i := uint32(0); i < n; i++ {
+		name, err := r.readName()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.readU32()
+		if err != nil {
+			return nil, err
+		}
+		if kind == externalKindFun {
+			idx = inj.remapFunction(idx)
+		}
+		buf.Write(encodeName(name))
+		buf.WriteByte(kind)
+		buf.Write(encodeU32(idx))
+	}
+	return buf.Bytes(), nil
+}
+
+func (inj *gasInjector) rewriteStart(data []byte) ([]byte, error) {
+	idx, err := newWasmReader(data).readU32()
+	if err != nil {
+		return nil, err
+	}
+	return encodeU32(inj.remapFunction(idx)), nil
+}
+
+func (inj *gasInjector) rewriteElements(data []byte) ([]byte, error) {
+	r := newWasmReader(data)
+	n, err := r.readU32()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(encodeU32(n))
+	for i := uint32(0); i < n; i++ {
+		flags, err := r.readU32()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodeU32(flags))
+		if flags > 3 {
+			return nil, fmt.Errorf("element segments with expressions are not supported")
+		}
+		if flags == 2 {
+			// table index
+			if err = copyU32(r, &buf); err != nil {
+				return nil, err
+			}
+		}
+		if flags == 0 || flags == 2 {
+			// offset
+			if err = inj.copyExpr(r, &buf); err != nil {
+				return nil, err
+			}
+		}
+		if flags != 0 {
+			// element kind
+			b, err := r.readByte()
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteByte(b)
+		}
+		num, err := r.readU32()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodeU32(num))
+		for j := uint32(0); j < num; j++ {
+			idx, err := r.readU32()
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(encodeU32(inj.remapFunction(idx)))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (inj *gasInjector) rewriteCode(data []byte) ([]byte, error) {
+	r := newWasmReader(data)
+	n, err := r.readU32()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(encodeU32(n))
+	for i := uint32(0); i < n; i++ {
+		size, err := r.readU32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.readBytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		body, err = inj.rewriteFunctionBody(body)
+		if err != nil {
+			return nil, fmt.Errorf("function #%d: %v", i, err)
+		}
+		buf.Write(encodeU32(uint32(len(body))))
+		buf.Write(body)
+	}
+	return buf.Bytes(), nil
+}
+
+func (inj *gasInjector) rewriteFunctionBody(body []byte) ([]byte, error) {
+	r := newWasmReader(body)
+	var buf bytes.Buffer
+	// locals
+	n, err := r.readU32()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(encodeU32(n))
+	for i := uint32(0); i < n; i++ {
+		if err = copyU32(r, &buf); err != nil {
+			return nil, err
+		}
+		b, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(b)
+	}
+	// instructions
+	var segment bytes.Buffer
+	var numInstructions int32
+	for !r.eof() {
+		opcode, err := inj.copyInstruction(r, &segment)
+		if err != nil {
+			return nil, err
+		}
+		numInstructions++
+		if !endsSegment(opcode) {
+			continue
+		}
+		buf.Write(inj.gasCharge(numInstructions))
+		buf.Write(segment.Bytes())
+		segment.Reset()
+		numInstructions = 0
+	}
+	if segment.Len() != 0 {
+		return nil, errors.New("function body must end with 'end'")
+	}
+	return buf.Bytes(), nil
+}
+
+// gasCharge encodes 'i32.const n; call $gas'
+func (inj *gasInjector) gasCharge(n int32) []byte {
+	ret := []byte{0x41}
+	ret = append(ret, encodeS32(n)...)
+	ret = append(ret, 0x10)
+	return append(ret, encodeU32(inj.gasFunctionIdx)...)
+}
+
+func endsSegment(opcode byte) bool {
+	switch opcode {
+	case 0x00, // unreachable
+		0x02, // block
+		0x03, // loop
+		0x04, // if
+		0x05, // else
+		0x0b, // end
+		0x0c, // br
+		0x0d, // br_if
+		0x0e, // br_table
+		0x0f, // return
+		0x10, // call
+		0x11: // call_indirect
+		return true
+	}
+	return false
+}
+
+// copyExpr copies constant expression, including the 'end'
+func (inj *gasInjector) copyExpr(r *wasmReader, w *bytes.Buffer) error {
+	for {
+		opcode, err := inj.copyInstruction(r, w)
+		if err != nil {
+			return err
+		}
+		if opcode == 0x0b {
+			return nil
+		}
+	}
+}
+
+// copyInstruction decodes one instruction and copies it to the writer, remapping function indices
+func (inj *gasInjector) copyInstruction(r *wasmReader, w *bytes.Buffer) (byte, error) {
+	start := r.pos
+	opcode, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case opcode == 0x10 || opcode == 0xd2:
+		// call, ref.func
+		idx, err := r.readU32()
+		if err != nil {
+			return 0, err
+		}
+		w.WriteByte(opcode)
+		w.Write(encodeU32(inj.remapFunction(idx)))
+		return opcode, nil
+
+	case opcode <= 0x01, opcode == 0x05, opcode == 0x0b, opcode == 0x0f,
+		opcode == 0x1a, opcode == 0x1b, opcode == 0xd1,
+		opcode >= 0x45 && opcode <= 0xc4:
+		// no immediates
+
+	case opcode >= 0x02 && opcode <= 0x04:
+		err = r.skipBlockType()
+
+	case opcode == 0x0c, opcode == 0x0d,
+		opcode >= 0x20 && opcode <= 0x26,
+		opcode == 0x3f, opcode == 0x40:
+		_, err = r.readU32()
+
+	case opcode == 0x0e:
+		var n uint32
+		if n, err = r.readU32(); err == nil {
+			for i := uint32(0); i <= n && err == nil; i++ {
+				_, err = r.readU32()
+			}
+		}
+
+	case opcode == 0x11:
+		if _, err = r.readU32(); err == nil {
+			_, err = r.readU32()
+		}
+
+	case opcode == 0x1c:
+		var n uint32
+		if n, err = r.readU32(); err == nil {
+			_, err = r.readBytes(int(n))
+		}
+
+	case opcode >= 0x28 && opcode <= 0x3e:
+		if _, err = r.readU32(); err == nil {
+			_, err = r.readU32()
+		}
+
+	case opcode == 0x41, opcode == 0x42:
+		err = r.skipSLEB()
+
+	case opcode == 0x43:
+		_, err = r.readBytes(4)
+
+	case opcode == 0x44:
+		_, err = r.readBytes(8)
+
+	case opcode == 0xd0:
+		_, err = r.readByte()
+
+	case opcode == 0xfc:
+		var sub uint32
+		if sub, err = r.readU32(); err != nil {
+			break
+		}
+		switch {
+		case sub <= 7:
+		case sub == 9, sub == 11, sub == 13, sub == 15, sub == 16, sub == 17:
+			_, err = r.readU32()
+		case sub == 8, sub == 10, sub == 12, sub == 14:
+			if _, err = r.readU32(); err == nil {
+				_, err = r.readU32()
+			}
+		default:
+			err = fmt.Errorf("unsupported instruction 0xfc %d", sub)
+		}
+
+	default:
+		err = fmt.Errorf("unsupported opcode 0x%02x", opcode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	w.Write(r.data[start:r.pos])
+	return opcode, nil
+}
+
+func newWasmReader(data []byte) *wasmReader {
+	return &wasmReader{data: data}
+}
+
+func (r *wasmReader) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *wasmReader) rest() []byte {
+	return r.data[r.pos:]
+}
+
+func (r *wasmReader) readByte() (byte, error) {
+	if r.eof() {
+		return 0, errUnexpectedEnd
+	}
+	r.pos++
+	return r.data[r.pos-1], nil
+}
+
+func (r *wasmReader) readBytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errUnexpectedEnd
+	}
+	r.pos += n
+	return r.data[r.pos-n : r.pos], nil
+}
+
+func (r *wasmReader) readU32() (uint32, error) {
+	var ret uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		ret |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return ret, nil
+		}
+	}
+	return 0, errors.New("wrong LEB128 encoding")
+}
+
+func (r *wasmReader) skipSLEB() error {
+	for i := 0; i < 10; i++ {
+		b, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+	return errors.New("wrong LEB128 encoding")
+}
+
+func (r *wasmReader) skipBlockType() error {
+	if r.eof() {
+		return errUnexpectedEnd
+	}
+	switch r.data[r.pos] {
+	case 0x40, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f:
+		r.pos++
+		return nil
+	}
+	// type index
+	return r.skipSLEB()
+}
+
+func (r *wasmReader) readName() (string, error) {
+	n, err := r.readU32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.readBytes(int(n))
+	return string(b), err
+}
+
+func (r *wasmReader) skipLimits() error {
+	flags, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	if _, err = r.readU32(); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		_, err = r.readU32()
+	}
+	return err
+}
+
+// skipImport returns kind of the import
+func (r *wasmReader) skipImport() (byte, error) {
+	if _, err := r.readName(); err != nil {
+		return 0, err
+	}
+	if _, err := r.readName(); err != nil {
+		return 0, err
+	}
+	kind, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch kind {
+	case 0x00:
+		_, err = r.readU32()
+	case 0x01:
+		if _, err = r.readByte(); err == nil {
+			err = r.skipLimits()
+		}
+	case 0x02:
+		err = r.skipLimits()
+	case 0x03:
+		_, err = r.readBytes(2)
+	default:
+		err = fmt.Errorf("wrong import kind %d", kind)
+	}
+	return kind, err
+}
+
+func copyU32(r *wasmReader, w *bytes.Buffer) error {
+	v, err := r.readU32()
+	if err != nil {
+		return err
+	}
+	w.Write(encodeU32(v))
+	return nil
+}
+
+func encodeU32(v uint32) []byte {
+	ret := make([]byte, 0, 5)
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(ret, b)
+		}
+		ret = append(ret, b|0x80)
+	}
+}
+
+func encodeS32(v int32) []byte {
+	ret := make([]byte, 0, 5)
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(ret, b)
+		}
+		ret = append(ret, b|0x80)
+	}
+}
+
+func encodeName(s string) []byte {
+	return append(encodeU32(uint32(len(s))), []byte(s)...)
+}
@@ -0,0 +1,73 @@
+package wasmtimevm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var wasmHeader = []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+func wasmModule(sections ...[]byte) []byte {
+	ret := append([]byte(nil), wasmHeader...)
+	for _, s := range sections {
+		ret = append(ret, s...)
+	}
+	return ret
+}
+
+func TestInjectGasMetering(t *testing.T) {
+	// (func (export "ep_1") i32.const 1 drop)
+	code := wasmModule(
+		[]byte{sectionType, 4, 1, 0x60, 0, 0},
+		[]byte{3, 2, 1, 0},
+		[]byte{sectionExport, 8, 1, 4, 'e', 'p', '_', '1', externalKindFun, 0},
+		[]byte{sectionCode, 7, 1, 5, 0, 0x41, 1, 0x1a, 0x0b},
+	)
+	expected := wasmModule(
+		// type (i32)->() is appended
+		[]byte{sectionType, 8, 2, 0x60, 0, 0, 0x60, 1, 0x7f, 0},
+		// import "wasp" "gas" becomes function 0
+		[]byte{sectionImport, 12, 1, 4, 'w', 'a', 's', 'p', 3, 'g', 'a', 's', externalKindFun, 1},
+		[]byte{3, 2, 1, 0},
+		[]byte{sectionExport, 8, 1, 4, 'e', 'p', '_', '1', externalKindFun, 1},
+		// i32.const 3 call $gas
+		[]byte{sectionCode, 11, 1, 9, 0, 0x41, 3, 0x10, 0, 0x41, 1, 0x1a, 0x0b},
+	)
+	ret, err := injectGasMetering(code)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, ret)
+}
+
+func TestInjectGasMeteringSegments(t *testing.T) {
+	// (func (export "ep_1") (if (i32.const 1) (then nop nop)))
+	code := wasmModule(
+		[]byte{sectionType, 4, 1, 0x60, 0, 0},
+		[]byte{3, 2, 1, 0},
+		[]byte{sectionExport, 8, 1, 4, 'e', 'p', '_', '1', externalKindFun, 0},
+		[]byte{sectionCode, 11, 1, 9, 0, 0x41, 1, 0x04, 0x40, 0x01, 0x01, 0x0b, 0x0b},
+	)
+	ret, err := injectGasMetering(code)
+	assert.NoError(t, err)
+	// each segment is charged before its first instruction
+	expected := []byte{sectionCode, 23, 1, 21, 0,
+		0x41, 2, 0x10, 0, 0x41, 1, 0x04, 0x40,
+		0x41, 3, 0x10, 0, 0x01, 0x01, 0x0b,
+		0x41, 1, 0x10, 0, 0x0b,
+	}
+	assert.Equal(t, expected, ret[len(ret)-len(expected):])
+}
+
+func TestInjectGasMeteringUnsupported(t *testing.T) {
+	// SIMD prefix is not supported
+	code := wasmModule(
+		[]byte{sectionType, 4, 1, 0x60, 0, 0},
+		[]byte{3, 2, 1, 0},
+		[]byte{sectionCode, 5, 1, 3, 0, 0xfd, 0x0b},
+	)
+	_, err := injectGasMetering(code)
+	assert.Error(t, err)
+
+	_, err = injectGasMetering([]byte("not wasm"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,325 @@
+package wasmtimevm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// Host functions are imported by the program from the module 'wasp'.
+//
+// Byte slices are passed as (ptr, len) pairs pointing to the linear memory of the program.
+// Addresses (33 bytes) and colors (32 bytes) are passed by pointer only.
+// Functions which return data of variable size take an output buffer (ptr, cap) and return the length
+// of the data, or -1 if the data is absent. If the buffer is too small nothing is written and the
+// returned length tells how big the buffer must be.
+// Boolean results are returned as i32: 1 means true, 0 means false.
+// Request arguments and results of calls are passed in the binary encoding of kv.Map.
+const hostModuleName = "wasp"
+
+type hostFunction struct {
+	name string
+	fun  interface{}
+}
+
+func defineHostFunctions(linker *wasmtime.Linker, ctx vmtypes.Sandbox) error {
+	for _, hf := range hostFunctions(ctx) {
+		if err := linker.DefineFunc(hostModuleName, hf.name, hf.fun); err != nil {
+			return fmt.Errorf("can't define host function '%s': %v", hf.name, err)
+		}
+	}
+	return nil
+}
+
+func hostFunctions(ctx vmtypes.Sandbox) []hostFunction {
+	// results of the last synchronous call, encoded
+	var callResults []byte
+
+	return []hostFunction{
+		// general
+
+		{gasFunctionName, func(numInstructions int32) {
+			vmtypes.BurnGas(ctx, int(numInstructions)*vmconst.GasPerInstruction)
+		}},
+		{"is_origin_state", func() int32 {
+			return boolToInt32(ctx.IsOriginState())
+		}},
+		{"get_sc_address", func(c *wasmtime.Caller, ptr, capacity int32) int32 {
+			return writeBytes(c, ptr, capacity, ctx.GetSCAddress().Bytes())
+		}},
+		{"get_owner_address", func(c *wasmtime.Caller, ptr, capacity int32) int32 {
+			return writeBytes(c, ptr, capacity, ctx.GetOwnerAddress().Bytes())
+		}},
+		{"get_timestamp", func() int64 {
+			return ctx.GetTimestamp()
+		}},
+		{"get_state_index", func() int32 {
+			return int32(ctx.GetStateIndex())
+		}},
+		{"get_entropy", func(c *wasmtime.Caller, ptr, capacity int32) int32 {
+			h := ctx.GetEntropy()
+			return writeBytes(c, ptr, capacity, h[:])
+		}},
+		{"panic", func(c *wasmtime.Caller, ptr, length int32) {
+			ctx.Panic(string(readBytes(c, ptr, length)))
+		}},
+		{"rollback", func() {
+			ctx.Rollback()
+		}},
+		{"log_info", func(c *wasmtime.Caller, ptr, length int32) {
+			ctx.GetWaspLog().Info(string(readBytes(c, ptr, length)))
+		}},
+		{"log_debug", func(c *wasmtime.Caller, ptr, length int32) {
+			ctx.GetWaspLog().Debug(string(readBytes(c, ptr, length)))
+		}},
+		{"log_error", func(c *wasmtime.Caller, ptr, length int32) {
+			ctx.GetWaspLog().Error(string(readBytes(c, ptr, length)))
+		}},
+		{"publish", func(c *wasmtime.Caller, ptr, length int32) {
+			ctx.Publish(string(readBytes(c, ptr, length)))
+		}},
+
+		// request
+
+		{"request_id", func(c *wasmtime.Caller, ptr, capacity int32) int32 {
+			reqid := ctx.AccessRequest().ID()
+			return writeBytes(c, ptr, capacity, reqid[:])
+		}},
+		{"request_code", func() int32 {
+			return int32(ctx.AccessRequest().Code())
+		}},
+		{"request_is_authorised_by", func(c *wasmtime.Caller, addrPtr int32) int32 {
+			return boolToInt32(ctx.AccessRequest().IsAuthorisedByAddress(readAddress(c, addrPtr)))
+		}},
+		{"request_senders", func(c *wasmtime.Caller, ptr, capacity int32) int32 {
+			var buf bytes.Buffer
+			for _, addr := range ctx.AccessRequest().Senders() {
+				buf.Write(addr[:])
+			}
+			return writeBytes(c, ptr, capacity, buf.Bytes())
+		}},
+		{"request_arg", func(c *wasmtime.Caller, keyPtr, keyLen, ptr, capacity int32) int32 {
+			v, err := ctx.AccessRequest().Args().Get(readKey(c, keyPtr, keyLen))
+			if err != nil {
+				ctx.Panic(err)
+			}
+			return writeBytes(c, ptr, capacity, v)
+		}},
+
+		// state
+
+		{"state_has", func(c *wasmtime.Caller, keyPtr, keyLen int32) int32 {
+			return boolToInt32(ctx.AccessState().Has(readKey(c, keyPtr, keyLen)))
+		}},
+		{"state_get", func(c *wasmtime.Caller, keyPtr, keyLen, ptr, capacity int32) int32 {
+			return writeBytes(c, ptr, capacity, ctx.AccessState().Get(readKey(c, keyPtr, keyLen)))
+		}},
+		{"state_set", func(c *wasmtime.Caller, keyPtr, keyLen, valuePtr, valueLen int32) {
+			ctx.AccessState().Set(readKey(c, keyPtr, keyLen), readBytes(c, valuePtr, valueLen))
+		}},
+		{"state_del", func(c *wasmtime.Caller, keyPtr, keyLen int32) {
+			ctx.AccessState().Del(readKey(c, keyPtr, keyLen))
+		}},
+		{"state_get_int64", func(c *wasmtime.Caller, keyPtr, keyLen int32) int64 {
+			ret, _ := ctx.AccessState().GetInt64(readKey(c, keyPtr, keyLen))
+			return ret
+		}},
+		{"state_set_int64", func(c *wasmtime.Caller, keyPtr, keyLen int32, value int64) {
+			ctx.AccessState().SetInt64(readKey(c, keyPtr, keyLen), value)
+		}},
+		{"state_array_len", func(c *wasmtime.Caller, keyPtr, keyLen int32) int32 {
+			return int32(ctx.AccessState().GetArray(readKey(c, keyPtr, keyLen)).Len())
+		}},
+		{"state_array_push", func(c *wasmtime.Caller, keyPtr, keyLen, valuePtr, valueLen int32) {
+			ctx.AccessState().GetArray(readKey(c, keyPtr, keyLen)).Push(readBytes(c, valuePtr, valueLen))
+		}},
+		{"state_array_get_at", func(c *wasmtime.Caller, keyPtr, keyLen, idx, ptr, capacity int32) int32 {
+			arr := ctx.AccessState().GetArray(readKey(c, keyPtr, keyLen))
+			if idx < 0 || idx >= int32(arr.Len()) {
+				return -1
+			}
+			return writeBytes(c, ptr, capacity, arr.GetAt(uint16(idx)))
+		}},
+		{"state_array_set_at", func(c *wasmtime.Caller, keyPtr, keyLen, idx, valuePtr, valueLen int32) int32 {
+			arr := ctx.AccessState().GetArray(readKey(c, keyPtr, keyLen))
+			if idx < 0 || idx >= int32(arr.Len()) {
+				return 0
+			}
+			return boolToInt32(arr.SetAt(uint16(idx), readBytes(c, valuePtr, valueLen)))
+		}},
+		{"state_array_erase", func(c *wasmtime.Caller, keyPtr, keyLen int32) {
+			ctx.AccessState().GetArray(readKey(c, keyPtr, keyLen)).Erase()
+		}},
+		{"state_dict_len", func(c *wasmtime.Caller, keyPtr, keyLen int32) int32 {
+			return int32(ctx.AccessState().GetDictionary(readKey(c, keyPtr, keyLen)).Len())
+		}},
+		{"state_dict_has_at", func(c *wasmtime.Caller, keyPtr, keyLen, elemKeyPtr, elemKeyLen int32) int32 {
+			dict := ctx.AccessState().GetDictionary(readKey(c, keyPtr, keyLen))
+			return boolToInt32(dict.HasAt(readBytes(c, elemKeyPtr, elemKeyLen)))
+		}},
+		{"state_dict_get_at", func(c *wasmtime.Caller, keyPtr, keyLen, elemKeyPtr, elemKeyLen, ptr, capacity int32) int32 {
+			dict := ctx.AccessState().GetDictionary(readKey(c, keyPtr, keyLen))
+			return writeBytes(c, ptr, capacity, dict.GetAt(readBytes(c, elemKeyPtr, elemKeyLen)))
+		}},
+		{"state_dict_set_at", func(c *wasmtime.Caller, keyPtr, keyLen, elemKeyPtr, elemKeyLen, valuePtr, valueLen int32) {
+			dict := ctx.AccessState().GetDictionary(readKey(c, keyPtr, keyLen))
+			dict.SetAt(readBytes(c, elemKeyPtr, elemKeyLen), readBytes(c, valuePtr, valueLen))
+		}},
+		{"state_dict_del_at", func(c *wasmtime.Caller, keyPtr, keyLen, elemKeyPtr, elemKeyLen int32) {
+			dict := ctx.AccessState().GetDictionary(readKey(c, keyPtr, keyLen))
+			dict.DelAt(readBytes(c, elemKeyPtr, elemKeyLen))
+		}},
+
+		// own account
+
+		{"available_balance", func(c *wasmtime.Caller, colorPtr int32) int64 {
+			return ctx.AccessOwnAccount().AvailableBalance(readColor(c, colorPtr))
+		}},
+		{"move_tokens", func(c *wasmtime.Caller, addrPtr, colorPtr int32, amount int64) int32 {
+			return boolToInt32(ctx.AccessOwnAccount().MoveTokens(readAddress(c, addrPtr), readColor(c, colorPtr), amount))
+		}},
+		{"erase_color", func(c *wasmtime.Caller, addrPtr, colorPtr int32, amount int64) int32 {
+			return boolToInt32(ctx.AccessOwnAccount().EraseColor(readAddress(c, addrPtr), readColor(c, colorPtr), amount))
+		}},
+		{"available_balance_from_request", func(c *wasmtime.Caller, colorPtr int32) int64 {
+			return ctx.AccessOwnAccount().AvailableBalanceFromRequest(readColor(c, colorPtr))
+		}},
+		{"move_tokens_from_request", func(c *wasmtime.Caller, addrPtr, colorPtr int32, amount int64) int32 {
+			return boolToInt32(ctx.AccessOwnAccount().MoveTokensFromRequest(readAddress(c, addrPtr), readColor(c, colorPtr), amount))
+		}},
+		{"erase_color_from_request", func(c *wasmtime.Caller, addrPtr, colorPtr int32, amount int64) int32 {
+			return boolToInt32(ctx.AccessOwnAccount().EraseColorFromRequest(readAddress(c, addrPtr), readColor(c, colorPtr), amount))
+		}},
+		{"harvest_fees", func(amount int64) int32 {
+			return boolToInt32(ctx.AccessOwnAccount().HarvestFees(amount))
+		}},
+		{"harvest_fees_from_request", func(amount int64) int32 {
+			return boolToInt32(ctx.AccessOwnAccount().HarvestFeesFromRequest(amount))
+		}},
+
+		// outgoing requests
+
+		{"send_request", func(c *wasmtime.Caller, addrPtr, code, timelock, argsPtr, argsLen int32, reward int64) int32 {
+			return boolToInt32(ctx.SendRequest(vmtypes.NewRequestParams{
+				TargetAddress: readAddress(c, addrPtr),
+				RequestCode:   sctransaction.RequestCode(uint16(code)),
+				Timelock:      uint32(timelock),
+				Args:          readArgs(c, argsPtr, argsLen),
+				IncludeReward: reward,
+			}))
+		}},
+		{"send_request_to_self", func(c *wasmtime.Caller, code, argsPtr, argsLen int32) int32 {
+			return boolToInt32(ctx.SendRequestToSelf(sctransaction.RequestCode(uint16(code)), readArgs(c, argsPtr, argsLen)))
+		}},
+		{"send_request_to_self_with_delay", func(c *wasmtime.Caller, code, argsPtr, argsLen, delaySec int32) int32 {
+			args := readArgs(c, argsPtr, argsLen)
+			return boolToInt32(ctx.SendRequestToSelfWithDelay(sctransaction.RequestCode(uint16(code)), args, uint32(delaySec)))
+		}},
+
+		// synchronous calls
+
+		// call calls the entry point of the smart contract itself or of another one run by the committee, see Sandbox.Call.
+		// Returns the length of encoded results or -1 if the call failed.
+		// Results are taken with 'call_results'
+		{"call", func(c *wasmtime.Caller, addrPtr, code, argsPtr, argsLen int32) int32 {
+			callResults = nil
+			res, err := ctx.Call(readAddress(c, addrPtr), sctransaction.RequestCode(uint16(code)), readArgs(c, argsPtr, argsLen))
+			if err != nil {
+				ctx.GetWaspLog().Warnf("wasmtime: %v", err)
+				return -1
+			}
+			callResults, err = util.Bytes(res)
+			if err != nil {
+				ctx.Panic(err)
+			}
+			return int32(len(callResults))
+		}},
+		{"call_results", func(c *wasmtime.Caller, ptr, capacity int32) int32 {
+			return writeBytes(c, ptr, capacity, callResults)
+		}},
+		{"results_set", func(c *wasmtime.Caller, keyPtr, keyLen, valuePtr, valueLen int32) {
+			ctx.AccessResults().Set(readKey(c, keyPtr, keyLen), readBytes(c, valuePtr, valueLen))
+		}},
+		// fields are kv.Map in binary serialized form, same as call arguments
+		{"event", func(c *wasmtime.Caller, namePtr, nameLen, fieldsPtr, fieldsLen int32) {
+			ctx.Event(string(readBytes(c, namePtr, nameLen)), readArgs(c, fieldsPtr, fieldsLen))
+		}},
+	}
+}
+
+func memory(c *wasmtime.Caller) []byte {
+	exp := c.GetExport("memory")
+	if exp == nil || exp.Memory() == nil {
+		panic("wasmtime: module doesn't export 'memory'")
+	}
+	return exp.Memory().UnsafeData()
+}
+
+func checkBounds(mem []byte, ptr, length int32) {
+	if ptr < 0 || length < 0 || int(ptr)+int(length) > len(mem) {
+		panic(fmt.Sprintf("wasmtime: memory access out of bounds: ptr = %d, len = %d", ptr, length))
+	}
+}
+
+func readBytes(c *wasmtime.Caller, ptr, length int32) []byte {
+	mem := memory(c)
+	checkBounds(mem, ptr, length)
+	ret := make([]byte, length)
+	copy(ret, mem[ptr:ptr+length])
+	return ret
+}
+
+func writeBytes(c *wasmtime.Caller, ptr, capacity int32, data []byte) int32 {
+	if data == nil {
+		return -1
+	}
+	if len(data) > int(capacity) {
+		return int32(len(data))
+	}
+	mem := memory(c)
+	checkBounds(mem, ptr, int32(len(data)))
+	copy(mem[ptr:], data)
+	return int32(len(data))
+}
+
+func readKey(c *wasmtime.Caller, ptr, length int32) kv.Key {
+	return kv.Key(readBytes(c, ptr, length))
+}
+
+func readAddress(c *wasmtime.Caller, ptr int32) *address.Address {
+	var ret address.Address
+	copy(ret[:], readBytes(c, ptr, address.Length))
+	return &ret
+}
+
+func readColor(c *wasmtime.Caller, ptr int32) *balance.Color {
+	var ret balance.Color
+	copy(ret[:], readBytes(c, ptr, balance.ColorLength))
+	return &ret
+}
+
+func readArgs(c *wasmtime.Caller, ptr, length int32) kv.Map {
+	if length == 0 {
+		return nil
+	}
+	ret := kv.NewMap()
+	if err := ret.Read(bytes.NewReader(readBytes(c, ptr, length))); err != nil {
+		panic(fmt.Sprintf("wasmtime: wrong request arguments: %v", err))
+	}
+	return ret
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+// Package wasmtimevm implements the "wasmtime" VM type: smart contract programs compiled to Wasm
+// and run by the wasmtime runtime.
+//
+// Entry points are the functions exported by the Wasm module without parameters or results:
+//   - 'ep_<n>' is the entry point for the user-defined request code n
+//   - 'ep_protected_<n>' is the entry point for the protected request code n
+//   - 'view_<n>' is the read-only view entry point for the view code n (see view.go)
+//   - 'migrate' is the migration entry point, run once when the smart contract is upgraded to the program
+//
+// where n is the decimal number 0 <= n < 0x4000.
+//
+// Executed instructions are charged through the gas meter of the Sandbox (see gasinject.go).
+//
+// The module must export its linear memory as 'memory'. The Sandbox is exposed to the program
+// through host functions imported from the module 'wasp' (see hostfuncs.go).
+package wasmtimevm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+const (
+	VMType = "wasmtime"
+
+	entryPointPrefix          = "ep_"
+	protectedEntryPointPrefix = "ep_protected_"
+	viewEntryPointPrefix      = "view_"
+	migrationEntryPoint       = "migrate"
+)
+
+type wasmProcessor struct {
+	engine      *wasmtime.Engine
+	module      *wasmtime.Module
+	entryPoints map[sctransaction.RequestCode]string
+	// view entry points, view code -> export name
+	viewEntryPoints map[sctransaction.RequestCode]string
+	hasMigration    bool
+}
+
+type wasmEntryPoint struct {
+	proc *wasmProcessor
+	name string
+}
+
+func init() {
+	if err := vmtypes.RegisterVMType(VMType, NewProcessor); err != nil {
+		panic(err)
+	}
+}
+
+// NewProcessor instruments the binary Wasm code with gas metering, compiles it and resolves its entry points
+func NewProcessor(binaryCode []byte) (vmtypes.Processor, error) {
+	binaryCode, err := injectGasMetering(binaryCode)
+	if err != nil {
+		return nil, fmt.Errorf("wasmtime: can't inject gas metering: %v", err)
+	}
+	engine := wasmtime.NewEngine()
+	module, err := wasmtime.NewModule(engine, binaryCode)
+	if err != nil {
+		return nil, fmt.Errorf("wasmtime: can't compile module: %v", err)
+	}
+	ret := &wasmProcessor{
+		engine:          engine,
+		module:          module,
+		entryPoints:     make(map[sctransaction.RequestCode]string),
+		viewEntryPoints: make(map[sctransaction.RequestCode]string),
+	}
+	for _, exp := range module.Exports() {
+		if exp.Type().FuncType() == nil {
+			continue
+		}
+		entryPoints := ret.entryPoints
+		code, ok := requestCodeFromExportName(exp.Name())
+		if !ok {
+			code, ok = viewCodeFromExportName(exp.Name())
+			entryPoints = ret.viewEntryPoints
+		}
+		if !ok && exp.Name() != migrationEntryPoint {
+			continue
+		}
+		ft := exp.Type().FuncType()
+		if len(ft.Params()) != 0 || len(ft.Results()) != 0 {
+			return nil, fmt.Errorf("wasmtime: entry point '%s' must have no params and no results", exp.Name())
+		}
+		if !ok {
+			ret.hasMigration = true
+			continue
+		}
+		entryPoints[code] = exp.Name()
+	}
+	return ret, nil
+}
+
+func requestCodeFromExportName(name string) (sctransaction.RequestCode, bool) {
+	var flags uint16
+	var numStr string
+	switch {
+	case strings.HasPrefix(name, protectedEntryPointPrefix):
+		flags = sctransaction.RequestCodeProtected
+		numStr = name[len(protectedEntryPointPrefix):]
+	case strings.HasPrefix(name, entryPointPrefix):
+		numStr = name[len(entryPointPrefix):]
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseUint(numStr, 10, 16)
+	if err != nil || uint16(n)&sctransaction.RequestCodeProtectedReserved != 0 {
+		return 0, false
+	}
+	return sctransaction.RequestCode(uint16(n) | flags), true
+}
+
+func viewCodeFromExportName(name string) (sctransaction.RequestCode, bool) {
+	if !strings.HasPrefix(name, viewEntryPointPrefix) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(name[len(viewEntryPointPrefix):], 10, 16)
+	if err != nil || uint16(n)&sctransaction.RequestCodeProtectedReserved != 0 {
+		return 0, false
+	}
+	return sctransaction.RequestCode(uint16(n)), true
+}
+
+func (proc *wasmProcessor) GetEntryPoint(code sctransaction.RequestCode) (vmtypes.EntryPoint, bool) {
+	name, ok := proc.entryPoints[code]
+	if !ok {
+		return nil, false
+	}
+	return &wasmEntryPoint{
+		proc: proc,
+		name: name,
+	}, true
+}
+
+func (proc *wasmProcessor) GetMigrationEntryPoint() (vmtypes.EntryPoint, bool) {
+	if !proc.hasMigration {
+		return nil, false
+	}
+	return &wasmEntryPoint{
+		proc: proc,
+		name: migrationEntryPoint,
+	}, true
+}
+
+func (ep *wasmEntryPoint) WithGasLimit(gas int) vmtypes.EntryPoint {
+	return vmtypes.WithGasLimit(ep, gas)
+}
+
+// Run instantiates the module with host functions bound to the sandbox and calls the entry point.
+// Each call runs on a fresh instance, so nothing is carried over between requests except the state.
+// A trap is turned into the sandbox panic, which leads to the rollback of the call
+func (ep *wasmEntryPoint) Run(ctx vmtypes.Sandbox) {
+	store := wasmtime.NewStore(ep.proc.engine)
+	linker := wasmtime.NewLinker(store)
+	if err := defineHostFunctions(linker, ctx); err != nil {
+		ctx.Panic(fmt.Errorf("wasmtime: %v", err))
+	}
+	instance, err := linker.Instantiate(ep.proc.module)
+	if err != nil {
+		ctx.Panic(fmt.Errorf("wasmtime: can't instantiate module: %v", err))
+	}
+	if _, err = instance.GetExport(ep.name).Func().Call(); err != nil {
+		ctx.Panic(fmt.Errorf("wasmtime: '%s' trapped: %v", ep.name, err))
+	}
+}
@@ -0,0 +1,41 @@
+package wasmtimevm
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCodeFromExportName(t *testing.T) {
+	code, ok := requestCodeFromExportName("ep_1")
+	assert.True(t, ok)
+	assert.Equal(t, sctransaction.RequestCode(1), code)
+
+	code, ok = requestCodeFromExportName("ep_protected_2")
+	assert.True(t, ok)
+	assert.Equal(t, sctransaction.RequestCode(2|sctransaction.RequestCodeProtected), code)
+	assert.True(t, code.IsProtected())
+	assert.True(t, code.IsUserDefined())
+
+	_, ok = requestCodeFromExportName("ep_16384")
+	assert.False(t, ok)
+
+	_, ok = requestCodeFromExportName("ep_protected_x")
+	assert.False(t, ok)
+
+	_, ok = requestCodeFromExportName("memory")
+	assert.False(t, ok)
+}
+
+func TestViewCodeFromExportName(t *testing.T) {
+	code, ok := viewCodeFromExportName("view_3")
+	assert.True(t, ok)
+	assert.Equal(t, sctransaction.RequestCode(3), code)
+
+	_, ok = viewCodeFromExportName("view_16384")
+	assert.False(t, ok)
+
+	_, ok = viewCodeFromExportName("ep_3")
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package wasmtimevm
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/vm/sandbox"
+	"github.com/stretchr/testify/assert"
+)
+
+const counterWat = `
+(module
+	(import "wasp" "state_get_int64" (func $get (param i32 i32) (result i64)))
+	(import "wasp" "state_set_int64" (func $set (param i32 i32 i64)))
+	(import "wasp" "panic" (func $panic (param i32 i32)))
+	(memory (export "memory") 1)
+	(data (i32.const 0) "counter")
+	(data (i32.const 16) "boom")
+	(func (export "ep_1")
+		(call $set (i32.const 0) (i32.const 7)
+			(i64.add (call $get (i32.const 0) (i32.const 7)) (i64.const 1))))
+	(func (export "ep_2")
+		(call $panic (i32.const 16) (i32.const 4)))
+)`
+
+const probeEnv = "WASMTIME_ENGINE_PROBE"
+
+// TestEngineProbe only creates the engine when run by skipIfNoEngine in a separate process
+func TestEngineProbe(t *testing.T) {
+	if os.Getenv(probeEnv) != "" {
+		wasmtime.NewEngine()
+	}
+}
+
+// skipIfNoEngine skips the test on hosts where the wasmtime engine aborts the process on creation,
+// e.g. some virtual machines with incomplete CPUID
+func skipIfNoEngine(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestEngineProbe$")
+	cmd.Env = append(os.Environ(), probeEnv+"=1")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("wasmtime engine is not available: %v", err)
+	}
+}
+
+func TestRunEntryPoint(t *testing.T) {
+	skipIfNoEngine(t)
+	code, err := wasmtime.Wat2Wasm(counterWat)
+	assert.NoError(t, err)
+	proc, err := NewProcessor(code)
+	assert.NoError(t, err)
+
+	_, ok := proc.GetEntryPoint(3)
+	assert.False(t, ok)
+
+	ep, ok := proc.GetEntryPoint(1)
+	assert.True(t, ok)
+	sb := sandbox.NewMockedSandbox()
+	assert.NoError(t, sb.Run(ep))
+	assert.NoError(t, sb.Run(ep))
+	v, ok := sb.State().GetInt64(kv.Key("counter"))
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, v)
+
+	// the panic of the program is the panic of the call
+	ep, ok = proc.GetEntryPoint(2)
+	assert.True(t, ok)
+	err = sb.Run(ep)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
@@ -0,0 +1,163 @@
+package wasmtimevm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// errNotInView is the panic value of host functions which are not available to view entry points
+var errNotInView = errors.New("wasmtime: host function is not available in view")
+
+type wasmViewEntryPoint struct {
+	proc *wasmProcessor
+	name string
+}
+
+func (proc *wasmProcessor) GetViewEntryPoint(code sctransaction.RequestCode) (vmtypes.ViewEntryPoint, bool) {
+	name, ok := proc.viewEntryPoints[code]
+	if !ok {
+		return nil, false
+	}
+	return &wasmViewEntryPoint{
+		proc: proc,
+		name: name,
+	}, true
+}
+
+// Run instantiates the module with all host functions, same as for requests.
+// Host functions which modify the state, move tokens or need the request panic
+func (ep *wasmViewEntryPoint) Run(ctx vmtypes.SandboxView) {
+	sb := &viewSandbox{ctx}
+	store := wasmtime.NewStore(ep.proc.engine)
+	linker := wasmtime.NewLinker(store)
+	if err := defineHostFunctions(linker, sb); err != nil {
+		ctx.Panic(fmt.Errorf("wasmtime: %v", err))
+	}
+	instance, err := linker.Instantiate(ep.proc.module)
+	if err != nil {
+		ctx.Panic(fmt.Errorf("wasmtime: can't instantiate module: %v", err))
+	}
+	if _, err = instance.GetExport(ep.name).Func().Call(); err != nil {
+		ctx.Panic(fmt.Errorf("wasmtime: '%s' trapped: %v", ep.name, err))
+	}
+}
+
+// viewSandbox adapts the read-only SandboxView to the Sandbox interface expected by host functions.
+// View parameters are seen by the program as request arguments
+type viewSandbox struct {
+	vmtypes.SandboxView
+}
+
+func (sb *viewSandbox) IsOriginState() bool {
+	return sb.GetStateIndex() == 0
+}
+
+func (sb *viewSandbox) GetOwnerAddress() *address.Address {
+	ret, ok := sb.AccessState().GetAddress(vmconst.VarNameOwnerAddress)
+	if !ok {
+		sb.Panic(fmt.Errorf("wasmtime: owner address not found in the state"))
+	}
+	return ret
+}
+
+func (sb *viewSandbox) GetEntropy() hashing.HashValue {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) Rollback() {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) AccessRequest() vmtypes.RequestAccess {
+	return &viewParams{sb.Params()}
+}
+
+func (sb *viewSandbox) AccessOwnAccount() vmtypes.AccountAccess {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) SendRequest(par vmtypes.NewRequestParams) bool {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) SendRequestToSelf(reqCode sctransaction.RequestCode, args kv.Map) bool {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) SendRequestToSelfWithDelay(reqCode sctransaction.RequestCode, args kv.Map, deferForSec uint32) bool {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) Call(targetAddress *address.Address, reqCode sctransaction.RequestCode, args kv.Map) (kv.Map, error) {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) Event(name string, fields kv.Map) {
+	panic(errNotInView)
+}
+
+func (sb *viewSandbox) Publish(msg string) {
+	sb.GetWaspLog().Info(msg)
+}
+
+func (sb *viewSandbox) Publishf(format string, args ...interface{}) {
+	sb.GetWaspLog().Infof(format, args...)
+}
+
+func (sb *viewSandbox) DumpAccount() string {
+	panic(errNotInView)
+}
+
+// vmtypes.GasMeter implementation: instructions are charged by the view sandbox
+
+func (sb *viewSandbox) SetGasLimit(limit int) {
+	if meter, ok := sb.SandboxView.(vmtypes.GasMeter); ok {
+		meter.SetGasLimit(limit)
+	}
+}
+
+func (sb *viewSandbox) BurnGas(gas int) {
+	if meter, ok := sb.SandboxView.(vmtypes.GasMeter); ok {
+		meter.BurnGas(gas)
+	}
+}
+
+func (sb *viewSandbox) GasUsed() int {
+	if meter, ok := sb.SandboxView.(vmtypes.GasMeter); ok {
+		return meter.GasUsed()
+	}
+	return 0
+}
+
+// viewParams exposes view parameters as request arguments. There is no request behind the view
+type viewParams struct {
+	params kv.RCodec
+}
+
+func (p *viewParams) ID() sctransaction.RequestId {
+	panic(errNotInView)
+}
+
+func (p *viewParams) Code() sctransaction.RequestCode {
+	panic(errNotInView)
+}
+
+func (p *viewParams) IsAuthorisedByAddress(addr *address.Address) bool {
+	return false
+}
+
+func (p *viewParams) Senders() []address.Address {
+	return nil
+}
+
+func (p *viewParams) Args() kv.RCodec {
+	return p.params
+}
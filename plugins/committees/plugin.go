@@ -7,6 +7,7 @@ import (
 	"github.com/iotaledger/hive.go/node"
 	"github.com/iotaledger/wasp/packages/committee"
 	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/plugins/database"
 	"github.com/iotaledger/wasp/plugins/nodeconn"
 	"sync"
 )
@@ -29,6 +30,7 @@ func init() {
 
 func configure(_ *node.Plugin) {
 	log = logger.NewLogger(PluginName)
+	database.SetPruneFunc(pruneBatches)
 }
 
 func run(_ *node.Plugin) {
@@ -81,6 +83,11 @@ func ActivateCommittee(bootupData *registry.BootupData, subscribe bool) committe
 		log.Warnf("committee already active: %s", bootupData.Address)
 		return nil
 	}
+	if bootupData.RotatedTo != nil {
+		log.Warnf("smart contract %s was handed over to the address %s. Committee is not activated",
+			bootupData.Address.String(), bootupData.RotatedTo.String())
+		return nil
+	}
 	c := committee.New(bootupData, log)
 	if c != nil {
 		committeesByAddress[bootupData.Address] = c
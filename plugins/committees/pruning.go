@@ -0,0 +1,35 @@
+package committees
+
+import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/state"
+)
+
+// pruneBatches is called periodically by the database plugin for each partition.
+// Batches are pruned only for active committees and only those which all peers
+// have already passed: peers may request the batch while syncing
+func pruneBatches(addr *address.Address) error {
+	c := CommitteeByAddress(*addr)
+	if c == nil {
+		return nil
+	}
+	policy, _, err := state.LoadRetentionPolicy(addr)
+	if err != nil {
+		return err
+	}
+	if !policy.IsEnabled() {
+		return nil
+	}
+	keepFromIndex, ok := c.OldestPeerStateIndex()
+	if !ok {
+		return nil
+	}
+	n, err := state.PruneBatches(addr, policy, keepFromIndex)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Debugf("pruned %d batch(es) of %s", n, addr.String())
+	}
+	return nil
+}
@@ -23,6 +23,17 @@ const (
 	ObjectTypeStateVariable
 	ObjectTypeProgramMetadata
 	ObjectTypeProgramCode
+	ObjectTypeEvent
+	ObjectTypeRequestReceipt
+	ObjectTypeStateHistory
+	ObjectTypeRetentionPolicy
+	ObjectTypePrunedStateIndex
+	ObjectTypeNodeIdentity
+	ObjectTypeMasterKeyCheck
+	ObjectTypeEventByStateIndex
+	ObjectTypeMerkleNode
+	ObjectTypeStateTransaction
+	ObjectTypeRefreshedDKShare
 )
 
 type Partition struct {
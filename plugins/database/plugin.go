@@ -51,6 +51,9 @@ func run(_ *node.Plugin) {
 	if err := daemon.BackgroundWorker(PluginName+"[GC]", runGC, parameters.PriorityBadgerGarbageCollection); err != nil {
 		log.Errorf("Failed to start as daemon: %s", err)
 	}
+	if err := daemon.BackgroundWorker(PluginName+"[Pruning]", runPruning, parameters.PriorityDatabasePruning); err != nil {
+		log.Errorf("Failed to start as daemon: %s", err)
+	}
 }
 
 func closeDB(shutdownSignal <-chan struct{}) {
@@ -62,6 +65,19 @@ func closeDB(shutdownSignal <-chan struct{}) {
 	log.Infof("Syncing database to disk... done")
 }
 
+// RunGC runs the garbage collection in the background without waiting for the next period,
+// so deleted and overwritten private data is removed from database files sooner
+func RunGC() {
+	if db == nil || !db.RequiresGC() {
+		return
+	}
+	go func() {
+		if err := db.GC(); err != nil {
+			log.Debugf("Garbage collection: %s", err)
+		}
+	}()
+}
+
 func runGC(shutdownSignal <-chan struct{}) {
 	if !db.RequiresGC() {
 		return
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/timeutil"
+	"github.com/iotaledger/wasp/packages/parameters"
+)
+
+// PruneFunc removes old data from the partition of the smart contract.
+// The database plugin doesn't know the layout of the partition, so the function
+// is provided by the plugin which does
+type PruneFunc func(addr *address.Address) error
+
+var (
+	pruneFunc      PruneFunc
+	pruneFuncMutex sync.RWMutex
+)
+
+// SetPruneFunc sets the function which is periodically called for each partition
+// of a smart contract to prune it
+func SetPruneFunc(f PruneFunc) {
+	pruneFuncMutex.Lock()
+	defer pruneFuncMutex.Unlock()
+	pruneFunc = f
+}
+
+func getPruneFunc() PruneFunc {
+	pruneFuncMutex.RLock()
+	defer pruneFuncMutex.RUnlock()
+	return pruneFunc
+}
+
+func runPruning(shutdownSignal <-chan struct{}) {
+	interval := time.Duration(parameters.GetInt(parameters.DatabasePruningInterval)) * time.Second
+	if interval <= 0 {
+		log.Infof("pruning of the database is disabled")
+		return
+	}
+	timeutil.Ticker(pruneAll, interval, shutdownSignal)
+}
+
+func pruneAll() {
+	f := getPruneFunc()
+	if f == nil {
+		return
+	}
+	for _, addr := range partitionAddresses() {
+		if err := f(&addr); err != nil {
+			log.Warnf("pruning of the partition %s failed: %v", addr.String(), err)
+		}
+	}
+}
+
+// partitionAddresses returns addresses of partitions of smart contracts, without the registry
+func partitionAddresses() []address.Address {
+	partitionsMutex.RLock()
+	defer partitionsMutex.RUnlock()
+
+	var niladdr address.Address
+	ret := make([]address.Address, 0, len(partitions))
+	for addr := range partitions {
+		if addr != niladdr {
+			ret = append(ret, addr)
+		}
+	}
+	return ret
+}
@@ -11,6 +11,9 @@ import (
 )
 
 func dispatchState(tx *sctransaction.Transaction) {
+	if cmt := getCommitteeRotatedFrom(tx); cmt != nil {
+		dispatchRotation(cmt, tx)
+	}
 	cmt := getCommitteeByState(tx)
 	if cmt == nil {
 		return
@@ -50,6 +53,11 @@ func dispatchAddressUpdate(addr address.Address, balances map[valuetransaction.I
 		log.Errorf("transaction %s is not among provided outputs. Ignored", tx.ID().String())
 		return
 	}
+	if cmtFrom := getCommitteeRotatedFrom(tx); cmtFrom != nil && *cmtFrom.Address() == addr {
+		// the transaction moves the smart contract away from the address
+		dispatchRotation(cmtFrom, tx)
+		return
+	}
 	if _, err := tx.ValidateBlocks(&addr); err != nil {
 		log.Warnf("invalid transaction %s ignored: %v", tx.ID().String(), err)
 		return
@@ -118,3 +126,49 @@ func getCommitteeByState(tx *sctransaction.Transaction) committee.Committee {
 
 	return committees.CommitteeByAddress(stateAddr)
 }
+
+// getCommitteeRotatedFrom returns the committee the smart contract is rotated from by the state transaction,
+// i.e. the committee with the same smart contract color, whose address is among inputs of the transaction
+// but the smart contract token was moved to another address. Returns nil if it is not a rotation
+// or the committee is not active on the node
+func getCommitteeRotatedFrom(tx *sctransaction.Transaction) committee.Committee {
+	stateBlock, ok := tx.State()
+	if !ok || stateBlock.Color() == balance.ColorNew {
+		return nil
+	}
+	stateAddr, ok, err := tx.StateAddress()
+	if !ok || err != nil {
+		return nil
+	}
+	var ret committee.Committee
+	tx.Inputs().ForEach(func(oid valuetransaction.OutputID) bool {
+		addr := oid.Address()
+		if addr == stateAddr {
+			return true
+		}
+		if cmt := committees.CommitteeByAddress(addr); cmt != nil && *cmt.Color() == stateBlock.Color() {
+			ret = cmt
+			return false
+		}
+		return true
+	})
+	return ret
+}
+
+// dispatchRotation passes the last state transaction of the rotated smart contract to the old committee.
+// The transaction is validated against the new address of the smart contract
+func dispatchRotation(cmt committee.Committee, tx *sctransaction.Transaction) {
+	stateAddr, _, _ := tx.StateAddress()
+	if _, err := tx.ValidateBlocks(&stateAddr); err != nil {
+		log.Errorf("invalid rotation transaction %s ignored: %v", tx.ID().String(), err)
+		return
+	}
+	log.Debugw("dispatchRotation",
+		"txid", tx.ID().String(),
+		"from", cmt.Address().String(),
+		"to", stateAddr.String(),
+	)
+	cmt.ReceiveMessage(committee.StateTransactionMsg{
+		Transaction: tx,
+	})
+}
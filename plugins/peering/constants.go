@@ -10,6 +10,8 @@ const (
 	MsgTypeHeartbeat = byte(0)
 	MsgTypeHandshake = byte(1)
 	MsgTypeMsgChunk  = byte(2)
+	// last message of the handshake, see secure.go
+	MsgTypeHandshakeAuth = byte(3)
 
 	restartAfter = 1 * time.Second
 	dialTimeout  = 1 * time.Second
@@ -12,14 +12,17 @@ import (
 // MsgType type    1 byte
 //  -- if MsgType == 0 (heartbeat) --> the end of message
 //  -- if MsgType == 1 (handshake)
-// MsgData (a string of peer network location) --> end of message
+// MsgData (hello of the handshake) --> end of message
+//  -- if MsgType == 3 (handshake auth)
+// MsgData (signature of the handshake transcript) --> end of message
 //  -- if MsgType >= FirstCommitteeMsgCode
 // Address 32 bytes
 // SenderIndex 2 bytes
 // MsgData variable bytes to the end
 //  -- otherwise panic wrong MsgType
 
-const chunkMessageOverhead = 8 + 1
+// encrypted messages are longer by sessionOverhead
+const chunkMessageOverhead = 8 + 1 + sessionOverhead
 
 // always puts timestamp into first 8 bytes and 1 byte msg type
 func encodeMessage(msg *PeerMessage) ([]byte, int64) {
@@ -42,6 +45,10 @@ func encodeMessage(msg *PeerMessage) ([]byte, int64) {
 		buf.WriteByte(MsgTypeMsgChunk)
 		buf.Write(msg.MsgData)
 
+	case msg.MsgType == MsgTypeHandshakeAuth:
+		buf.WriteByte(MsgTypeHandshakeAuth)
+		buf.Write(msg.MsgData)
+
 	case msg.MsgType >= FirstCommitteeMsgCode:
 		buf.WriteByte(msg.MsgType)
 		buf.Write(msg.Address.Bytes())
@@ -83,6 +90,10 @@ func decodeMessage(data []byte) (*PeerMessage, error) {
 		ret.MsgData = rdr.Bytes()
 		return ret, nil
 
+	case ret.MsgType == MsgTypeHandshakeAuth:
+		ret.MsgData = rdr.Bytes()
+		return ret, nil
+
 	case ret.MsgType >= FirstCommitteeMsgCode:
 		// committee message
 		if err = util.ReadAddress(rdr, &ret.Address); err != nil {
@@ -2,6 +2,7 @@ package peering
 
 import (
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
 	"github.com/iotaledger/hive.go/events"
 )
 
@@ -19,4 +20,9 @@ type PeerMessage struct {
 	Timestamp   int64
 	MsgType     byte
 	MsgData     []byte
+	// peer of the connection the message was received from and the identity key proved
+	// by the peer in the handshake. Not transmitted.
+	// Receivers take the sender index from them, not the one claimed in the message
+	SenderPeer   *Peer
+	SenderPubKey ed25519.PublicKey
 }
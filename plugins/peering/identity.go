@@ -0,0 +1,27 @@
+package peering
+
+import (
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+)
+
+// identity key of the node. It is loaded from the registry when the plugin is configured
+var identity ed25519.PrivateKey
+
+func loadIdentity() error {
+	var err error
+	identity, err = registry.GetNodeIdentity()
+	return err
+}
+
+// MyPublicKey is the public identity key of the node.
+// Other nodes may pin it in the bootup data of smart contracts
+func MyPublicKey() ed25519.PublicKey {
+	return identity.Public()
+}
+
+// DecryptWithIdentity decrypts data encrypted for the identity key of the node with tcrypto.EncryptForPubKey
+func DecryptWithIdentity(data []byte) ([]byte, error) {
+	return tcrypto.DecryptWithPrivateKey(identity, data)
+}
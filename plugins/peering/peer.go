@@ -6,6 +6,7 @@ import (
 	"github.com/iotaledger/goshimmer/dapps/waspconn/packages/chopper"
 	"github.com/iotaledger/goshimmer/packages/binary/messagelayer/payload"
 	"github.com/iotaledger/hive.go/backoff"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
 	"go.uber.org/atomic"
 	"net"
 	"sync"
@@ -25,6 +26,9 @@ type Peer struct {
 	handshakeOk bool
 	// network locations as taken from the SC data
 	remoteLocation string
+	// identity key the peer must prove during handshake. It is taken from the bootup data of the committee
+	// or from parameters of DKG. Handshakes of the peer with unknown key are rejected
+	pinnedPubKey *ed25519.PublicKey
 
 	startOnce *sync.Once
 	numUsers  int
@@ -61,6 +65,35 @@ func (peer *Peer) PeeringId() string {
 	return peeringId(peer.remoteLocation)
 }
 
+// PinPublicKey sets the identity key the peer must prove during handshake.
+// Existing connection is closed if it was established with another key
+func (peer *Peer) PinPublicKey(pubKey ed25519.PublicKey) error {
+	peer.Lock()
+	defer peer.Unlock()
+
+	if peer.pinnedPubKey != nil && *peer.pinnedPubKey != pubKey {
+		return fmt.Errorf("peer %s is already pinned to another public key %s", peer.remoteLocation, peer.pinnedPubKey.String())
+	}
+	peer.pinnedPubKey = &pubKey
+	if peer.peerconn != nil && peer.peerconn.remotePubKey != pubKey {
+		_ = peer.peerconn.Close()
+	}
+	return nil
+}
+
+func (peer *Peer) checkPubKey(pubKey ed25519.PublicKey) error {
+	peer.RLock()
+	defer peer.RUnlock()
+
+	if peer.pinnedPubKey == nil {
+		return fmt.Errorf("public key of the peer is unknown, %s is rejected", pubKey.String())
+	}
+	if *peer.pinnedPubKey != pubKey {
+		return fmt.Errorf("public key %s doesn't match the pinned key %s", pubKey.String(), peer.pinnedPubKey.String())
+	}
+	return nil
+}
+
 func (peer *Peer) connStatus() (bool, bool) {
 	peer.RLock()
 	defer peer.RUnlock()
@@ -138,13 +171,23 @@ func (peer *Peer) runOutbound() {
 	//}
 }
 
-// sends handshake message. It contains myLocation
+// sends the first handshake message. It contains peering id, public identity key and ephemeral key
 func (peer *Peer) sendHandshake() error {
+	hs, err := newHandshakeState()
+	if err != nil {
+		return err
+	}
+	hs.outbound = &handshakeMsg{
+		peeringId: peer.PeeringId(),
+		pubKey:    MyPublicKey(),
+		ephPubKey: hs.ephPubKey,
+	}
+	peer.peerconn.handshake = hs
 	data, _ := encodeMessage(&PeerMessage{
 		MsgType: MsgTypeHandshake,
-		MsgData: []byte(peer.PeeringId()),
+		MsgData: hs.outbound.bytes(),
 	})
-	_, err := peer.peerconn.Write(data)
+	err = peer.peerconn.send(data)
 	log.Debugf("sendHandshake '%s' --> '%s', id = %s", MyNetworkId(), peer.remoteLocation, peer.PeeringId())
 	return err
 }
@@ -215,12 +258,11 @@ func SendMsgToPeers(msg *PeerMessage, peers ...*Peer) (uint16, int64) {
 }
 
 func (peer *Peer) sendData(data []byte) error {
-	if peer.peerconn == nil {
+	if peer.peerconn == nil || !peer.handshakeOk {
 		return fmt.Errorf("no connection with %s", peer.remoteLocation)
 	}
-	num, err := peer.peerconn.Write(data)
-	if num != len(data) {
-		return fmt.Errorf("not all bytes were written. err = %v", err)
+	if err := peer.peerconn.send(data); err != nil {
+		return err
 	}
 	go peer.scheduleNexHeartbeat()
 	return nil
@@ -1,10 +1,13 @@
 package peering
 
 import (
+	"fmt"
 	"net"
+	"sync"
 
 	"github.com/iotaledger/goshimmer/dapps/waspconn/packages/chopper"
 	"github.com/iotaledger/goshimmer/packages/binary/messagelayer/payload"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/netutil/buffconn"
 )
@@ -13,10 +16,17 @@ import (
 // BufferedConnection is a wrapper for net.Conn
 // peeredConnection first handles handshake and then links
 // with peer (peers) according to the handshake information
+// After the handshake all traffic is encrypted by the session
 type peeredConnection struct {
 	*buffconn.BufferedConnection
 	peer        *Peer
 	handshakeOk bool
+	handshake   *handshakeState
+	// sendMutex serializes encryption and writing, so nonces are used in the order of writes
+	sendMutex *sync.Mutex
+	session   *session
+	// identity key proved by the remote peer. Guarded by the lock of the peer
+	remotePubKey ed25519.PublicKey
 }
 
 // creates new peered connection and attach event handlers for received data and closing
@@ -24,6 +34,7 @@ func newPeeredConnection(conn net.Conn, peer *Peer) *peeredConnection {
 	bconn := &peeredConnection{
 		BufferedConnection: buffconn.NewBufferedConnection(conn, payload.MaxMessageSize),
 		peer:               peer,
+		sendMutex:          &sync.Mutex{},
 	}
 	bconn.Events.ReceiveMessage.Attach(events.NewClosure(func(data []byte) {
 		bconn.receiveData(data)
@@ -31,8 +42,10 @@ func newPeeredConnection(conn net.Conn, peer *Peer) *peeredConnection {
 	bconn.Events.Close.Attach(events.NewClosure(func() {
 		if bconn.peer != nil {
 			bconn.peer.Lock()
-			bconn.peer.peerconn = nil
-			bconn.peer.handshakeOk = false
+			if bconn.peer.peerconn == bconn {
+				bconn.peer.peerconn = nil
+				bconn.peer.handshakeOk = false
+			}
 			bconn.peer.Unlock()
 			log.Debugw("closed buff connection", "conn", conn.RemoteAddr().String())
 		}
@@ -40,12 +53,50 @@ func newPeeredConnection(conn net.Conn, peer *Peer) *peeredConnection {
 	return bconn
 }
 
+// send encrypts data if the session is established and writes it to the connection
+func (bconn *peeredConnection) send(data []byte) error {
+	bconn.sendMutex.Lock()
+	defer bconn.sendMutex.Unlock()
+
+	if bconn.session != nil {
+		data = bconn.session.seal(data)
+	}
+	num, err := bconn.Write(data)
+	if err != nil {
+		return err
+	}
+	if num != len(data) {
+		return fmt.Errorf("not all bytes were written")
+	}
+	return nil
+}
+
+func (bconn *peeredConnection) setSession(s *session) {
+	bconn.sendMutex.Lock()
+	defer bconn.sendMutex.Unlock()
+	bconn.session = s
+	bconn.handshake = nil
+}
+
 // receive data handler for peered connection
 func (bconn *peeredConnection) receiveData(data []byte) {
+	// session is only set by the reading goroutine
+	if bconn.session != nil {
+		var err error
+		if data, err = bconn.session.open(data); err != nil {
+			log.Errorf("can't decrypt message from %s: %v. Closing connection", bconn.RemoteAddr().String(), err)
+			_ = bconn.Close()
+			return
+		}
+	}
+	bconn.processData(data)
+}
+
+func (bconn *peeredConnection) processData(data []byte) {
 	msg, err := decodeMessage(data)
 	if err != nil {
 		log.Errorf("decodeMessage: %v", err)
-		bconn.peer.closeConn()
+		_ = bconn.Close()
 		return
 	}
 	if msg.MsgType == MsgTypeMsgChunk {
@@ -55,91 +106,180 @@ func (bconn *peeredConnection) receiveData(data []byte) {
 			return
 		}
 		if finalMsg != nil {
-			bconn.receiveData(finalMsg)
+			bconn.processData(finalMsg)
 		}
+		return
 	}
-	if bconn.peer != nil {
-		// it is peered but maybe not handshaked yet (can only be outbound)
-		if bconn.peer.handshakeOk {
-			// it is handshake-ed
-			bconn.peer.receiveHeartbeat(msg.Timestamp)
-			if msg.MsgType == MsgTypeHeartbeat {
-				// heartbeat msg. No need for further processing
-				return
-			}
-			// trigger event to be processed
-			EventPeerMessageReceived.Trigger(msg)
-		} else {
-			// expected handshake msg
-			if msg.MsgType != MsgTypeHandshake {
-				log.Errorf("unexpected message during handshake")
-				return
-			}
-			// not handshaked => do handshake
-			bconn.processHandShakeOutbound(msg)
-		}
-	} else {
-		// expected handshake msg
-		if msg.MsgType != MsgTypeHandshake {
-			log.Errorf("unexpected message during handshake")
+	if bconn.session != nil {
+		// it is handshake-ed
+		bconn.peer.receiveHeartbeat(msg.Timestamp)
+		if msg.MsgType < FirstCommitteeMsgCode {
+			// heartbeat msg. No need for further processing
 			return
 		}
+		// the sender is the peer of the authenticated connection
+		msg.SenderPeer = bconn.peer
+		msg.SenderPubKey = bconn.remotePubKey
+		// trigger event to be processed
+		EventPeerMessageReceived.Trigger(msg)
+		return
+	}
+	switch {
+	case msg.MsgType == MsgTypeHandshake && bconn.peer != nil:
+		// it is peered but not handshaked yet (can only be outbound)
+		bconn.processHandShakeOutbound(msg)
+
+	case msg.MsgType == MsgTypeHandshake && bconn.handshake == nil:
 		// not peered yet can be only inbound
-		// peer up and do handshake
 		bconn.processHandShakeInbound(msg)
+
+	case msg.MsgType == MsgTypeHandshakeAuth && bconn.handshake != nil && bconn.peer == nil:
+		// peer up and finish the handshake
+		bconn.processHandShakeAuth(msg)
+
+	default:
+		log.Errorf("unexpected message during handshake from %s. Closing connection", bconn.RemoteAddr().String())
+		_ = bconn.Close()
 	}
 }
 
 // receives handshake response from the outbound peer
 // assumes the connection is already peered (i can be only for outbound peers)
+// checks the signature of the response, sends own signature and starts the session
 func (bconn *peeredConnection) processHandShakeOutbound(msg *PeerMessage) {
-	id := string(msg.MsgData)
-	log.Debugf("received handshake from outbound %s", id)
-	if id != bconn.peer.PeeringId() {
-		log.Error("closeConn the peer connection: wrong handshake message from outbound peer: expected %s got '%s'",
-			bconn.peer.PeeringId(), id)
-		bconn.peer.closeConn()
-	} else {
-		log.Infof("CONNECTED WITH PEER %s (outbound)", id)
-		bconn.peer.handshakeOk = true
-
-		bconn.peer.initHeartbeats()
-		bconn.peer.receiveHeartbeat(msg.Timestamp)
-		go bconn.peer.scheduleNexHeartbeat()
+	peer := bconn.peer
+	hs := bconn.handshake
+	resp, err := handshakeMsgFromBytes(msg.MsgData)
+	if err != nil || hs == nil {
+		log.Errorf("wrong handshake message from outbound peer %s: %v. Closing connection", peer.remoteLocation, err)
+		peer.closeConn()
+		return
+	}
+	log.Debugf("received handshake from outbound %s", resp.peeringId)
+	if resp.peeringId != peer.PeeringId() {
+		log.Errorf("closeConn the peer connection: wrong handshake message from outbound peer: expected %s got '%s'",
+			peer.PeeringId(), resp.peeringId)
+		peer.closeConn()
+		return
+	}
+	if err := peer.checkPubKey(resp.pubKey); err != nil {
+		log.Errorf("closeConn the peer connection with %s: %v", peer.PeeringId(), err)
+		peer.closeConn()
+		return
 	}
+	hs.inbound = resp
+	if err := hs.verify(resp.pubKey, sigRoleInbound, resp.signature); err != nil {
+		log.Errorf("closeConn the peer connection with %s: %v", peer.PeeringId(), err)
+		peer.closeConn()
+		return
+	}
+	sess, err := hs.newSession(true)
+	if err != nil {
+		log.Errorf("closeConn the peer connection with %s: %v", peer.PeeringId(), err)
+		peer.closeConn()
+		return
+	}
+	data, _ := encodeMessage(&PeerMessage{
+		MsgType: MsgTypeHandshakeAuth,
+		MsgData: hs.sign(sigRoleOutbound),
+	})
+	if err := bconn.send(data); err != nil {
+		log.Errorf("error while sending handshake auth: %v. Closing connection", err)
+		peer.closeConn()
+		return
+	}
+	bconn.setSession(sess)
+
+	log.Infof("CONNECTED WITH PEER %s (outbound), public key %s", resp.peeringId, resp.pubKey.String())
+	peer.Lock()
+	bconn.remotePubKey = resp.pubKey
+	peer.handshakeOk = true
+	peer.Unlock()
+
+	peer.initHeartbeats()
+	peer.receiveHeartbeat(msg.Timestamp)
+	go peer.scheduleNexHeartbeat()
 }
 
 // receives handshake from the inbound peer
-// links connection with the peer
-// sends response back to finish the handshake
+// sends signed response back and waits for the signature of the inbound peer
 func (bconn *peeredConnection) processHandShakeInbound(msg *PeerMessage) {
-	peeringId := string(msg.MsgData)
-	log.Debugf("received handshake from inbound id = %s", peeringId)
+	hello, err := handshakeMsgFromBytes(msg.MsgData)
+	if err != nil || len(hello.signature) != 0 {
+		log.Debugf("wrong handshake message from %s. Closing..", bconn.RemoteAddr().String())
+		_ = bconn.Close()
+		return
+	}
+	log.Debugf("received handshake from inbound id = %s", hello.peeringId)
 
 	peersMutex.RLock()
-	peer, ok := peers[peeringId]
+	peer, ok := peers[hello.peeringId]
 	peersMutex.RUnlock()
 
 	if !ok || !peer.isInbound() {
-		log.Debugf("inbound connection from unexpected peer id %s. Closing..", peeringId)
+		log.Debugf("inbound connection from unexpected peer id %s. Closing..", hello.peeringId)
+		_ = bconn.Close()
+		return
+	}
+	if err := peer.checkPubKey(hello.pubKey); err != nil {
+		log.Errorf("inbound connection from %s: %v. Closing..", hello.peeringId, err)
+		_ = bconn.Close()
+		return
+	}
+	hs, err := newHandshakeState()
+	if err != nil {
+		log.Errorf("can't start handshake: %v. Closing..", err)
+		_ = bconn.Close()
+		return
+	}
+	hs.peer = peer
+	hs.outbound = hello
+	hs.inbound = &handshakeMsg{
+		peeringId: hello.peeringId,
+		pubKey:    MyPublicKey(),
+		ephPubKey: hs.ephPubKey,
+	}
+	hs.inbound.signature = hs.sign(sigRoleInbound)
+	bconn.handshake = hs
+
+	data, _ := encodeMessage(&PeerMessage{
+		MsgType: MsgTypeHandshake,
+		MsgData: hs.inbound.bytes(),
+	})
+	if err := bconn.send(data); err != nil {
+		log.Errorf("error while responding to handshake: %v. Closing connection", err)
+		_ = bconn.Close()
+	}
+}
+
+// receives the signature of the inbound peer which finishes the handshake
+// links connection with the peer
+func (bconn *peeredConnection) processHandShakeAuth(msg *PeerMessage) {
+	hs := bconn.handshake
+	peer := hs.peer
+	if err := hs.verify(hs.outbound.pubKey, sigRoleOutbound, msg.MsgData); err != nil {
+		log.Errorf("inbound connection from %s: %v. Closing..", peer.PeeringId(), err)
 		_ = bconn.Close()
 		return
 	}
+	sess, err := hs.newSession(false)
+	if err != nil {
+		log.Errorf("inbound connection from %s: %v. Closing..", peer.PeeringId(), err)
+		_ = bconn.Close()
+		return
+	}
+	bconn.setSession(sess)
 	bconn.peer = peer
 
 	peer.Lock()
+	bconn.remotePubKey = hs.outbound.pubKey
 	peer.peerconn = bconn
 	peer.handshakeOk = true
 	peer.Unlock()
 
-	log.Infof("CONNECTED WITH PEER %s (inbound)", peeringId)
+	log.Infof("CONNECTED WITH PEER %s (inbound), public key %s", peer.PeeringId(), hs.outbound.pubKey.String())
 
-	if err := peer.sendHandshake(); err == nil {
-		bconn.peer.initHeartbeats()
-		bconn.peer.receiveHeartbeat(msg.Timestamp)
-		go bconn.peer.scheduleNexHeartbeat()
-	} else {
-		log.Error("error while responding to handshake: %v. Closing connection", err)
-		_ = bconn.Close()
-	}
+	peer.initHeartbeats()
+	peer.receiveHeartbeat(msg.Timestamp)
+	go peer.scheduleNexHeartbeat()
 }
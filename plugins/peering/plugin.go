@@ -24,7 +24,12 @@ func configure(_ *node.Plugin) {
 		log.Errorf("can't continue: %v", err)
 		return
 	}
+	if err := loadIdentity(); err != nil {
+		log.Errorf("can't load node identity: %v", err)
+		return
+	}
 	log.Infof("my network Id = %s", MyNetworkId())
+	log.Infof("my public key = %s", MyPublicKey().String())
 	initialized.Store(true)
 }
 
@@ -0,0 +1,189 @@
+package peering
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/util"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+// the handshake authenticates both peers by their identity keys and establishes the encrypted session:
+// 1. outbound --> inbound: hello with peering id, identity public key and ephemeral X25519 public key
+// 2. inbound --> outbound: hello with the same peering id, own keys and the signature of the transcript
+// 3. outbound --> inbound: signature of the transcript (MsgTypeHandshakeAuth)
+// The transcript contains both hellos, so each signature covers the fresh ephemeral key of the other side.
+// Keys of the session are derived from the shared secret of ephemeral keys.
+// After the handshake all messages are encrypted with ChaCha20-Poly1305, separate key for each direction
+
+const (
+	sigRoleInbound  = byte(0)
+	sigRoleOutbound = byte(1)
+
+	// size of the Poly1305 authentication tag
+	sessionOverhead = 16
+)
+
+type handshakeMsg struct {
+	peeringId string
+	pubKey    ed25519.PublicKey
+	ephPubKey [32]byte
+	// empty in the hello of the outbound peer
+	signature []byte
+}
+
+// state of the handshake while it is in progress
+type handshakeState struct {
+	ephPrivKey [32]byte
+	ephPubKey  [32]byte
+	outbound   *handshakeMsg
+	inbound    *handshakeMsg
+	// peer which will be linked with inbound connection when handshake is finished
+	peer *Peer
+}
+
+// session encrypts and decrypts messages of one connection.
+// Nonces are counters, so messages must be opened in the order they were sealed
+type session struct {
+	sendAEAD  cipher.AEAD
+	recvAEAD  cipher.AEAD
+	sendNonce uint64
+	recvNonce uint64
+}
+
+func (msg *handshakeMsg) bytes() []byte {
+	var buf bytes.Buffer
+	_ = util.WriteString16(&buf, msg.peeringId)
+	buf.Write(msg.pubKey[:])
+	buf.Write(msg.ephPubKey[:])
+	_ = util.WriteBytes16(&buf, msg.signature)
+	return buf.Bytes()
+}
+
+func handshakeMsgFromBytes(data []byte) (*handshakeMsg, error) {
+	rdr := bytes.NewReader(data)
+	ret := &handshakeMsg{}
+	var err error
+	if ret.peeringId, err = util.ReadString16(rdr); err != nil {
+		return nil, err
+	}
+	if _, err = io.ReadFull(rdr, ret.pubKey[:]); err != nil {
+		return nil, err
+	}
+	if _, err = io.ReadFull(rdr, ret.ephPubKey[:]); err != nil {
+		return nil, err
+	}
+	if ret.signature, err = util.ReadBytes16(rdr); err != nil {
+		return nil, err
+	}
+	if rdr.Len() != 0 {
+		return nil, errors.New("unexpected bytes at the end of the handshake message")
+	}
+	return ret, nil
+}
+
+func newHandshakeState() (*handshakeState, error) {
+	ret := &handshakeState{}
+	if _, err := rand.Read(ret.ephPrivKey[:]); err != nil {
+		return nil, err
+	}
+	pub, err := curve25519.X25519(ret.ephPrivKey[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	copy(ret.ephPubKey[:], pub)
+	return ret, nil
+}
+
+// transcript is the hash of both hellos without signatures
+func (hs *handshakeState) transcript() []byte {
+	return hashing.HashData(
+		[]byte("wasp peering handshake"),
+		[]byte(hs.outbound.peeringId),
+		hs.outbound.pubKey[:],
+		hs.outbound.ephPubKey[:],
+		hs.inbound.pubKey[:],
+		hs.inbound.ephPubKey[:],
+	).Bytes()
+}
+
+func (hs *handshakeState) sign(role byte) []byte {
+	sig := identity.Sign(append(hs.transcript(), role))
+	return sig.Bytes()
+}
+
+func (hs *handshakeState) verify(pubKey ed25519.PublicKey, role byte, signature []byte) error {
+	sig, _, err := ed25519.SignatureFromBytes(signature)
+	if err != nil {
+		return err
+	}
+	if !pubKey.VerifySignature(append(hs.transcript(), role), sig) {
+		return fmt.Errorf("invalid handshake signature")
+	}
+	return nil
+}
+
+// newSession derives keys of the session. Both hellos must be known
+func (hs *handshakeState) newSession(isOutbound bool) (*session, error) {
+	theirEphPubKey := hs.inbound.ephPubKey
+	if !isOutbound {
+		theirEphPubKey = hs.outbound.ephPubKey
+	}
+	shared, err := curve25519.X25519(hs.ephPrivKey[:], theirEphPubKey[:])
+	if err != nil {
+		return nil, err
+	}
+	kdf := hkdf.New(sha256.New, shared, hs.transcript(), nil)
+	var outKey, inKey [chacha20poly1305.KeySize]byte
+	if _, err = io.ReadFull(kdf, outKey[:]); err != nil {
+		return nil, err
+	}
+	if _, err = io.ReadFull(kdf, inKey[:]); err != nil {
+		return nil, err
+	}
+	// outKey encrypts traffic from outbound to inbound peer, inKey the opposite direction
+	sendKey, recvKey := outKey, inKey
+	if !isOutbound {
+		sendKey, recvKey = inKey, outKey
+	}
+	ret := &session{}
+	if ret.sendAEAD, err = chacha20poly1305.New(sendKey[:]); err != nil {
+		return nil, err
+	}
+	if ret.recvAEAD, err = chacha20poly1305.New(recvKey[:]); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func makeNonce(counter uint64) []byte {
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[:8], counter)
+	return nonce[:]
+}
+
+// seal encrypts the message. Not thread safe
+func (s *session) seal(data []byte) []byte {
+	ret := s.sendAEAD.Seal(nil, makeNonce(s.sendNonce), data, nil)
+	s.sendNonce++
+	return ret
+}
+
+// open decrypts the next message received from the peer
+func (s *session) open(data []byte) ([]byte, error) {
+	ret, err := s.recvAEAD.Open(nil, makeNonce(s.recvNonce), data, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.recvNonce++
+	return ret, nil
+}
@@ -0,0 +1,82 @@
+package peering
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandshake(t *testing.T) {
+	var err error
+	_, identity, err = ed25519.GenerateKey()
+	assert.NoError(t, err)
+
+	out, err := newHandshakeState()
+	assert.NoError(t, err)
+	out.outbound = &handshakeMsg{peeringId: "a<b", pubKey: MyPublicKey(), ephPubKey: out.ephPubKey}
+
+	hello, err := handshakeMsgFromBytes(out.outbound.bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, out.outbound, hello)
+
+	in, err := newHandshakeState()
+	assert.NoError(t, err)
+	in.outbound = hello
+	in.inbound = &handshakeMsg{peeringId: hello.peeringId, pubKey: MyPublicKey(), ephPubKey: in.ephPubKey}
+	in.inbound.signature = in.sign(sigRoleInbound)
+
+	resp, err := handshakeMsgFromBytes(in.inbound.bytes())
+	assert.NoError(t, err)
+	out.inbound = resp
+	assert.NoError(t, out.verify(resp.pubKey, sigRoleInbound, resp.signature))
+	// signature of one role can't be used for another
+	assert.Error(t, out.verify(resp.pubKey, sigRoleOutbound, resp.signature))
+
+	auth := out.sign(sigRoleOutbound)
+	assert.NoError(t, in.verify(hello.pubKey, sigRoleOutbound, auth))
+
+	outSession, err := out.newSession(true)
+	assert.NoError(t, err)
+	inSession, err := in.newSession(false)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		sealed := outSession.seal([]byte("to inbound"))
+		data, err := inSession.open(sealed)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("to inbound"), data)
+
+		sealed = inSession.seal([]byte("to outbound"))
+		data, err = outSession.open(sealed)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("to outbound"), data)
+	}
+	// replayed message is rejected
+	sealed := outSession.seal([]byte("once"))
+	_, err = inSession.open(sealed)
+	assert.NoError(t, err)
+	_, err = inSession.open(sealed)
+	assert.Error(t, err)
+}
+
+func TestPinnedPubKey(t *testing.T) {
+	pinned, _, err := ed25519.GenerateKey()
+	assert.NoError(t, err)
+	other, _, err := ed25519.GenerateKey()
+	assert.NoError(t, err)
+
+	peer := &Peer{RWMutex: &sync.RWMutex{}, remoteLocation: "127.0.0.1:4000"}
+	// peers with keys not from the bootup data are rejected
+	assert.Error(t, peer.checkPubKey(pinned))
+
+	assert.NoError(t, peer.PinPublicKey(pinned))
+	assert.NoError(t, peer.checkPubKey(pinned))
+	assert.Error(t, peer.checkPubKey(other))
+
+	// the key pinned by one committee can't be replaced by another
+	assert.NoError(t, peer.PinPublicKey(pinned))
+	assert.Error(t, peer.PinPublicKey(other))
+	assert.NoError(t, peer.checkPubKey(pinned))
+}
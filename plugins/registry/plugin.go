@@ -0,0 +1,61 @@
+// Package registry is a plugin which unlocks the registry of the node with the master key
+package registry
+
+import (
+	"io/ioutil"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hive.go/node"
+	"github.com/iotaledger/wasp/packages/parameters"
+	"github.com/iotaledger/wasp/packages/registry"
+)
+
+// PluginName is the name of the registry plugin.
+const PluginName = "Registry"
+
+var (
+	// Plugin is the plugin instance of the registry plugin.
+	Plugin = node.NewPlugin(PluginName, node.Enabled, configure)
+	log    *logger.Logger
+)
+
+// configure derives the master key from the key file or the passphrase before other plugins
+// read private keys from the registry. The key file takes precedence.
+// The master key is mandatory if the registry contains key shares
+func configure(_ *node.Plugin) {
+	log = logger.NewLogger(PluginName)
+	registry.InitLogger()
+
+	var secret []byte
+	if keyFile := parameters.GetString(parameters.RegistryMasterKeyFile); keyFile != "" {
+		var err error
+		if secret, err = ioutil.ReadFile(keyFile); err != nil {
+			log.Panicf("can't read master key file: %v", err)
+		}
+	} else {
+		secret = []byte(parameters.GetString(parameters.RegistryMasterKeyPhrase))
+	}
+	if len(secret) == 0 {
+		encrypted, err := registry.IsEncrypted()
+		if err != nil {
+			log.Panicf("can't read the registry: %v", err)
+		}
+		if encrypted {
+			log.Panicf("private keys in the registry are encrypted. The master key file or passphrase must be configured")
+		}
+		hasKeyShares, err := registry.HasDKShares()
+		if err != nil {
+			log.Panicf("can't read the registry: %v", err)
+		}
+		if hasKeyShares {
+			log.Panicf("key shares are stored in the registry. The master key file or passphrase must be configured to encrypt them")
+		}
+		log.Warnf("master key is not configured: private keys of the node are stored unencrypted. " +
+			"The node won't start without the master key after it stores key shares")
+		return
+	}
+	if err := registry.SetMasterKey(secret); err != nil {
+		log.Panicf("can't set the master key: %v", err)
+	}
+	log.Infof("private keys of the node are encrypted with the master key")
+}
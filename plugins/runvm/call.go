@@ -0,0 +1,93 @@
+package runvm
+
+import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/processor"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// callTargets loads smart contracts called synchronously by the request.
+// Only smart contracts run by the same committee nodes can be called: their solid state is kept by the node
+// and their processors are loaded by their own committees. Processors are not loaded here:
+// the call fails if the processor is not ready.
+// The called smart contract is run over its solid state, so nodes not synced with it yet may come to
+// a different result. Such results don't reach the quorum and the batch is not finalized
+type callTargets struct {
+	caller *vm.VMContext
+	// processors acquired for the request, by program hash. Released when the request is finished
+	acquired map[string]vmtypes.Processor
+}
+
+func newCallTargets(ctx *vm.VMContext) *callTargets {
+	return &callTargets{
+		caller:   ctx,
+		acquired: make(map[string]vmtypes.Processor),
+	}
+}
+
+func (ct *callTargets) load(addr *address.Address) (*vm.CallTarget, bool, error) {
+	callerData, ok, err := registry.GetBootupData(&ct.caller.Address)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	bd, ok, err := registry.GetBootupData(addr)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if bd.RotatedTo != nil || !sameNodes(bd.CommitteeNodes, callerData.CommitteeNodes) {
+		return nil, false, nil
+	}
+	vs, _, ok, err := state.LoadSolidState(addr)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	progHash, ok, err := vs.Variables().Codec().GetHashValue(vmconst.VarNameProgramHash)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	progHashStr := progHash.String()
+	proc, ok := ct.acquired[progHashStr]
+	if !ok {
+		if *progHash == ct.caller.ProgramHash {
+			// the same program as the caller's, its processor is acquired by the request
+			proc = ct.caller.Processor
+		} else {
+			if proc, err = processor.Acquire(progHashStr); err != nil {
+				ct.caller.Log.Warnf("call: processor of %s is not ready: %v", addr.String(), err)
+				return nil, false, nil
+			}
+		}
+		ct.acquired[progHashStr] = proc
+	}
+	return &vm.CallTarget{
+		OwnerAddress: bd.OwnerAddress,
+		ProgramHash:  *progHash,
+		VirtualState: vs,
+		Processor:    proc,
+	}, true, nil
+}
+
+// release releases processors acquired for the request
+func (ct *callTargets) release() {
+	for progHash := range ct.acquired {
+		if progHash != ct.caller.ProgramHash.String() {
+			processor.Release(progHash)
+		}
+	}
+}
+
+func sameNodes(nodes1, nodes2 []string) bool {
+	if len(nodes1) != len(nodes2) {
+		return false
+	}
+	for i := range nodes1 {
+		if nodes1[i] != nodes2[i] {
+			return false
+		}
+	}
+	return true
+}
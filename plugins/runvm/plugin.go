@@ -103,12 +103,23 @@ func runTask(ctx *vm.VMTask, txb *txbuilder.Builder, shutdownSignal <-chan struc
 		RewardAddress: ctx.RewardAddress,
 		ProgramHash:   ctx.ProgramHash,
 		MinimumReward: ctx.MinimumReward,
+		GasLimit:      ctx.GasLimit,
 		Entropy:       *hashing.HashData(ctx.Entropy[:]), // mutates deterministically
 		TxBuilder:     txb,                               // mutates
 		Timestamp:     ctx.Timestamp,                     // mutate by incrementing 1 nanosec
 		VirtualState:  ctx.VirtualState.Clone(),
 		Log:           ctx.Log,
 	}
+	// database error of the node aborts the batch. It is not a result of the request
+	defer func() {
+		if r := recover(); r != nil {
+			if !vmtypes.IsDBError(r) {
+				panic(r)
+			}
+			ctx.Log.Errorf("RunVM: database error, the batch is aborted: %v", r)
+			ctx.OnFinish(fmt.Errorf("RunVM: database error: %v", r))
+		}
+	}()
 	stateUpdates := make([]state.StateUpdate, 0, len(ctx.Requests))
 	for _, reqRef := range ctx.Requests {
 
@@ -149,7 +160,17 @@ func runTask(ctx *vm.VMTask, txb *txbuilder.Builder, shutdownSignal <-chan struc
 		ctx.OnFinish(fmt.Errorf("RunVM: %v", err))
 		return
 	}
-	stateHash := vsClone.Hash()
+	stateHash, err := vsClone.Hash()
+	if err != nil {
+		ctx.OnFinish(fmt.Errorf("RunVM: %v", err))
+		return
+	}
+
+	if vmctx.RotationAddress != nil {
+		// the smart contract token and all funds go to the address of the new committee
+		vmctx.TxBuilder.MoveAllToAddress(ctx.Address, *vmctx.RotationAddress)
+		ctx.Log.Infof("RunVM: smart contract is rotated to the new address %s", vmctx.RotationAddress.String())
+	}
 
 	// add state block
 	err = vmctx.TxBuilder.SetStateParams(ctx.VirtualState.StateIndex()+1, &stateHash, vsClone.Timestamp())
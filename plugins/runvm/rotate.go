@@ -0,0 +1,32 @@
+package runvm
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+)
+
+// finalizeRotation is run after the builtin rotation request. If the request approved the new address,
+// the smart contract token and all funds are moved to it when the batch is completed, so the state
+// transaction of the batch is the last one produced by the current committee.
+// Requests of the batch after the rotation are still processed by the current committee
+func finalizeRotation(ctx *vm.VMContext) {
+	if ctx.StateUpdate.Error() != "" {
+		return
+	}
+	mut := ctx.StateUpdate.Mutations().Latest(vmconst.VarNameSCAddress)
+	if mut == nil || mut.Value() == nil {
+		// the request was ignored
+		return
+	}
+	newAddr, _, err := address.FromBytes(mut.Value())
+	if err != nil {
+		ctx.StateUpdate.Clear()
+		ctx.StateUpdate.WithError(fmt.Sprintf("rotate: %v", err))
+		return
+	}
+	ctx.Log.Infof("smart contract %s will be rotated to the address %s", ctx.Address.String(), newAddr.String())
+	ctx.RotationAddress = &newAddr
+}
@@ -1,14 +1,16 @@
 package runvm
 
 import (
+	"fmt"
+
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
-	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm"
 	"github.com/iotaledger/wasp/packages/vm/builtin"
 	"github.com/iotaledger/wasp/packages/vm/processor"
 	"github.com/iotaledger/wasp/packages/vm/sandbox"
 	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
 )
 
 // runTheRequest:
@@ -23,6 +25,7 @@ func runTheRequest(ctx *vm.VMContext) {
 	ctx.Log.Debugf("runTheRequest IN:\n%s\n", ctx.RequestRef.RequestBlock().String(ctx.RequestRef.RequestId()))
 
 	if !handleRewards(ctx) {
+		ctx.StateUpdate.WithError("not enough reward")
 		return
 	}
 
@@ -43,6 +46,7 @@ func runTheRequest(ctx *vm.VMContext) {
 				"owner", ctx.OwnerAddress.String(),
 				"inputs", util.InputsToStringByAddress(ctx.RequestRef.Tx.Inputs()),
 			)
+			ctx.StateUpdate.WithError("protected request is not authorised")
 			return
 		}
 		if ctx.VirtualState.StateIndex() > 0 && !ctx.VirtualState.InitiatedBy(&ctx.OwnerAddress) {
@@ -54,6 +58,7 @@ func runTheRequest(ctx *vm.VMContext) {
 			ctx.Log.Errorf("inconsistent state: variable '%s' != owner record from bootup record '%s'",
 				vmconst.VarNameOwnerAddress, ctx.OwnerAddress.String())
 
+			ctx.StateUpdate.WithError("inconsistent state: owner address")
 			return
 		}
 	}
@@ -63,9 +68,17 @@ func runTheRequest(ctx *vm.VMContext) {
 		entryPoint, ok := builtin.Processor.GetEntryPoint(reqBlock.RequestCode())
 		if !ok {
 			ctx.Log.Warnf("can't find entry point for request code %s in the builtin processor", reqBlock.RequestCode())
+			ctx.StateUpdate.WithError(fmt.Sprintf("entry point not found: %s", reqBlock.RequestCode()))
 			return
 		}
-		entryPoint.Run(sandbox.NewSandbox(ctx))
+		// builtin requests are not limited by gas, however the gas burned is recorded
+		runEntryPoint(ctx, entryPoint.WithGasLimit(0))
+		switch reqBlock.RequestCode() {
+		case vmconst.RequestCodeUpgrade:
+			finalizeUpgrade(ctx)
+		case vmconst.RequestCodeRotateCommittee:
+			finalizeRotation(ctx)
+		}
 
 		defer ctx.Log.Debugw("runTheRequest OUT BUILTIN",
 			"reqId", ctx.RequestRef.RequestId().Short(),
@@ -76,10 +89,12 @@ func runTheRequest(ctx *vm.VMContext) {
 		return
 	}
 
-	// request requires user-defined program on VM
+	// request requires user-defined program on VM.
+	// The processor is used by the committee, so it is not unloaded
 	proc, err := processor.Acquire(ctx.ProgramHash.String())
 	if err != nil {
 		ctx.Log.Warn(err)
+		ctx.StateUpdate.WithError(vmtypes.RecordedError(vmtypes.ErrProcessorNotAvailable).Error())
 		return
 	}
 	defer processor.Release(ctx.ProgramHash.String())
@@ -88,23 +103,19 @@ func runTheRequest(ctx *vm.VMContext) {
 	if !ok {
 		ctx.Log.Warnf("can't find entry point for request code %s in the user-defined processor prog hash: %s",
 			reqBlock.RequestCode(), ctx.ProgramHash.String())
+		ctx.StateUpdate.WithError(fmt.Sprintf("entry point not found: %s", reqBlock.RequestCode()))
 		return
 	}
 
-	sandbox := sandbox.NewSandbox(ctx)
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				ctx.Log.Errorf("Recovered from panic in SC: %v", r)
-				if _, ok := r.(kv.DBError); ok {
-					// There was an error accessing the DB
-					// TODO invalidate the whole batch?
-				}
-				sandbox.Rollback()
-			}
-		}()
-		entryPoint.Run(sandbox)
+	ctx.Processor = proc
+	targets := newCallTargets(ctx)
+	ctx.LoadCallTarget = targets.load
+	defer func() {
+		targets.release()
+		ctx.Processor = nil
+		ctx.LoadCallTarget = nil
 	}()
+	runEntryPoint(ctx, entryPoint.WithGasLimit(ctx.GasLimit))
 
 	defer ctx.Log.Debugw("runTheRequest OUT USER DEFINED",
 		"reqId", ctx.RequestRef.RequestId().Short(),
@@ -114,6 +125,36 @@ func runTheRequest(ctx *vm.VMContext) {
 	)
 }
 
+// runEntryPoint runs the entry point in the sandbox and records gas burned in the state update.
+// In case of panic (including out of gas) the state update is rolled back, however gas burned
+// and the error are still recorded. The state update is hashed for consensus, so only errors which are the same
+// on all nodes are recorded (see vmtypes.RecordedError).
+// Database errors are failures of the node, not results of the request: they are passed to runTask
+// which aborts the batch
+func runEntryPoint(ctx *vm.VMContext, entryPoint vmtypes.EntryPoint) {
+	sb := sandbox.NewSandbox(ctx)
+	defer func() {
+		ctx.StateUpdate.WithGasUsed(sb.(vmtypes.GasMeter).GasUsed())
+	}()
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if vmtypes.IsDBError(r) {
+			panic(r)
+		}
+		if r == vmtypes.ErrOutOfGas {
+			ctx.Log.Warnf("request %s ran out of gas", ctx.RequestRef.RequestId().Short())
+		} else {
+			ctx.Log.Errorf("Recovered from panic in SC: %v", r)
+		}
+		sb.Rollback()
+		ctx.StateUpdate.WithError(vmtypes.RecordedError(r).Error())
+	}()
+	entryPoint.Run(sb)
+}
+
 // handleRewards return true if to continue with request processing
 func handleRewards(ctx *vm.VMContext) bool {
 	if ctx.RewardAddress[0] == 0 {
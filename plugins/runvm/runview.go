@@ -0,0 +1,60 @@
+package runvm
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm/processor"
+	"github.com/iotaledger/wasp/packages/vm/sandbox"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// RunView runs the view entry point of the smart contract against its solid state on this node.
+// The processor of the smart contract must be loaded on the node.
+// Views are limited by the same gas limit as requests, however never unlimited
+func RunView(scAddress *address.Address, code sctransaction.RequestCode, params kv.Map) (kv.Map, error) {
+	// TODO serialize access to solid state
+	virtualState, _, exist, err := state.LoadSolidState(scAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, fmt.Errorf("state not found with address %s", scAddress.String())
+	}
+	vars := virtualState.Variables().Codec()
+	progHash, ok, err := vars.GetHashValue(vmconst.VarNameProgramHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("program hash not found in the state of %s", scAddress.String())
+	}
+	gasLimit, ok, err := vars.GetInt64(vmconst.VarNameGasLimit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || gasLimit <= 0 {
+		// views are never unlimited: they are not paid for
+		gasLimit = vmconst.DefaultGasLimit
+	}
+
+	proc, err := processor.Acquire(progHash.String())
+	if err != nil {
+		return nil, err
+	}
+	defer processor.Release(progHash.String())
+
+	viewProc, ok := proc.(vmtypes.ViewProcessor)
+	if !ok {
+		return nil, fmt.Errorf("processor %s has no view entry points", progHash.String())
+	}
+	entryPoint, ok := viewProc.GetViewEntryPoint(code)
+	if !ok {
+		return nil, fmt.Errorf("can't find view entry point for code %s in processor %s", code.String(), progHash.String())
+	}
+	return sandbox.RunView(entryPoint, sandbox.NewSandboxView(scAddress, virtualState, params, int(gasLimit), log))
+}
@@ -0,0 +1,56 @@
+package runvm
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/processor"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/packages/vm/vmtypes"
+)
+
+// finalizeUpgrade is run after the builtin upgrade request. It acquires the processor of the new program hash
+// and runs its migration entry point (if any) in the same state update. The processor is not loaded here:
+// the committee loads it before the request is selected for the batch (see consensus.upgradeProcessorReady).
+// If the processor is not available or migration fails, the whole state update is discarded
+// and the smart contract remains with the old program hash.
+// Upon success, next requests of the batch are run by the new processor.
+// The committee stops using the old processor when the new state is settled
+func finalizeUpgrade(ctx *vm.VMContext) {
+	if ctx.StateUpdate.Error() != "" {
+		return
+	}
+	mut := ctx.StateUpdate.Mutations().Latest(vmconst.VarNameProgramHash)
+	if mut == nil || mut.Value() == nil {
+		ctx.StateUpdate.WithError("upgrade: program hash not changed")
+		return
+	}
+	var newHash hashing.HashValue
+	copy(newHash[:], mut.Value())
+	newHashStr := newHash.String()
+
+	proc, err := processor.Acquire(newHashStr)
+	if err != nil {
+		ctx.Log.Warnf("upgrade to %s failed: %v", newHashStr, err)
+		ctx.StateUpdate.Clear()
+		ctx.StateUpdate.WithError(vmtypes.RecordedError(vmtypes.ErrProcessorNotAvailable).Error())
+		return
+	}
+	defer processor.Release(newHashStr)
+
+	if mp, ok := proc.(vmtypes.MigrationProcessor); ok {
+		if entryPoint, ok := mp.GetMigrationEntryPoint(); ok {
+			ctx.Processor = proc
+			runEntryPoint(ctx, entryPoint.WithGasLimit(ctx.GasLimit))
+			ctx.Processor = nil
+			if ctx.StateUpdate.Error() != "" {
+				ctx.Log.Warnf("migration to %s failed: %s", newHashStr, ctx.StateUpdate.Error())
+				ctx.StateUpdate.WithError(fmt.Sprintf("upgrade: migration failed: %s", ctx.StateUpdate.Error()))
+				return
+			}
+		}
+	}
+	ctx.Log.Infof("smart contract upgraded from program hash %s to %s", ctx.ProgramHash.String(), newHashStr)
+	ctx.ProgramHash = newHash
+}
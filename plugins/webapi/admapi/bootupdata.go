@@ -1,11 +1,14 @@
 package admapi
 
 import (
+	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
 	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/webapi/misc"
 	"github.com/labstack/echo"
+	"github.com/mr-tron/base58"
 )
 
 type BootupDataJsonable struct {
@@ -14,6 +17,8 @@ type BootupDataJsonable struct {
 	Color          string   `json:"color"`
 	CommitteeNodes []string `json:"committee_nodes"`
 	AccessNodes    []string `json:"access_nodes"`
+	// base58 encoded identity keys of committee nodes in the same order. Required
+	CommitteePubKeys []string `json:"committee_pubkeys,omitempty"`
 }
 
 //----------------------------------------------------------
@@ -43,6 +48,12 @@ func HandlerPutSCData(c echo.Context) error {
 
 	rec.CommitteeNodes = req.CommitteeNodes
 	rec.AccessNodes = req.AccessNodes
+	if rec.CommitteePubKeys, err = PubKeysFromBase58(req.CommitteePubKeys); err != nil {
+		return misc.OkJsonErr(c, err)
+	}
+	if len(rec.CommitteePubKeys) != len(rec.CommitteeNodes) {
+		return misc.OkJsonErr(c, fmt.Errorf("public keys of all committee nodes are required"))
+	}
 
 	// TODO it is always overwritten!
 
@@ -69,8 +80,10 @@ type GetBootupDataRequest struct {
 
 type GetBootupDataResponse struct {
 	BootupDataJsonable
-	Exists bool   `json:"exists"`
-	Error  string `json:"err"`
+	// base58 encoded new address if the smart contract was handed over to another committee
+	RotatedTo string `json:"rotated_to,omitempty"`
+	Exists    bool   `json:"exists"`
+	Error     string `json:"err"`
 }
 
 func HandlerGetSCData(c echo.Context) error {
@@ -95,14 +108,21 @@ func HandlerGetSCData(c echo.Context) error {
 	if !exists {
 		return misc.OkJson(c, &GetBootupDataResponse{Exists: false})
 	}
-	return misc.OkJson(c, &GetBootupDataResponse{
+	ret := &GetBootupDataResponse{
 		BootupDataJsonable: BootupDataJsonable{
-			Address:        bd.Address.String(),
-			CommitteeNodes: bd.CommitteeNodes,
-			AccessNodes:    bd.AccessNodes,
+			Address:          bd.Address.String(),
+			OwnerAddress:     bd.OwnerAddress.String(),
+			Color:            bd.Color.String(),
+			CommitteeNodes:   bd.CommitteeNodes,
+			AccessNodes:      bd.AccessNodes,
+			CommitteePubKeys: PubKeysToBase58(bd.CommitteePubKeys),
 		},
 		Exists: exists,
-	})
+	}
+	if bd.RotatedTo != nil {
+		ret.RotatedTo = bd.RotatedTo.String()
+	}
+	return misc.OkJson(c, ret)
 }
 
 type GetScAddressesResponse struct {
@@ -121,3 +141,31 @@ func HandlerGetSCList(c echo.Context) error {
 	}
 	return misc.OkJson(c, &GetScAddressesResponse{Addresses: ret})
 }
+
+func PubKeysToBase58(pubKeys []ed25519.PublicKey) []string {
+	if len(pubKeys) == 0 {
+		return nil
+	}
+	ret := make([]string, len(pubKeys))
+	for i := range pubKeys {
+		ret[i] = pubKeys[i].String()
+	}
+	return ret
+}
+
+func PubKeysFromBase58(strs []string) ([]ed25519.PublicKey, error) {
+	if len(strs) == 0 {
+		return nil, nil
+	}
+	ret := make([]ed25519.PublicKey, len(strs))
+	for i, s := range strs {
+		data, err := base58.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		if ret[i], _, err = ed25519.PublicKeyFromBytes(data); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,21 @@
+package admapi
+
+import (
+	"github.com/iotaledger/wasp/plugins/peering"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type NodeIdentityResponse struct {
+	NetId  string `json:"net_id"`
+	PubKey string `json:"pub_key"`
+}
+
+// HandlerNodeIdentity returns the network id and the public identity key of the node.
+// The key can be pinned in the bootup data of smart contracts on other nodes
+func HandlerNodeIdentity(c echo.Context) error {
+	return misc.OkJson(c, &NodeIdentityResponse{
+		NetId:  peering.MyNetworkId(),
+		PubKey: peering.MyPublicKey().String(),
+	})
+}
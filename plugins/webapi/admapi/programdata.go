@@ -3,6 +3,7 @@ package admapi
 import (
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/schema"
 	"github.com/iotaledger/wasp/plugins/webapi/misc"
 	"github.com/labstack/echo"
 )
@@ -12,6 +13,8 @@ type ProgramMetadataJsonable struct {
 	Location    string `json:"location"`
 	VMType      string `json:"vm_type"`
 	Description string `json:"description"`
+	// optional
+	Schema *schema.Schema `json:"schema,omitempty"`
 }
 
 //----------------------------------------------------------
@@ -31,6 +34,7 @@ func HandlerPutProgramMetaData(c echo.Context) error {
 	rec.Location = req.Location
 	rec.VMType = req.VMType
 	rec.Description = req.Description
+	rec.Schema = req.Schema
 
 	// TODO it is always overwritten!
 
@@ -83,6 +87,7 @@ func HandlerGetProgramMetadata(c echo.Context) error {
 			Location:    md.Location,
 			VMType:      md.VMType,
 			Description: md.Description,
+			Schema:      md.Schema,
 		},
 		ExistsMetadata: true,
 		ExistsCode:     exists,
@@ -0,0 +1,64 @@
+package admapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type RetentionPolicyRequest struct {
+	Address     string `json:"address"` //base58
+	KeepStates  uint32 `json:"keep_states"`
+	KeepMinutes uint32 `json:"keep_minutes"`
+	// if true, the smart contract falls back to the node's policy and other fields are ignored
+	NodeDefault bool `json:"node_default"`
+}
+
+type RetentionPolicyResponse struct {
+	Err         string `json:"error"`
+	KeepStates  uint32 `json:"keep_states"`
+	KeepMinutes uint32 `json:"keep_minutes"`
+	NodeDefault bool   `json:"node_default"`
+}
+
+func HandlerSetRetentionPolicy(c echo.Context) error {
+	var req RetentionPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{Error: err.Error()})
+	}
+	addr, err := address.FromBase58(req.Address)
+	if err != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{Error: err.Error()})
+	}
+	var policy *state.RetentionPolicy
+	if !req.NodeDefault {
+		policy = &state.RetentionPolicy{
+			KeepStates: req.KeepStates,
+			KeepTime:   time.Duration(req.KeepMinutes) * time.Minute,
+		}
+	}
+	if err := state.SaveRetentionPolicy(&addr, policy); err != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{Error: err.Error()})
+	}
+	return c.JSON(http.StatusOK, &misc.SimpleResponse{})
+}
+
+func HandlerGetRetentionPolicy(c echo.Context) error {
+	addr, err := address.FromBase58(c.Param("scaddress"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &RetentionPolicyResponse{Err: err.Error()})
+	}
+	policy, own, err := state.LoadRetentionPolicy(&addr)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &RetentionPolicyResponse{Err: err.Error()})
+	}
+	return c.JSON(http.StatusOK, &RetentionPolicyResponse{
+		KeepStates:  policy.KeepStates,
+		KeepMinutes: uint32(policy.KeepTime / time.Minute),
+		NodeDefault: !own,
+	})
+}
@@ -0,0 +1,44 @@
+package admapi
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type ConfirmRotationRequest struct {
+	Address string `json:"address"` //base58, the old address of the smart contract
+}
+
+// HandlerConfirmRotation is called after the new committee confirmed it runs the smart contract
+// handed over from the address. The bootup record of the old address is removed from the node.
+// The state of the old address is not deleted.
+// Only records marked as rotated by the committee can be removed. The call is idempotent
+func HandlerConfirmRotation(c echo.Context) error {
+	var req ConfirmRotationRequest
+	if err := c.Bind(&req); err != nil {
+		return misc.OkJsonErr(c, err)
+	}
+	addr, err := address.FromBase58(req.Address)
+	if err != nil {
+		return misc.OkJsonErr(c, err)
+	}
+	bd, exists, err := registry.GetBootupData(&addr)
+	if err != nil {
+		return misc.OkJsonErr(c, err)
+	}
+	if !exists {
+		return misc.OkJsonErr(c, nil)
+	}
+	if bd.RotatedTo == nil {
+		return misc.OkJsonErr(c, fmt.Errorf("smart contract %s was not rotated", req.Address))
+	}
+	if err = registry.DeleteBootupData(&addr); err != nil {
+		return misc.OkJsonErr(c, err)
+	}
+	log.Infof("rotation of %s to the address %s confirmed. Bootup record removed", req.Address, bd.RotatedTo.String())
+	return misc.OkJsonErr(c, nil)
+}
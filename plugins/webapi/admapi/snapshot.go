@@ -0,0 +1,79 @@
+package admapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/committees"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type ExportSnapshotResponse struct {
+	Err        string `json:"error"`
+	StateIndex uint32 `json:"state_index"`
+	StateHash  string `json:"state_hash"` // base58
+	Snapshot   []byte `json:"snapshot"`   // serialized state.Snapshot
+}
+
+type ImportSnapshotRequest struct {
+	Snapshot []byte `json:"snapshot"` // serialized state.Snapshot, with the anchoring state transaction
+}
+
+func HandlerExportSnapshot(c echo.Context) error {
+	scAddress, err := address.FromBase58(c.Param("scaddress"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ExportSnapshotResponse{Err: err.Error()})
+	}
+	snapshot, exist, err := state.ExportSnapshot(&scAddress)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ExportSnapshotResponse{Err: err.Error()})
+	}
+	if !exist {
+		return c.JSON(http.StatusNotFound, &ExportSnapshotResponse{
+			Err: fmt.Sprintf("state not found with address %s", scAddress.String()),
+		})
+	}
+	stateHash, err := snapshot.StateHash()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ExportSnapshotResponse{Err: err.Error()})
+	}
+	data, err := util.Bytes(snapshot)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ExportSnapshotResponse{Err: err.Error()})
+	}
+	return c.JSON(http.StatusOK, &ExportSnapshotResponse{
+		StateIndex: snapshot.StateIndex,
+		StateHash:  stateHash.String(),
+		Snapshot:   data,
+	})
+}
+
+// HandlerImportSnapshot stores the snapshot as the solid state of the smart contract.
+// The committee of the smart contract must not be active on the node.
+// The state hash is calculated from variables of the snapshot and compared with the one in the state
+// transaction of the snapshot. After activation the state manager checks the state transaction on the ledger
+func HandlerImportSnapshot(c echo.Context) error {
+	var req ImportSnapshotRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{Error: err.Error()})
+	}
+	snapshot, err := state.SnapshotFromBytes(req.Snapshot)
+	if err != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{Error: err.Error()})
+	}
+	if committees.CommitteeByAddress(snapshot.SCAddress) != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{
+			Error: fmt.Sprintf("smart contract %s is already active", snapshot.SCAddress.String()),
+		})
+	}
+	log.Infof("importing snapshot of %s, state index #%d", snapshot.SCAddress.String(), snapshot.StateIndex)
+
+	if err := state.ImportSnapshot(snapshot); err != nil {
+		return c.JSON(http.StatusOK, &misc.SimpleResponse{Error: err.Error()})
+	}
+	return c.JSON(http.StatusOK, &misc.SimpleResponse{})
+}
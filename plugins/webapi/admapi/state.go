@@ -1,11 +1,14 @@
 package admapi
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/plugins/committees"
+	"github.com/iotaledger/wasp/plugins/webapi/stateapi"
 	"github.com/labstack/echo"
 )
 
@@ -14,6 +17,8 @@ type DumpSCStateResponse struct {
 	Exists    bool              `json:"exists"`
 	Index     uint32            `json:"index"`
 	Variables map[kv.Key][]byte `json:"variables"`
+	// variables decoded according to the schema, see stateapi.DecodeState. Only with ?decode=true
+	Decoded map[string]interface{} `json:"decoded,omitempty"`
 }
 
 func HandlerDumpSCState(c echo.Context) error {
@@ -30,9 +35,53 @@ func HandlerDumpSCState(c echo.Context) error {
 		return c.JSON(http.StatusOK, &DumpSCStateResponse{Exists: false})
 	}
 
-	return c.JSON(http.StatusOK, &DumpSCStateResponse{
+	ret := &DumpSCStateResponse{
 		Exists:    true,
 		Index:     virtualState.StateIndex(),
 		Variables: virtualState.Variables().DangerouslyDumpToMap().ToGoMap(),
+	}
+	if c.QueryParam("decode") == "true" {
+		_, sch, err := stateapi.LoadSchema(&scAddress)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, &DumpSCStateResponse{Err: err.Error()})
+		}
+		if sch == nil {
+			return c.JSON(http.StatusOK, &DumpSCStateResponse{
+				Err: fmt.Sprintf("schema of smart contract %s is not known", scAddress.String()),
+			})
+		}
+		if ret.Decoded, err = stateapi.DecodeState(virtualState.Variables(), sch); err != nil {
+			return c.JSON(http.StatusInternalServerError, &DumpSCStateResponse{Err: err.Error()})
+		}
+	}
+	return c.JSON(http.StatusOK, ret)
+}
+
+type ReadCacheStatsResponse struct {
+	Err string `json:"error"`
+	// false if the committee of the smart contract is not running on the node or its state is not loaded yet
+	Exists bool   `json:"exists"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// HandlerReadCacheStats returns statistics of the read cache of state variables of the smart contract
+func HandlerReadCacheStats(c echo.Context) error {
+	scAddress, err := address.FromBase58(c.Param("scaddress"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ReadCacheStatsResponse{Err: err.Error()})
+	}
+	cmt := committees.CommitteeByAddress(scAddress)
+	if cmt == nil {
+		return c.JSON(http.StatusOK, &ReadCacheStatsResponse{Exists: false})
+	}
+	stats, ok := cmt.ReadCacheStats()
+	if !ok {
+		return c.JSON(http.StatusOK, &ReadCacheStatsResponse{Exists: false})
+	}
+	return c.JSON(http.StatusOK, &ReadCacheStatsResponse{
+		Exists: true,
+		Hits:   stats.Hits,
+		Misses: stats.Misses,
 	})
 }
@@ -0,0 +1,79 @@
+package dkgapi
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/parameters"
+)
+
+// Exports and imports of key shares are appended to the audit file (see registry.auditLog) as JSON lines,
+// one record per operation. The file is synced after every record, so it survives a crash of the node.
+// The export is refused if its record can't be written
+
+const (
+	auditExport = "export"
+	auditImport = "import"
+)
+
+type auditRecord struct {
+	Time      string `json:"time"`
+	Operation string `json:"operation"`
+	Address   string `json:"address"`
+	Recipient string `json:"recipient,omitempty"`
+	Remote    string `json:"remote"`
+	Ok        bool   `json:"ok"`
+	Err       string `json:"err,omitempty"`
+}
+
+var auditMutex = &sync.Mutex{}
+
+func newAuditRecord(operation, addr, remote string, err error) *auditRecord {
+	ret := &auditRecord{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Operation: operation,
+		Address:   addr,
+		Remote:    remote,
+		Ok:        err == nil,
+	}
+	if err != nil {
+		ret.Err = err.Error()
+	}
+	return ret
+}
+
+// audit writes the record to the audit file and to the audit logger
+func audit(rec *auditRecord) error {
+	auditLog.Infow(rec.Operation+" of DKShare",
+		"address", rec.Address,
+		"recipient", rec.Recipient,
+		"remote", rec.Remote,
+		"ok", rec.Ok,
+	)
+	return appendAuditRecord(parameters.GetString(parameters.RegistryAuditLog), rec)
+}
+
+func appendAuditRecord(path string, rec *auditRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
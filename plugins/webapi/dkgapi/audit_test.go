@@ -0,0 +1,46 @@
+package dkgapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendAuditRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dkgaudit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	export := newAuditRecord(auditExport, "addr1", "127.0.0.1", nil)
+	export.Recipient = "recipient"
+	assert.NoError(t, appendAuditRecord(path, export))
+	assert.NoError(t, appendAuditRecord(path, newAuditRecord(auditImport, "", "127.0.0.2", errors.New("wrong blob"))))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	info, err := f.Stat()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0600, info.Mode().Perm())
+
+	// records are appended as JSON lines
+	var recs []*auditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		rec := &auditRecord{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), rec))
+		recs = append(recs, rec)
+	}
+	assert.Len(t, recs, 2)
+	assert.Equal(t, export, recs[0])
+	assert.Equal(t, auditImport, recs[1].Operation)
+	assert.False(t, recs[1].Ok)
+	assert.Equal(t, "wrong blob", recs[1].Err)
+}
@@ -2,18 +2,25 @@ package dkgapi
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"net/http"
 
 	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/plugins/peering"
+	"github.com/iotaledger/wasp/plugins/webapi/admapi"
 	"github.com/labstack/echo"
 	"github.com/mr-tron/base58"
 )
 
+// Key shares are exported encrypted for the identity key of the recipient node (see adm/nodeidentity),
+// so only that node can import them. Every export and import is written to the audit file, see audit.go
+
 type ExportDKShareRequest struct {
-	Address string `json:"address"` //base58
+	Address         string `json:"address"`         //base58
+	RecipientPubKey string `json:"recipientPubKey"` //base58, identity key of the node which will import the key share
 }
 
 type ExportDKShareResponse struct {
@@ -34,32 +41,51 @@ func HandlerExportDKShare(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, &ExportDKShareResponse{Err: err.Error()})
 	}
+	blob, err := exportDKShare(&req)
+	rec := newAuditRecord(auditExport, req.Address, c.RealIP(), err)
+	rec.Recipient = req.RecipientPubKey
+	if errAudit := audit(rec); errAudit != nil {
+		log.Errorf("can't write the audit record of the export: %v", errAudit)
+		if err == nil {
+			return c.JSON(http.StatusInternalServerError, &ExportDKShareResponse{Err: "export is refused: can't write the audit record"})
+		}
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ExportDKShareResponse{Err: err.Error()})
+	}
+	return c.JSON(http.StatusOK, &ExportDKShareResponse{DKShare: blob})
+}
 
+func exportDKShare(req *ExportDKShareRequest) (string, error) {
 	addr, err := address.FromBase58(req.Address)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, &ExportDKShareResponse{Err: err.Error()})
+		return "", err
 	}
-	dkshare, exist, err := registry.GetDKShare(&addr)
+	recipient, err := admapi.PubKeysFromBase58([]string{req.RecipientPubKey})
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, &ExportDKShareResponse{Err: err.Error()})
+		return "", err
 	}
-	if !exist {
-		return c.JSON(http.StatusBadRequest, &ExportDKShareResponse{Err: "dkshare not found"})
+	if len(recipient) == 0 {
+		return "", errors.New("recipient public key is not specified")
 	}
-	blob, err := exportDKShare(dkshare)
+	dkshare, exist, err := registry.GetDKShare(&addr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, &ExportDKShareResponse{Err: err.Error()})
+		return "", err
 	}
-	return c.JSON(http.StatusOK, &ExportDKShareResponse{DKShare: blob})
-}
+	if !exist {
+		return "", errors.New("dkshare not found")
+	}
+	defer dkshare.ErasePrivate()
 
-func exportDKShare(dkshare *tcrypto.DKShare) (string, error) {
 	var buf bytes.Buffer
-	err := dkshare.Write(&buf)
+	if err = dkshare.Write(&buf); err != nil {
+		return "", err
+	}
+	data, err := tcrypto.EncryptForPubKey(recipient[0], buf.Bytes())
 	if err != nil {
 		return "", err
 	}
-	return base58.Encode(buf.Bytes()), nil
+	return base58.Encode(data), nil
 }
 
 func HandlerImportDKShare(c echo.Context) error {
@@ -67,39 +93,67 @@ func HandlerImportDKShare(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, &ImportDKShareResponse{Err: err.Error()})
 	}
-	err := importDKShare(req.Blob)
+	addr, err := importDKShare(req.Blob)
+	if errAudit := audit(newAuditRecord(auditImport, addr, c.RealIP(), err)); errAudit != nil {
+		log.Errorf("can't write the audit record of the import: %v", errAudit)
+	}
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, &ImportDKShareResponse{Err: err.Error()})
 	}
 	return c.JSON(http.StatusOK, &ImportDKShareResponse{})
 }
 
-func importDKShare(blob string) error {
+// importDKShare returns base58 address of the key share, if the blob is decrypted
+func importDKShare(blob string) (string, error) {
 	data, err := base58.Decode(blob)
 	if err != nil {
-		return err
+		return "", err
 	}
-	dks, err := tcrypto.UnmarshalDKShare(data, false)
+	dks, err := decryptDKShare(data)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer dks.ErasePrivate()
+	addr := dks.Address.String()
 
 	oldDks, exists, err := registry.GetDKShare(dks.Address)
 	if err != nil {
-		return err
+		return addr, err
 	}
 	if exists {
-		oldBlob, err := exportDKShare(oldDks)
-		if err != nil {
-			return err
+		defer oldDks.ErasePrivate()
+		var oldData, newData bytes.Buffer
+		if err = oldDks.Write(&oldData); err != nil {
+			return addr, err
 		}
-		if oldBlob != blob {
-			return fmt.Errorf("A different DKShare exists with same address %s", dks.Address)
+		if err = dks.Write(&newData); err != nil {
+			return addr, err
+		}
+		if !bytes.Equal(oldData.Bytes(), newData.Bytes()) {
+			return addr, fmt.Errorf("A different DKShare exists with same address %s", dks.Address)
 		}
 		log.Debugf("DKShare with address %s already imported", dks.Address)
-		return nil
+		return addr, nil
 	}
 
 	log.Infof("Importing DKShare with address %s...", dks.Address)
-	return registry.SaveDKShareToRegistry(dks)
+	return addr, registry.SaveDKShareToRegistry(dks)
+}
+
+// decryptDKShare decrypts the blob with the identity key of the node.
+// Unencrypted blobs exported by older nodes are accepted only if the master key is not set
+func decryptDKShare(data []byte) (*tcrypto.DKShare, error) {
+	plain, err := peering.DecryptWithIdentity(data)
+	if err == nil {
+		return tcrypto.UnmarshalDKShare(plain, false)
+	}
+	if registry.HasMasterKey() {
+		return nil, fmt.Errorf("can't decrypt DKShare with the identity key of the node: %v", err)
+	}
+	dks, errPlain := tcrypto.UnmarshalDKShare(data, false)
+	if errPlain != nil {
+		return nil, fmt.Errorf("can't decrypt DKShare with the identity key of the node: %v", err)
+	}
+	log.Warnf("importing unencrypted DKShare with address %s", dks.Address)
+	return dks, nil
 }
@@ -4,8 +4,13 @@ import "github.com/iotaledger/hive.go/logger"
 
 const modulename = "dkgapi"
 
-var log *logger.Logger
+var (
+	log *logger.Logger
+	// exports and imports of private key material are logged to the separate logger and to the audit file
+	auditLog *logger.Logger
+)
 
 func InitLogger() {
 	log = logger.NewLogger(modulename)
+	auditLog = logger.NewLogger(modulename + ".audit")
 }
@@ -0,0 +1,747 @@
+package dkgapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/wasp/packages/committee"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/committees"
+	"github.com/iotaledger/wasp/plugins/peering"
+	"go.dedis.ch/kyber/v3"
+	"io"
+	"sync"
+	"time"
+)
+
+// DKG is run by the nodes over the peering connections, which are authenticated and encrypted.
+// Every node keeps its polynomial in dkgCache and a session for the protocol:
+// - the initiator sends MsgDkgStart to all nodes after all of them joined (see adm/rundkg)
+// - each node sends private share Pi(j) of its polynomial to node j together with public commitments
+//   to the polynomial. Node j verifies the share against the commitments
+// - after receiving private shares from all nodes, the node aggregates them and sends own public share to all
+// - after receiving all public shares, the node commits the key share and reports the address to the initiator
+// The same protocol refreshes the existing key share (see adm/refreshdks): each node starts from its private key
+// and a random polynomial with zero secret, so all shares change while the master public key and the address
+// remain the same. The refreshed key share is committed next to the old one, which stays in use.
+// When all nodes committed, the initiator replaces the old key share with the refreshed one, sends MsgDkgConfirm
+// to all nodes and each node does the same. The initiator decides: if it fails before the activation,
+// it drops the refreshed key share. Other nodes never drop the committed key share on their own. If the session
+// fails after the commit, e.g. MsgDkgConfirm is lost, the node keeps asking the initiator with MsgDkgStatus.
+// When the session of the initiator is finished, it answers with MsgDkgConfirm if the refreshed key share
+// is in use and with MsgDkgAbort otherwise.
+// The sender of a message is the node of the authenticated peering connection, the sender index in the message
+// is ignored. Any error, including a duplicate message, makes the node send MsgDkgAbort to all nodes.
+// The session and dkgCache entry are deleted on abort, on timeout and when the protocol is finished
+
+const (
+	MsgDkgStart     = 0x20 + peering.FirstCommitteeMsgCode
+	MsgDkgPriShare  = 0x21 + peering.FirstCommitteeMsgCode
+	MsgDkgPubShare  = 0x22 + peering.FirstCommitteeMsgCode
+	MsgDkgCommitted = 0x23 + peering.FirstCommitteeMsgCode
+	MsgDkgAbort     = 0x24 + peering.FirstCommitteeMsgCode
+	MsgDkgConfirm   = 0x25 + peering.FirstCommitteeMsgCode
+	MsgDkgStatus    = 0x26 + peering.FirstCommitteeMsgCode
+
+	dkgTimeout = 1 * time.Minute
+	// the node which committed the refreshed key share asks the initiator about the outcome until it answers
+	statusPeriod  = 5 * time.Second
+	resumeTimeout = 30 * time.Minute
+	// connections are kept for a while after the session is finished to let last messages to be delivered
+	releasePeersAfter = 5 * time.Second
+)
+
+var (
+	dkgSessions      = make(map[int]*dkgSession)
+	dkgSessionsMutex = &sync.Mutex{}
+
+	errAbortedByPeer = errors.New("DKG aborted by peer")
+)
+
+type dkgSession struct {
+	tmpId     int
+	ks        *tcrypto.DKShare
+	initiator uint16
+	// nil at own index
+	peers []*peering.Peer
+	// identity keys of the nodes. Messages are only accepted from peers authenticated with them
+	pubKeys   []ed25519.PublicKey
+	transport dkgTransport
+	// refreshing the existing key share
+	refresh bool
+	// finalizes and saves the key share when public shares of all nodes are received.
+	// The refreshed key share is saved next to the old one
+	commit func(ks *tcrypto.DKShare, pubShares []kyber.Point) error
+	// replaces the old key share with the refreshed one when all nodes committed
+	activate func(ks *tcrypto.DKShare) error
+	// drops the committed refreshed key share if the refresh failed
+	drop  func(ks *tcrypto.DKShare)
+	chMsg chan *peering.PeerMessage
+	// local error which aborts the DKG
+	chAbort chan error
+	// result of the DKG, only used by the initiator
+	chResult chan *dkgResult
+	// dkgTimeout, statusPeriod and resumeTimeout. Tests make them shorter
+	timeout       time.Duration
+	statusPeriod  time.Duration
+	resumeTimeout time.Duration
+
+	started      bool
+	priShares    []kyber.Scalar
+	numPriShares uint16
+	pubShares    []kyber.Point
+	numPubShares uint16
+	addresses    []*address.Address
+	numCommitted uint16
+	// own key share is committed
+	committed bool
+}
+
+type dkgResult struct {
+	address *address.Address
+	err     error
+}
+
+// dkgTransport delivers messages of the session to other nodes
+type dkgTransport interface {
+	// waitAlive waits until connections with all other nodes are established
+	waitAlive() error
+	send(index uint16, msg *peering.PeerMessage) error
+	// broadcast sends the message to all other nodes ignoring errors
+	broadcast(msg *peering.PeerMessage)
+	// release is called when the session is finished
+	release()
+}
+
+// peeringTransport sends messages over peering connections. nil at own index
+type peeringTransport []*peering.Peer
+
+// AttachToPeering routes DKG messages received from peers to the sessions
+func AttachToPeering() {
+	peering.EventPeerMessageReceived.Attach(events.NewClosure(func(msg *peering.PeerMessage) {
+		if msg.MsgType < MsgDkgStart || msg.MsgType > MsgDkgStatus {
+			return
+		}
+		rdr := bytes.NewReader(msg.MsgData)
+		var tmpId uint64
+		if err := util.ReadUint64(rdr, &tmpId); err != nil {
+			return
+		}
+		if msg.MsgType == MsgDkgStatus {
+			answerRefreshStatus(int(tmpId), rdr, msg.SenderPeer)
+			return
+		}
+		s := getDkgSession(int(tmpId))
+		if s == nil {
+			return
+		}
+		from, err := s.senderIndex(msg)
+		if err != nil {
+			log.Warnf("DKG %d: %v. Message dropped", s.tmpId, err)
+			return
+		}
+		s.receive(from, msg)
+	}))
+}
+
+// senderIndex returns the index of the node the message was received from. The node is identified by the peer
+// of the connection, which must be authenticated with the identity key of the node
+func (s *dkgSession) senderIndex(msg *peering.PeerMessage) (uint16, error) {
+	for i, p := range s.peers {
+		if p == nil || p != msg.SenderPeer {
+			continue
+		}
+		if msg.SenderPubKey != s.pubKeys[i] {
+			return 0, fmt.Errorf("peer is not authenticated with the key of the node #%d", i)
+		}
+		return uint16(i), nil
+	}
+	return 0, errors.New("message from the peer which is not in the DKG")
+}
+
+// receive queues the message from the node with the index
+func (s *dkgSession) receive(from uint16, msg *peering.PeerMessage) {
+	msgCopy := *msg
+	msgCopy.SenderIndex = from
+	select {
+	case s.chMsg <- &msgCopy:
+	default:
+		log.Warnf("DKG %d: message from peer #%d dropped", s.tmpId, from)
+	}
+}
+
+func getDkgSession(tmpId int) *dkgSession {
+	dkgSessionsMutex.Lock()
+	defer dkgSessionsMutex.Unlock()
+	return dkgSessions[tmpId]
+}
+
+// newDkgSession creates the session for the key share in the dkgCache and connects to all peers
+func newDkgSession(tmpId int, ks *tcrypto.DKShare, initiator uint16, netIds []string, pubKeys []ed25519.PublicKey) (*dkgSession, error) {
+	dkgSessionsMutex.Lock()
+	defer dkgSessionsMutex.Unlock()
+
+	if _, ok := dkgSessions[tmpId]; ok {
+		return nil, fmt.Errorf("duplicate tmpId %d during DKG", tmpId)
+	}
+	ret := makeDkgSession(tmpId, ks, initiator, make([]*peering.Peer, ks.N), pubKeys)
+	for i, netId := range netIds {
+		if uint16(i) == ks.Index {
+			continue
+		}
+		ret.peers[i] = peering.UsePeer(netId)
+		if ret.peers[i] == nil {
+			releasePeers(ret.peers)
+			return nil, fmt.Errorf("can't connect to peer %s", netId)
+		}
+		if err := ret.peers[i].PinPublicKey(pubKeys[i]); err != nil {
+			releasePeers(ret.peers)
+			return nil, err
+		}
+	}
+	dkgSessions[tmpId] = ret
+	go ret.run()
+	return ret, nil
+}
+
+// makeDkgSession creates the session which sends messages over the peering connections
+// and saves the key share in the registry
+func makeDkgSession(tmpId int, ks *tcrypto.DKShare, initiator uint16, peers []*peering.Peer, pubKeys []ed25519.PublicKey) *dkgSession {
+	return &dkgSession{
+		tmpId:     tmpId,
+		ks:        ks,
+		initiator: initiator,
+		peers:     peers,
+		pubKeys:   pubKeys,
+		transport: peeringTransport(peers),
+		refresh:   ks.Address != nil,
+		commit:    commitKeyShare,
+		activate:  activateKeyShare,
+		drop:      dropKeyShare,
+		chMsg:     make(chan *peering.PeerMessage, 4*int(ks.N)+4),
+		chAbort:   make(chan error, 1),
+		chResult:  make(chan *dkgResult, 1),
+		priShares: make([]kyber.Scalar, ks.N),
+		pubShares: make([]kyber.Point, ks.N),
+		addresses: make([]*address.Address, ks.N),
+
+		timeout:       dkgTimeout,
+		statusPeriod:  statusPeriod,
+		resumeTimeout: resumeTimeout,
+	}
+}
+
+func releasePeers(peers []*peering.Peer) {
+	for _, p := range peers {
+		if p != nil {
+			peering.StopUsingPeer(p.PeeringId())
+		}
+	}
+}
+
+// close deletes the session and the key share from the dkgCache
+func (s *dkgSession) close() {
+	dkgSessionsMutex.Lock()
+	delete(dkgSessions, s.tmpId)
+	dkgSessionsMutex.Unlock()
+
+	_ = putToDkgCache(s.tmpId, nil)
+
+	s.transport.release()
+}
+
+func (s *dkgSession) run() {
+	addr, err := s.runProtocol()
+	if err != nil {
+		log.Errorf("DKG %d failed: %v", s.tmpId, err)
+		if err != errAbortedByPeer {
+			s.sendAbort(err)
+		}
+		if s.refresh && s.committed {
+			if s.ks.Index == s.initiator {
+				// nothing was confirmed, the old key share stays in use
+				s.drop(s.ks)
+			} else {
+				addr, err = s.resumeRefresh()
+			}
+		}
+	}
+	s.close()
+	s.chResult <- &dkgResult{address: addr, err: err}
+}
+
+func (s *dkgSession) runProtocol() (*address.Address, error) {
+	timeout := time.After(s.timeout)
+	for {
+		select {
+		case msg := <-s.chMsg:
+			addr, finished, err := s.processMsg(msg)
+			if err != nil || finished {
+				return addr, err
+			}
+		case err := <-s.chAbort:
+			return nil, err
+		case <-timeout:
+			return nil, fmt.Errorf("timeout")
+		}
+	}
+}
+
+// start is called by the initiator when all nodes joined the DKG
+func (s *dkgSession) start() {
+	s.chMsg <- &peering.PeerMessage{
+		SenderIndex: s.ks.Index,
+		MsgType:     MsgDkgStart,
+	}
+}
+
+// abort is called by the initiator if not all nodes joined the DKG
+func (s *dkgSession) abort(reason error) {
+	s.chAbort <- reason
+}
+
+// processMsg returns true when the session is finished
+func (s *dkgSession) processMsg(msg *peering.PeerMessage) (*address.Address, bool, error) {
+	if msg.SenderIndex >= s.ks.N {
+		return nil, false, fmt.Errorf("wrong sender index %d", msg.SenderIndex)
+	}
+	rdr := bytes.NewReader(msg.MsgData)
+	var tmpId uint64
+	// local start message has no data
+	_ = util.ReadUint64(rdr, &tmpId)
+
+	switch msg.MsgType {
+	case MsgDkgStart:
+		if msg.SenderIndex != s.initiator {
+			return nil, false, fmt.Errorf("start message from the node #%d which is not the initiator", msg.SenderIndex)
+		}
+		if s.started {
+			return nil, false, errors.New("duplicate start message")
+		}
+		return nil, false, s.sendPriShares()
+
+	case MsgDkgPriShare:
+		priShare, commits, err := s.readPriShare(rdr)
+		if err != nil {
+			return nil, false, err
+		}
+		return nil, false, s.receivePriShare(msg.SenderIndex, priShare, commits)
+
+	case MsgDkgPubShare:
+		data, err := util.ReadBytes16(rdr)
+		if err != nil {
+			return nil, false, err
+		}
+		return s.receivePubShare(msg.SenderIndex, data)
+
+	case MsgDkgCommitted:
+		var addr address.Address
+		if err := util.ReadAddress(rdr, &addr); err != nil {
+			return nil, false, err
+		}
+		return s.receiveCommitted(msg.SenderIndex, &addr)
+
+	case MsgDkgAbort:
+		reason, _ := util.ReadString16(rdr)
+		log.Errorf("DKG %d aborted by peer #%d: %s", s.tmpId, msg.SenderIndex, reason)
+		return nil, false, errAbortedByPeer
+
+	case MsgDkgConfirm:
+		if msg.SenderIndex != s.initiator {
+			return nil, false, fmt.Errorf("confirmation from the node #%d which is not the initiator", msg.SenderIndex)
+		}
+		if !s.refresh || !s.committed {
+			return nil, false, errors.New("unexpected confirmation")
+		}
+		return s.ks.Address, true, s.activate(s.ks)
+	}
+	return nil, false, nil
+}
+
+func (s *dkgSession) sendPriShares() error {
+	s.started = true
+	if err := s.transport.waitAlive(); err != nil {
+		return err
+	}
+	if s.ks.Index == s.initiator {
+		for i := range s.peers {
+			if err := s.sendTo(uint16(i), MsgDkgStart, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for i, pshare := range s.ks.PriShares {
+		if uint16(i) == s.ks.Index {
+			continue
+		}
+		data, err := encodePriShare(pshare.V, s.ks.Commits)
+		if err != nil {
+			return err
+		}
+		if err := s.sendTo(uint16(i), MsgDkgPriShare, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodePriShare encodes the private share together with the commitments to the polynomial
+func encodePriShare(priShare kyber.Scalar, commits []kyber.Point) ([]byte, error) {
+	var buf bytes.Buffer
+	data, err := priShare.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	_ = util.WriteBytes16(&buf, data)
+	_ = util.WriteUint16(&buf, uint16(len(commits)))
+	for _, c := range commits {
+		if data, err = c.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		_ = util.WriteBytes16(&buf, data)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *dkgSession) readPriShare(r io.Reader) (kyber.Scalar, []kyber.Point, error) {
+	data, err := util.ReadBytes16(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	priShare := s.ks.Suite.G2().Scalar()
+	if err = priShare.UnmarshalBinary(data); err != nil {
+		return nil, nil, err
+	}
+	var numCommits uint16
+	if err = util.ReadUint16(r, &numCommits); err != nil {
+		return nil, nil, err
+	}
+	if numCommits != s.ks.T {
+		return nil, nil, fmt.Errorf("wrong number of commitments %d", numCommits)
+	}
+	commits := make([]kyber.Point, numCommits)
+	for i := range commits {
+		if data, err = util.ReadBytes16(r); err != nil {
+			return nil, nil, err
+		}
+		commits[i] = s.ks.Suite.G2().Point()
+		if err = commits[i].UnmarshalBinary(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return priShare, commits, nil
+}
+
+func (s *dkgSession) receivePriShare(from uint16, priShare kyber.Scalar, commits []kyber.Point) error {
+	if from == s.ks.Index {
+		return nil
+	}
+	if s.priShares[from] != nil {
+		return fmt.Errorf("duplicate private share from the node #%d", from)
+	}
+	if err := s.ks.VerifyPriShare(from, commits, priShare); err != nil {
+		return err
+	}
+	s.priShares[from] = priShare
+	s.numPriShares++
+	if s.numPriShares < s.ks.N-1 {
+		return nil
+	}
+	if err := s.ks.AggregateDKS(s.priShares); err != nil {
+		return err
+	}
+	data, err := s.ks.PubKeyOwn.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	_ = util.WriteBytes16(&buf, data)
+	for i := range s.peers {
+		if err := s.sendTo(uint16(i), MsgDkgPubShare, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	_, _, err = s.receivePubShare(s.ks.Index, data)
+	return err
+}
+
+func (s *dkgSession) receivePubShare(from uint16, data []byte) (*address.Address, bool, error) {
+	if s.pubShares[from] != nil {
+		return nil, false, fmt.Errorf("duplicate public share from the node #%d", from)
+	}
+	s.pubShares[from] = s.ks.Suite.G2().Point()
+	if err := s.pubShares[from].UnmarshalBinary(data); err != nil {
+		return nil, false, err
+	}
+	s.numPubShares++
+	if s.numPubShares < s.ks.N || !s.ks.Aggregated {
+		return nil, false, nil
+	}
+	if err := s.commit(s.ks, s.pubShares); err != nil {
+		return nil, false, err
+	}
+	s.committed = true
+	if s.ks.Index != s.initiator {
+		if err := s.sendTo(s.initiator, MsgDkgCommitted, s.ks.Address.Bytes()); err != nil {
+			return nil, false, err
+		}
+		// the refreshed key share is activated when the initiator confirms
+		return s.ks.Address, !s.refresh, nil
+	}
+	return s.receiveCommitted(s.ks.Index, s.ks.Address)
+}
+
+// commitKeyShare finalizes the new or refreshed key share and saves it in the registry
+func commitKeyShare(ks *tcrypto.DKShare, pubShares []kyber.Point) error {
+	if ks.Address == nil {
+		if err := registry.CommitDKShare(ks, pubShares); err != nil {
+			return err
+		}
+		log.Infow("Created new key share",
+			"address", ks.Address.String(),
+			"N", ks.N,
+			"T", ks.T,
+			"Index", ks.Index,
+		)
+		return nil
+	}
+	if err := registry.CommitRefreshedDKShare(ks, pubShares); err != nil {
+		return err
+	}
+	log.Infow("Committed refreshed key share",
+		"address", ks.Address.String(),
+		"N", ks.N,
+		"T", ks.T,
+		"Index", ks.Index,
+	)
+	return nil
+}
+
+// activateKeyShare replaces the old key share with the refreshed one in the registry and in the running committee
+func activateKeyShare(ks *tcrypto.DKShare) error {
+	if _, err := registry.ActivateRefreshedDKShare(ks.Address); err != nil {
+		return err
+	}
+	log.Infow("Refreshed key share",
+		"address", ks.Address.String(),
+		"N", ks.N,
+		"T", ks.T,
+		"Index", ks.Index,
+	)
+	// the running committee switches to the new key share
+	if c := committees.CommitteeByAddress(*ks.Address); c != nil {
+		c.ReceiveMessage(committee.DKShareRefreshedMsg{DKShare: ks})
+	}
+	return nil
+}
+
+func dropKeyShare(ks *tcrypto.DKShare) {
+	if err := registry.DeleteRefreshedDKShare(ks.Address); err != nil {
+		log.Errorf("failed to delete refreshed key share %s: %v", ks.Address.String(), err)
+		return
+	}
+	log.Warnf("refreshed key share %s was dropped, the old key share stays in use", ks.Address.String())
+}
+
+// receiveCommitted collects addresses committed by nodes. Only the initiator receives them
+func (s *dkgSession) receiveCommitted(from uint16, addr *address.Address) (*address.Address, bool, error) {
+	if s.ks.Index != s.initiator {
+		return nil, false, nil
+	}
+	if s.addresses[from] != nil {
+		return nil, false, fmt.Errorf("duplicate commit message from the node #%d", from)
+	}
+	s.addresses[from] = addr
+	s.numCommitted++
+	if s.numCommitted < s.ks.N {
+		return nil, false, nil
+	}
+	for _, a := range s.addresses {
+		if *a != *s.addresses[0] {
+			return nil, false, errors.New("nodes committed key shares with different addresses")
+		}
+	}
+	if s.refresh {
+		// other nodes ask the initiator about the outcome if the confirmation is lost,
+		// so the initiator activates the refreshed key share first
+		if err := s.activate(s.ks); err != nil {
+			return nil, false, err
+		}
+		s.broadcast(MsgDkgConfirm, s.ks.Address.Bytes())
+	}
+	return s.addresses[0], true, nil
+}
+
+// resumeRefresh asks the initiator whether the refreshed key share was activated after the session failed
+// on the node which had already committed it. The refreshed key share is kept until the initiator answers.
+// If it doesn't answer in resumeTimeout, the refreshed key share stays in the registry next to the old one
+func (s *dkgSession) resumeRefresh() (*address.Address, error) {
+	data, err := s.ks.PubKeyOwn.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(s.ks.Address.Bytes())
+	_ = util.WriteBytes16(&buf, data)
+
+	log.Infof("DKG %d: asking the initiator whether the refreshed key share %s was activated", s.tmpId, s.ks.Address.String())
+	retry := time.NewTicker(s.statusPeriod)
+	defer retry.Stop()
+	deadline := time.After(s.resumeTimeout)
+	for {
+		if err := s.sendTo(s.initiator, MsgDkgStatus, buf.Bytes()); err != nil {
+			log.Debugf("DKG %d: can't ask the initiator: %v", s.tmpId, err)
+		}
+	waitAnswer:
+		for {
+			select {
+			case msg := <-s.chMsg:
+				if msg.SenderIndex != s.initiator {
+					continue
+				}
+				switch msg.MsgType {
+				case MsgDkgConfirm:
+					return s.ks.Address, s.activate(s.ks)
+				case MsgDkgAbort:
+					s.drop(s.ks)
+					return nil, errors.New("refresh was not activated by the initiator")
+				}
+			case <-retry.C:
+				break waitAnswer
+			case <-deadline:
+				log.Errorf("DKG %d: the initiator didn't tell whether the refreshed key share %s was activated. "+
+					"It is kept in the registry, the old key share stays in use", s.tmpId, s.ks.Address.String())
+				return nil, errors.New("outcome of the refresh is unknown")
+			}
+		}
+	}
+}
+
+// answerRefreshStatus answers the node which committed the refreshed key share, see resumeRefresh.
+// There is no answer while the session is running, the outcome is not known yet
+func answerRefreshStatus(tmpId int, r io.Reader, peer *peering.Peer) {
+	if peer == nil || getDkgSession(tmpId) != nil {
+		return
+	}
+	var addr address.Address
+	if err := util.ReadAddress(r, &addr); err != nil {
+		return
+	}
+	pubShare, err := util.ReadBytes16(r)
+	if err != nil {
+		return
+	}
+	ks, exists, err := registry.GetDKShare(&addr)
+	if err != nil || !exists {
+		log.Warnf("DKG %d: can't answer about the refresh of %s: key share not found", tmpId, addr.String())
+		return
+	}
+	ks.ErasePrivate()
+	msg, err := refreshStatusMsg(tmpId, ks, pubShare)
+	if err != nil {
+		log.Warnf("DKG %d: can't answer about the refresh of %s: %v", tmpId, addr.String(), err)
+		return
+	}
+	if err := peer.SendMsg(msg); err != nil {
+		log.Warnf("DKG %d: can't answer about the refresh of %s: %v", tmpId, addr.String(), err)
+	}
+}
+
+// refreshStatusMsg is MsgDkgConfirm if the public share of the node is in the key share of the initiator
+// which is in use, i.e. the refresh was activated. Otherwise it is MsgDkgAbort
+func refreshStatusMsg(tmpId int, active *tcrypto.DKShare, pubShareData []byte) (*peering.PeerMessage, error) {
+	pubShare := active.Suite.G2().Point()
+	if err := pubShare.UnmarshalBinary(pubShareData); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	_ = util.WriteUint64(&buf, uint64(tmpId))
+	for _, pk := range active.PubKeys {
+		if pk.Equal(pubShare) {
+			buf.Write(active.Address.Bytes())
+			return &peering.PeerMessage{
+				SenderIndex: active.Index,
+				MsgType:     MsgDkgConfirm,
+				MsgData:     buf.Bytes(),
+			}, nil
+		}
+	}
+	_ = util.WriteString16(&buf, "refreshed key share was not activated")
+	return &peering.PeerMessage{
+		SenderIndex: active.Index,
+		MsgType:     MsgDkgAbort,
+		MsgData:     buf.Bytes(),
+	}, nil
+}
+
+// sendTo sends the message to the node with the index. Messages to the own node are ignored
+func (s *dkgSession) sendTo(index uint16, msgType byte, data []byte) error {
+	if index == s.ks.Index {
+		return nil
+	}
+	var buf bytes.Buffer
+	_ = util.WriteUint64(&buf, uint64(s.tmpId))
+	buf.Write(data)
+	return s.transport.send(index, &peering.PeerMessage{
+		SenderIndex: s.ks.Index,
+		MsgType:     msgType,
+		MsgData:     buf.Bytes(),
+	})
+}
+
+func (s *dkgSession) sendAbort(reason error) {
+	var buf bytes.Buffer
+	_ = util.WriteString16(&buf, reason.Error())
+	s.broadcast(MsgDkgAbort, buf.Bytes())
+}
+
+// broadcast sends the message to all other nodes ignoring errors
+func (s *dkgSession) broadcast(msgType byte, data []byte) {
+	var buf bytes.Buffer
+	_ = util.WriteUint64(&buf, uint64(s.tmpId))
+	buf.Write(data)
+	s.transport.broadcast(&peering.PeerMessage{
+		SenderIndex: s.ks.Index,
+		MsgType:     msgType,
+		MsgData:     buf.Bytes(),
+	})
+}
+
+func (peers peeringTransport) waitAlive() error {
+	deadline := time.Now().Add(dkgTimeout / 2)
+	for _, p := range peers {
+		if p == nil {
+			continue
+		}
+		for {
+			if alive, _ := p.IsAlive(); alive {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("peer %s is not connected", p.PeeringId())
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+func (peers peeringTransport) send(index uint16, msg *peering.PeerMessage) error {
+	return peers[index].SendMsg(msg)
+}
+
+func (peers peeringTransport) broadcast(msg *peering.PeerMessage) {
+	peering.SendMsgToPeers(msg, peers...)
+}
+
+func (peers peeringTransport) release() {
+	go func() {
+		time.Sleep(releasePeersAfter)
+		releasePeers(peers)
+	}()
+}
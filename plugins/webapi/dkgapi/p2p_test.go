@@ -0,0 +1,409 @@
+package dkgapi
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/peering"
+	"github.com/stretchr/testify/assert"
+	"go.dedis.ch/kyber/v3"
+	"go.uber.org/atomic"
+)
+
+// testNodes are DKG sessions of all nodes connected in memory
+type testNodes struct {
+	sessions []*dkgSession
+	// peer of each node, the same object in sessions of all other nodes
+	peers   []*peering.Peer
+	pubKeys []ed25519.PublicKey
+	// refreshed key shares activated and dropped by nodes
+	activated []bool
+	dropped   []bool
+	// lost messages
+	lost func(to uint16, msg *peering.PeerMessage) bool
+	// key share of the initiator which is in use. The initiator answers MsgDkgStatus with it, if not nil
+	mutex       sync.Mutex
+	activeShare *tcrypto.DKShare
+}
+
+// memTransport delivers messages of the node to sessions of other nodes in memory
+type memTransport struct {
+	nodes *testNodes
+	from  uint16
+}
+
+func (t *memTransport) waitAlive() error {
+	return nil
+}
+
+func (t *memTransport) send(index uint16, msg *peering.PeerMessage) error {
+	if t.nodes.lost != nil && t.nodes.lost(index, msg) {
+		return nil
+	}
+	if msg.MsgType == MsgDkgStatus {
+		t.nodes.answerStatus(t.from, msg)
+		return nil
+	}
+	msgCopy := *msg
+	msgCopy.SenderPeer = t.nodes.peers[t.from]
+	msgCopy.SenderPubKey = t.nodes.pubKeys[t.from]
+	s := t.nodes.sessions[index]
+	from, err := s.senderIndex(&msgCopy)
+	if err != nil {
+		return err
+	}
+	s.receive(from, &msgCopy)
+	return nil
+}
+
+func (t *memTransport) broadcast(msg *peering.PeerMessage) {
+	for i := range t.nodes.sessions {
+		if uint16(i) != t.from {
+			_ = t.send(uint16(i), msg)
+		}
+	}
+}
+
+func (t *memTransport) release() {}
+
+// answerStatus answers MsgDkgStatus in the name of the initiator, like answerRefreshStatus
+func (nodes *testNodes) answerStatus(from uint16, msg *peering.PeerMessage) {
+	nodes.mutex.Lock()
+	active := nodes.activeShare
+	nodes.mutex.Unlock()
+	if active == nil {
+		return
+	}
+	rdr := bytes.NewReader(msg.MsgData)
+	var tmpId uint64
+	var addr address.Address
+	if util.ReadUint64(rdr, &tmpId) != nil || util.ReadAddress(rdr, &addr) != nil {
+		return
+	}
+	pubShare, err := util.ReadBytes16(rdr)
+	if err != nil {
+		return
+	}
+	answer, err := refreshStatusMsg(int(tmpId), active, pubShare)
+	if err != nil {
+		return
+	}
+	(&memTransport{nodes: nodes, from: 0}).send(from, answer)
+}
+
+func newTestNodes(t *testing.T, tmpId int, keyShares []*tcrypto.DKShare) *testNodes {
+	if log == nil {
+		log = logger.NewExampleLogger("dkgapi")
+	}
+	n := len(keyShares)
+	ret := &testNodes{
+		sessions:  make([]*dkgSession, n),
+		peers:     make([]*peering.Peer, n),
+		pubKeys:   make([]ed25519.PublicKey, n),
+		activated: make([]bool, n),
+		dropped:   make([]bool, n),
+	}
+	for i := range ret.peers {
+		ret.peers[i] = &peering.Peer{}
+		pubKey, _, err := ed25519.GenerateKey()
+		assert.NoError(t, err)
+		ret.pubKeys[i] = pubKey
+	}
+	for i, ks := range keyShares {
+		peers := make([]*peering.Peer, n)
+		copy(peers, ret.peers)
+		peers[i] = nil
+		s := makeDkgSession(tmpId, ks, 0, peers, ret.pubKeys)
+		s.transport = &memTransport{nodes: ret, from: uint16(i)}
+		s.timeout = time.Second
+		s.statusPeriod = 50 * time.Millisecond
+		s.resumeTimeout = time.Second
+		s.commit = func(ks *tcrypto.DKShare, pubShares []kyber.Point) error {
+			return ks.FinalizeDKS(pubShares)
+		}
+		s.activate = func(ks *tcrypto.DKShare) error {
+			ret.activated[ks.Index] = true
+			if ks.Index == 0 {
+				ret.mutex.Lock()
+				ret.activeShare = ks
+				ret.mutex.Unlock()
+			}
+			return nil
+		}
+		s.drop = func(ks *tcrypto.DKShare) {
+			ret.dropped[ks.Index] = true
+		}
+		ret.sessions[i] = s
+	}
+	return ret
+}
+
+// run runs the DKG and returns results of all nodes
+func (nodes *testNodes) run(t *testing.T) []*dkgResult {
+	for _, s := range nodes.sessions {
+		go s.run()
+	}
+	nodes.sessions[0].start()
+	ret := make([]*dkgResult, len(nodes.sessions))
+	for i, s := range nodes.sessions {
+		select {
+		case ret[i] = <-s.chResult:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("DKG of the node #%d is not finished", i)
+		}
+	}
+	return ret
+}
+
+func newRndKeyShares(t *testing.T, threshold, n uint16) []*tcrypto.DKShare {
+	ret := make([]*tcrypto.DKShare, n)
+	for i := range ret {
+		ks, err := tcrypto.NewRndDKShare(threshold, n, uint16(i))
+		assert.NoError(t, err)
+		ret[i] = ks
+	}
+	return ret
+}
+
+func TestDkgSessions(t *testing.T) {
+	const n, threshold = 4, 3
+
+	keyShares := newRndKeyShares(t, threshold, n)
+	nodes := newTestNodes(t, 1, keyShares)
+	results := nodes.run(t)
+	for i, res := range results {
+		assert.NoError(t, res.err)
+		assert.True(t, keyShares[i].Committed)
+		assert.EqualValues(t, *keyShares[0].Address, *keyShares[i].Address)
+		// new key shares are saved when committed
+		assert.False(t, nodes.activated[i])
+	}
+	// only the initiator waits for commits of all nodes
+	assert.EqualValues(t, *keyShares[0].Address, *results[0].address)
+	addr := *keyShares[0].Address
+
+	// the refresh keeps the address
+	refreshed := make([]*tcrypto.DKShare, n)
+	for i, ks := range keyShares {
+		var err error
+		refreshed[i], err = ks.NewRefreshingDKShare()
+		assert.NoError(t, err)
+	}
+	nodes = newTestNodes(t, 2, refreshed)
+	results = nodes.run(t)
+	for i, res := range results {
+		assert.NoError(t, res.err)
+		assert.EqualValues(t, addr, *refreshed[i].Address)
+		assert.False(t, refreshed[i].PubKeyOwn.Equal(keyShares[i].PubKeyOwn))
+		// all nodes replace old key shares after the initiator confirmed
+		assert.True(t, nodes.activated[i])
+		assert.False(t, nodes.dropped[i])
+	}
+}
+
+func TestDkgRefreshConfirm(t *testing.T) {
+	keyShares := newRndKeyShares(t, 3, 4)
+	nodes := newTestNodes(t, 1, keyShares)
+	nodes.run(t)
+	refreshed := make([]*tcrypto.DKShare, len(keyShares))
+	for i, ks := range keyShares {
+		var err error
+		refreshed[i], err = ks.NewRefreshingDKShare()
+		assert.NoError(t, err)
+	}
+	nodes = newTestNodes(t, 6, refreshed)
+	s := nodes.sessions[1]
+	confirm := func(from uint16) *peering.PeerMessage {
+		return &peering.PeerMessage{SenderIndex: from, MsgType: MsgDkgConfirm, MsgData: msgData(6, refreshed[0].Address.Bytes())}
+	}
+
+	// the refreshed key share must be committed first
+	_, _, err := s.processMsg(confirm(0))
+	assert.Error(t, err)
+
+	s.committed = true
+	_, _, err = s.processMsg(confirm(2))
+	assert.Error(t, err)
+	assert.False(t, nodes.activated[1])
+
+	addr, finished, err := s.processMsg(confirm(0))
+	assert.NoError(t, err)
+	assert.True(t, finished)
+	assert.EqualValues(t, *refreshed[0].Address, *addr)
+	assert.True(t, nodes.activated[1])
+
+	// the committed key share is kept if the refresh fails and the initiator doesn't tell the outcome
+	s = nodes.sessions[2]
+	s.committed = true
+	s.ks.PubKeyOwn = keyShares[2].PubKeyOwn
+	go s.run()
+	s.abort(errors.New("test"))
+	res := <-s.chResult
+	assert.Error(t, res.err)
+	assert.False(t, nodes.dropped[2])
+	assert.False(t, nodes.activated[2])
+
+	// the initiator drops the committed key share if the refresh fails before it is activated
+	s = nodes.sessions[0]
+	s.committed = true
+	go s.run()
+	s.abort(errors.New("test"))
+	res = <-s.chResult
+	assert.Error(t, res.err)
+	assert.True(t, nodes.dropped[0])
+	assert.False(t, nodes.activated[0])
+}
+
+func refreshingKeyShares(t *testing.T, keyShares []*tcrypto.DKShare) []*tcrypto.DKShare {
+	ret := make([]*tcrypto.DKShare, len(keyShares))
+	for i, ks := range keyShares {
+		var err error
+		ret[i], err = ks.NewRefreshingDKShare()
+		assert.NoError(t, err)
+	}
+	return ret
+}
+
+func TestDkgRefreshConfirmLost(t *testing.T) {
+	keyShares := newRndKeyShares(t, 3, 4)
+	newTestNodes(t, 1, keyShares).run(t)
+	refreshed := refreshingKeyShares(t, keyShares)
+
+	nodes := newTestNodes(t, 7, refreshed)
+	// only the confirmation of the session is lost, not the answer to MsgDkgStatus
+	var lost atomic.Bool
+	nodes.lost = func(to uint16, msg *peering.PeerMessage) bool {
+		return to == 1 && msg.MsgType == MsgDkgConfirm && lost.CAS(false, true)
+	}
+	results := nodes.run(t)
+	for i, res := range results {
+		assert.NoError(t, res.err)
+		assert.EqualValues(t, *keyShares[0].Address, *res.address)
+		// the node without the confirmation asked the initiator and activated the refreshed key share
+		assert.True(t, nodes.activated[i])
+		assert.False(t, nodes.dropped[i])
+	}
+}
+
+func TestDkgRefreshNotActivated(t *testing.T) {
+	keyShares := newRndKeyShares(t, 3, 4)
+	newTestNodes(t, 1, keyShares).run(t)
+	refreshed := refreshingKeyShares(t, keyShares)
+
+	nodes := newTestNodes(t, 8, refreshed)
+	// the initiator doesn't receive the commit of the node #3 and keeps the old key share
+	nodes.lost = func(to uint16, msg *peering.PeerMessage) bool {
+		return to == 0 && msg.MsgType == MsgDkgCommitted && msg.SenderIndex == 3
+	}
+	nodes.activeShare = keyShares[0]
+	results := nodes.run(t)
+	for i, res := range results {
+		assert.Error(t, res.err)
+		assert.True(t, refreshed[i].Committed)
+		// all nodes dropped the refreshed key share when the initiator told it wasn't activated
+		assert.False(t, nodes.activated[i])
+		assert.True(t, nodes.dropped[i])
+	}
+}
+
+func TestDkgSenderIndex(t *testing.T) {
+	nodes := newTestNodes(t, 3, newRndKeyShares(t, 3, 4))
+	s := nodes.sessions[1]
+
+	// the index claimed in the message is ignored
+	from, err := s.senderIndex(&peering.PeerMessage{
+		SenderIndex:  3,
+		SenderPeer:   nodes.peers[2],
+		SenderPubKey: nodes.pubKeys[2],
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, from)
+
+	// the peer must be authenticated with the key of the node
+	_, err = s.senderIndex(&peering.PeerMessage{
+		SenderPeer:   nodes.peers[2],
+		SenderPubKey: nodes.pubKeys[3],
+	})
+	assert.Error(t, err)
+
+	// peers outside of the DKG and the own node
+	_, err = s.senderIndex(&peering.PeerMessage{SenderPeer: &peering.Peer{}, SenderPubKey: nodes.pubKeys[2]})
+	assert.Error(t, err)
+	_, err = s.senderIndex(&peering.PeerMessage{SenderPeer: nodes.peers[1], SenderPubKey: nodes.pubKeys[1]})
+	assert.Error(t, err)
+}
+
+// msgData prefixes data of the message with tmpId of the session
+func msgData(tmpId int, data []byte) []byte {
+	var buf bytes.Buffer
+	_ = util.WriteUint64(&buf, uint64(tmpId))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// priShareMsg makes the message with the private share of the dealer for the node
+func priShareMsg(t *testing.T, dealer *tcrypto.DKShare, node uint16, commits []kyber.Point) *peering.PeerMessage {
+	data, err := encodePriShare(dealer.PriShares[node].V, commits)
+	assert.NoError(t, err)
+	return &peering.PeerMessage{
+		SenderIndex: dealer.Index,
+		MsgType:     MsgDkgPriShare,
+		MsgData:     msgData(4, data),
+	}
+}
+
+func TestDkgPriShares(t *testing.T) {
+	keyShares := newRndKeyShares(t, 3, 4)
+	s := newTestNodes(t, 4, keyShares).sessions[1]
+
+	_, _, err := s.processMsg(priShareMsg(t, keyShares[2], 1, keyShares[2].Commits))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, s.numPriShares)
+
+	// duplicate is an error
+	_, _, err = s.processMsg(priShareMsg(t, keyShares[2], 1, keyShares[2].Commits))
+	assert.Error(t, err)
+
+	// share for another node doesn't match commitments of the dealer
+	_, _, err = s.processMsg(priShareMsg(t, keyShares[3], 0, keyShares[3].Commits))
+	assert.Error(t, err)
+
+	// share of another polynomial than the one committed to
+	_, _, err = s.processMsg(priShareMsg(t, keyShares[3], 1, keyShares[0].Commits))
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, s.numPriShares)
+}
+
+func TestDkgDuplicateMessages(t *testing.T) {
+	keyShares := newRndKeyShares(t, 3, 4)
+	s := newTestNodes(t, 5, keyShares).sessions[0]
+
+	// only the initiator starts the DKG
+	_, _, err := s.processMsg(&peering.PeerMessage{SenderIndex: 1, MsgType: MsgDkgStart, MsgData: msgData(5, nil)})
+	assert.Error(t, err)
+
+	pubKey, err := keyShares[0].Suite.G2().Point().Pick(keyShares[0].Suite.RandomStream()).MarshalBinary()
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	_ = util.WriteBytes16(&buf, pubKey)
+	pubShareMsg := &peering.PeerMessage{SenderIndex: 2, MsgType: MsgDkgPubShare, MsgData: msgData(5, buf.Bytes())}
+	_, _, err = s.processMsg(pubShareMsg)
+	assert.NoError(t, err)
+	_, _, err = s.processMsg(pubShareMsg)
+	assert.Error(t, err)
+
+	addr := address.Random()
+	committedMsg := &peering.PeerMessage{SenderIndex: 3, MsgType: MsgDkgCommitted, MsgData: msgData(5, addr.Bytes())}
+	_, _, err = s.processMsg(committedMsg)
+	assert.NoError(t, err)
+	_, _, err = s.processMsg(committedMsg)
+	assert.Error(t, err)
+}
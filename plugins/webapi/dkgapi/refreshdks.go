@@ -0,0 +1,77 @@
+package dkgapi
+
+import (
+	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+//----------------------------------------------------------
+// The POST handler implements 'adm/refreshdks' API
+// Parameters (see RefreshDKSRequest struct):
+//     tmpId:       int value, tmp id for the refresh session. Should be unique during DKG session
+//     address:     address of the key set, base58
+//     hosts:       web API hosts of all n nodes of the committee in the order of indices of their key shares
+//
+// The called node must hold the key share. It runs the same DKG between the nodes as 'adm/rundkg',
+// but every node refreshes its existing key share instead of creating a new one.
+// After the refresh the address and the master public key remain the same, private and public
+// key shares of all nodes change. Each node keeps the old key share until all nodes committed the refreshed
+// ones, then the old key shares are deleted from the registry.
+// Old key shares can't be combined with the refreshed ones
+//
+// Response (see RefreshDKSResponse): error if the refresh failed
+
+func HandlerRefreshDKS(c echo.Context) error {
+	var req RefreshDKSRequest
+
+	if err := c.Bind(&req); err != nil {
+		return misc.OkJson(c, &RefreshDKSResponse{
+			Err: err.Error(),
+		})
+	}
+	return misc.OkJson(c, RefreshDKSReq(&req))
+}
+
+type RefreshDKSRequest struct {
+	TmpId   int      `json:"tmpId"`
+	Address string   `json:"address"` //base58
+	Hosts   []string `json:"hosts"`
+}
+
+type RefreshDKSResponse struct {
+	Err string `json:"err"`
+}
+
+func RefreshDKSReq(req *RefreshDKSRequest) *RefreshDKSResponse {
+	addr, err := address.FromBase58(req.Address)
+	if err != nil {
+		return &RefreshDKSResponse{Err: err.Error()}
+	}
+	ks, exists, err := registry.GetDKShare(&addr)
+	if err != nil {
+		return &RefreshDKSResponse{Err: err.Error()}
+	}
+	if !exists {
+		return &RefreshDKSResponse{Err: fmt.Sprintf("key share with address %s does not exist", req.Address)}
+	}
+	ks.ErasePrivate()
+	if len(req.Hosts) != int(ks.N) {
+		return &RefreshDKSResponse{Err: fmt.Sprintf("expected %d hosts", ks.N)}
+	}
+	newAddr, err := runDkgSession(&JoinDKGRequest{
+		TmpId:   req.TmpId,
+		N:       ks.N,
+		T:       ks.T,
+		Address: req.Address,
+	}, req.Hosts)
+	if err != nil {
+		return &RefreshDKSResponse{Err: err.Error()}
+	}
+	if *newAddr != addr {
+		return &RefreshDKSResponse{Err: "address of the key share changed during refresh"}
+	}
+	return &RefreshDKSResponse{}
+}
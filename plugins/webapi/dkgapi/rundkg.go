@@ -0,0 +1,254 @@
+package dkgapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/plugins/peering"
+	"github.com/iotaledger/wasp/plugins/webapi/admapi"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+	"net/http"
+	"time"
+)
+
+//----------------------------------------------------------
+// The POST handler implements 'adm/rundkg' API
+// Parameters (see RunDKGRequest struct):
+//     tmpId:       int value, tmp id for the key set. Should be unique during DKG session
+//     t:           required quorum: normally t=floor( 2*n/3)+1
+//     hosts:       web API hosts of all n nodes of the committee, including the called one
+//
+// The called node is the initiator of the DKG:
+// - it retrieves network ids and public identity keys of all nodes (adm/nodeidentity)
+// - calls 'adm/joindkg' on every node with the index of the node
+// - when all nodes joined, starts the DKG, which runs between the nodes over the peering connections
+//
+// The initiator only relays public parameters. Private shares are sent by the nodes directly to each other,
+// so neither the caller nor the initiator learns them.
+//
+// Response (see RunDKGResponse): address of the new key set, committed by all nodes
+
+func HandlerRunDKG(c echo.Context) error {
+	var req RunDKGRequest
+
+	if err := c.Bind(&req); err != nil {
+		return misc.OkJson(c, &RunDKGResponse{
+			Err: err.Error(),
+		})
+	}
+	return misc.OkJson(c, RunDKGReq(&req))
+}
+
+type RunDKGRequest struct {
+	TmpId int      `json:"tmpId"`
+	T     uint16   `json:"t"`
+	Hosts []string `json:"hosts"`
+}
+
+type RunDKGResponse struct {
+	Address string `json:"address"` //base58
+	Err     string `json:"err"`
+}
+
+func RunDKGReq(req *RunDKGRequest) *RunDKGResponse {
+	n := uint16(len(req.Hosts))
+	if err := tcrypto.ValidateDKSParams(req.T, n, 0); err != nil {
+		return &RunDKGResponse{Err: err.Error()}
+	}
+	addr, err := runDkgSession(&JoinDKGRequest{
+		TmpId: req.TmpId,
+		N:     n,
+		T:     req.T,
+	}, req.Hosts)
+	if err != nil {
+		return &RunDKGResponse{Err: err.Error()}
+	}
+	return &RunDKGResponse{Address: addr.String()}
+}
+
+// runDkgSession is run by the initiator. It makes all hosts to join the DKG with the parameters
+// and waits for the result
+func runDkgSession(joinReq *JoinDKGRequest, hosts []string) (*address.Address, error) {
+	n := joinReq.N
+	joinReq.NetIds = make([]string, n)
+	joinReq.PubKeys = make([]string, n)
+	if joinReq.TmpId == 0 {
+		joinReq.TmpId = int(time.Now().UnixNano())
+	}
+	ownIndex := -1
+	for i, host := range hosts {
+		identity, err := getNodeIdentity(host)
+		if err != nil {
+			return nil, err
+		}
+		joinReq.NetIds[i] = identity.NetId
+		joinReq.PubKeys[i] = identity.PubKey
+		if identity.NetId == peering.MyNetworkId() {
+			ownIndex = i
+		}
+	}
+	if ownIndex < 0 {
+		return nil, errors.New("the initiator of DKG must be one of the nodes")
+	}
+	joinReq.Initiator = uint16(ownIndex)
+
+	// the initiator joins first, so it can abort the DKG if other nodes fail to join
+	ownReq := *joinReq
+	ownReq.Index = uint16(ownIndex)
+	if resp := JoinDKGReq(&ownReq); resp.Err != "" {
+		return nil, errors.New(resp.Err)
+	}
+	s := getDkgSession(joinReq.TmpId)
+	var joinErr error
+	for i, host := range hosts {
+		if i == ownIndex {
+			continue
+		}
+		par := *joinReq
+		par.Index = uint16(i)
+		if joinErr = callJoinDKG(host, &par); joinErr != nil {
+			joinErr = fmt.Errorf("node %s failed to join DKG: %v", host, joinErr)
+			break
+		}
+	}
+	if joinErr != nil {
+		s.abort(joinErr)
+	} else {
+		s.start()
+	}
+	res := <-s.chResult
+	return res.address, res.err
+}
+
+// The POST handler implements 'adm/joindkg' API. It is called by the initiator of DKG, see 'adm/rundkg'
+// The node generates random polynomial, keeps it in the dkgCache and waits for other nodes
+// to exchange shares. If the address is specified, the node refreshes its existing key share
+// with the address (see 'adm/refreshdks')
+func HandlerJoinDKG(c echo.Context) error {
+	var req JoinDKGRequest
+
+	if err := c.Bind(&req); err != nil {
+		return misc.OkJson(c, &JoinDKGResponse{
+			Err: err.Error(),
+		})
+	}
+	return misc.OkJson(c, JoinDKGReq(&req))
+}
+
+type JoinDKGRequest struct {
+	TmpId     int      `json:"tmpId"`
+	N         uint16   `json:"n"`
+	T         uint16   `json:"t"`
+	Index     uint16   `json:"index"` // 0 to N-1
+	Initiator uint16   `json:"initiator"`
+	NetIds    []string `json:"net_ids"`
+	PubKeys   []string `json:"pub_keys"`          // base58
+	Address   string   `json:"address,omitempty"` // base58, only when refreshing the key share
+}
+
+type JoinDKGResponse struct {
+	Err string `json:"err"`
+}
+
+func JoinDKGReq(req *JoinDKGRequest) *JoinDKGResponse {
+	if err := tcrypto.ValidateDKSParams(req.T, req.N, req.Index); err != nil {
+		return &JoinDKGResponse{Err: err.Error()}
+	}
+	if len(req.NetIds) != int(req.N) || len(req.PubKeys) != int(req.N) || req.Initiator >= req.N {
+		return &JoinDKGResponse{Err: "wrong number of nodes"}
+	}
+	if req.NetIds[req.Index] != peering.MyNetworkId() {
+		return &JoinDKGResponse{Err: fmt.Sprintf("wrong index %d of the node %s", req.Index, peering.MyNetworkId())}
+	}
+	pubKeys, err := admapi.PubKeysFromBase58(req.PubKeys)
+	if err != nil {
+		return &JoinDKGResponse{Err: err.Error()}
+	}
+	if pubKeys[req.Index] != peering.MyPublicKey() {
+		return &JoinDKGResponse{Err: "wrong public key of the node"}
+	}
+	var ks *tcrypto.DKShare
+	if req.Address == "" {
+		ks, err = tcrypto.NewRndDKShare(req.T, req.N, req.Index)
+	} else {
+		ks, err = newRefreshingDKShare(req)
+	}
+	if err != nil {
+		return &JoinDKGResponse{Err: err.Error()}
+	}
+	if err = putToDkgCache(req.TmpId, ks); err != nil {
+		return &JoinDKGResponse{Err: err.Error()}
+	}
+	if _, err = newDkgSession(req.TmpId, ks, req.Initiator, req.NetIds, pubKeys); err != nil {
+		_ = putToDkgCache(req.TmpId, nil)
+		return &JoinDKGResponse{Err: err.Error()}
+	}
+	return &JoinDKGResponse{}
+}
+
+// newRefreshingDKShare loads the existing key share with the address and starts its refresh
+func newRefreshingDKShare(req *JoinDKGRequest) (*tcrypto.DKShare, error) {
+	addr, err := address.FromBase58(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	old, exists, err := registry.GetDKShare(&addr)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("key share with address %s does not exist", req.Address)
+	}
+	defer old.ErasePrivate()
+	if old.N != req.N || old.T != req.T || old.Index != req.Index {
+		return nil, fmt.Errorf("parameters do not match the key share %s", req.Address)
+	}
+	return old.NewRefreshingDKShare()
+}
+
+func getNodeIdentity(host string) (*admapi.NodeIdentityResponse, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/adm/nodeidentity", host))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("adm/nodeidentity returned code %d", resp.StatusCode)
+	}
+	var ret admapi.NodeIdentityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	if _, err := admapi.PubKeysFromBase58([]string{ret.PubKey}); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func callJoinDKG(host string, req *JoinDKGRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/adm/joindkg", host), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("adm/joindkg returned code %d", resp.StatusCode)
+	}
+	var result JoinDKGResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Err != "" {
+		return errors.New(result.Err)
+	}
+	return nil
+}
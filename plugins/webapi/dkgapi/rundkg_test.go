@@ -0,0 +1,49 @@
+package dkgapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer responds to all requests with the status code and the JSON encoded response
+func newTestServer(status int, resp interface{}) (*httptest.Server, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	return server, strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestCallJoinDKG(t *testing.T) {
+	req := &JoinDKGRequest{TmpId: 1, N: 4, T: 3}
+
+	server, host := newTestServer(http.StatusOK, &JoinDKGResponse{})
+	assert.NoError(t, callJoinDKG(host, req))
+	server.Close()
+
+	server, host = newTestServer(http.StatusOK, &JoinDKGResponse{Err: "wrong index"})
+	assert.EqualError(t, callJoinDKG(host, req), "wrong index")
+	server.Close()
+
+	// error status without the error in the response
+	server, host = newTestServer(http.StatusInternalServerError, &JoinDKGResponse{})
+	assert.Error(t, callJoinDKG(host, req))
+	server.Close()
+
+	server, host = newTestServer(http.StatusNotFound, map[string]string{"message": "Not Found"})
+	assert.Error(t, callJoinDKG(host, req))
+	server.Close()
+}
+
+func TestGetNodeIdentity(t *testing.T) {
+	server, host := newTestServer(http.StatusNotFound, map[string]string{"message": "Not Found"})
+	_, err := getNodeIdentity(host)
+	assert.Error(t, err)
+	server.Close()
+}
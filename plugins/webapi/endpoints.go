@@ -15,10 +15,16 @@ func addEndpoints() {
 	Server.GET("/", IndexRequest)
 	// sc api
 	Server.POST("/sc/state/query", stateapi.HandlerQueryState)
+	Server.POST("/sc/state/proof", stateapi.HandlerStateProof)
+	Server.GET("/sc/events/:address", stateapi.HandlerQueryEvents)
+	Server.GET("/sc/receipt/:address/:reqid", stateapi.HandlerRequestReceipt)
+	Server.GET("/sc/view/:address/:code", stateapi.HandlerView)
+	Server.POST("/sc/view/:address/:code", stateapi.HandlerView)
+	Server.GET("/sc/schema/:address", stateapi.HandlerSchema)
 	// dkgapi
-	Server.POST("/adm/newdks", dkgapi.HandlerNewDks)
-	Server.POST("/adm/aggregatedks", dkgapi.HandlerAggregateDks)
-	Server.POST("/adm/commitdks", dkgapi.HandlerCommitDks)
+	Server.POST("/adm/rundkg", dkgapi.HandlerRunDKG)
+	Server.POST("/adm/joindkg", dkgapi.HandlerJoinDKG)
+	Server.POST("/adm/refreshdks", dkgapi.HandlerRefreshDKS)
 	Server.POST("/adm/signdigest", dkgapi.HandlerSignDigest)
 	Server.POST("/adm/getpubkeyinfo", dkgapi.HandlerGetKeyPubInfo)
 	Server.POST("/adm/exportdkshare", dkgapi.HandlerExportDKShare)
@@ -28,8 +34,15 @@ func addEndpoints() {
 	Server.POST("/adm/getscdata", admapi.HandlerGetSCData)
 	Server.GET("/adm/getsclist", admapi.HandlerGetSCList)
 	Server.GET("/adm/shutdown", admapi.HandlerShutdown)
+	Server.GET("/adm/nodeidentity", admapi.HandlerNodeIdentity)
 	Server.POST("/adm/activatesc", admapi.HandlerActivateSC)
+	Server.POST("/adm/confirmrotation", admapi.HandlerConfirmRotation)
 	Server.GET("/adm/dumpscstate/:scaddress", admapi.HandlerDumpSCState)
+	Server.GET("/adm/cachestats/:scaddress", admapi.HandlerReadCacheStats)
+	Server.GET("/adm/exportsnapshot/:scaddress", admapi.HandlerExportSnapshot)
+	Server.POST("/adm/importsnapshot", admapi.HandlerImportSnapshot)
+	Server.POST("/adm/setretention", admapi.HandlerSetRetentionPolicy)
+	Server.GET("/adm/getretention/:scaddress", admapi.HandlerGetRetentionPolicy)
 	Server.POST("/adm/putprogrammetadata", admapi.HandlerPutProgramMetaData)
 	Server.POST("/adm/getprogrammetadata", admapi.HandlerGetProgramMetadata)
 	// redirect to goshimmer
@@ -41,6 +41,7 @@ func WaitUntilIsUp() {
 func configure(*node.Plugin) {
 	log = logger.NewLogger(PluginName)
 	dkgapi.InitLogger()
+	dkgapi.AttachToPeering()
 	admapi.InitLogger()
 
 	Server.HideBanner = true
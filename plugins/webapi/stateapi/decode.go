@@ -0,0 +1,305 @@
+package stateapi
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/schema"
+)
+
+// Decoded results contain values decoded according to the schema of the smart contract.
+// Values are as returned by schema.StateVar.DecodeValue, keys of dictionaries as returned by DecodeKey
+
+type DecodedArrayResult struct {
+	Len    uint32
+	Values []interface{}
+}
+
+type DecodedDictEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// DecodedDictResult is the decoded dictionary or sorted map
+type DecodedDictResult struct {
+	Len     uint32
+	Entries []DecodedDictEntry
+}
+
+type DecodedDequeResult struct {
+	Len    uint32
+	Values []interface{}
+}
+
+type DecodedTLogRecord struct {
+	Index     uint32
+	Timestamp int64
+	Data      interface{}
+}
+
+type DecodedTLogResult struct {
+	Len      uint32
+	Earliest int64
+	Latest   int64
+	Records  []DecodedTLogRecord
+}
+
+// decodeQueryResult decodes the result of the query. Results of typed queries are already decoded,
+// nil is returned for them
+func decodeQueryResult(sv *schema.StateVar, result interface{}) (interface{}, error) {
+	switch r := result.(type) {
+	case []byte:
+		return decodeValue(sv, r)
+
+	case ArrayResult:
+		ret := DecodedArrayResult{Len: r.Len, Values: make([]interface{}, len(r.Values))}
+		for i, v := range r.Values {
+			var err error
+			if ret.Values[i], err = decodeValue(sv, v); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+
+	case DictResult:
+		return decodeEntries(sv, r.Len, r.Entries)
+
+	case SortedMapResult:
+		return decodeEntries(sv, r.Len, r.Entries)
+
+	case DequeResult:
+		ret := DecodedDequeResult{Len: r.Len, Values: make([]interface{}, len(r.Values))}
+		for i, v := range r.Values {
+			var err error
+			if ret.Values[i], err = decodeValue(sv, v); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+
+	case TLogResult:
+		ret := DecodedTLogResult{
+			Len:      r.Len,
+			Earliest: r.Earliest,
+			Latest:   r.Latest,
+			Records:  make([]DecodedTLogRecord, len(r.Records)),
+		}
+		for i, rec := range r.Records {
+			data, err := decodeValue(sv, rec.Data)
+			if err != nil {
+				return nil, err
+			}
+			ret.Records[i] = DecodedTLogRecord{Index: rec.Index, Timestamp: rec.Timestamp, Data: data}
+		}
+		return ret, nil
+	}
+	return nil, nil
+}
+
+func decodeEntries(sv *schema.StateVar, size uint32, entries []KeyValuePair) (interface{}, error) {
+	ret := DecodedDictResult{Len: size, Entries: make([]DecodedDictEntry, len(entries))}
+	for i, e := range entries {
+		var err error
+		if ret.Entries[i], err = decodeDictEntry(sv, e.Key, e.Value); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// DecodeState decodes all variables of the state declared in the schema.
+// Returns map key => decoded value, where collections are DecodedArrayResult, DecodedDictResult,
+// DecodedDequeResult or DecodedTLogResult. Variables not declared in the schema are not included
+func DecodeState(vars kv.BufferedKVStore, sch *schema.Schema) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	for i := range sch.StateVars {
+		sv := &sch.StateVars[i]
+		var value interface{}
+		var err error
+		switch sv.Collection {
+		case schema.CollectionScalar:
+			err = decodeScalars(vars, sch, sv, ret)
+		case schema.CollectionArray:
+			value, err = decodeArray(vars, sv)
+		case schema.CollectionDict:
+			value, err = decodeDict(vars, sv)
+		case schema.CollectionTLog:
+			value, err = decodeTLog(vars, sv)
+		case schema.CollectionSortedMap:
+			value, err = decodeSortedMap(vars, sv)
+		case schema.CollectionDeque:
+			value, err = decodeDeque(vars, sv)
+		default:
+			err = fmt.Errorf("unknown collection type '%s'", sv.Collection)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("state variable '%s': %v", sv.Key, err)
+		}
+		if value != nil {
+			ret[sv.Key] = value
+		}
+	}
+	return ret, nil
+}
+
+func decodeScalars(vars kv.BufferedKVStore, sch *schema.Schema, sv *schema.StateVar, ret map[string]interface{}) error {
+	if !sv.IsPrefix {
+		value, err := vars.Get(kv.Key(sv.Key))
+		if err != nil || value == nil {
+			return err
+		}
+		ret[sv.Key], err = sv.DecodeValue(value)
+		return err
+	}
+	var err error
+	errIt := vars.Iterate(kv.Key(sv.Key), func(key kv.Key, value []byte) bool {
+		if owner, _ := sch.StateVar(key); owner != sv || isCollectionKey(sch, key) {
+			return true
+		}
+		ret[string(key)], err = sv.DecodeValue(value)
+		return err == nil
+	})
+	if errIt != nil {
+		return errIt
+	}
+	return err
+}
+
+func decodeArray(vars kv.BufferedKVStore, sv *schema.StateVar) (interface{}, error) {
+	arr, err := vars.Codec().GetArray32(kv.Key(sv.Key))
+	if err != nil || arr.Len() == 0 {
+		return nil, err
+	}
+	ret := DecodedArrayResult{Len: arr.Len(), Values: make([]interface{}, arr.Len())}
+	for i := range ret.Values {
+		v, err := arr.GetAt(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		if ret.Values[i], err = decodeValue(sv, v); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func decodeDict(vars kv.BufferedKVStore, sv *schema.StateVar) (interface{}, error) {
+	dict, err := vars.Codec().GetDictionary(kv.Key(sv.Key))
+	if err != nil || dict.Len() == 0 {
+		return nil, err
+	}
+	ret := DecodedDictResult{Len: dict.Len(), Entries: make([]DecodedDictEntry, 0, dict.Len())}
+	errIt := dict.Iterate(func(elemKey []byte, value []byte) bool {
+		var e DecodedDictEntry
+		if e, err = decodeDictEntry(sv, elemKey, value); err != nil {
+			return false
+		}
+		ret.Entries = append(ret.Entries, e)
+		return true
+	})
+	if errIt != nil {
+		return nil, errIt
+	}
+	return ret, err
+}
+
+func decodeSortedMap(vars kv.BufferedKVStore, sv *schema.StateVar) (interface{}, error) {
+	smap, err := vars.Codec().GetSortedMap(kv.Key(sv.Key))
+	if err != nil || smap.Len() == 0 {
+		return nil, err
+	}
+	ret := DecodedDictResult{Len: smap.Len(), Entries: make([]DecodedDictEntry, 0, smap.Len())}
+	errIt := smap.Iterate(func(elemKey []byte, value []byte) bool {
+		var e DecodedDictEntry
+		if e, err = decodeDictEntry(sv, elemKey, value); err != nil {
+			return false
+		}
+		ret.Entries = append(ret.Entries, e)
+		return true
+	})
+	if errIt != nil {
+		return nil, errIt
+	}
+	return ret, err
+}
+
+func decodeDeque(vars kv.BufferedKVStore, sv *schema.StateVar) (interface{}, error) {
+	deque, err := vars.Codec().GetDeque(kv.Key(sv.Key))
+	if err != nil || deque.Len() == 0 {
+		return nil, err
+	}
+	ret := DecodedDequeResult{Len: deque.Len(), Values: make([]interface{}, deque.Len())}
+	for i := range ret.Values {
+		v, err := deque.GetAt(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		if ret.Values[i], err = decodeValue(sv, v); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func decodeTLog(vars kv.BufferedKVStore, sv *schema.StateVar) (interface{}, error) {
+	tlog, err := vars.Codec().GetTimestampedLog(kv.Key(sv.Key))
+	if err != nil || tlog.Len() == 0 {
+		return nil, err
+	}
+	slice, err := tlog.TakeTimeSlice(tlog.Earliest(), tlog.Latest())
+	if err != nil || slice == nil {
+		return nil, err
+	}
+	recs, err := slice.LoadSlice()
+	if err != nil {
+		return nil, err
+	}
+	ret := DecodedTLogResult{
+		Len:      tlog.Len(),
+		Earliest: tlog.Earliest(),
+		Latest:   tlog.Latest(),
+		Records:  make([]DecodedTLogRecord, len(recs)),
+	}
+	for i, r := range recs {
+		data, err := decodeValue(sv, r.Data)
+		if err != nil {
+			return nil, err
+		}
+		ret.Records[i] = DecodedTLogRecord{Index: r.Index, Timestamp: r.Timestamp, Data: data}
+	}
+	return ret, nil
+}
+
+func decodeDictEntry(sv *schema.StateVar, key []byte, value []byte) (DecodedDictEntry, error) {
+	k, err := sv.DecodeKey(key)
+	if err != nil {
+		return DecodedDictEntry{}, err
+	}
+	v, err := decodeValue(sv, value)
+	if err != nil {
+		return DecodedDictEntry{}, err
+	}
+	return DecodedDictEntry{Key: k, Value: v}, nil
+}
+
+func decodeValue(sv *schema.StateVar, value []byte) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return sv.DecodeValue(value)
+}
+
+// isCollectionKey returns true if the key is the internal key of a collection declared in the schema
+func isCollectionKey(sch *schema.Schema, key kv.Key) bool {
+	for i := range sch.StateVars {
+		sv := &sch.StateVars[i]
+		if sv.Collection == schema.CollectionScalar || len(key) <= len(sv.Key) {
+			continue
+		}
+		// internal keys of collections are the name followed by the size or element code
+		if string(key[:len(sv.Key)]) == sv.Key && key[len(sv.Key)] <= 1 {
+			return true
+		}
+	}
+	return false
+}
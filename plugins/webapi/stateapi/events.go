@@ -0,0 +1,75 @@
+package stateapi
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type EventInfo struct {
+	StateIndex uint32
+	BatchIndex uint16
+	RequestId  string
+	Index      uint16
+	Name       string
+	Fields     []KeyValuePair
+}
+
+type EventsResponse struct {
+	Events []*EventInfo
+	Error  string
+}
+
+// HandlerQueryEvents returns events of the smart contract emitted in the range of state indices.
+// Query parameters (all optional):
+//   - name: only events with the name are returned
+//   - from, to: state index range, inclusive. By default all states up to the solid one
+func HandlerQueryEvents(c echo.Context) error {
+	addr, err := address.FromBase58(c.Param("address"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &EventsResponse{Error: err.Error()})
+	}
+	fromIndex, err := stateIndexParam(c, "from", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &EventsResponse{Error: err.Error()})
+	}
+	toIndex, err := stateIndexParam(c, "to", math.MaxUint32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &EventsResponse{Error: err.Error()})
+	}
+	records, err := state.LoadEvents(&addr, c.QueryParam("name"), fromIndex, toIndex)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &EventsResponse{Error: err.Error()})
+	}
+	ret := &EventsResponse{
+		Events: make([]*EventInfo, len(records)),
+	}
+	for i, rec := range records {
+		ret.Events[i] = &EventInfo{
+			StateIndex: rec.StateIndex,
+			BatchIndex: rec.BatchIndex,
+			RequestId:  rec.RequestId.String(),
+			Index:      rec.Index,
+			Name:       rec.Event.Name,
+			Fields:     toKeyValuePairs(rec.Event.Fields),
+		}
+	}
+	return misc.OkJson(c, ret)
+}
+
+func stateIndexParam(c echo.Context, name string, def uint32) (uint32, error) {
+	s := c.QueryParam(name)
+	if s == "" {
+		return def, nil
+	}
+	ret, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(ret), nil
+}
@@ -0,0 +1,108 @@
+package stateapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type ProofRequest struct {
+	Address string
+	Key     []byte
+}
+
+// ProofResponse contains the value of the state variable in the solid state and the proof
+// of it. Value is nil if the variable does not exist
+type ProofResponse struct {
+	Key                []byte
+	Value              []byte
+	StateIndex         uint32
+	StateTransactionId string
+	ChainHash          *hashing.HashValue
+	MerkleRoot         *hashing.HashValue
+	// serialized state.MerkleProof
+	Proof []byte
+	Error string
+}
+
+// Verify checks the value against the state hash taken from the confirmed state transaction
+func (r *ProofResponse) Verify(stateHash *hashing.HashValue) error {
+	if r.ChainHash == nil || r.MerkleRoot == nil {
+		return errors.New("incomplete proof")
+	}
+	if state.StateHashFromMerkleRoot(r.ChainHash, r.MerkleRoot) != *stateHash {
+		return errors.New("Merkle root is not committed to by the state hash")
+	}
+	proof := &state.MerkleProof{}
+	if err := proof.Read(bytes.NewReader(r.Proof)); err != nil {
+		return err
+	}
+	if !proof.Verify(r.MerkleRoot, kv.Key(r.Key), r.Value) {
+		return errors.New("wrong Merkle proof")
+	}
+	return nil
+}
+
+// HandlerStateProof returns the value of the state variable together with the Merkle proof
+// which can be verified against the state hash in the state transaction
+func HandlerStateProof(c echo.Context) error {
+	var req ProofRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, &ProofResponse{Error: err.Error()})
+	}
+	addr, err := address.FromBase58(req.Address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ProofResponse{Error: err.Error()})
+	}
+	// TODO serialize access to solid state
+	vs, batch, exist, err := state.LoadSolidState(&addr)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ProofResponse{Error: err.Error()})
+	}
+	if !exist {
+		return c.JSON(http.StatusNotFound, &ProofResponse{
+			Error: fmt.Sprintf("State not found with address %s", addr),
+		})
+	}
+	if vs.StateIndex() < vs.MerkleFrom() {
+		return c.JSON(http.StatusNotFound, &ProofResponse{
+			Error: fmt.Sprintf("state #%d of %s is anchored without the Merkle root", vs.StateIndex(), addr),
+		})
+	}
+	key := kv.Key(req.Key)
+	value, err := vs.Variables().Get(key)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ProofResponse{Error: err.Error()})
+	}
+	proof, err := vs.MerkleProof(key)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ProofResponse{Error: err.Error()})
+	}
+	var buf bytes.Buffer
+	if err = proof.Write(&buf); err != nil {
+		return c.JSON(http.StatusInternalServerError, &ProofResponse{Error: err.Error()})
+	}
+	chainHash := vs.ChainHash()
+	root, err := vs.MerkleRoot()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ProofResponse{Error: err.Error()})
+	}
+	return misc.OkJson(c, &ProofResponse{
+		Key:                req.Key,
+		Value:              value,
+		StateIndex:         vs.StateIndex(),
+		StateTransactionId: batch.StateTransactionId().String(),
+		ChainHash:          &chainHash,
+		MerkleRoot:         &root,
+		Proof:              buf.Bytes(),
+	})
+}
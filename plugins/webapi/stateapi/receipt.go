@@ -0,0 +1,84 @@
+package stateapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type ReceiptEvent struct {
+	Name   string
+	Fields []KeyValuePair
+}
+
+type ReceiptResponse struct {
+	RequestId          string
+	StateIndex         uint32
+	StateTransactionId string
+	Timestamp          int64
+	GasUsed            int
+	// panic message. Empty string means success
+	RequestError string
+	Events       []*ReceiptEvent
+	Results      []KeyValuePair
+	Error        string
+}
+
+// HandlerRequestReceipt returns the outcome of the request settled in the solid state of the smart contract.
+// The request id is in the form returned by RequestId.String(), i.e. '[index]txid'
+func HandlerRequestReceipt(c echo.Context) error {
+	addr, err := address.FromBase58(c.Param("address"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ReceiptResponse{Error: err.Error()})
+	}
+	reqidStr, err := url.PathUnescape(c.Param("reqid"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ReceiptResponse{Error: err.Error()})
+	}
+	reqid, err := sctransaction.NewRequestIdFromString(reqidStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ReceiptResponse{Error: err.Error()})
+	}
+	rcpt, exist, err := state.LoadRequestReceipt(&addr, &reqid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ReceiptResponse{Error: err.Error()})
+	}
+	if !exist {
+		return c.JSON(http.StatusNotFound, &ReceiptResponse{
+			Error: fmt.Sprintf("receipt of request %s not found", reqid.String()),
+		})
+	}
+	ret := &ReceiptResponse{
+		RequestId:          rcpt.RequestId.String(),
+		StateIndex:         rcpt.StateIndex,
+		StateTransactionId: rcpt.StateTransactionId.String(),
+		Timestamp:          rcpt.Timestamp,
+		GasUsed:            rcpt.GasUsed,
+		RequestError:       rcpt.Error,
+		Events:             make([]*ReceiptEvent, len(rcpt.Events)),
+		Results:            toKeyValuePairs(rcpt.Results),
+	}
+	for i, ev := range rcpt.Events {
+		ret.Events[i] = &ReceiptEvent{
+			Name:   ev.Name,
+			Fields: toKeyValuePairs(ev.Fields),
+		}
+	}
+	return misc.OkJson(c, ret)
+}
+
+func toKeyValuePairs(m kv.Map) []KeyValuePair {
+	ret := make([]KeyValuePair, 0)
+	m.ForEachDeterministic(func(key kv.Key, value []byte) bool {
+		ret = append(ret, KeyValuePair{Key: []byte(key), Value: value})
+		return true
+	})
+	return ret
+}
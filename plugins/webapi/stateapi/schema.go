@@ -0,0 +1,57 @@
+package stateapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/schema"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/vm/processor"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type SchemaResponse struct {
+	ProgramHash string
+	Schema      *schema.Schema
+	Error       string
+}
+
+// HandlerSchema returns the schema of the program currently run by the smart contract
+func HandlerSchema(c echo.Context) error {
+	addr, err := address.FromBase58(c.Param("address"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &SchemaResponse{Error: err.Error()})
+	}
+	progHash, sch, err := LoadSchema(&addr)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &SchemaResponse{Error: err.Error()})
+	}
+	if sch == nil {
+		return c.JSON(http.StatusNotFound, &SchemaResponse{
+			ProgramHash: progHash,
+			Error:       fmt.Sprintf("schema of smart contract %s is not known", addr.String()),
+		})
+	}
+	return misc.OkJson(c, &SchemaResponse{
+		ProgramHash: progHash,
+		Schema:      sch,
+	})
+}
+
+// LoadSchema returns the program hash in the solid state of the smart contract and the schema of the program.
+// The schema is nil if the state or the schema doesn't exist
+func LoadSchema(addr *address.Address) (string, *schema.Schema, error) {
+	vs, _, exist, err := state.LoadSolidState(addr)
+	if err != nil || !exist {
+		return "", nil, err
+	}
+	progHash, ok, err := vs.Variables().Codec().GetHashValue(vmconst.VarNameProgramHash)
+	if err != nil || !ok {
+		return "", nil, err
+	}
+	sch, _, err := processor.GetSchema(progHash)
+	return progHash.String(), sch, err
+}
@@ -7,10 +7,14 @@ import (
 	"net/http"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/schema"
+	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/plugins/webapi/misc"
 	"github.com/labstack/echo"
+	"github.com/mr-tron/base58"
 )
 
 type ValueType string
@@ -20,32 +24,82 @@ const (
 	ValueTypeInt64  = ValueType("int64")
 	ValueTypeArray  = ValueType("array")
 	ValueTypeDict   = ValueType("dict")
+	ValueTypeTLog   = ValueType("tlog")
+
+	ValueTypeSortedMap = ValueType("sortedmap")
+	ValueTypeDeque     = ValueType("deque")
+
+	ValueTypeUint16    = ValueType("uint16")
+	ValueTypeUint32    = ValueType("uint32")
+	ValueTypeUint64    = ValueType("uint64")
+	ValueTypeBool      = ValueType("bool")
+	ValueTypeColor     = ValueType("color")
+	ValueTypeBalances  = ValueType("balances")
+	ValueTypeRequestId = ValueType("requestid")
+	ValueTypeMap       = ValueType("map")
 )
 
 type KeyQuery struct {
 	Key    []byte
 	Type   ValueType
-	Params json.RawMessage // one of DictQueryParams, ArrayQueryParams, ...
+	Params json.RawMessage // one of DictQueryParams, ArrayQueryParams, TLogQueryParams, ...
 }
 
 type DictQueryParams struct {
 	Limit uint32
 }
 
+// ArrayQueryParams selects the elements with indices From <= index < To. Arrays longer than 65535
+// elements (kv.Array32) are paged with several queries
 type ArrayQueryParams struct {
-	From uint16
-	To   uint16
+	From uint32
+	To   uint32
+}
+
+// MaxTLogQueryLimit is the maximum number of records of the timestamped log returned by one query
+const MaxTLogQueryLimit = 1000
+
+// TLogQueryParams selects the records of the timestamped log with timestamps
+// between FromTs and ToTs, inclusive. At most Limit records are returned, skipping first Offset of them.
+// Limit is capped by MaxTLogQueryLimit, 0 means the maximum
+type TLogQueryParams struct {
+	FromTs int64
+	ToTs   int64
+	Offset uint32
+	Limit  uint32
+}
+
+// SortedMapQueryParams selects entries with keys From <= key < To in the order of keys,
+// at most Limit entries. Empty From or To means the range is not limited from that side
+type SortedMapQueryParams struct {
+	From  []byte
+	To    []byte
+	Limit uint32
+}
+
+// DequeQueryParams selects the elements of the deque with indices From <= index < To,
+// counted from the front
+type DequeQueryParams struct {
+	From uint32
+	To   uint32
 }
 
 type QueryRequest struct {
 	Address string
-	Query   []*KeyQuery
+	// if set, the query is answered as of the state index. Requires the node to keep the state history
+	StateIndex *uint32
+	// if set, results of keys declared in the schema of the smart contract are also returned decoded
+	Decode bool
+	Query  []*KeyQuery
 }
 
 type QueryResult struct {
 	Key   []byte
 	Type  ValueType
-	Value json.RawMessage // one of DictResult, ArrayResult, ...
+	Value json.RawMessage // one of DictResult, ArrayResult, TLogResult, ...
+	// decoded value, one of DecodedDictResult, DecodedArrayResult, DecodedTLogResult or the decoded scalar.
+	// Only if requested and the key is declared in the schema
+	Decoded json.RawMessage
 }
 
 type KeyValuePair struct {
@@ -57,16 +111,70 @@ type Int64Result struct {
 	Value int64
 }
 
+// Uint64Result is the result of uint16, uint32 and uint64 queries
+type Uint64Result struct {
+	Value uint64
+}
+
+type BoolResult struct {
+	Value bool
+}
+
+// ColorResult contains the color in base58
+type ColorResult struct {
+	Value string
+}
+
+type BalancesResult struct {
+	Balances []*balance.Balance
+}
+
+// RequestIdResult contains the request id as returned by RequestId.String()
+type RequestIdResult struct {
+	Value string
+}
+
+type MapResult struct {
+	Entries []KeyValuePair
+}
+
 type DictResult struct {
 	Len     uint32
 	Entries []KeyValuePair
 }
 
 type ArrayResult struct {
-	Len    uint16
+	Len    uint32
+	Values [][]byte
+}
+
+// SortedMapResult contains the entries in the order of keys
+type SortedMapResult struct {
+	Len     uint32
+	Entries []KeyValuePair
+}
+
+type DequeResult struct {
+	Len    uint32
 	Values [][]byte
 }
 
+type TLogRecord struct {
+	Index     uint32
+	Timestamp int64
+	Data      []byte
+}
+
+// TLogResult contains the records of the query. NumPoints is the number of all records
+// between the timestamps of the query, so the rest can be queried with the next Offset
+type TLogResult struct {
+	Len       uint32
+	Earliest  int64
+	Latest    int64
+	NumPoints uint32
+	Records   []TLogRecord
+}
+
 type QueryResponse struct {
 	Results []*QueryResult
 	Error   string
@@ -76,6 +184,18 @@ func NewQueryRequest(address *address.Address) *QueryRequest {
 	return &QueryRequest{Address: address.String()}
 }
 
+// AtStateIndex makes the query to be answered as of the past state
+func (q *QueryRequest) AtStateIndex(stateIndex uint32) *QueryRequest {
+	q.StateIndex = &stateIndex
+	return q
+}
+
+// WithDecode requests the results to be decoded according to the schema of the smart contract
+func (q *QueryRequest) WithDecode() *QueryRequest {
+	q.Decode = true
+	return q
+}
+
 func (q *QueryRequest) AddScalar(key kv.Key) {
 	q.Query = append(q.Query, &KeyQuery{
 		Key:    []byte(key),
@@ -92,7 +212,17 @@ func (q *QueryRequest) AddInt64(key kv.Key) {
 	})
 }
 
-func (q *QueryRequest) AddArray(key kv.Key, from uint16, to uint16) {
+// AddTyped adds the query of the scalar value decoded as one of types
+// uint16, uint32, uint64, bool, color, balances, requestid or map
+func (q *QueryRequest) AddTyped(key kv.Key, valueType ValueType) {
+	q.Query = append(q.Query, &KeyQuery{
+		Key:    []byte(key),
+		Type:   valueType,
+		Params: nil,
+	})
+}
+
+func (q *QueryRequest) AddArray(key kv.Key, from uint32, to uint32) {
 	p := &ArrayQueryParams{From: from, To: to}
 	params, _ := json.Marshal(p)
 	q.Query = append(q.Query, &KeyQuery{
@@ -112,6 +242,36 @@ func (q *QueryRequest) AddDictionary(key kv.Key, limit uint32) {
 	})
 }
 
+func (q *QueryRequest) AddTLog(key kv.Key, fromTs int64, toTs int64, offset uint32, limit uint32) {
+	p := &TLogQueryParams{FromTs: fromTs, ToTs: toTs, Offset: offset, Limit: limit}
+	params, _ := json.Marshal(p)
+	q.Query = append(q.Query, &KeyQuery{
+		Key:    []byte(key),
+		Type:   ValueTypeTLog,
+		Params: json.RawMessage(params),
+	})
+}
+
+func (q *QueryRequest) AddSortedMap(key kv.Key, from []byte, to []byte, limit uint32) {
+	p := &SortedMapQueryParams{From: from, To: to, Limit: limit}
+	params, _ := json.Marshal(p)
+	q.Query = append(q.Query, &KeyQuery{
+		Key:    []byte(key),
+		Type:   ValueTypeSortedMap,
+		Params: json.RawMessage(params),
+	})
+}
+
+func (q *QueryRequest) AddDeque(key kv.Key, from uint32, to uint32) {
+	p := &DequeQueryParams{From: from, To: to}
+	params, _ := json.Marshal(p)
+	q.Query = append(q.Query, &KeyQuery{
+		Key:    []byte(key),
+		Type:   ValueTypeDeque,
+		Params: json.RawMessage(params),
+	})
+}
+
 func (r *QueryResult) MustScalar() []byte {
 	var b []byte
 	err := json.Unmarshal(r.Value, &b)
@@ -133,6 +293,98 @@ func (r *QueryResult) MustInt64() int64 {
 	return ir.Value
 }
 
+// MustUint64 returns the value of uint16, uint32 or uint64 result
+func (r *QueryResult) MustUint64() uint64 {
+	var ur *Uint64Result
+	err := json.Unmarshal(r.Value, &ur)
+	if err != nil {
+		panic(err)
+	}
+	if ur == nil {
+		return 0
+	}
+	return ur.Value
+}
+
+func (r *QueryResult) MustBool() bool {
+	var br *BoolResult
+	err := json.Unmarshal(r.Value, &br)
+	if err != nil {
+		panic(err)
+	}
+	if br == nil {
+		return false
+	}
+	return br.Value
+}
+
+// MustColor returns nil if the value does not exist
+func (r *QueryResult) MustColor() *balance.Color {
+	var cr *ColorResult
+	err := json.Unmarshal(r.Value, &cr)
+	if err != nil {
+		panic(err)
+	}
+	if cr == nil {
+		return nil
+	}
+	b, err := base58.Decode(cr.Value)
+	if err != nil {
+		panic(err)
+	}
+	ret, err := kv.DecodeColor(b)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func (r *QueryResult) MustBalances() []*balance.Balance {
+	var br *BalancesResult
+	err := json.Unmarshal(r.Value, &br)
+	if err != nil {
+		panic(err)
+	}
+	if br == nil {
+		return nil
+	}
+	return br.Balances
+}
+
+// MustRequestId returns nil if the value does not exist
+func (r *QueryResult) MustRequestId() *sctransaction.RequestId {
+	var rr *RequestIdResult
+	err := json.Unmarshal(r.Value, &rr)
+	if err != nil {
+		panic(err)
+	}
+	if rr == nil {
+		return nil
+	}
+	ret, err := sctransaction.NewRequestIdFromString(rr.Value)
+	if err != nil {
+		panic(err)
+	}
+	return &ret
+}
+
+// MustMap returns nil if the value does not exist
+func (r *QueryResult) MustMap() kv.Map {
+	var mr *MapResult
+	err := json.Unmarshal(r.Value, &mr)
+	if err != nil {
+		panic(err)
+	}
+	if mr == nil {
+		return nil
+	}
+	ret := kv.NewMap()
+	for _, e := range mr.Entries {
+		ret.Set(kv.Key(e.Key), e.Value)
+	}
+	return ret
+}
+
 func (r *QueryResult) MustArrayResult() *ArrayResult {
 	var ar ArrayResult
 	err := json.Unmarshal(r.Value, &ar)
@@ -151,6 +403,33 @@ func (r *QueryResult) MustDictionaryResult() *DictResult {
 	return &dr
 }
 
+func (r *QueryResult) MustTLogResult() *TLogResult {
+	var tr TLogResult
+	err := json.Unmarshal(r.Value, &tr)
+	if err != nil {
+		panic(err)
+	}
+	return &tr
+}
+
+func (r *QueryResult) MustSortedMapResult() *SortedMapResult {
+	var mr SortedMapResult
+	err := json.Unmarshal(r.Value, &mr)
+	if err != nil {
+		panic(err)
+	}
+	return &mr
+}
+
+func (r *QueryResult) MustDequeResult() *DequeResult {
+	var dr DequeResult
+	err := json.Unmarshal(r.Value, &dr)
+	if err != nil {
+		panic(err)
+	}
+	return &dr
+}
+
 func HandlerQueryState(c echo.Context) error {
 	var req QueryRequest
 
@@ -162,7 +441,7 @@ func HandlerQueryState(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, &QueryResponse{Error: err.Error()})
 	}
 	// TODO serialize access to solid state
-	state, _, exist, err := state.LoadSolidState(&addr)
+	vars, exist, err := loadVariables(&addr, req.StateIndex)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, &QueryResponse{Error: err.Error()})
 	}
@@ -171,10 +450,15 @@ func HandlerQueryState(c echo.Context) error {
 			Error: fmt.Sprintf("State not found with address %s", addr),
 		})
 	}
+	var sch *schema.Schema
+	if req.Decode {
+		if _, sch, err = LoadSchema(&addr); err != nil {
+			return c.JSON(http.StatusInternalServerError, &QueryResponse{Error: err.Error()})
+		}
+	}
 	ret := &QueryResponse{
 		Results: make([]*QueryResult, 0),
 	}
-	vars := state.Variables()
 	for _, q := range req.Query {
 		value, err := processQuery(q, vars)
 		if err != nil {
@@ -184,16 +468,49 @@ func HandlerQueryState(c echo.Context) error {
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, &QueryResponse{Error: err.Error()})
 		}
-		ret.Results = append(ret.Results, &QueryResult{
+		res := &QueryResult{
 			Key:   q.Key,
 			Type:  q.Type,
 			Value: json.RawMessage(b),
-		})
+		}
+		if sch != nil {
+			if res.Decoded, err = decodeQuery(sch, q, value); err != nil {
+				return c.JSON(http.StatusInternalServerError, &QueryResponse{Error: err.Error()})
+			}
+		}
+		ret.Results = append(ret.Results, res)
 	}
 
 	return misc.OkJson(c, ret)
 }
 
+func decodeQuery(sch *schema.Schema, q *KeyQuery, value interface{}) (json.RawMessage, error) {
+	sv, ok := sch.StateVar(kv.Key(q.Key))
+	if !ok {
+		return nil, nil
+	}
+	decoded, err := decodeQueryResult(sv, value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key '%s': %v", string(q.Key), err)
+	}
+	if decoded == nil {
+		return nil, nil
+	}
+	return json.Marshal(decoded)
+}
+
+// loadVariables loads variables of the solid state or, if stateIndex is not nil, of the past state
+func loadVariables(addr *address.Address, stateIndex *uint32) (kv.BufferedKVStore, bool, error) {
+	if stateIndex != nil {
+		return state.LoadVariablesAt(addr, *stateIndex)
+	}
+	vs, _, exist, err := state.LoadSolidState(addr)
+	if err != nil || !exist {
+		return nil, exist, err
+	}
+	return vs.Variables(), true, nil
+}
+
 func processQuery(q *KeyQuery, vars kv.BufferedKVStore) (interface{}, error) {
 	key := kv.Key(q.Key)
 	switch q.Type {
@@ -215,6 +532,14 @@ func processQuery(q *KeyQuery, vars kv.BufferedKVStore) (interface{}, error) {
 		}
 		return Int64Result{Value: n}, nil
 
+	case ValueTypeUint16, ValueTypeUint32, ValueTypeUint64, ValueTypeBool, ValueTypeColor,
+		ValueTypeBalances, ValueTypeRequestId, ValueTypeMap:
+		value, err := vars.Get(key)
+		if err != nil || value == nil {
+			return value, err
+		}
+		return decodeTypedValue(q.Type, value)
+
 	case ValueTypeArray:
 		var params ArrayQueryParams
 		err := json.Unmarshal(q.Params, &params)
@@ -222,7 +547,8 @@ func processQuery(q *KeyQuery, vars kv.BufferedKVStore) (interface{}, error) {
 			return nil, err
 		}
 
-		arr, err := vars.Codec().GetArray(key)
+		// reads both kv.Array and kv.Array32
+		arr, err := vars.Codec().GetArray32(key)
 		if err != nil {
 			return nil, err
 		}
@@ -259,7 +585,149 @@ func processQuery(q *KeyQuery, vars kv.BufferedKVStore) (interface{}, error) {
 			return nil, err
 		}
 		return DictResult{Len: dict.Len(), Entries: entries}, nil
+
+	case ValueTypeSortedMap:
+		var params SortedMapQueryParams
+		err := json.Unmarshal(q.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		smap, err := vars.Codec().GetSortedMap(key)
+		if err != nil {
+			return nil, err
+		}
+
+		var from, to []byte
+		if len(params.From) > 0 {
+			from = params.From
+		}
+		if len(params.To) > 0 {
+			to = params.To
+		}
+		entries := make([]KeyValuePair, 0)
+		err = smap.IterateRange(from, to, func(elemKey []byte, value []byte) bool {
+			entries = append(entries, KeyValuePair{Key: elemKey, Value: value})
+			return len(entries) < int(params.Limit)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return SortedMapResult{Len: smap.Len(), Entries: entries}, nil
+
+	case ValueTypeDeque:
+		var params DequeQueryParams
+		err := json.Unmarshal(q.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		deque, err := vars.Codec().GetDeque(key)
+		if err != nil {
+			return nil, err
+		}
+
+		size := deque.Len()
+		values := make([][]byte, 0)
+		for i := params.From; i < size && i < params.To; i++ {
+			v, err := deque.GetAt(i)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return DequeResult{Len: size, Values: values}, nil
+
+	case ValueTypeTLog:
+		var params TLogQueryParams
+		err := json.Unmarshal(q.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		tlog, err := vars.Codec().GetTimestampedLog(key)
+		if err != nil {
+			return nil, err
+		}
+
+		slice, err := tlog.TakeTimeSlice(params.FromTs, params.ToTs)
+		if err != nil {
+			return nil, err
+		}
+		limit := params.Limit
+		if limit == 0 || limit > MaxTLogQueryLimit {
+			limit = MaxTLogQueryLimit
+		}
+		// nil slice means no records in the interval
+		records := make([]TLogRecord, 0)
+		if slice != nil {
+			recs, err := slice.LoadRecords(params.Offset, limit)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range recs {
+				records = append(records, TLogRecord{Index: r.Index, Timestamp: r.Timestamp, Data: r.Data})
+			}
+		}
+		return TLogResult{
+			Len:       tlog.Len(),
+			Earliest:  tlog.Earliest(),
+			Latest:    tlog.Latest(),
+			NumPoints: slice.NumPoints(),
+			Records:   records,
+		}, nil
 	}
 
 	return nil, fmt.Errorf("No handler for type %s", q.Type)
 }
+
+func decodeTypedValue(valueType ValueType, value []byte) (interface{}, error) {
+	switch valueType {
+	case ValueTypeUint16:
+		n, err := kv.DecodeUint16(value)
+		return Uint64Result{Value: uint64(n)}, err
+
+	case ValueTypeUint32:
+		n, err := kv.DecodeUint32(value)
+		return Uint64Result{Value: uint64(n)}, err
+
+	case ValueTypeUint64:
+		n, err := kv.DecodeUint64(value)
+		return Uint64Result{Value: n}, err
+
+	case ValueTypeBool:
+		b, err := kv.DecodeBool(value)
+		return BoolResult{Value: b}, err
+
+	case ValueTypeColor:
+		color, err := kv.DecodeColor(value)
+		if err != nil {
+			return nil, err
+		}
+		return ColorResult{Value: base58.Encode(color[:])}, nil
+
+	case ValueTypeBalances:
+		bals, err := kv.DecodeBalances(value)
+		return BalancesResult{Balances: bals}, err
+
+	case ValueTypeRequestId:
+		reqid, err := kv.DecodeRequestId(value)
+		if err != nil {
+			return nil, err
+		}
+		return RequestIdResult{Value: reqid.String()}, nil
+
+	case ValueTypeMap:
+		m, err := kv.DecodeMap(value)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]KeyValuePair, 0)
+		m.ForEachDeterministic(func(key kv.Key, value []byte) bool {
+			entries = append(entries, KeyValuePair{Key: []byte(key), Value: value})
+			return true
+		})
+		return MapResult{Entries: entries}, nil
+	}
+	return nil, fmt.Errorf("No handler for type %s", valueType)
+}
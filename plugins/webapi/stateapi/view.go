@@ -0,0 +1,48 @@
+package stateapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/plugins/runvm"
+	"github.com/iotaledger/wasp/plugins/webapi/misc"
+	"github.com/labstack/echo"
+)
+
+type ViewRequest struct {
+	Params []KeyValuePair
+}
+
+type ViewResponse struct {
+	Results []KeyValuePair
+	Error   string
+}
+
+// HandlerView runs the view entry point of the smart contract against its solid state on this node.
+// The code is the decimal view code. Parameters of the view are optional (GET or POST without body)
+func HandlerView(c echo.Context) error {
+	addr, err := address.FromBase58(c.Param("address"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ViewResponse{Error: err.Error()})
+	}
+	code, err := strconv.ParseUint(c.Param("code"), 10, 16)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &ViewResponse{Error: err.Error()})
+	}
+	var req ViewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, &ViewResponse{Error: err.Error()})
+	}
+	params := kv.NewMap()
+	for _, p := range req.Params {
+		params.Set(kv.Key(p.Key), p.Value)
+	}
+	results, err := runvm.RunView(&addr, sctransaction.RequestCode(uint16(code)), params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &ViewResponse{Error: err.Error()})
+	}
+	return misc.OkJson(c, &ViewResponse{Results: toKeyValuePairs(results)})
+}
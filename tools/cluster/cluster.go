@@ -542,6 +542,12 @@ func (cluster *Cluster) VerifySCState(sc *SmartContractFinalConfig, expectedInde
 		expectedState := kv.FromGoMap(expectedState)
 		expectedState.Codec().SetAddress(vmconst.VarNameOwnerAddress, &ownerAddr)
 		expectedState.Codec().SetHashValue(vmconst.VarNameProgramHash, &scProgHash)
+		// program hash is set by the init request, settled in the state #1
+		progHashHistory, err := expectedState.Codec().GetArray(vmconst.VarNameProgramHashHistory)
+		if err != nil {
+			panic(err)
+		}
+		progHashHistory.Push(append(util.Uint32To4Bytes(1), scProgHash[:]...))
 
 		fmt.Printf("    Expected: index %d\n%s\n", expectedIndex, expectedState)
 		fmt.Printf("      Actual: index %d\n%s\n", stateIndex, state)
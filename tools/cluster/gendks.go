@@ -41,7 +41,12 @@ func (cluster *Cluster) GenerateDKSets() error {
 
 		dkShares := make([]string, 0)
 		for _, host := range cluster.ApiHosts() {
-			dks, err := waspapi.ExportDKShare(host, addr)
+			// the key share is exported for the node itself, to be imported after restart
+			identity, err := waspapi.GetNodeIdentity(host)
+			if err != nil {
+				return err
+			}
+			dks, err := waspapi.ExportDKShare(host, addr, identity.PubKey)
 			if err != nil {
 				return err
 			}
@@ -39,16 +39,21 @@ func putScData(sc *cluster.SmartContractFinalConfig, clu *cluster.Cluster) error
 	committeePeerNodes := clu.WaspHosts(sc.CommitteeNodes, (*cluster.WaspNodeConfig).PeeringHost)
 	accessPeerNodes := clu.WaspHosts(sc.AccessNodes, (*cluster.WaspNodeConfig).PeeringHost)
 	allNodesApi := clu.WaspHosts(sc.AllNodes(), (*cluster.WaspNodeConfig).ApiHost)
+	committeePubKeys, err := waspapi.GetCommitteePubKeys(clu.WaspHosts(sc.CommitteeNodes, (*cluster.WaspNodeConfig).ApiHost))
+	if err != nil {
+		return err
+	}
 
 	var failed bool
 	for _, host := range allNodesApi {
 
 		err = waspapi.PutSCData(host, registry.BootupData{
-			Address:        addr,
-			Color:          color,
-			OwnerAddress:   utxodb.GetAddress(sc.OwnerIndexUtxodb),
-			CommitteeNodes: committeePeerNodes,
-			AccessNodes:    accessPeerNodes,
+			Address:          addr,
+			Color:            color,
+			OwnerAddress:     utxodb.GetAddress(sc.OwnerIndexUtxodb),
+			CommitteeNodes:   committeePeerNodes,
+			AccessNodes:      accessPeerNodes,
+			CommitteePubKeys: committeePubKeys,
 		})
 		if err != nil {
 			fmt.Printf("[cluster] apilib.PutSCData returned for host %s: %v\n", host, err)
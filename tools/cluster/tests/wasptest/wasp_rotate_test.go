@@ -0,0 +1,115 @@
+package wasptest
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/waspconn/packages/utxodb"
+	waspapi "github.com/iotaledger/wasp/packages/apilib"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/examples/inccounter"
+	"github.com/iotaledger/wasp/packages/vm/vmconst"
+	"github.com/iotaledger/wasp/tools/cluster"
+)
+
+// the rotation request is processed by the VM, which moves the smart contract token to the new address.
+// Each node of the old committee receives the state transaction from the dispatcher, hands the smart contract
+// over and is dismissed. The new committee continues with the state
+func TestRotateCommittee(t *testing.T) {
+	// setup
+	wasps := setup(t, "test_cluster", "TestRotateCommittee")
+
+	err := wasps.ListenToMessages(map[string]int{
+		"bootuprec":           -1,
+		"active_committee":    -1,
+		"dismissed_committee": 1,
+		"rotated":             1,
+		"request_in":          -1,
+		"request_out":         -1,
+		"state":               -1,
+	})
+	check(err, t)
+
+	err = PutBootupRecords(wasps)
+	check(err, t)
+
+	sc := &wasps.SmartContractConfig[2]
+	err = Activate1SC(wasps, sc)
+	check(err, t)
+
+	err = CreateOrigin1SC(wasps, sc)
+	check(err, t)
+
+	reqs := []*waspapi.RequestBlockJson{{
+		Address:     sc.Address,
+		RequestCode: inccounter.RequestInc,
+	}}
+	err = SendRequests(wasps, sc.OwnerIndexUtxodb, reqs)
+	check(err, t)
+	time.Sleep(5 * time.Second)
+
+	scAddress, err := address.FromBase58(sc.Address)
+	check(err, t)
+	ownerAddr := utxodb.GetAddress(sc.OwnerIndexUtxodb)
+
+	newAddr, err := waspapi.RotateCommittee(waspapi.RotateCommitteeParams{
+		Node:                     wasps.Config.GoshimmerApiHost(),
+		SCAddress:                &scAddress,
+		OwnerSigScheme:           utxodb.GetSigScheme(ownerAddr),
+		CommitteeApiHosts:        wasps.WaspHosts(sc.CommitteeNodes, (*cluster.WaspNodeConfig).ApiHost),
+		NewCommitteeApiHosts:     wasps.WaspHosts(sc.CommitteeNodes, (*cluster.WaspNodeConfig).ApiHost),
+		NewCommitteePeeringHosts: wasps.WaspHosts(sc.CommitteeNodes, (*cluster.WaspNodeConfig).PeeringHost),
+		T:                        3,
+		Timeout:                  30 * time.Second,
+	})
+	check(err, t)
+	fmt.Printf("[cluster] smart contract %s rotated to %s\n", sc.Address, newAddr.String())
+
+	// bootup records of the old address are removed after the handover is completed
+	for _, host := range wasps.WaspHosts(sc.CommitteeNodes, (*cluster.WaspNodeConfig).ApiHost) {
+		_, exists, err := waspapi.GetSCData(host, &scAddress)
+		check(err, t)
+		if exists {
+			t.Errorf("bootup record of %s still exists on %s", sc.Address, host)
+		}
+	}
+
+	reqs = []*waspapi.RequestBlockJson{{
+		Address:     newAddr.String(),
+		RequestCode: inccounter.RequestInc,
+	}}
+	err = SendRequests(wasps, sc.OwnerIndexUtxodb, reqs)
+	check(err, t)
+
+	wasps.CollectMessages(15 * time.Second)
+
+	if !wasps.Report() {
+		t.Fail()
+	}
+	rotated := *sc
+	rotated.Address = newAddr.String()
+	if !wasps.WithSCState(&rotated, func(host string, stateIndex uint32, state kv.Map) bool {
+		// origin, init, 1st increment, rotation, 2nd increment
+		if stateIndex != 4 {
+			fmt.Printf("   FAIL: state index %d on %s\n", stateIndex, host)
+			return false
+		}
+		counter, err := state.Get("counter")
+		if err != nil || !bytes.Equal(counter, util.Uint64To8Bytes(2)) {
+			fmt.Printf("   FAIL: counter mismatch on %s\n", host)
+			return false
+		}
+		addr, ok, err := state.Codec().GetAddress(vmconst.VarNameSCAddress)
+		if err != nil || !ok || *addr != *newAddr {
+			fmt.Printf("   FAIL: address of the smart contract mismatch on %s\n", host)
+			return false
+		}
+		return true
+	}) {
+		t.Fail()
+	}
+}
@@ -92,12 +92,15 @@ func genDKSets() *address.Address {
 }
 
 func putScData(scAddress *address.Address, color *balance.Color) {
+	pubKeys, err := waspapi.GetCommitteePubKeys(config.CommitteeApi(committee()))
+	check(err)
 	bootupData := registry.BootupData{
-		Address:        *scAddress,
-		Color:          *color,
-		OwnerAddress:   ownerAddress(),
-		CommitteeNodes: config.CommitteePeering(committee()),
-		AccessNodes:    []string{},
+		Address:          *scAddress,
+		Color:            *color,
+		OwnerAddress:     ownerAddress(),
+		CommitteeNodes:   config.CommitteePeering(committee()),
+		AccessNodes:      []string{},
+		CommitteePubKeys: pubKeys,
 	}
 	for _, host := range config.CommitteeApi(committee()) {
 		check(waspapi.PutSCData(host, bootupData))
@@ -19,10 +19,10 @@ const BetsSliceLength = 10
 type Status struct {
 	SCBalance int64
 
-	CurrentBetsAmount uint16
+	CurrentBetsAmount uint32
 	CurrentBets       []*fairroulette.BetInfo
 
-	LockedBetsAmount uint16
+	LockedBetsAmount uint32
 	LockedBets       []*fairroulette.BetInfo
 
 	LastWinningColor int64
@@ -118,7 +118,7 @@ func fetchSCBalance() (int64, error) {
 	return b, nil
 }
 
-func decodeBets(result *stateapi.ArrayResult) (uint16, []*fairroulette.BetInfo, error) {
+func decodeBets(result *stateapi.ArrayResult) (uint32, []*fairroulette.BetInfo, error) {
 	size := result.Len
 	bets := make([]*fairroulette.BetInfo, 0)
 	for _, b := range result.Values {
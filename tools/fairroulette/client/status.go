@@ -30,7 +30,7 @@ func StatusCmd(args []string) {
 	}
 }
 
-func dumpBets(n uint16, bets []*fairroulette.BetInfo) {
+func dumpBets(n uint32, bets []*fairroulette.BetInfo) {
 	if len(bets) < int(n) {
 		fmt.Printf("    (showing first %d)\n", len(bets))
 	}